@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// completionScripts holds the completion script for each supported shell.
+// They complete the subcommands, their flags, the values of --format,
+// --theme and --shell, and .puml and .uml files for the commands that read
+// a diagram.
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+func cmdCompletion(args []string) int {
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+	shell := fs.String("shell", "", "shell to write the completion script for: bash, zsh or fish")
+	if err := fs.Parse(args); err != nil {
+		return exitSystem
+	}
+	if err := writeCompletion(os.Stdout, *shell); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\nUsage: go-uml completion --shell bash|zsh|fish\n", err)
+		return exitSystem
+	}
+	return exitSuccess
+}
+
+// writeCompletion writes the completion script for shell to w.
+func writeCompletion(w io.Writer, shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+const bashCompletion = `# bash completion for go-uml
+# Load with: source <(go-uml completion --shell bash)
+
+_go_uml() {
+    local cur prev cmd flags
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "render validate ast watch serve completion version help" -- "$cur"))
+        return
+    fi
+    cmd="${COMP_WORDS[1]}"
+    case "$prev" in
+        -f|--format)
+            COMPREPLY=($(compgen -W "svg png mermaid dot" -- "$cur"))
+            return
+            ;;
+        --theme)
+            COMPREPLY=($(compgen -W "darcula monokai solarized light mono" -- "$cur"))
+            return
+            ;;
+        --shell)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            return
+            ;;
+        -o|--theme-file)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+        --port|--host|--interval)
+            return
+            ;;
+    esac
+    case "$cmd" in
+        render) flags="-o -f --format --theme --theme-file --json-errors --minify --transparent --debug-layout" ;;
+        validate) flags="--json-errors" ;;
+        watch) flags="-o --interval" ;;
+        serve) flags="--port --host" ;;
+        completion) flags="--shell" ;;
+    esac
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+        return
+    fi
+    case "$cmd" in
+        render|validate|ast|watch)
+            COMPREPLY=($(compgen -f -X '!*.puml' -- "$cur") $(compgen -f -X '!*.uml' -- "$cur") $(compgen -d -- "$cur"))
+            ;;
+    esac
+}
+
+complete -o filenames -F _go_uml go-uml
+`
+
+const zshCompletion = `#compdef go-uml
+# zsh completion for go-uml
+# Load with: source <(go-uml completion --shell zsh)
+
+_go_uml() {
+    local -a commands
+    commands=(
+        'render:Render a PlantUML file to SVG, PNG, Mermaid or DOT'
+        'validate:Validate a PlantUML file'
+        'ast:Print the parsed syntax tree of a PlantUML file as JSON'
+        'watch:Re-render a PlantUML file to SVG whenever it changes'
+        'serve:Start the HTTP server with live editor'
+        'completion:Print a shell completion script'
+        'version:Print version information'
+        'help:Show help'
+    )
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+    local cmd=$words[2]
+    shift words
+    (( CURRENT-- ))
+    local diagrams='*:PlantUML file:_files -g "*.(puml|uml)"'
+    case $cmd in
+        render)
+            _arguments \
+                '-o[output file]:output file:_files' \
+                '(-f --format)'{-f,--format}'[output format]:format:(svg png mermaid dot)' \
+                '--theme[built-in theme or JSON theme file]:theme:(darcula monokai solarized light mono)' \
+                '--theme-file[JSON theme file]:theme file:_files -g "*.json"' \
+                '--json-errors[report errors as JSON]' \
+                '--minify[write the SVG on a single line]' \
+                '--transparent[leave the background unfilled]' \
+                '--debug-layout[print the layout graph to stderr]' \
+                $diagrams
+            ;;
+        validate)
+            _arguments '--json-errors[report problems as JSON]' $diagrams
+            ;;
+        ast)
+            _arguments $diagrams
+            ;;
+        watch)
+            _arguments \
+                '-o[output SVG path]:output file:_files' \
+                '--interval[how often to check for changes]:interval:' \
+                $diagrams
+            ;;
+        serve)
+            _arguments \
+                '--port[port to listen on]:port:' \
+                '--host[host to bind to]:host:_hosts'
+            ;;
+        completion)
+            _arguments '--shell[shell to complete for]:shell:(bash zsh fish)'
+            ;;
+    esac
+}
+
+if [ "$funcstack[1]" = "_go_uml" ]; then
+    _go_uml "$@"
+else
+    compdef _go_uml go-uml
+fi
+`
+
+const fishCompletion = `# fish completion for go-uml
+# Load with: go-uml completion --shell fish | source
+
+set -l commands render validate ast watch serve completion version help
+
+complete -c go-uml -f
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a render -d 'Render a PlantUML file to SVG, PNG, Mermaid or DOT'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a validate -d 'Validate a PlantUML file'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a ast -d 'Print the parsed syntax tree as JSON'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a watch -d 'Re-render a PlantUML file whenever it changes'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a serve -d 'Start the HTTP server with live editor'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a completion -d 'Print a shell completion script'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a version -d 'Print version information'
+complete -c go-uml -n "not __fish_seen_subcommand_from $commands" -a help -d 'Show help'
+
+complete -c go-uml -n '__fish_seen_subcommand_from render validate ast watch' -a '(__fish_complete_suffix .puml)'
+complete -c go-uml -n '__fish_seen_subcommand_from render validate ast watch' -a '(__fish_complete_suffix .uml)'
+
+complete -c go-uml -n '__fish_seen_subcommand_from render' -s o -r -F -d 'Output file'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -s f -l format -x -a 'svg png mermaid dot' -d 'Output format'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -l theme -x -a 'darcula monokai solarized light mono' -d 'Built-in theme or JSON theme file'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -l theme-file -r -F -d 'JSON theme file'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -l json-errors -d 'Report errors as JSON'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -l minify -d 'Write the SVG on a single line'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -l transparent -d 'Leave the background unfilled'
+complete -c go-uml -n '__fish_seen_subcommand_from render' -l debug-layout -d 'Print the layout graph to stderr'
+
+complete -c go-uml -n '__fish_seen_subcommand_from validate' -l json-errors -d 'Report problems as JSON'
+
+complete -c go-uml -n '__fish_seen_subcommand_from watch' -s o -r -F -d 'Output SVG path'
+complete -c go-uml -n '__fish_seen_subcommand_from watch' -l interval -x -d 'How often to check for changes'
+
+complete -c go-uml -n '__fish_seen_subcommand_from serve' -l port -x -d 'Port to listen on'
+complete -c go-uml -n '__fish_seen_subcommand_from serve' -l host -x -d 'Host to bind to'
+
+complete -c go-uml -n '__fish_seen_subcommand_from completion' -l shell -x -a 'bash zsh fish' -d 'Shell to complete for'
+`
@@ -1,12 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bobcob7/go-uml/internal/server"
+	"github.com/bobcob7/go-uml/internal/theme"
 	"github.com/bobcob7/go-uml/pkg/gouml"
 )
 
@@ -29,8 +40,14 @@ func main() {
 		os.Exit(cmdRender(os.Args[2:]))
 	case "validate":
 		os.Exit(cmdValidate(os.Args[2:]))
+	case "ast":
+		os.Exit(cmdAST(os.Args[2:]))
+	case "watch":
+		os.Exit(cmdWatch(os.Args[2:]))
 	case "serve":
 		os.Exit(cmdServe(os.Args[2:]))
+	case "completion":
+		os.Exit(cmdCompletion(os.Args[2:]))
 	case "version":
 		fmt.Printf("go-uml %s\n", version)
 		os.Exit(exitSuccess)
@@ -48,38 +65,97 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, `Usage: go-uml <command> [options]
 
 Commands:
-  render    Render a PlantUML file to SVG
-  validate  Validate a PlantUML file
-  serve     Start the HTTP server with live editor
-  version   Print version information
-  help      Show this help
+  render      Render a PlantUML file to SVG, PNG, Mermaid or DOT
+  validate    Validate a PlantUML file
+  ast         Print the parsed syntax tree of a PlantUML file as JSON
+  watch       Re-render a PlantUML file to SVG whenever it changes
+  serve       Start the HTTP server with live editor
+  completion  Print a shell completion script (bash, zsh or fish)
+  version     Print version information
+  help        Show this help
 
 Run 'go-uml <command> --help' for command-specific help.`)
 }
 
+const renderUsage = "Usage: go-uml render <file.puml|-> [-o output.svg] [-f svg|png|mermaid|dot] [--theme darcula|monokai|solarized|light|mono|theme.json] [--theme-file theme.json] [--json-errors] [--minify] [--transparent] [--debug-layout]"
+
 func cmdRender(args []string) int {
-	outputFile, inputPath := parseRenderArgs(args)
+	ra := parseRenderArgs(args)
+	inputPath := ra.inputPath
 	if inputPath == "" {
-		fmt.Fprintln(os.Stderr, "Usage: go-uml render <file.puml|-> [-o output.svg]")
+		fmt.Fprintln(os.Stderr, renderUsage)
 		return exitSystem
 	}
-	var input *os.File
-	if inputPath == "-" {
-		input = os.Stdin
-	} else {
-		f, err := os.Open(inputPath)
-		if err != nil {
+	format, err := resolveFormat(ra)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n%s\n", err, renderUsage)
+		return exitSystem
+	}
+	// lines maps the preprocessed source back to the files it was read
+	// from, so that parse errors name the file and line that hold them.
+	var lines gouml.LineMap
+	report := func(err error) {
+		var ge *gouml.Error
+		if errors.As(err, &ge) {
+			locate(ge, lines)
+		}
+		if !ra.jsonErrors {
 			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return
+		}
+		if ge == nil {
+			ge = &gouml.Error{Message: err.Error()}
+		}
+		_ = writeJSONErrors(os.Stderr, inputPath, []*gouml.Error{ge})
+	}
+	opts := []gouml.Option{gouml.WithMinify(ra.minify)}
+	if ra.transparent {
+		opts = append(opts, gouml.WithBackground(gouml.BackgroundTransparent))
+	}
+	if ra.debugLayout {
+		opts = append(opts, gouml.WithLayoutDebug(os.Stderr))
+	}
+	// A theme file takes precedence over a named theme; with neither, the
+	// renderer uses Darcula.
+	if ra.themeName != "" && ra.themeFile == "" {
+		load := theme.ByName
+		if strings.EqualFold(filepath.Ext(ra.themeName), ".json") {
+			load = theme.LoadFile
+		}
+		t, err := load(ra.themeName)
+		if err != nil {
+			report(err)
 			return exitSystem
 		}
-		defer func() { _ = f.Close() }()
-		input = f
+		opts = append(opts, gouml.WithTheme(t))
+	}
+	if ra.themeFile != "" {
+		t, err := theme.LoadFile(ra.themeFile)
+		if err != nil {
+			report(err)
+			return exitSystem
+		}
+		opts = append(opts, gouml.WithTheme(t))
+	}
+	var input io.Reader = os.Stdin
+	if inputPath != "-" {
+		source, sourceLines, preErrs, err := gouml.PreprocessFile(inputPath)
+		if err != nil {
+			report(err)
+			return exitSystem
+		}
+		lines = sourceLines
+		if len(preErrs) > 0 {
+			report(preErrs[0])
+			return exitValidation
+		}
+		input = strings.NewReader(source)
 	}
 	var out *os.File
-	if outputFile != "" {
-		f, err := os.Create(outputFile)
+	if ra.outputFile != "" {
+		f, err := os.Create(ra.outputFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			report(err)
 			return exitSystem
 		}
 		defer func() { _ = f.Close() }()
@@ -87,8 +163,18 @@ func cmdRender(args []string) int {
 	} else {
 		out = os.Stdout
 	}
-	if err := gouml.Render(input, out); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	switch format {
+	case "png":
+		err = gouml.RenderPNG(input, out, opts...)
+	case "mermaid":
+		err = gouml.RenderAsMermaid(input, out, opts...)
+	case "dot":
+		err = gouml.RenderDOT(input, out, opts...)
+	default:
+		err = gouml.Render(input, out, opts...)
+	}
+	if err != nil {
+		report(err)
 		if isValidationError(err) {
 			return exitValidation
 		}
@@ -99,32 +185,185 @@ func cmdRender(args []string) int {
 
 func cmdValidate(args []string) int {
 	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	jsonErrors := fs.Bool("json-errors", false, "write errors to stdout as a JSON array")
 	if err := fs.Parse(args); err != nil {
 		return exitSystem
 	}
 	remaining := fs.Args()
 	if len(remaining) == 0 {
-		fmt.Fprintln(os.Stderr, "Usage: go-uml validate <file.puml>")
+		fmt.Fprintln(os.Stderr, "Usage: go-uml validate [--json-errors] <file.puml>")
 		return exitSystem
 	}
 	inputPath := remaining[0]
-	f, err := os.Open(inputPath)
+	source, lines, preErrs, err := gouml.PreprocessFile(inputPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %s\n", err)
 		return exitSystem
 	}
-	defer func() { _ = f.Close() }()
-	errs := gouml.Validate(f)
-	if len(errs) > 0 {
+	var errs []gouml.ValidationError
+	for _, e := range preErrs {
+		errs = append(errs, gouml.ValidationError{File: e.File, Line: e.Line, Column: e.Column, Message: e.Message, Severity: gouml.SeverityError})
+	}
+	for _, e := range gouml.Validate(strings.NewReader(source)) {
+		if file, line := lines.Locate(e.Line); file != "" {
+			e.File, e.Line = file, line
+		}
+		errs = append(errs, e)
+	}
+	code := exitSuccess
+	if slices.ContainsFunc(errs, func(e gouml.ValidationError) bool { return e.Severity == gouml.SeverityError }) {
+		code = exitValidation
+	}
+	if *jsonErrors {
+		out := make([]jsonError, 0, len(errs))
 		for _, e := range errs {
-			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", inputPath, e.Line, e.Column, e.Message)
+			out = append(out, jsonError{File: cmp.Or(e.File, inputPath), Line: e.Line, Column: e.Column, Message: e.Message, Severity: e.Severity.String()})
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return exitSystem
+		}
+		return code
+	}
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %s:%d:%d: %s\n", e.Severity, cmp.Or(e.File, inputPath), e.Line, e.Column, e.Message)
+	}
+	if code == exitSuccess {
+		fmt.Println("OK")
+	}
+	return code
+}
+
+func cmdAST(args []string) int {
+	fs := flag.NewFlagSet("ast", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return exitSystem
+	}
+	remaining := fs.Args()
+	if len(remaining) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: go-uml ast <file.puml|->")
+		return exitSystem
+	}
+	var input io.Reader = os.Stdin
+	if inputPath := remaining[0]; inputPath != "-" {
+		source, _, preErrs, err := gouml.PreprocessFile(inputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return exitSystem
+		}
+		if len(preErrs) > 0 {
+			for _, e := range preErrs {
+				fmt.Fprintf(os.Stderr, "error: %s\n", e)
+			}
+			return exitValidation
 		}
-		return exitValidation
+		input = strings.NewReader(source)
+	}
+	if err := gouml.DumpAST(input, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitSystem
 	}
-	fmt.Println("OK")
 	return exitSuccess
 }
 
+func cmdWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	outputFile := fs.String("o", "", "output SVG path (default: input path with .svg extension)")
+	interval := fs.Duration("interval", 200*time.Millisecond, "how often to check the file for changes")
+	if err := fs.Parse(args); err != nil {
+		return exitSystem
+	}
+	remaining := fs.Args()
+	if len(remaining) == 0 || *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "Usage: go-uml watch [-o output.svg] [--interval 200ms] <file.puml>")
+		return exitSystem
+	}
+	inputPath := remaining[0]
+	output := *outputFile
+	if output == "" {
+		output = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".svg"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "watching %s (Ctrl-C to stop)\n", inputPath)
+	if err := watchFile(ctx, inputPath, output, *interval, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitSystem
+	}
+	return exitSuccess
+}
+
+// watchFile renders inputPath to outputPath, then polls the input every
+// interval and renders again whenever its modification time or size changes.
+// Render errors are written to logw and watching continues; it returns nil
+// once ctx is cancelled.
+func watchFile(ctx context.Context, inputPath, outputPath string, interval time.Duration, logw io.Writer) error {
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return err
+	}
+	renderWatched(inputPath, outputPath, logw)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		cur, err := os.Stat(inputPath)
+		if err != nil {
+			// The file may be mid-save by an editor that replaces it; try again next tick.
+			continue
+		}
+		if cur.ModTime().Equal(info.ModTime()) && cur.Size() == info.Size() {
+			continue
+		}
+		info = cur
+		renderWatched(inputPath, outputPath, logw)
+	}
+}
+
+// renderWatched renders inputPath into memory and only replaces outputPath
+// on success, so a broken edit leaves the last good SVG in place. Only
+// inputPath is watched; a change to a file it includes is picked up with
+// the next change to inputPath.
+func renderWatched(inputPath, outputPath string, logw io.Writer) {
+	source, lines, preErrs, err := gouml.PreprocessFile(inputPath)
+	if err != nil {
+		fmt.Fprintf(logw, "error: %s\n", err)
+		return
+	}
+	if len(preErrs) > 0 {
+		fmt.Fprintf(logw, "error: %s\n", preErrs[0])
+		return
+	}
+	var buf bytes.Buffer
+	if err := gouml.RenderString(source, &buf); err != nil {
+		var ge *gouml.Error
+		if errors.As(err, &ge) {
+			locate(ge, lines)
+		}
+		fmt.Fprintf(logw, "error: %s\n", err)
+		return
+	}
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(logw, "error: %s\n", err)
+		return
+	}
+	fmt.Fprintf(logw, "rendered %s\n", outputPath)
+}
+
 func cmdServe(args []string) int {
 	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
 	port := fs.Int("port", 8080, "port to listen on")
@@ -135,6 +374,7 @@ func cmdServe(args []string) int {
 	cfg := server.DefaultConfig()
 	cfg.Port = *port
 	cfg.Host = *host
+	cfg.Version = version
 	srv := server.New(cfg)
 	fmt.Fprintf(os.Stderr, "go-uml server listening on http://%s:%d\n", cfg.Host, cfg.Port)
 	if err := srv.ListenAndServe(); err != nil {
@@ -144,19 +384,108 @@ func cmdServe(args []string) int {
 	return exitSuccess
 }
 
-func parseRenderArgs(args []string) (outputFile, inputPath string) {
+// renderArgs holds the command-line arguments of the render command.
+type renderArgs struct {
+	outputFile  string
+	inputPath   string
+	format      string
+	themeName   string
+	themeFile   string
+	jsonErrors  bool
+	minify      bool
+	transparent bool
+	debugLayout bool
+}
+
+func parseRenderArgs(args []string) renderArgs {
+	var ra renderArgs
 	for i := 0; i < len(args); i++ {
 		switch {
 		case args[i] == "-o" && i+1 < len(args):
-			outputFile = args[i+1]
+			ra.outputFile = args[i+1]
+			i++
+		case (args[i] == "-f" || args[i] == "--format" || args[i] == "-format") && i+1 < len(args):
+			ra.format = args[i+1]
 			i++
+		case (args[i] == "--theme" || args[i] == "-theme") && i+1 < len(args):
+			ra.themeName = args[i+1]
+			i++
+		case (args[i] == "--theme-file" || args[i] == "-theme-file") && i+1 < len(args):
+			ra.themeFile = args[i+1]
+			i++
+		case args[i] == "--json-errors" || args[i] == "-json-errors":
+			ra.jsonErrors = true
+		case args[i] == "--minify" || args[i] == "-minify":
+			ra.minify = true
+		case args[i] == "--transparent" || args[i] == "-transparent":
+			ra.transparent = true
+		case args[i] == "--debug-layout" || args[i] == "-debug-layout":
+			ra.debugLayout = true
 		case args[i] == "--help" || args[i] == "-h":
-			return "", ""
+			return renderArgs{}
 		case args[i] == "-" || !strings.HasPrefix(args[i], "-"):
-			inputPath = args[i]
+			ra.inputPath = args[i]
 		}
 	}
-	return outputFile, inputPath
+	return ra
+}
+
+// formatsByExtension maps the output file extensions the render command
+// recognises to the format they imply.
+var formatsByExtension = map[string]string{
+	".svg": "svg",
+	".png": "png",
+	".mmd": "mermaid",
+	".dot": "dot",
+}
+
+// resolveFormat returns the output format of the render command: the one
+// given with --format, else the one implied by the extension of the output
+// file, else svg. A --format that the extension contradicts is an error.
+func resolveFormat(ra renderArgs) (string, error) {
+	inferred := formatsByExtension[strings.ToLower(filepath.Ext(ra.outputFile))]
+	switch {
+	case ra.format == "" && inferred == "":
+		return "svg", nil
+	case ra.format == "":
+		return inferred, nil
+	case !slices.Contains([]string{"svg", "png", "mermaid", "dot"}, ra.format):
+		return "", fmt.Errorf("unknown format %q: want svg, png, mermaid or dot", ra.format)
+	case inferred != "" && inferred != ra.format:
+		return "", fmt.Errorf("--format %s conflicts with output file %s", ra.format, ra.outputFile)
+	}
+	return ra.format, nil
+}
+
+// jsonError is the machine-readable form of an error written by --json-errors.
+type jsonError struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// writeJSONErrors writes errs as a JSON array, emitting [] when there are
+// none. Errors without a file of their own are reported in file.
+func writeJSONErrors(w io.Writer, file string, errs []*gouml.Error) error {
+	out := make([]jsonError, 0, len(errs))
+	for _, e := range errs {
+		out = append(out, jsonError{File: cmp.Or(e.File, file), Line: e.Line, Column: e.Column, Message: e.Message})
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// locate moves a parse error of a preprocessed source to the file and line
+// that lines maps it to. Errors that already name their file, such as
+// preprocessor errors, are left alone.
+func locate(e *gouml.Error, lines gouml.LineMap) {
+	if e.File != "" {
+		return
+	}
+	if file, line := lines.Locate(e.Line); file != "" {
+		e.File, e.Line = file, line
+	}
 }
 
 func isValidationError(err error) bool {
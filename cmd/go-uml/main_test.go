@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/bobcob7/go-uml/pkg/gouml"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -33,48 +40,125 @@ func TestParseRenderArgs(t *testing.T) {
 	t.Parallel()
 	t.Run("FileOnly", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{"input.puml"})
-		assert.Equal(t, "", out)
-		assert.Equal(t, "input.puml", in)
+		ra := parseRenderArgs([]string{"input.puml"})
+		assert.Equal(t, "", ra.outputFile)
+		assert.Equal(t, "input.puml", ra.inputPath)
 	})
 	t.Run("FileWithOutputAfter", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{"input.puml", "-o", "out.svg"})
-		assert.Equal(t, "out.svg", out)
-		assert.Equal(t, "input.puml", in)
+		ra := parseRenderArgs([]string{"input.puml", "-o", "out.svg"})
+		assert.Equal(t, "out.svg", ra.outputFile)
+		assert.Equal(t, "input.puml", ra.inputPath)
 	})
 	t.Run("OutputBeforeFile", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{"-o", "out.svg", "input.puml"})
-		assert.Equal(t, "out.svg", out)
-		assert.Equal(t, "input.puml", in)
+		ra := parseRenderArgs([]string{"-o", "out.svg", "input.puml"})
+		assert.Equal(t, "out.svg", ra.outputFile)
+		assert.Equal(t, "input.puml", ra.inputPath)
 	})
 	t.Run("Stdin", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{"-"})
-		assert.Equal(t, "", out)
-		assert.Equal(t, "-", in)
+		ra := parseRenderArgs([]string{"-"})
+		assert.Equal(t, "", ra.outputFile)
+		assert.Equal(t, "-", ra.inputPath)
 	})
 	t.Run("StdinWithOutput", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{"-", "-o", "out.svg"})
-		assert.Equal(t, "out.svg", out)
-		assert.Equal(t, "-", in)
+		ra := parseRenderArgs([]string{"-", "-o", "out.svg"})
+		assert.Equal(t, "out.svg", ra.outputFile)
+		assert.Equal(t, "-", ra.inputPath)
 	})
 	t.Run("Help", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{"--help"})
-		assert.Equal(t, "", out)
-		assert.Equal(t, "", in)
+		ra := parseRenderArgs([]string{"--help"})
+		assert.Equal(t, "", ra.outputFile)
+		assert.Equal(t, "", ra.inputPath)
+	})
+	t.Run("JSONErrors", func(t *testing.T) {
+		t.Parallel()
+		ra := parseRenderArgs([]string{"--json-errors", "input.puml"})
+		assert.Equal(t, "", ra.outputFile)
+		assert.Equal(t, "input.puml", ra.inputPath)
+		assert.True(t, ra.jsonErrors)
+	})
+	t.Run("Minify", func(t *testing.T) {
+		t.Parallel()
+		ra := parseRenderArgs([]string{"--minify", "input.puml"})
+		assert.Equal(t, "input.puml", ra.inputPath)
+		assert.True(t, ra.minify)
+	})
+	t.Run("Transparent", func(t *testing.T) {
+		t.Parallel()
+		ra := parseRenderArgs([]string{"--transparent", "input.puml"})
+		assert.Equal(t, "input.puml", ra.inputPath)
+		assert.True(t, ra.transparent)
+	})
+	t.Run("DebugLayout", func(t *testing.T) {
+		t.Parallel()
+		ra := parseRenderArgs([]string{"input.puml", "--debug-layout"})
+		assert.Equal(t, "input.puml", ra.inputPath)
+		assert.True(t, ra.debugLayout)
+	})
+	t.Run("Theme", func(t *testing.T) {
+		t.Parallel()
+		ra := parseRenderArgs([]string{"input.puml", "--theme", "monokai"})
+		assert.Equal(t, "monokai", ra.themeName)
+		assert.Equal(t, "input.puml", ra.inputPath)
+	})
+	t.Run("ThemeFile", func(t *testing.T) {
+		t.Parallel()
+		ra := parseRenderArgs([]string{"--theme-file", "light.json", "input.puml"})
+		assert.Equal(t, "light.json", ra.themeFile)
+		assert.Equal(t, "input.puml", ra.inputPath)
+	})
+	t.Run("Format", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "png", parseRenderArgs([]string{"-f", "png", "input.puml"}).format)
+		assert.Equal(t, "dot", parseRenderArgs([]string{"input.puml", "--format", "dot"}).format)
 	})
 	t.Run("Empty", func(t *testing.T) {
 		t.Parallel()
-		out, in := parseRenderArgs([]string{})
-		assert.Equal(t, "", out)
-		assert.Equal(t, "", in)
+		ra := parseRenderArgs([]string{})
+		assert.Equal(t, "", ra.outputFile)
+		assert.Equal(t, "", ra.inputPath)
 	})
 }
 
+func TestResolveFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		format  string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{name: "StdoutDefault", want: "svg"},
+		{name: "StdoutExplicit", format: "mermaid", want: "mermaid"},
+		{name: "SVGExtension", output: "out.svg", want: "svg"},
+		{name: "PNGExtension", output: "out.png", want: "png"},
+		{name: "MermaidExtension", output: "out.mmd", want: "mermaid"},
+		{name: "DOTExtension", output: "out.DOT", want: "dot"},
+		{name: "UnknownExtension", output: "out.txt", want: "svg"},
+		{name: "ExplicitWithUnknownExtension", format: "dot", output: "graph.gv", want: "dot"},
+		{name: "ExplicitMatchesExtension", format: "png", output: "out.png", want: "png"},
+		{name: "Conflict", format: "svg", output: "out.png", wantErr: true},
+		{name: "UnknownFormat", format: "pdf", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveFormat(renderArgs{format: tt.format, outputFile: tt.output})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestCmdRender(t *testing.T) {
 	t.Parallel()
 	t.Run("FileToFile", func(t *testing.T) {
@@ -88,6 +172,40 @@ func TestCmdRender(t *testing.T) {
 		assert.Contains(t, string(data), "<svg")
 		assert.Contains(t, string(data), "Foo")
 	})
+	t.Run("Formats", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			args   []string
+			output string
+			expect string
+		}{
+			{[]string{"-f", "svg"}, "out", "<svg"},
+			{nil, "out.png", "\x89PNG"},
+			{[]string{"--format", "png"}, "out", "\x89PNG"},
+			{nil, "out.mmd", "classDiagram"},
+			{[]string{"-f", "mermaid"}, "out", "classDiagram"},
+			{nil, "out.dot", "digraph G {"},
+			{[]string{"--format", "dot"}, "out", "digraph G {"},
+		}
+		for _, tt := range tests {
+			input := writeTempFile(t, validClass)
+			output := filepath.Join(t.TempDir(), tt.output)
+			code := cmdRender(append([]string{input, "-o", output}, tt.args...))
+			require.Equal(t, exitSuccess, code, tt.args, tt.output)
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(data), tt.expect), "%v %s: %.40q", tt.args, tt.output, data)
+		}
+	})
+	t.Run("FormatConflict", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		output := filepath.Join(t.TempDir(), "out.png")
+		code := cmdRender([]string{input, "-o", output, "--format", "svg"})
+		assert.Equal(t, exitSystem, code)
+		_, err := os.Stat(output)
+		assert.True(t, os.IsNotExist(err))
+	})
 	t.Run("OutputBeforeFile", func(t *testing.T) {
 		t.Parallel()
 		input := writeTempFile(t, validClass)
@@ -115,6 +233,121 @@ func TestCmdRender(t *testing.T) {
 		code := cmdRender([]string{input, "-o", output})
 		assert.Equal(t, exitValidation, code)
 	})
+	t.Run("Include", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\n!include shared.puml\nFoo --> Shared\n@enduml")
+		require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(input), "shared.puml"), []byte("class Shared"), 0o644))
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{input, "-o", output})
+		assert.Equal(t, exitSuccess, code)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), ">Shared<")
+	})
+	t.Run("MissingInclude", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\n!include shared.puml\n@enduml")
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{input, "-o", output})
+		assert.Equal(t, exitValidation, code)
+	})
+	t.Run("ThemeFile", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		themeFile := filepath.Join(t.TempDir(), "theme.json")
+		require.NoError(t, os.WriteFile(themeFile, []byte(`{"backgroundColor": "#123456"}`), 0o644))
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{"--theme-file", themeFile, input, "-o", output})
+		assert.Equal(t, exitSuccess, code)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `fill="#123456"`)
+	})
+	t.Run("Theme", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{"--theme", "monokai", input, "-o", output})
+		assert.Equal(t, exitSuccess, code)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `fill="#272822"`)
+	})
+	t.Run("NamedThemes", func(t *testing.T) {
+		t.Parallel()
+		for name, background := range map[string]string{
+			"darcula":   "#2B2B2B",
+			"monokai":   "#272822",
+			"solarized": "#002B36",
+			"light":     "#FFFFFF",
+			"mono":      "#FFFFFF",
+		} {
+			input := writeTempFile(t, validClass)
+			output := filepath.Join(t.TempDir(), "out.svg")
+			code := cmdRender([]string{"--theme", name, input, "-o", output})
+			require.Equal(t, exitSuccess, code, name)
+			data, err := os.ReadFile(output)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `fill="`+background+`"`, name)
+		}
+	})
+	t.Run("DefaultTheme", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		output := filepath.Join(t.TempDir(), "out.svg")
+		require.Equal(t, exitSuccess, cmdRender([]string{input, "-o", output}))
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `fill="#2B2B2B"`)
+	})
+	t.Run("ThemeFileTakesPrecedence", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		themeFile := filepath.Join(t.TempDir(), "theme.json")
+		require.NoError(t, os.WriteFile(themeFile, []byte(`{"backgroundColor": "#123456"}`), 0o644))
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{"--theme", "nope", "--theme-file", themeFile, input, "-o", output})
+		assert.Equal(t, exitSuccess, code)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `fill="#123456"`)
+	})
+	t.Run("MissingThemeFile", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		themeFile := filepath.Join(t.TempDir(), "missing.json")
+		code := cmdRender([]string{"--theme-file", themeFile, input, "-o", filepath.Join(t.TempDir(), "out.svg")})
+		assert.Equal(t, exitSystem, code)
+	})
+	t.Run("ThemeJSONPath", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		themeFile := filepath.Join(t.TempDir(), "mytheme.json")
+		require.NoError(t, os.WriteFile(themeFile, []byte(`{"backgroundColor": "#123456", "extra": "ignored"}`), 0o644))
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{"--theme", themeFile, input, "-o", output})
+		assert.Equal(t, exitSuccess, code)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `fill="#123456"`)
+	})
+	t.Run("UnknownTheme", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		code := cmdRender([]string{"--theme", "nope", input, "-o", filepath.Join(t.TempDir(), "out.svg")})
+		assert.Equal(t, exitSystem, code)
+	})
+	t.Run("BadThemeFile", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		themeFile := filepath.Join(t.TempDir(), "theme.json")
+		require.NoError(t, os.WriteFile(themeFile, []byte(`{"backgroundColor": 1}`), 0o644))
+		output := filepath.Join(t.TempDir(), "out.svg")
+		code := cmdRender([]string{"--theme-file", themeFile, input, "-o", output})
+		assert.Equal(t, exitSystem, code)
+		_, err := os.Stat(output)
+		assert.True(t, os.IsNotExist(err))
+	})
 }
 
 func TestCmdValidate(t *testing.T) {
@@ -131,6 +364,18 @@ func TestCmdValidate(t *testing.T) {
 		code := cmdValidate([]string{input})
 		assert.Equal(t, exitValidation, code)
 	})
+	t.Run("WarningsOnly", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\nskinparam clasBackgroundColor red\nclass Foo\n@enduml")
+		code := cmdValidate([]string{input})
+		assert.Equal(t, exitSuccess, code)
+	})
+	t.Run("MissingInclude", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\n!include shared.puml\nclass Foo\n@enduml")
+		code := cmdValidate([]string{input})
+		assert.Equal(t, exitValidation, code)
+	})
 	t.Run("MissingFile", func(t *testing.T) {
 		t.Parallel()
 		code := cmdValidate([]string{"/nonexistent/file.puml"})
@@ -143,6 +388,97 @@ func TestCmdValidate(t *testing.T) {
 	})
 }
 
+func TestCmdAST(t *testing.T) {
+	t.Parallel()
+	t.Run("MissingFile", func(t *testing.T) {
+		t.Parallel()
+		code := cmdAST([]string{"/nonexistent/file.puml"})
+		assert.Equal(t, exitSystem, code)
+	})
+	t.Run("NoArgs", func(t *testing.T) {
+		t.Parallel()
+		code := cmdAST([]string{})
+		assert.Equal(t, exitSystem, code)
+	})
+}
+
+func TestCmdWatch(t *testing.T) {
+	t.Parallel()
+	t.Run("MissingFile", func(t *testing.T) {
+		t.Parallel()
+		code := cmdWatch([]string{"/nonexistent/file.puml"})
+		assert.Equal(t, exitSystem, code)
+	})
+	t.Run("NoArgs", func(t *testing.T) {
+		t.Parallel()
+		code := cmdWatch([]string{})
+		assert.Equal(t, exitSystem, code)
+	})
+}
+
+func TestWatchFile(t *testing.T) {
+	t.Parallel()
+	input := writeTempFile(t, "@startuml\nclass First\n@enduml\n")
+	output := filepath.Join(t.TempDir(), "out.svg")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watchFile(ctx, input, output, 10*time.Millisecond, io.Discard) }()
+	outputContains := func(s string) func() bool {
+		return func() bool {
+			data, err := os.ReadFile(output)
+			return err == nil && strings.Contains(string(data), s)
+		}
+	}
+	require.Eventually(t, outputContains("First"), 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, os.WriteFile(input, []byte("@startuml\nclass SecondVersion\n@enduml\n"), 0o644))
+	require.Eventually(t, outputContains("SecondVersion"), 5*time.Second, 10*time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchFile did not stop after cancel")
+	}
+}
+
+func TestWriteJSONErrors(t *testing.T) {
+	t.Parallel()
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		errs := []*gouml.Error{{Line: 2, Column: 5, Message: "unexpected token"}}
+		require.NoError(t, writeJSONErrors(&buf, "in.puml", errs))
+		assert.JSONEq(t, `[{"file":"in.puml","line":2,"column":5,"message":"unexpected token"}]`, buf.String())
+	})
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, writeJSONErrors(&buf, "in.puml", nil))
+		assert.Equal(t, "[]\n", buf.String())
+	})
+}
+
+func TestWriteCompletion(t *testing.T) {
+	t.Parallel()
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, writeCompletion(&buf, shell))
+			out := buf.String()
+			for _, want := range []string{"go-uml", "render", "validate", "ast", "watch", "serve", "svg png mermaid dot", "darcula monokai solarized light mono", "puml", "uml"} {
+				assert.Contains(t, out, want)
+			}
+		})
+	}
+	t.Run("UnknownShell", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		assert.Error(t, writeCompletion(&buf, "powershell"))
+		assert.Empty(t, buf.String())
+	})
+}
+
 func TestBinary(t *testing.T) {
 	t.Parallel()
 	bin := buildBinary(t)
@@ -160,6 +496,48 @@ func TestBinary(t *testing.T) {
 		assert.Contains(t, string(out), "render")
 		assert.Contains(t, string(out), "validate")
 		assert.Contains(t, string(out), "serve")
+		assert.Contains(t, string(out), "completion")
+	})
+	t.Run("CompletionBash", func(t *testing.T) {
+		t.Parallel()
+		out, err := exec.Command(bin, "completion", "--shell", "bash").Output()
+		require.NoError(t, err)
+		script := string(out)
+		assert.Contains(t, script, "render validate ast watch serve")
+		assert.Contains(t, script, "-o -f --format --theme")
+		assert.Contains(t, script, `"svg png mermaid dot"`)
+		assert.Contains(t, script, `"darcula monokai solarized light mono"`)
+		assert.Contains(t, script, "complete -o filenames -F _go_uml go-uml")
+		if _, err := exec.LookPath("bash"); err != nil {
+			return
+		}
+		// Run the completion function the way bash would.
+		dir := t.TempDir()
+		for _, name := range []string{"a.puml", "b.uml", "c.txt"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+		}
+		complete := func(words ...string) string {
+			t.Helper()
+			cmd := exec.Command("bash", "-c", `source /dev/stdin
+COMP_WORDS=("$@"); COMP_CWORD=$(($#-1)); _go_uml; echo "${COMPREPLY[*]}"`, "bash")
+			cmd.Args = append(cmd.Args, words...)
+			cmd.Dir = dir
+			cmd.Stdin = strings.NewReader(script)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+			return strings.TrimSpace(string(out))
+		}
+		assert.Equal(t, "render", complete("go-uml", "ren"))
+		assert.Equal(t, "svg png mermaid dot", complete("go-uml", "render", "--format", ""))
+		assert.Equal(t, "light", complete("go-uml", "render", "--theme", "l"))
+		assert.Equal(t, "--theme --theme-file", complete("go-uml", "render", "--them"))
+		assert.Equal(t, "a.puml b.uml", complete("go-uml", "render", ""))
+	})
+	t.Run("CompletionUnknownShell", func(t *testing.T) {
+		t.Parallel()
+		out, err := exec.Command(bin, "completion", "--shell", "tcsh").CombinedOutput()
+		assert.Error(t, err)
+		assert.Contains(t, string(out), "unsupported shell")
 	})
 	t.Run("NoArgs", func(t *testing.T) {
 		t.Parallel()
@@ -213,4 +591,109 @@ func TestBinary(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, string(out), "expected @startuml")
 	})
+	t.Run("AST", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		out, err := exec.Command(bin, "ast", input).Output()
+		require.NoError(t, err)
+		var doc struct {
+			Diagram struct {
+				Statements []map[string]any `json:"statements"`
+			} `json:"diagram"`
+			Errors []any `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(out, &doc))
+		require.Len(t, doc.Diagram.Statements, 1)
+		assert.Equal(t, "ClassDef", doc.Diagram.Statements[0]["type"])
+		assert.Empty(t, doc.Errors)
+	})
+	t.Run("ValidateJSONErrors", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "not a diagram")
+		out, err := exec.Command(bin, "validate", "--json-errors", input).Output()
+		assert.Error(t, err)
+		var errs []map[string]any
+		require.NoError(t, json.Unmarshal(out, &errs))
+		require.NotEmpty(t, errs)
+		assert.Equal(t, input, errs[0]["file"])
+		assert.Equal(t, float64(1), errs[0]["line"])
+		assert.Contains(t, errs[0]["message"], "expected @startuml")
+	})
+	t.Run("ValidateWarnings", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\nparticipant Alice\nAlice -> Bob\n@enduml")
+		out, err := exec.Command(bin, "validate", input).CombinedOutput()
+		require.NoError(t, err, "warnings alone must not fail validation")
+		assert.Contains(t, string(out), "warning: "+input+":3:")
+		assert.Contains(t, string(out), "OK")
+		out, err = exec.Command(bin, "validate", "--json-errors", input).Output()
+		require.NoError(t, err)
+		var errs []map[string]any
+		require.NoError(t, json.Unmarshal(out, &errs))
+		require.Len(t, errs, 1)
+		assert.Equal(t, "warning", errs[0]["severity"])
+	})
+	t.Run("ValidateErrorPrefix", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\nclass Foo {\n@enduml")
+		out, err := exec.Command(bin, "validate", input).CombinedOutput()
+		assert.Error(t, err)
+		assert.Contains(t, string(out), "error: "+input+":")
+		assert.NotContains(t, string(out), "OK")
+	})
+	t.Run("ErrorAfterInclude", func(t *testing.T) {
+		t.Parallel()
+		// The merged source has three more lines than main.puml, which
+		// holds the error on its line 4.
+		input := writeTempFile(t, "@startuml\n!include shapes.puml\nclass Foo {\n@enduml")
+		shapes := filepath.Join(filepath.Dir(input), "shapes.puml")
+		require.NoError(t, os.WriteFile(shapes, []byte("class A\nclass B\nclass C"), 0o644))
+		out, err := exec.Command(bin, "validate", input).CombinedOutput()
+		assert.Error(t, err)
+		assert.Contains(t, string(out), "error: "+input+":4:7: expected closing }")
+		out, err = exec.Command(bin, "validate", "--json-errors", input).Output()
+		assert.Error(t, err)
+		var errs []map[string]any
+		require.NoError(t, json.Unmarshal(out, &errs))
+		require.NotEmpty(t, errs)
+		assert.Equal(t, input, errs[0]["file"])
+		assert.Equal(t, float64(4), errs[0]["line"])
+		cmd := exec.Command(bin, "render", "--json-errors", input, "-o", filepath.Join(t.TempDir(), "out.svg"))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		assert.Error(t, cmd.Run())
+		errs = nil
+		require.NoError(t, json.Unmarshal(stderr.Bytes(), &errs))
+		require.Len(t, errs, 1)
+		assert.Equal(t, input, errs[0]["file"])
+		assert.Equal(t, float64(4), errs[0]["line"])
+	})
+	t.Run("ErrorInIncludedFile", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "@startuml\nclass Foo\n!include shapes.puml\n@enduml")
+		shapes := filepath.Join(filepath.Dir(input), "shapes.puml")
+		require.NoError(t, os.WriteFile(shapes, []byte("@startuml\nclass A\n$bad\n@enduml"), 0o644))
+		out, err := exec.Command(bin, "render", input, "-o", filepath.Join(t.TempDir(), "out.svg")).CombinedOutput()
+		assert.Error(t, err)
+		assert.Contains(t, string(out), "error: "+shapes+":3:1: unexpected token")
+	})
+	t.Run("ValidateJSONSuccess", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, validClass)
+		out, err := exec.Command(bin, "validate", "--json-errors", input).Output()
+		require.NoError(t, err)
+		assert.Equal(t, "[]\n", string(out))
+	})
+	t.Run("RenderJSONErrors", func(t *testing.T) {
+		t.Parallel()
+		input := writeTempFile(t, "not a diagram")
+		cmd := exec.Command(bin, "render", "--json-errors", input, "-o", filepath.Join(t.TempDir(), "out.svg"))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		assert.Error(t, cmd.Run())
+		var errs []map[string]any
+		require.NoError(t, json.Unmarshal(stderr.Bytes(), &errs))
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0]["message"], "expected @startuml")
+	})
 }
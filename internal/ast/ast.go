@@ -30,10 +30,12 @@ const (
 type Modifier int
 
 const (
-	ModifierNone   Modifier = iota
-	ModifierStatic          // {static}
-	ModifierField           // {field}
-	ModifierMethod          // {method}
+	ModifierNone     Modifier = iota
+	ModifierStatic            // {static}
+	ModifierField             // {field}
+	ModifierMethod            // {method}
+	ModifierAbstract          // {abstract}
+	ModifierReadOnly          // {readOnly}
 )
 
 // Diagram is the root AST node representing a complete PlantUML diagram.
@@ -48,6 +50,84 @@ type Diagram struct {
 
 func (d *Diagram) Position() lexer.Pos { return d.Pos }
 
+// DiagramKind classifies a diagram by the kind of PlantUML diagram its
+// statements make up.
+type DiagramKind int
+
+const (
+	DiagramKindUnknown   DiagramKind = iota // no statement decides the kind
+	DiagramKindClass                        // classes, interfaces, enums and their relationships
+	DiagramKindSequence                     // participants and messages
+	DiagramKindActivity                     // start, actions and control flow
+	DiagramKindUsecase                      // usecases and their actors
+	DiagramKindComponent                    // components and interfaces
+	DiagramKindER                           // entities and crow's-foot relationships
+)
+
+var diagramKindNames = [...]string{
+	DiagramKindUnknown:   "unknown",
+	DiagramKindClass:     "class",
+	DiagramKindSequence:  "sequence",
+	DiagramKindActivity:  "activity",
+	DiagramKindUsecase:   "usecase",
+	DiagramKindComponent: "component",
+	DiagramKindER:        "er",
+}
+
+// String returns the lower-case name of the kind, such as "sequence".
+func (k DiagramKind) String() string {
+	if k < 0 || int(k) >= len(diagramKindNames) {
+		return "unknown"
+	}
+	return diagramKindNames[k]
+}
+
+// DiagramType returns the kind of diagram d is, judged from its top-level
+// statements. The kinds are checked in order: activity, usecase, sequence,
+// component, ER and class, so a diagram that mixes messages with classes
+// is a sequence diagram. A diagram with none of their statements, such as
+// an empty one, is DiagramKindUnknown.
+func (d *Diagram) DiagramType() DiagramKind {
+	seen := map[DiagramKind]bool{}
+	for _, stmt := range d.Statements {
+		seen[statementKind(stmt)] = true
+	}
+	for _, kind := range []DiagramKind{
+		DiagramKindActivity, DiagramKindUsecase, DiagramKindSequence,
+		DiagramKindComponent, DiagramKindER, DiagramKindClass,
+	} {
+		if seen[kind] {
+			return kind
+		}
+	}
+	return DiagramKindUnknown
+}
+
+// statementKind returns the kind of diagram stmt belongs to, or
+// DiagramKindUnknown if it can appear in several kinds.
+func statementKind(stmt Statement) DiagramKind {
+	switch s := stmt.(type) {
+	case *ActivityStart, *ActivityStop, *Action, *Decision:
+		return DiagramKindActivity
+	case *Usecase:
+		return DiagramKindUsecase
+	case *Participant, *Message, *Fragment, *Activate, *Autonumber, *Delay, *Lifecycle:
+		return DiagramKindSequence
+	case *Component:
+		return DiagramKindComponent
+	case *Entity:
+		return DiagramKindER
+	case *Relationship:
+		if s.LeftCrowFoot != CrowFootNone {
+			return DiagramKindER
+		}
+		return DiagramKindClass
+	case *ClassDef, *InterfaceDef, *AnnotationDef, *EnumDef, *ObjectDef, *AssociationClass, *Package:
+		return DiagramKindClass
+	}
+	return DiagramKindUnknown
+}
+
 // Comment represents a comment statement preserved in the AST.
 type Comment struct {
 	Pos  lexer.Pos
@@ -56,3 +136,72 @@ type Comment struct {
 
 func (c *Comment) Position() lexer.Pos { return c.Pos }
 func (c *Comment) stmtNode()           {}
+
+// Walk traverses the tree rooted at node in depth-first order. It calls
+// fn(node) for every node it reaches; if fn returns false, the children of
+// that node are skipped. The children of a node are the statements of a
+// Diagram, Package, Fragment, ElsePart or Decision, and the members of a
+// ClassDef, InterfaceDef, AnnotationDef, EnumDef or ObjectDef.
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+	for _, child := range children(node) {
+		Walk(child, fn)
+	}
+}
+
+// Inspect traverses the tree like Walk, but calls fn twice for every node
+// whose pre-order call returns true: once with the node before its children
+// are visited, and once with nil after all of them have been.
+func Inspect(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+	for _, child := range children(node) {
+		Inspect(child, fn)
+	}
+	fn(nil)
+}
+
+// children returns the direct child nodes of node in source order.
+func children(node Node) []Node {
+	var out []Node
+	addStmts := func(stmts []Statement) {
+		for _, s := range stmts {
+			out = append(out, s)
+		}
+	}
+	addMembers := func(members []Member) {
+		for _, m := range members {
+			out = append(out, m)
+		}
+	}
+	switch n := node.(type) {
+	case *Diagram:
+		addStmts(n.Statements)
+	case *Package:
+		addStmts(n.Statements)
+	case *Fragment:
+		addStmts(n.Statements)
+		for i := range n.ElseParts {
+			out = append(out, &n.ElseParts[i])
+		}
+	case *ElsePart:
+		addStmts(n.Statements)
+	case *Decision:
+		addStmts(n.Then)
+		addStmts(n.Else)
+	case *ClassDef:
+		addMembers(n.Members)
+	case *InterfaceDef:
+		addMembers(n.Members)
+	case *AnnotationDef:
+		addMembers(n.Members)
+	case *EnumDef:
+		addMembers(n.Members)
+	case *ObjectDef:
+		addMembers(n.Members)
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package ast
+
+import "github.com/bobcob7/go-uml/internal/lexer"
+
+// ActivityStart represents the start node of an activity diagram.
+type ActivityStart struct {
+	Pos lexer.Pos
+}
+
+func (a *ActivityStart) Position() lexer.Pos { return a.Pos }
+func (a *ActivityStart) stmtNode()           {}
+
+// ActivityStop represents the stop node of an activity diagram.
+type ActivityStop struct {
+	Pos lexer.Pos
+}
+
+func (a *ActivityStop) Position() lexer.Pos { return a.Pos }
+func (a *ActivityStop) stmtNode()           {}
+
+// Action represents an activity action written as :text;.
+type Action struct {
+	Pos  lexer.Pos
+	Text string
+}
+
+func (a *Action) Position() lexer.Pos { return a.Pos }
+func (a *Action) stmtNode()           {}
+
+// Decision represents an if/else/endif block in an activity diagram.
+type Decision struct {
+	Pos       lexer.Pos
+	Condition string
+	ThenLabel string
+	Then      []Statement
+	ElseLabel string
+	Else      []Statement
+}
+
+func (d *Decision) Position() lexer.Pos { return d.Pos }
+func (d *Decision) stmtNode()           {}
@@ -0,0 +1,51 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityStartStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 2, Column: 1}
+		var s ast.Statement = &ast.ActivityStart{Pos: pos}
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
+func TestActivityStopStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 5, Column: 1}
+		var s ast.Statement = &ast.ActivityStop{Pos: pos}
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
+func TestActionStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 3, Column: 1}
+		a := &ast.Action{Pos: pos, Text: "do something"}
+		var s ast.Statement = a
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
+func TestDecisionStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 4, Column: 1}
+		d := &ast.Decision{Pos: pos, Condition: "ready?", ThenLabel: "yes", ElseLabel: "no"}
+		var s ast.Statement = d
+		assert.Equal(t, pos, s.Position())
+	})
+}
@@ -24,6 +24,17 @@ const (
 	ArrowNone                        // --
 )
 
+// LineStyle is an inline style given inside a relationship arrow, as in
+// "-[dashed]->".
+type LineStyle int
+
+const (
+	LineDefault LineStyle = iota // no inline style; drawn as the type implies
+	LineDashed                   // -[dashed]->
+	LineDotted                   // -[dotted]->
+	LineBold                     // -[bold]->
+)
+
 // Member is the interface for class/interface/enum members.
 type Member interface {
 	Node
@@ -38,6 +49,17 @@ type ClassDef struct {
 	Abstract   bool
 	Members    []Member
 	Stereotype string
+	// ExtraStereotypes holds the stereotypes written after the first on
+	// the same line, as in class Foo <<service>> <<entity>>.
+	ExtraStereotypes []string
+	// SpotLetter and SpotColor describe the circled letter given in a
+	// stereotype such as <<(S,#FF7700) Service>>; SpotLetter is empty when
+	// there is no spot, and SpotColor when it has no colour of its own.
+	SpotLetter string
+	SpotColor  string
+	// BackgroundColor is the element's own colour, e.g. "#LightBlue" or
+	// "#FF0000", overriding the theme. Empty means use the theme.
+	BackgroundColor string
 }
 
 func (c *ClassDef) Position() lexer.Pos { return c.Pos }
@@ -55,6 +77,20 @@ type InterfaceDef struct {
 func (i *InterfaceDef) Position() lexer.Pos { return i.Pos }
 func (i *InterfaceDef) stmtNode()           {}
 
+// AnnotationDef represents a Java-style annotation type definition, such as
+// "annotation Deprecated". It is drawn like an interface, with an "@"
+// before its name.
+type AnnotationDef struct {
+	Pos        lexer.Pos
+	Name       string
+	Alias      string
+	Members    []Member
+	Stereotype string
+}
+
+func (a *AnnotationDef) Position() lexer.Pos { return a.Pos }
+func (a *AnnotationDef) stmtNode()           {}
+
 // EnumDef represents an enum definition.
 type EnumDef struct {
 	Pos        lexer.Pos
@@ -68,6 +104,19 @@ type EnumDef struct {
 func (e *EnumDef) Position() lexer.Pos { return e.Pos }
 func (e *EnumDef) stmtNode()           {}
 
+// ObjectDef represents an object (class instance) definition.
+type ObjectDef struct {
+	Pos        lexer.Pos
+	Name       string
+	InstanceOf string // class the object is an instance of, if given
+	Alias      string
+	Members    []Member
+	Stereotype string
+}
+
+func (o *ObjectDef) Position() lexer.Pos { return o.Pos }
+func (o *ObjectDef) stmtNode()           {}
+
 // Field represents a class field/attribute.
 type Field struct {
 	Pos        lexer.Pos
@@ -75,6 +124,9 @@ type Field struct {
 	Type       string
 	Visibility Visibility
 	Modifier   Modifier
+	// Value is the explicit value given after '=', such as the ordinal of
+	// an enum constant in "RED = 0"; empty when there is none.
+	Value string
 }
 
 func (f *Field) Position() lexer.Pos { return f.Pos }
@@ -93,6 +145,27 @@ type Method struct {
 func (m *Method) Position() lexer.Pos { return m.Pos }
 func (m *Method) memberNode()         {}
 
+// SeparatorStyle is the line drawn by a separator inside a class body.
+type SeparatorStyle int
+
+const (
+	SeparatorSolid     SeparatorStyle = iota // --
+	SeparatorDotted                          // ..
+	SeparatorDouble                          // ==
+	SeparatorUnderline                       // __
+)
+
+// Separator is a divider line between the members of a class body, such as
+// "..", "==" or "-- Section --", with an optional centred label.
+type Separator struct {
+	Pos   lexer.Pos
+	Style SeparatorStyle
+	Label string
+}
+
+func (s *Separator) Position() lexer.Pos { return s.Pos }
+func (s *Separator) memberNode()         {}
+
 // Relationship represents a connection between two elements.
 type Relationship struct {
 	Pos       lexer.Pos
@@ -103,12 +176,34 @@ type Relationship struct {
 	Label     string
 	LeftCard  string // left cardinality
 	RightCard string // right cardinality
-	Arrow     string // raw arrow literal
+	Arrow     string // raw arrow literal, without any inline style
+	LineStyle LineStyle
+	// LeftCrowFoot and RightCrowFoot are the cardinalities of an entity
+	// relationship arrow such as "||--o{"; CrowFootNone for other arrows.
+	LeftCrowFoot  CrowFoot
+	RightCrowFoot CrowFoot
 }
 
 func (r *Relationship) Position() lexer.Pos { return r.Pos }
 func (r *Relationship) stmtNode()           {}
 
+// LinkID identifies the relationship as the target of a note on link. It is
+// made from the two ends, so relationships between the same classes share
+// it; a note belongs to the last of them declared before it.
+func (r *Relationship) LinkID() string { return r.Left + "--" + r.Right }
+
+// AssociationClass attaches a class to the relationship between Left and
+// Right, as in "(Student, Course) .. Enrollment".
+type AssociationClass struct {
+	Pos   lexer.Pos
+	Left  string
+	Right string
+	Class string
+}
+
+func (a *AssociationClass) Position() lexer.Pos { return a.Pos }
+func (a *AssociationClass) stmtNode()           {}
+
 // Package represents a package or namespace grouping.
 type Package struct {
 	Pos         lexer.Pos
@@ -30,6 +30,17 @@ func TestInterfaceDefStatement(t *testing.T) {
 	})
 }
 
+func TestAnnotationDefStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 5, Column: 1}
+		ad := &ast.AnnotationDef{Pos: pos, Name: "Deprecated"}
+		var s ast.Statement = ad
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
 func TestEnumDefStatement(t *testing.T) {
 	t.Parallel()
 	t.Run("ImplementsStatement", func(t *testing.T) {
@@ -41,6 +52,17 @@ func TestEnumDefStatement(t *testing.T) {
 	})
 }
 
+func TestObjectDefStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 4, Column: 1}
+		od := &ast.ObjectDef{Pos: pos, Name: "o1", InstanceOf: "Foo"}
+		var s ast.Statement = od
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
 func TestRelationshipStatement(t *testing.T) {
 	t.Parallel()
 	t.Run("ImplementsStatement", func(t *testing.T) {
@@ -52,6 +74,17 @@ func TestRelationshipStatement(t *testing.T) {
 	})
 }
 
+func TestAssociationClassStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 5, Column: 1}
+		a := &ast.AssociationClass{Pos: pos, Left: "A", Right: "B", Class: "C"}
+		var s ast.Statement = a
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
 func TestPackageStatement(t *testing.T) {
 	t.Parallel()
 	t.Run("ImplementsStatement", func(t *testing.T) {
@@ -85,6 +118,24 @@ func TestMethodMember(t *testing.T) {
 	})
 }
 
+func TestSeparatorMember(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsMember", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 9, Column: 3}
+		s := &ast.Separator{Pos: pos, Style: ast.SeparatorDotted, Label: "Getters"}
+		var member ast.Member = s
+		assert.Equal(t, pos, member.Position())
+	})
+	t.Run("StyleValues", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, ast.SeparatorStyle(0), ast.SeparatorSolid)
+		assert.Equal(t, ast.SeparatorStyle(1), ast.SeparatorDotted)
+		assert.Equal(t, ast.SeparatorStyle(2), ast.SeparatorDouble)
+		assert.Equal(t, ast.SeparatorStyle(3), ast.SeparatorUnderline)
+	})
+}
+
 func TestRelationshipTypeConstants(t *testing.T) {
 	t.Parallel()
 	t.Run("Values", func(t *testing.T) {
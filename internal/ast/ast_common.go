@@ -9,13 +9,16 @@ const (
 	NoteLeft NotePosition = iota
 	NoteRight
 	NoteOver
+	// NoteOnLink places the note beside a relationship line; its Target is
+	// the LinkID of the relationship.
+	NoteOnLink
 )
 
 // Note represents a note attached to an element or floating.
 type Note struct {
 	Pos       lexer.Pos
 	Placement NotePosition
-	Target    string // element the note is attached to
+	Target    string // element the note is attached to, or a relationship's LinkID
 	Text      string
 }
 
@@ -41,3 +44,58 @@ type HideShow struct {
 
 func (h *HideShow) Position() lexer.Pos { return h.Pos }
 func (h *HideShow) stmtNode()           {}
+
+// LayoutDirection represents a "left to right direction" or "top to bottom direction" directive.
+type LayoutDirection struct {
+	Pos         lexer.Pos
+	LeftToRight bool
+}
+
+func (d *LayoutDirection) Position() lexer.Pos { return d.Pos }
+func (d *LayoutDirection) stmtNode()           {}
+
+// Title represents a title directive.
+type Title struct {
+	Pos  lexer.Pos
+	Text string
+}
+
+func (t *Title) Position() lexer.Pos { return t.Pos }
+func (t *Title) stmtNode()           {}
+
+// Header represents a header directive.
+type Header struct {
+	Pos  lexer.Pos
+	Text string
+}
+
+func (h *Header) Position() lexer.Pos { return h.Pos }
+func (h *Header) stmtNode()           {}
+
+// Footer represents a footer directive.
+type Footer struct {
+	Pos  lexer.Pos
+	Text string
+}
+
+func (f *Footer) Position() lexer.Pos { return f.Pos }
+func (f *Footer) stmtNode()           {}
+
+// LegendAlignment indicates where a legend is placed horizontally.
+type LegendAlignment int
+
+const (
+	LegendRight LegendAlignment = iota
+	LegendLeft
+	LegendCenter
+)
+
+// Legend represents a legend ... end legend block.
+type Legend struct {
+	Pos       lexer.Pos
+	Alignment LegendAlignment
+	Text      string
+}
+
+func (l *Legend) Position() lexer.Pos { return l.Pos }
+func (l *Legend) stmtNode()           {}
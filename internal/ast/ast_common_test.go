@@ -41,6 +41,47 @@ func TestHideShowStatement(t *testing.T) {
 	})
 }
 
+func TestLayoutDirectionStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 2, Column: 1}
+		d := &ast.LayoutDirection{Pos: pos, LeftToRight: true}
+		var s ast.Statement = d
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
+func TestTitleHeaderFooterStatements(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 2, Column: 1}
+		for _, s := range []ast.Statement{
+			&ast.Title{Pos: pos, Text: "My System"},
+			&ast.Header{Pos: pos, Text: "draft"},
+			&ast.Footer{Pos: pos, Text: "page 1"},
+		} {
+			assert.Equal(t, pos, s.Position())
+		}
+	})
+}
+
+func TestLegendStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 5, Column: 1}
+		l := &ast.Legend{Pos: pos, Alignment: ast.LegendLeft, Text: "key"}
+		var s ast.Statement = l
+		assert.Equal(t, pos, s.Position())
+	})
+	t.Run("DefaultsToRight", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, ast.LegendRight, ast.Legend{}.Alignment)
+	})
+}
+
 func TestNotePositionConstants(t *testing.T) {
 	t.Parallel()
 	t.Run("Values", func(t *testing.T) {
@@ -48,5 +89,6 @@ func TestNotePositionConstants(t *testing.T) {
 		assert.Equal(t, ast.NotePosition(0), ast.NoteLeft)
 		assert.Equal(t, ast.NotePosition(1), ast.NoteRight)
 		assert.Equal(t, ast.NotePosition(2), ast.NoteOver)
+		assert.Equal(t, ast.NotePosition(3), ast.NoteOnLink)
 	})
 }
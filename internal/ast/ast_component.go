@@ -0,0 +1,15 @@
+package ast
+
+import "github.com/bobcob7/go-uml/internal/lexer"
+
+// Component represents a component diagram element: a component declared as
+// [Name] or "component Name", or an interface declared as () "Name".
+type Component struct {
+	Pos         lexer.Pos
+	Name        string
+	Alias       string
+	IsInterface bool // true for () interface declarations
+}
+
+func (c *Component) Position() lexer.Pos { return c.Pos }
+func (c *Component) stmtNode()           {}
@@ -0,0 +1,25 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponent(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 2, Column: 1}
+		c := &ast.Component{Pos: pos, Name: "Web Server", Alias: "WS"}
+		var s ast.Statement = c
+		assert.Equal(t, pos, s.Position())
+	})
+	t.Run("Interface", func(t *testing.T) {
+		t.Parallel()
+		c := &ast.Component{Name: "HTTP", IsInterface: true}
+		assert.True(t, c.IsInterface)
+	})
+}
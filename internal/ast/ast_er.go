@@ -0,0 +1,38 @@
+package ast
+
+import "github.com/bobcob7/go-uml/internal/lexer"
+
+// CrowFoot is the cardinality at one end of an entity relationship drawn in
+// crow's-foot notation.
+type CrowFoot int
+
+const (
+	CrowFootNone       CrowFoot = iota // not a crow's-foot end
+	CrowFootExactlyOne                 // ||
+	CrowFootZeroOrOne                  // |o or o|
+	CrowFootOneOrMany                  // }| or |{
+	CrowFootZeroOrMany                 // }o or o{
+)
+
+// Entity represents an entity of an entity-relationship diagram, declared
+// as "entity Name { attributes }". A diagram is read as an ER diagram when
+// one of its entities has a body or it uses a crow's-foot arrow; otherwise
+// "entity Name" declares a sequence diagram participant.
+type Entity struct {
+	Pos        lexer.Pos
+	Name       string
+	Alias      string
+	Attributes []EntityAttribute
+}
+
+func (e *Entity) Position() lexer.Pos { return e.Pos }
+func (e *Entity) stmtNode()           {}
+
+// EntityAttribute is one attribute row of an entity, such as "* id : int".
+type EntityAttribute struct {
+	Pos       lexer.Pos
+	Name      string
+	Type      string
+	Mandatory bool // marked with a leading '*'
+	Key       bool // listed above a "--" separator line
+}
@@ -0,0 +1,39 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntity(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 2, Column: 1}
+		e := &ast.Entity{Pos: pos, Name: "User"}
+		var s ast.Statement = e
+		assert.Equal(t, pos, s.Position())
+	})
+	t.Run("JSONRoundTrip", func(t *testing.T) {
+		t.Parallel()
+		d := &ast.Diagram{Statements: []ast.Statement{
+			&ast.Entity{Name: "User", Attributes: []ast.EntityAttribute{
+				{Name: "id", Type: "int", Mandatory: true, Key: true},
+				{Name: "email", Type: "text"},
+			}},
+			&ast.Relationship{
+				Left: "User", Right: "Order", Arrow: "||--o{",
+				LeftCrowFoot: ast.CrowFootExactlyOne, RightCrowFoot: ast.CrowFootZeroOrMany,
+			},
+		}}
+		data, err := d.MarshalJSON()
+		require.NoError(t, err)
+		var got ast.Diagram
+		require.NoError(t, got.UnmarshalJSON(data))
+		assert.Equal(t, d, &got)
+	})
+}
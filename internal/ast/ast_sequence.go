@@ -20,12 +20,16 @@ const (
 type FragmentKind int
 
 const (
-	FragmentAlt   FragmentKind = iota // alt/else
-	FragmentLoop                      // loop
-	FragmentPar                       // par
-	FragmentBreak                     // break
-	FragmentRef                       // ref
-	FragmentGroup                     // group
+	FragmentAlt      FragmentKind = iota // alt/else
+	FragmentLoop                         // loop
+	FragmentPar                          // par
+	FragmentBreak                        // break
+	FragmentRef                          // ref
+	FragmentGroup                        // group
+	FragmentOpt                          // opt
+	FragmentCritical                     // critical
+	FragmentIgnore                       // ignore
+	FragmentConsider                     // consider
 )
 
 // Participant represents a sequence diagram participant declaration.
@@ -34,6 +38,8 @@ type Participant struct {
 	Name  string
 	Alias string
 	Kind  ParticipantKind
+	Color string // optional per-participant colour such as "#FF0000"
+	Order int    // column order from "order N"; lower values are placed further left
 }
 
 func (p *Participant) Position() lexer.Pos { return p.Pos }
@@ -47,6 +53,16 @@ type Message struct {
 	Label  string
 	Arrow  string // raw arrow literal
 	Dashed bool
+	// ActivateTarget and DeactivateSource record the "++" and "--"
+	// activation shorthand written after the target.
+	ActivateTarget   bool
+	DeactivateSource bool
+	// DestroyTarget records the "!!" shorthand, which destroys the target
+	// as the message reaches it.
+	DestroyTarget bool
+	// Bidirectional records an arrow with heads at both ends, such as
+	// "<->" or "<-->".
+	Bidirectional bool
 }
 
 func (m *Message) Position() lexer.Pos { return m.Pos }
@@ -93,15 +109,19 @@ func (r *Return) Position() lexer.Pos { return r.Pos }
 func (r *Return) stmtNode()           {}
 
 // Autonumber represents an autonumber directive in a sequence diagram.
+// Stop pauses numbering and Resume continues it from the last number used.
 type Autonumber struct {
-	Pos   lexer.Pos
-	Start string
+	Pos    lexer.Pos
+	Start  string
+	Stop   bool
+	Resume bool
 }
 
 func (a *Autonumber) Position() lexer.Pos { return a.Pos }
 func (a *Autonumber) stmtNode()           {}
 
-// Divider represents a divider (== text ==) in a sequence diagram.
+// Divider represents a divider (== text ==) in a sequence diagram, or a
+// band separating groups of classes in a class diagram.
 type Divider struct {
 	Pos  lexer.Pos
 	Text string
@@ -118,3 +138,13 @@ type Delay struct {
 
 func (d *Delay) Position() lexer.Pos { return d.Pos }
 func (d *Delay) stmtNode()           {}
+
+// Lifecycle represents a create or destroy statement for a participant.
+type Lifecycle struct {
+	Pos     lexer.Pos
+	Target  string
+	Destroy bool // true for destroy, false for create
+}
+
+func (l *Lifecycle) Position() lexer.Pos { return l.Pos }
+func (l *Lifecycle) stmtNode()           {}
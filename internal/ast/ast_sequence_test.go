@@ -52,6 +52,17 @@ func TestActivateStatement(t *testing.T) {
 	})
 }
 
+func TestLifecycleStatement(t *testing.T) {
+	t.Parallel()
+	t.Run("ImplementsStatement", func(t *testing.T) {
+		t.Parallel()
+		pos := lexer.Pos{Line: 5, Column: 1}
+		l := &ast.Lifecycle{Pos: pos, Target: "Bob", Destroy: true}
+		var s ast.Statement = l
+		assert.Equal(t, pos, s.Position())
+	})
+}
+
 func TestReturnStatement(t *testing.T) {
 	t.Parallel()
 	t.Run("ImplementsStatement", func(t *testing.T) {
@@ -132,5 +143,9 @@ func TestFragmentKindConstants(t *testing.T) {
 		assert.Equal(t, ast.FragmentKind(3), ast.FragmentBreak)
 		assert.Equal(t, ast.FragmentKind(4), ast.FragmentRef)
 		assert.Equal(t, ast.FragmentKind(5), ast.FragmentGroup)
+		assert.Equal(t, ast.FragmentKind(6), ast.FragmentOpt)
+		assert.Equal(t, ast.FragmentKind(7), ast.FragmentCritical)
+		assert.Equal(t, ast.FragmentKind(8), ast.FragmentIgnore)
+		assert.Equal(t, ast.FragmentKind(9), ast.FragmentConsider)
 	})
 }
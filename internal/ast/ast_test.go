@@ -19,6 +19,48 @@ func TestDiagram(t *testing.T) {
 	})
 }
 
+func TestDiagramType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		statements []ast.Statement
+		want       ast.DiagramKind
+	}{
+		{"Empty", nil, ast.DiagramKindUnknown},
+		{"OnlyCommonStatements", []ast.Statement{&ast.Skinparam{}, &ast.Note{}, &ast.Comment{}}, ast.DiagramKindUnknown},
+		{"Participant", []ast.Statement{&ast.Participant{Name: "Alice"}}, ast.DiagramKindSequence},
+		{"Message", []ast.Statement{&ast.Message{From: "Alice", To: "Bob"}}, ast.DiagramKindSequence},
+		{"ClassDef", []ast.Statement{&ast.ClassDef{Name: "Foo"}}, ast.DiagramKindClass},
+		{"InterfaceDef", []ast.Statement{&ast.InterfaceDef{Name: "Foo"}}, ast.DiagramKindClass},
+		{"AnnotationDef", []ast.Statement{&ast.AnnotationDef{Name: "Foo"}}, ast.DiagramKindClass},
+		{"EnumDef", []ast.Statement{&ast.EnumDef{Name: "Color"}}, ast.DiagramKindClass},
+		{"Relationship", []ast.Statement{&ast.Relationship{Left: "A", Right: "B"}}, ast.DiagramKindClass},
+		{"CrowFootRelationship", []ast.Statement{&ast.Relationship{Left: "A", Right: "B", LeftCrowFoot: ast.CrowFootExactlyOne, RightCrowFoot: ast.CrowFootZeroOrMany}}, ast.DiagramKindER},
+		{"Entity", []ast.Statement{&ast.Entity{Name: "User"}}, ast.DiagramKindER},
+		{"Component", []ast.Statement{&ast.Component{Name: "Web"}}, ast.DiagramKindComponent},
+		{"Usecase", []ast.Statement{&ast.Usecase{Name: "Log In"}}, ast.DiagramKindUsecase},
+		{"Action", []ast.Statement{&ast.Action{Text: "work"}}, ast.DiagramKindActivity},
+		{"ActivityStop", []ast.Statement{&ast.ActivityStop{}}, ast.DiagramKindActivity},
+		{"Decision", []ast.Statement{&ast.Decision{Condition: "x", Then: []ast.Statement{&ast.Action{Text: "a"}}}}, ast.DiagramKindActivity},
+		{"DividerAmongClasses", []ast.Statement{&ast.ClassDef{Name: "Foo"}, &ast.Divider{Text: "Domain"}, &ast.ClassDef{Name: "Bar"}}, ast.DiagramKindClass},
+		{"DividerAmongMessages", []ast.Statement{&ast.Divider{Text: "Init"}, &ast.Message{From: "Alice", To: "Bob"}}, ast.DiagramKindSequence},
+		{"MessagesAmongClasses", []ast.Statement{&ast.ClassDef{Name: "Foo"}, &ast.Message{From: "Foo", To: "Bar"}, &ast.Relationship{Left: "Foo", Right: "Bar"}}, ast.DiagramKindSequence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			d := &ast.Diagram{Statements: tt.statements}
+			assert.Equal(t, tt.want, d.DiagramType())
+		})
+	}
+	t.Run("String", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "sequence", ast.DiagramKindSequence.String())
+		assert.Equal(t, "er", ast.DiagramKindER.String())
+		assert.Equal(t, "unknown", ast.DiagramKind(99).String())
+	})
+}
+
 func TestComment(t *testing.T) {
 	t.Parallel()
 	t.Run("ImplementsNode", func(t *testing.T) {
@@ -35,3 +77,127 @@ func TestComment(t *testing.T) {
 		assert.Equal(t, lexer.Pos{Line: 1, Column: 1}, s.Position())
 	})
 }
+
+func walkFixture() *ast.Diagram {
+	return &ast.Diagram{
+		Statements: []ast.Statement{
+			&ast.Package{Name: "pkg", Statements: []ast.Statement{
+				&ast.ClassDef{Name: "Inner", Members: []ast.Member{
+					&ast.Field{Name: "id"},
+					&ast.Method{Name: "run"},
+				}},
+			}},
+			&ast.ClassDef{Name: "Outer"},
+			&ast.Fragment{
+				Statements: []ast.Statement{&ast.Message{From: "A", To: "B"}},
+				ElseParts: []ast.ElsePart{
+					{Statements: []ast.Statement{&ast.Message{From: "B", To: "A"}}},
+				},
+			},
+		},
+	}
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+	t.Run("VisitsAllNodesDepthFirst", func(t *testing.T) {
+		t.Parallel()
+		var names []string
+		ast.Walk(walkFixture(), func(n ast.Node) bool {
+			switch v := n.(type) {
+			case *ast.ClassDef:
+				names = append(names, v.Name)
+			case *ast.Field:
+				names = append(names, v.Name)
+			case *ast.Method:
+				names = append(names, v.Name)
+			case *ast.Message:
+				names = append(names, v.From+v.To)
+			}
+			return true
+		})
+		assert.Equal(t, []string{"Inner", "id", "run", "Outer", "AB", "BA"}, names)
+	})
+	t.Run("FalseSkipsSubtree", func(t *testing.T) {
+		t.Parallel()
+		var visited int
+		ast.Walk(walkFixture(), func(n ast.Node) bool {
+			visited++
+			_, isPkg := n.(*ast.Package)
+			_, isFrag := n.(*ast.Fragment)
+			return !isPkg && !isFrag
+		})
+		// Diagram, Package, Outer, Fragment.
+		assert.Equal(t, 4, visited)
+	})
+	t.Run("NestedPackagesAndFragments", func(t *testing.T) {
+		t.Parallel()
+		d := &ast.Diagram{Statements: []ast.Statement{
+			&ast.Package{Name: "outer", Statements: []ast.Statement{
+				&ast.Package{Name: "inner", Statements: []ast.Statement{
+					&ast.ClassDef{Name: "Deep"},
+				}},
+			}},
+			&ast.Fragment{Statements: []ast.Statement{
+				&ast.Fragment{ElseParts: []ast.ElsePart{
+					{Statements: []ast.Statement{&ast.Message{From: "A", To: "B"}}},
+				}},
+			}},
+		}}
+		var classes, messages int
+		ast.Walk(d, func(n ast.Node) bool {
+			switch n.(type) {
+			case *ast.ClassDef:
+				classes++
+			case *ast.Message:
+				messages++
+			}
+			return true
+		})
+		assert.Equal(t, 1, classes)
+		assert.Equal(t, 1, messages)
+	})
+	t.Run("NilNode", func(t *testing.T) {
+		t.Parallel()
+		called := false
+		ast.Walk(nil, func(ast.Node) bool {
+			called = true
+			return true
+		})
+		assert.False(t, called)
+	})
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+	t.Run("CallsPostOrderWithNil", func(t *testing.T) {
+		t.Parallel()
+		var pre, post int
+		ast.Inspect(walkFixture(), func(n ast.Node) bool {
+			if n == nil {
+				post++
+			} else {
+				pre++
+			}
+			return true
+		})
+		assert.Equal(t, 10, pre)
+		assert.Equal(t, pre, post)
+	})
+	t.Run("TracksDepth", func(t *testing.T) {
+		t.Parallel()
+		depth, maxDepth := 0, 0
+		ast.Inspect(walkFixture(), func(n ast.Node) bool {
+			if n == nil {
+				depth--
+				return true
+			}
+			depth++
+			maxDepth = max(maxDepth, depth)
+			return true
+		})
+		assert.Equal(t, 0, depth)
+		// Diagram > Package > ClassDef > Field.
+		assert.Equal(t, 4, maxDepth)
+	})
+}
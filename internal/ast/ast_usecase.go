@@ -0,0 +1,15 @@
+package ast
+
+import "github.com/bobcob7/go-uml/internal/lexer"
+
+// Usecase represents a usecase diagram element declared as "(Name)" or
+// "usecase Name". A usecase named only inside a relationship, such as
+// "User --> (Login)", is also reported as a Usecase statement.
+type Usecase struct {
+	Pos   lexer.Pos
+	Name  string
+	Alias string
+}
+
+func (u *Usecase) Position() lexer.Pos { return u.Pos }
+func (u *Usecase) stmtNode()           {}
@@ -0,0 +1,17 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsecase(t *testing.T) {
+	t.Parallel()
+	pos := lexer.Pos{Line: 3, Column: 1}
+	u := &ast.Usecase{Pos: pos, Name: "Log In", Alias: "UC1"}
+	var s ast.Statement = u
+	assert.Equal(t, pos, s.Position())
+}
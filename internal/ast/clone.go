@@ -0,0 +1,54 @@
+package ast
+
+import "reflect"
+
+// Clone returns a deep copy of d. Every node, slice and string in the copy is
+// independent of d, so either can be modified without affecting the other.
+func Clone(d *Diagram) *Diagram {
+	if d == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(d)).Interface().(*Diagram)
+}
+
+// cloneValue deep-copies v. Nodes only hold values, pointers, interfaces and
+// slices, so those are the kinds that need copying; anything else is copied
+// by assignment.
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := range v.NumField() {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
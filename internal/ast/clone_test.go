@@ -0,0 +1,64 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	newDiagram := func() *ast.Diagram {
+		return &ast.Diagram{
+			Name:  "example",
+			Title: "Orders",
+			Statements: []ast.Statement{
+				&ast.ClassDef{Name: "Order", Members: []ast.Member{
+					&ast.Field{Name: "id", Type: "int"},
+					&ast.Method{Name: "total", Params: "tax float"},
+				}},
+				&ast.EnumDef{Name: "Status", Values: []string{"Open", "Closed"}},
+				&ast.Package{Name: "billing", Statements: []ast.Statement{&ast.ClassDef{Name: "Invoice"}}},
+				&ast.Fragment{Kind: ast.FragmentAlt, Statements: []ast.Statement{
+					&ast.Message{From: "A", To: "B"},
+				}, ElseParts: []ast.ElsePart{{Condition: "else", Statements: []ast.Statement{
+					&ast.Message{From: "B", To: "A"},
+				}}}},
+			},
+		}
+	}
+	t.Run("Equal", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, newDiagram(), ast.Clone(newDiagram()))
+	})
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, ast.Clone(nil))
+	})
+	t.Run("Independent", func(t *testing.T) {
+		t.Parallel()
+		orig := newDiagram()
+		clone := ast.Clone(orig)
+		clone.Statements[0].(*ast.ClassDef).Name = "Changed"
+		clone.Statements[0].(*ast.ClassDef).Members[0].(*ast.Field).Name = "changed"
+		clone.Statements[0].(*ast.ClassDef).Members[1].(*ast.Method).Params = "changed"
+		clone.Statements[1].(*ast.EnumDef).Values[0] = "Changed"
+		clone.Statements[2].(*ast.Package).Statements[0] = &ast.Comment{Text: "gone"}
+		frag := clone.Statements[3].(*ast.Fragment)
+		frag.Statements[0].(*ast.Message).To = "C"
+		frag.ElseParts[0].Statements[0].(*ast.Message).To = "C"
+		clone.Statements = append(clone.Statements[:1], &ast.Comment{})
+		assert.Equal(t, newDiagram(), orig)
+	})
+	t.Run("NoSharedSlices", func(t *testing.T) {
+		t.Parallel()
+		orig := newDiagram()
+		clone := ast.Clone(orig)
+		require.Len(t, clone.Statements, len(orig.Statements))
+		assert.NotSame(t, &orig.Statements[0], &clone.Statements[0])
+		assert.NotSame(t, orig.Statements[0], clone.Statements[0])
+		assert.NotSame(t, &orig.Statements[0].(*ast.ClassDef).Members[0], &clone.Statements[0].(*ast.ClassDef).Members[0])
+	})
+}
@@ -0,0 +1,165 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// nodeTypes maps the JSON "type" discriminator to the concrete node type so
+// that Statement and Member interface values can be decoded again.
+var nodeTypes = registerNodeTypes(
+	&Comment{}, &Note{}, &Skinparam{}, &HideShow{}, &LayoutDirection{},
+	&Title{}, &Header{}, &Footer{}, &Legend{},
+	&ClassDef{}, &InterfaceDef{}, &AnnotationDef{}, &EnumDef{}, &ObjectDef{}, &Field{}, &Method{}, &Separator{},
+	&Relationship{}, &AssociationClass{}, &Package{},
+	&Participant{}, &Message{}, &Fragment{}, &ElsePart{}, &Activate{},
+	&Return{}, &Autonumber{}, &Divider{}, &Delay{}, &Lifecycle{},
+	&ActivityStart{}, &ActivityStop{}, &Action{}, &Decision{},
+	&Component{}, &Usecase{}, &Entity{},
+)
+
+var diagramType = reflect.TypeOf(Diagram{})
+
+func registerNodeTypes(nodes ...Node) map[string]reflect.Type {
+	m := make(map[string]reflect.Type, len(nodes))
+	for _, n := range nodes {
+		t := reflect.TypeOf(n).Elem()
+		m[t.Name()] = t
+	}
+	return m
+}
+
+// MarshalJSON encodes the diagram with every node carrying a "type" field
+// naming its Go type, and field names in lower camel case.
+func (d *Diagram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encodeStruct(reflect.ValueOf(d).Elem()))
+}
+
+// UnmarshalJSON decodes a diagram produced by MarshalJSON, using the "type"
+// field of each statement and member to restore its concrete type.
+func (d *Diagram) UnmarshalJSON(data []byte) error {
+	return decodeStruct(data, reflect.ValueOf(d).Elem())
+}
+
+func encodeValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		return encodeStruct(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = encodeValue(v.Index(i))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// encodeStruct converts a struct to a map keyed by lower camel case field
+// names, adding a "type" discriminator for structs declared in this package.
+func encodeStruct(v reflect.Value) map[string]any {
+	t := v.Type()
+	m := make(map[string]any, t.NumField()+1)
+	if t.PkgPath() == diagramType.PkgPath() {
+		m["type"] = t.Name()
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		m[jsonName(t, f.Name)] = encodeValue(v.Field(i))
+	}
+	return m
+}
+
+func decodeValue(data json.RawMessage, v reflect.Value) error {
+	if string(data) == "null" {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		var head struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &head); err != nil {
+			return err
+		}
+		t, ok := nodeTypes[head.Type]
+		if !ok {
+			return fmt.Errorf("unknown node type %q", head.Type)
+		}
+		ptr := reflect.New(t)
+		if !ptr.Type().Implements(v.Type()) {
+			return fmt.Errorf("node type %s is not a %s", head.Type, v.Type().Name())
+		}
+		if err := decodeStruct(data, ptr.Elem()); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	case reflect.Struct:
+		return decodeStruct(data, v)
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
+
+func decodeStruct(data []byte, v reflect.Value) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		raw, ok := fields[jsonName(t, f.Name)]
+		if !ok || !f.IsExported() {
+			continue
+		}
+		if err := decodeValue(raw, v.Field(i)); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+	}
+	return nil
+}
+
+// jsonName lower-cases the first letter of a Go field name. A field named
+// Type would clash with the discriminator, so it is prefixed with the owning
+// type instead, e.g. Field.Type becomes "fieldType".
+func jsonName(owner reflect.Type, name string) string {
+	if name == "Type" {
+		return lowerFirst(owner.Name()) + name
+	}
+	return lowerFirst(name)
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
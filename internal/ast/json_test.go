@@ -0,0 +1,75 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagramJSON(t *testing.T) {
+	t.Parallel()
+	t.Run("TypeDiscriminator", func(t *testing.T) {
+		t.Parallel()
+		d := &ast.Diagram{Statements: []ast.Statement{
+			&ast.ClassDef{Pos: lexer.Pos{Line: 2, Column: 1}, Name: "Foo"},
+		}}
+		data, err := json.Marshal(d)
+		require.NoError(t, err)
+		var out map[string]any
+		require.NoError(t, json.Unmarshal(data, &out))
+		assert.Equal(t, "Diagram", out["type"])
+		stmts, ok := out["statements"].([]any)
+		require.True(t, ok)
+		require.Len(t, stmts, 1)
+		cls, ok := stmts[0].(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, "ClassDef", cls["type"])
+		assert.Equal(t, "Foo", cls["name"])
+		assert.Equal(t, map[string]any{"line": float64(2), "column": float64(1)}, cls["pos"])
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+		d := &ast.Diagram{
+			Name:  "demo",
+			Title: "Demo",
+			Statements: []ast.Statement{
+				&ast.Title{Text: "Demo"},
+				&ast.Package{Name: "pkg", Statements: []ast.Statement{
+					&ast.ClassDef{Name: "Foo", Members: []ast.Member{
+						&ast.Field{Name: "id", Type: "int", Visibility: ast.VisibilityPrivate},
+						&ast.Method{Name: "run", Modifier: ast.ModifierStatic},
+					}},
+				}},
+				&ast.Relationship{Left: "Foo", Right: "Bar", Type: ast.RelInheritance},
+				&ast.Fragment{
+					Kind:       ast.FragmentAlt,
+					Statements: []ast.Statement{&ast.Message{From: "A", To: "B", Label: "hi"}},
+					ElseParts:  []ast.ElsePart{{Condition: "other"}},
+				},
+				&ast.Decision{Condition: "ok?", Then: []ast.Statement{&ast.Action{Text: "go"}}},
+			},
+		}
+		data, err := json.Marshal(d)
+		require.NoError(t, err)
+		var got ast.Diagram
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, d, &got)
+	})
+	t.Run("UnknownType", func(t *testing.T) {
+		t.Parallel()
+		var d ast.Diagram
+		err := json.Unmarshal([]byte(`{"statements":[{"type":"Bogus"}]}`), &d)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Bogus")
+	})
+	t.Run("MemberInStatementPosition", func(t *testing.T) {
+		t.Parallel()
+		var d ast.Diagram
+		err := json.Unmarshal([]byte(`{"statements":[{"type":"Field"}]}`), &d)
+		assert.Error(t, err)
+	})
+}
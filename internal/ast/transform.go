@@ -0,0 +1,147 @@
+package ast
+
+// Transform rewrites the tree rooted at n. It visits nodes depth-first,
+// transforming the children of a node before calling fn on the node itself,
+// and replaces each node with the value fn returns; returning nil removes the
+// node from its parent. The children are the same as for Walk.
+//
+// A replacement must fit the place of the node it replaces: a Statement in a
+// statement list, a Member in a member list and an *ElsePart in a fragment's
+// else branches. Anything else is removed as if fn had returned nil.
+//
+// Transform itself never modifies the input tree: a node whose children change
+// is copied before the new children are set, and subtrees that fn leaves alone
+// are shared with the input. If nothing changes, n is returned as is.
+func Transform(n Node, fn func(Node) Node) Node {
+	if n == nil {
+		return nil
+	}
+	switch v := n.(type) {
+	case *Diagram:
+		if stmts, changed := transformStmts(v.Statements, fn); changed {
+			c := *v
+			c.Statements = stmts
+			n = &c
+		}
+	case *Package:
+		if stmts, changed := transformStmts(v.Statements, fn); changed {
+			c := *v
+			c.Statements = stmts
+			n = &c
+		}
+	case *Fragment:
+		stmts, stmtsChanged := transformStmts(v.Statements, fn)
+		parts, partsChanged := transformElseParts(v.ElseParts, fn)
+		if stmtsChanged || partsChanged {
+			c := *v
+			c.Statements = stmts
+			c.ElseParts = parts
+			n = &c
+		}
+	case *ElsePart:
+		if stmts, changed := transformStmts(v.Statements, fn); changed {
+			c := *v
+			c.Statements = stmts
+			n = &c
+		}
+	case *Decision:
+		then, thenChanged := transformStmts(v.Then, fn)
+		els, elseChanged := transformStmts(v.Else, fn)
+		if thenChanged || elseChanged {
+			c := *v
+			c.Then = then
+			c.Else = els
+			n = &c
+		}
+	case *ClassDef:
+		if members, changed := transformMembers(v.Members, fn); changed {
+			c := *v
+			c.Members = members
+			n = &c
+		}
+	case *InterfaceDef:
+		if members, changed := transformMembers(v.Members, fn); changed {
+			c := *v
+			c.Members = members
+			n = &c
+		}
+	case *AnnotationDef:
+		if members, changed := transformMembers(v.Members, fn); changed {
+			c := *v
+			c.Members = members
+			n = &c
+		}
+	case *EnumDef:
+		if members, changed := transformMembers(v.Members, fn); changed {
+			c := *v
+			c.Members = members
+			n = &c
+		}
+	case *ObjectDef:
+		if members, changed := transformMembers(v.Members, fn); changed {
+			c := *v
+			c.Members = members
+			n = &c
+		}
+	}
+	return fn(n)
+}
+
+// transformStmts transforms each statement in stmts and reports whether the
+// result differs from the input. The input slice is left untouched.
+func transformStmts(stmts []Statement, fn func(Node) Node) ([]Statement, bool) {
+	out := make([]Statement, 0, len(stmts))
+	changed := false
+	for _, s := range stmts {
+		r, ok := Transform(s, fn).(Statement)
+		if !ok || r != s {
+			changed = true
+		}
+		if ok && r != nil {
+			out = append(out, r)
+		}
+	}
+	if !changed {
+		return stmts, false
+	}
+	return out, true
+}
+
+// transformMembers is transformStmts for member lists.
+func transformMembers(members []Member, fn func(Node) Node) ([]Member, bool) {
+	out := make([]Member, 0, len(members))
+	changed := false
+	for _, m := range members {
+		r, ok := Transform(m, fn).(Member)
+		if !ok || r != m {
+			changed = true
+		}
+		if ok && r != nil {
+			out = append(out, r)
+		}
+	}
+	if !changed {
+		return members, false
+	}
+	return out, true
+}
+
+// transformElseParts is transformStmts for the else branches of a fragment,
+// which are held by value rather than as nodes.
+func transformElseParts(parts []ElsePart, fn func(Node) Node) ([]ElsePart, bool) {
+	out := make([]ElsePart, 0, len(parts))
+	changed := false
+	for i := range parts {
+		r, ok := Transform(&parts[i], fn).(*ElsePart)
+		if !ok || r != &parts[i] {
+			changed = true
+		}
+		if ok && r != nil {
+			out = append(out, *r)
+		}
+	}
+	if !changed {
+		return parts, false
+	}
+	return out, true
+}
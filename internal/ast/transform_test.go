@@ -0,0 +1,126 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+	newDiagram := func() *ast.Diagram {
+		return &ast.Diagram{Statements: []ast.Statement{
+			&ast.Comment{Text: "top"},
+			&ast.ClassDef{Name: "Order", Members: []ast.Member{
+				&ast.Field{Name: "id"},
+				&ast.Method{Name: "total"},
+			}},
+			&ast.Package{Name: "billing", Statements: []ast.Statement{
+				&ast.Comment{Text: "nested"},
+				&ast.ClassDef{Name: "Invoice"},
+			}},
+			&ast.Fragment{Kind: ast.FragmentAlt, Statements: []ast.Statement{
+				&ast.Comment{Text: "in fragment"},
+				&ast.Message{From: "A", To: "B"},
+			}, ElseParts: []ast.ElsePart{{Condition: "else", Statements: []ast.Statement{
+				&ast.Comment{Text: "in else"},
+				&ast.Message{From: "B", To: "A"},
+			}}}},
+		}}
+	}
+	t.Run("Unchanged", func(t *testing.T) {
+		t.Parallel()
+		d := newDiagram()
+		got := ast.Transform(d, func(n ast.Node) ast.Node { return n })
+		assert.Same(t, d, got)
+	})
+	t.Run("RemoveComments", func(t *testing.T) {
+		t.Parallel()
+		d := newDiagram()
+		got := ast.Transform(d, func(n ast.Node) ast.Node {
+			if _, ok := n.(*ast.Comment); ok {
+				return nil
+			}
+			return n
+		})
+		ast.Walk(got, func(n ast.Node) bool {
+			_, isComment := n.(*ast.Comment)
+			assert.False(t, isComment)
+			return true
+		})
+		result := got.(*ast.Diagram)
+		require.Len(t, result.Statements, 3)
+		assert.Len(t, result.Statements[1].(*ast.Package).Statements, 1)
+		frag := result.Statements[2].(*ast.Fragment)
+		assert.Len(t, frag.Statements, 1)
+		assert.Len(t, frag.ElseParts[0].Statements, 1)
+		assert.Equal(t, newDiagram(), d, "input must not be modified")
+		assert.Same(t, d.Statements[1], result.Statements[0], "untouched subtrees are shared")
+	})
+	t.Run("InsertSkinparamBeforeClass", func(t *testing.T) {
+		t.Parallel()
+		insert := func(stmts []ast.Statement) []ast.Statement {
+			var out []ast.Statement
+			for _, s := range stmts {
+				if c, ok := s.(*ast.ClassDef); ok {
+					out = append(out, &ast.Skinparam{Name: "ClassBackgroundColor", Value: c.Name})
+				}
+				out = append(out, s)
+			}
+			return out
+		}
+		got := ast.Transform(newDiagram(), func(n ast.Node) ast.Node {
+			switch v := n.(type) {
+			case *ast.Diagram:
+				c := *v
+				c.Statements = insert(v.Statements)
+				return &c
+			case *ast.Package:
+				c := *v
+				c.Statements = insert(v.Statements)
+				return &c
+			}
+			return n
+		}).(*ast.Diagram)
+		require.Len(t, got.Statements, 5)
+		assert.Equal(t, &ast.Skinparam{Name: "ClassBackgroundColor", Value: "Order"}, got.Statements[1])
+		assert.IsType(t, &ast.ClassDef{}, got.Statements[2])
+		pkg := got.Statements[3].(*ast.Package)
+		require.Len(t, pkg.Statements, 3)
+		assert.Equal(t, &ast.Skinparam{Name: "ClassBackgroundColor", Value: "Invoice"}, pkg.Statements[1])
+	})
+	t.Run("ReplaceMembers", func(t *testing.T) {
+		t.Parallel()
+		got := ast.Transform(newDiagram(), func(n ast.Node) ast.Node {
+			switch v := n.(type) {
+			case *ast.Method:
+				return nil
+			case *ast.Field:
+				c := *v
+				c.Name = "ID"
+				return &c
+			}
+			return n
+		}).(*ast.Diagram)
+		class := got.Statements[1].(*ast.ClassDef)
+		require.Len(t, class.Members, 1)
+		assert.Equal(t, "ID", class.Members[0].(*ast.Field).Name)
+	})
+	t.Run("MismatchedReplacementRemoved", func(t *testing.T) {
+		t.Parallel()
+		got := ast.Transform(newDiagram(), func(n ast.Node) ast.Node {
+			if _, ok := n.(*ast.Field); ok {
+				return &ast.Comment{}
+			}
+			return n
+		}).(*ast.Diagram)
+		assert.Len(t, got.Statements[1].(*ast.ClassDef).Members, 1)
+	})
+	t.Run("RemoveRoot", func(t *testing.T) {
+		t.Parallel()
+		assert.Nil(t, ast.Transform(newDiagram(), func(ast.Node) ast.Node { return nil }))
+		assert.Nil(t, ast.Transform(nil, func(n ast.Node) ast.Node { return n }))
+	})
+}
@@ -76,10 +76,54 @@ func newFace(family Family, fontSize float64) (font.Face, error) {
 	return face, nil
 }
 
+// NewFace returns a face of the embedded font for family at fontSize pixels,
+// for drawing text. The caller must close it.
+func NewFace(family Family, fontSize float64) (font.Face, error) {
+	return newFace(family, fontSize)
+}
+
+// customMetrics holds metrics registered with RegisterCustomMetrics, in ems.
+type customMetrics struct {
+	charWidths map[rune]float64
+	lineHeight float64
+}
+
+var (
+	customMetricsMu sync.RWMutex
+	registered      = map[Family]customMetrics{}
+)
+
+// RegisterCustomMetrics registers exact advance widths for family, so that
+// MeasureText matches the font the SVG is eventually displayed with. Widths
+// and lineHeight are given in ems and scaled by the font size. Runes missing
+// from charWidths are measured with the built-in face for family. Registering
+// a built-in family such as FamilySans overrides its embedded metrics.
+func RegisterCustomMetrics(family Family, charWidths map[rune]float64, lineHeight float64) {
+	widths := make(map[rune]float64, len(charWidths))
+	for r, w := range charWidths {
+		widths[r] = w
+	}
+	customMetricsMu.Lock()
+	defer customMetricsMu.Unlock()
+	registered[family] = customMetrics{charWidths: widths, lineHeight: lineHeight}
+}
+
+func lookupCustomMetrics(family Family) (customMetrics, bool) {
+	customMetricsMu.RLock()
+	defer customMetricsMu.RUnlock()
+	m, ok := registered[family]
+	return m, ok
+}
+
 // MeasureText computes the pixel dimensions for the given text at the specified
 // font size and family. Multi-line text (separated by \n) is handled by measuring
 // each line independently and returning the maximum width and total height.
+// Metrics registered with RegisterCustomMetrics take precedence over the
+// embedded fonts.
 func MeasureText(text string, fontSize float64, family Family) (Size, error) {
+	if m, ok := lookupCustomMetrics(family); ok {
+		return measureCustom(text, fontSize, family, m)
+	}
 	face, err := newFace(family, fontSize)
 	if err != nil {
 		return Size{}, err
@@ -102,6 +146,36 @@ func MeasureText(text string, fontSize float64, family Family) (Size, error) {
 	return Size{Width: maxWidth, Height: totalHeight}, nil
 }
 
+func measureCustom(text string, fontSize float64, family Family, m customMetrics) (Size, error) {
+	lines := strings.Split(text, "\n")
+	var face font.Face
+	defer func() {
+		if face != nil {
+			_ = face.Close()
+		}
+	}()
+	var maxWidth float64
+	for _, line := range lines {
+		var w float64
+		for _, r := range line {
+			if cw, ok := m.charWidths[r]; ok {
+				w += cw * fontSize
+				continue
+			}
+			if face == nil {
+				var err error
+				if face, err = newFace(family, fontSize); err != nil {
+					return Size{}, err
+				}
+			}
+			adv, _ := face.GlyphAdvance(r)
+			w += fixedToFloat(adv)
+		}
+		maxWidth = max(maxWidth, w)
+	}
+	return Size{Width: maxWidth, Height: float64(len(lines)) * m.lineHeight * fontSize}, nil
+}
+
 func fixedToFloat(v fixed.Int26_6) float64 {
 	return float64(v) / 64.0
 }
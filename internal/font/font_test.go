@@ -105,3 +105,39 @@ func TestMeasureText(t *testing.T) {
 		assert.InDelta(t, sansSize.Width, size.Width, 0.1)
 	})
 }
+
+func TestRegisterCustomMetrics(t *testing.T) {
+	t.Parallel()
+	RegisterCustomMetrics("test-custom", map[rune]float64{'W': 1.0, 'i': 0.25, ' ': 0.5}, 1.2)
+	t.Run("Widths", func(t *testing.T) {
+		t.Parallel()
+		size, err := MeasureText("Wi W", 10, "test-custom")
+		require.NoError(t, err)
+		assert.InDelta(t, 27.5, size.Width, 1e-9)
+		assert.InDelta(t, 12.0, size.Height, 1e-9)
+	})
+	t.Run("MultiLine", func(t *testing.T) {
+		t.Parallel()
+		size, err := MeasureText("i\nWW\n", 20, "test-custom")
+		require.NoError(t, err)
+		assert.InDelta(t, 40.0, size.Width, 1e-9)
+		assert.InDelta(t, 72.0, size.Height, 1e-9)
+	})
+	t.Run("MissingRuneFallsBack", func(t *testing.T) {
+		t.Parallel()
+		size, err := MeasureText("Wx", 13, "test-custom")
+		require.NoError(t, err)
+		x, err := MeasureText("x", 13, FamilySans)
+		require.NoError(t, err)
+		assert.InDelta(t, 13+x.Width, size.Width, 0.1)
+	})
+	t.Run("CopiesMap", func(t *testing.T) {
+		t.Parallel()
+		widths := map[rune]float64{'a': 0.5}
+		RegisterCustomMetrics("test-copy", widths, 1)
+		widths['a'] = 2
+		size, err := MeasureText("a", 10, "test-copy")
+		require.NoError(t, err)
+		assert.InDelta(t, 5.0, size.Width, 1e-9)
+	})
+}
@@ -0,0 +1,32 @@
+package layout
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+)
+
+// Dump writes the nodes and edges of a laid-out graph to w as aligned
+// columns, for debugging layouts. Nodes are listed by layer and then by
+// order within the layer, with "-" standing in for the empty ID of a
+// virtual node; edges are listed in the order of g.Edges.
+func Dump(g *Graph, w io.Writer) error {
+	nodes := slices.Clone(g.Nodes)
+	slices.SortStableFunc(nodes, func(a, b *Node) int {
+		return cmp.Or(cmp.Compare(a.Layer, b.Layer), cmp.Compare(a.Order, b.Order))
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tLAYER\tORDER\tX\tY\tWIDTH\tHEIGHT\tVIRTUAL")
+	for _, n := range nodes {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%t\n",
+			cmp.Or(n.ID, "-"), n.Layer, n.Order, n.X, n.Y, n.Width, n.Height, n.Virtual)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "FROM\tTO\tREVERSED")
+	for _, e := range g.Edges {
+		fmt.Fprintf(tw, "%s\t%s\t%t\n", e.From, e.To, e.Reversed)
+	}
+	return tw.Flush()
+}
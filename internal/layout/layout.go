@@ -1,6 +1,11 @@
 // Package layout implements the Sugiyama hierarchical layout algorithm for graph positioning.
 package layout
 
+import (
+	"slices"
+	"strings"
+)
+
 // Node represents a graph node with dimensions.
 type Node struct {
 	ID      string
@@ -19,6 +24,15 @@ type Edge struct {
 	To       string
 	Label    string
 	Reversed bool // true if edge was reversed during cycle removal
+	// Bends holds the centres of the virtual nodes a long edge passes
+	// through, in order from From to To. It is empty for edges between
+	// adjacent layers.
+	Bends []Point
+}
+
+// Point is a position in layout coordinates.
+type Point struct {
+	X, Y float64
 }
 
 // Graph represents the input graph for layout.
@@ -27,10 +41,53 @@ type Graph struct {
 	Edges []*Edge
 }
 
-// Options configures the layout algorithm.
+// Direction controls the axis along which layers advance.
+type Direction int
+
+const (
+	TopDown   Direction = iota // layers stack top to bottom (default)
+	LeftRight                  // layers advance left to right
+)
+
+// Routing selects how renderers draw the edges between positioned nodes.
+type Routing int
+
+const (
+	RoutingStraight Routing = iota // a straight line between the nodes (default)
+	RoutingOrtho                   // horizontal and vertical segments only
+	RoutingPolyline                // straight segments through the edge's Bends
+)
+
+// ParseRouting returns the routing for a PlantUML "skinparam linetype"
+// value. It accepts "ortho" and "polyline"; anything else, including
+// "spline", is RoutingStraight.
+func ParseRouting(linetype string) Routing {
+	switch strings.ToLower(strings.TrimSpace(linetype)) {
+	case "ortho":
+		return RoutingOrtho
+	case "polyline":
+		return RoutingPolyline
+	}
+	return RoutingStraight
+}
+
+// Options configures the layout algorithm. The zero value of each field
+// beyond NodePadding and LayerSpacing keeps the default behaviour: top to
+// bottom flow, layers as wide as their nodes, and uniform layer spacing.
 type Options struct {
-	NodePadding  float64 // horizontal spacing between nodes in a layer
-	LayerSpacing float64 // vertical spacing between layers
+	NodePadding  float64   // spacing between nodes in a layer
+	LayerSpacing float64   // spacing between layers
+	Direction    Direction // flow direction of layers
+	// MinLayerWidth is the smallest extent of a layer across the flow
+	// (width for TopDown, height for LeftRight); narrower layers are centered
+	// within it.
+	MinLayerWidth float64
+	// LayerSpacings overrides LayerSpacing for the gap after layer i.
+	// Missing or non-positive entries fall back to LayerSpacing.
+	LayerSpacings []float64
+	// Routing is how edges are drawn. Layout itself does not use it; it
+	// travels with the options to the renderer.
+	Routing Routing
 }
 
 // DefaultOptions returns sensible default layout options.
@@ -42,7 +99,8 @@ func DefaultOptions() Options {
 }
 
 // Layout runs the full Sugiyama algorithm on the graph.
-// It modifies the nodes in place, setting their X, Y, Layer, and Order fields.
+// It modifies the nodes in place, setting their X, Y, Layer, and Order fields,
+// and sets the Bends of edges that span more than one layer.
 func Layout(g *Graph, opts Options) {
 	if len(g.Nodes) == 0 {
 		return
@@ -64,7 +122,8 @@ func Layout(g *Graph, opts Options) {
 		g.Nodes[i].Layer = layer
 	}
 	// Phase 3: Insert virtual nodes for long edges.
-	adj, layers, g.Nodes = insertVirtualNodes(adj, layers, g.Nodes, opts)
+	var chains map[[2]int][]int
+	adj, layers, g.Nodes, chains = insertVirtualNodes(adj, layers, g.Nodes, opts)
 	// Phase 4: Order nodes within layers.
 	layerBuckets := buildLayerBuckets(layers)
 	layerBuckets = minimizeCrossings(layerBuckets, adj, len(g.Nodes))
@@ -74,6 +133,27 @@ func Layout(g *Graph, opts Options) {
 	}
 	// Phase 5: Coordinate assignment.
 	assignCoordinates(g.Nodes, layerBuckets, opts)
+	setBends(g, nodeIndex, chains)
+}
+
+// setBends records on each long edge the centres of its virtual nodes.
+// Reversed edges run against their chain, so their bends are reversed.
+func setBends(g *Graph, nodeIndex map[string]int, chains map[[2]int][]int) {
+	for _, e := range g.Edges {
+		from, to := nodeIndex[e.From], nodeIndex[e.To]
+		if e.Reversed {
+			from, to = to, from
+		}
+		chain := chains[edgeKey(from, to)]
+		e.Bends = nil
+		for _, idx := range chain {
+			n := g.Nodes[idx]
+			e.Bends = append(e.Bends, Point{X: n.X + n.Width/2, Y: n.Y + n.Height/2})
+		}
+		if e.Reversed {
+			slices.Reverse(e.Bends)
+		}
+	}
 }
 
 func buildNodeIndex(g *Graph) map[string]int {
@@ -181,7 +261,9 @@ func assignLayers(adj [][]int, n int) []int {
 }
 
 // insertVirtualNodes adds dummy nodes for edges spanning more than one layer.
-func insertVirtualNodes(adj [][]int, layers []int, nodes []*Node, opts Options) ([][]int, []int, []*Node) {
+// It also returns the chain of virtual nodes that replaced each long edge.
+func insertVirtualNodes(adj [][]int, layers []int, nodes []*Node, opts Options) ([][]int, []int, []*Node, map[[2]int][]int) {
+	chains := make(map[[2]int][]int)
 	newAdj := make([][]int, len(adj))
 	for i := range adj {
 		newAdj[i] = append([]int(nil), adj[i]...)
@@ -193,6 +275,7 @@ func insertVirtualNodes(adj [][]int, layers []int, nodes []*Node, opts Options)
 				continue
 			}
 			// Replace long edge with chain of virtual nodes.
+			var chain []int
 			prev := u
 			for k := 1; k < span; k++ {
 				vn := &Node{
@@ -206,6 +289,7 @@ func insertVirtualNodes(adj [][]int, layers []int, nodes []*Node, opts Options)
 				nodes = append(nodes, vn)
 				layers = append(layers, layers[u]+k)
 				newAdj = append(newAdj, nil)
+				chain = append(chain, vnIdx)
 				// Remove old edge from prev to v.
 				if prev == u {
 					newAdj[prev][j] = vnIdx
@@ -215,10 +299,13 @@ func insertVirtualNodes(adj [][]int, layers []int, nodes []*Node, opts Options)
 				prev = vnIdx
 			}
 			newAdj[prev] = append(newAdj[prev], v)
+			if _, ok := chains[edgeKey(u, v)]; !ok {
+				chains[edgeKey(u, v)] = chain
+			}
 		}
 	}
 	_ = opts
-	return newAdj, layers, nodes
+	return newAdj, layers, nodes, chains
 }
 
 func buildLayerBuckets(layers []int) [][]int {
@@ -323,48 +410,60 @@ func orderInLayer(buckets [][]int, layer, nodeIdx int) int {
 	return 0
 }
 
-// assignCoordinates sets X and Y positions for all nodes.
+// assignCoordinates sets X and Y positions for all nodes. Layers advance
+// down the Y axis for TopDown and along the X axis for LeftRight; in the latter case
+// the roles of X and Y, and of width and height, are swapped throughout.
 func assignCoordinates(nodes []*Node, layerBuckets [][]int, opts Options) {
-	y := 0.0
-	for _, layer := range layerBuckets {
-		x := 0.0
-		maxHeight := 0.0
+	lr := opts.Direction == LeftRight
+	// extent returns a node's size across a layer and along the flow.
+	extent := func(n *Node) (across, along float64) {
+		if lr {
+			return n.Height, n.Width
+		}
+		return n.Width, n.Height
+	}
+	place := func(n *Node, across, along float64) {
+		if lr {
+			n.X, n.Y = along, across
+		} else {
+			n.X, n.Y = across, along
+		}
+	}
+	spans := make([]float64, len(layerBuckets))
+	maxSpan := opts.MinLayerWidth
+	along := 0.0
+	for i, layer := range layerBuckets {
+		across, depth := 0.0, 0.0
 		for _, idx := range layer {
-			node := nodes[idx]
-			node.X = x
-			node.Y = y
-			x += node.Width + opts.NodePadding
-			if node.Height > maxHeight {
-				maxHeight = node.Height
-			}
+			a, d := extent(nodes[idx])
+			place(nodes[idx], across, along)
+			across += a + opts.NodePadding
+			depth = max(depth, d)
 		}
-		y += maxHeight + opts.LayerSpacing
-	}
-	// Center layers horizontally relative to the widest layer.
-	maxWidth := 0.0
-	for _, layer := range layerBuckets {
-		w := layerWidth(nodes, layer, opts.NodePadding)
-		if w > maxWidth {
-			maxWidth = w
+		if len(layer) > 0 {
+			spans[i] = across - opts.NodePadding
 		}
+		maxSpan = max(maxSpan, spans[i])
+		along += depth + opts.layerSpacing(i)
 	}
-	for _, layer := range layerBuckets {
-		w := layerWidth(nodes, layer, opts.NodePadding)
-		offset := (maxWidth - w) / 2
+	// Center each layer across the widest one, or across MinLayerWidth if larger.
+	for i, layer := range layerBuckets {
+		offset := (maxSpan - spans[i]) / 2
 		for _, idx := range layer {
-			nodes[idx].X += offset
+			if lr {
+				nodes[idx].Y += offset
+			} else {
+				nodes[idx].X += offset
+			}
 		}
 	}
 }
 
-func layerWidth(nodes []*Node, layer []int, padding float64) float64 {
-	if len(layer) == 0 {
-		return 0
-	}
-	w := 0.0
-	for _, idx := range layer {
-		w += nodes[idx].Width
+// layerSpacing returns the gap after layer i, preferring a positive entry in
+// LayerSpacings over the uniform LayerSpacing.
+func (o Options) layerSpacing(i int) float64 {
+	if i < len(o.LayerSpacings) && o.LayerSpacings[i] > 0 {
+		return o.LayerSpacings[i]
 	}
-	w += padding * float64(len(layer)-1)
-	return w
+	return o.LayerSpacing
 }
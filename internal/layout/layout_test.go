@@ -1,6 +1,9 @@
 package layout
 
 import (
+	"bytes"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -146,6 +149,139 @@ func TestLayout(t *testing.T) {
 		assert.Equal(t, 0, g.Nodes[0].Layer)
 		assert.Equal(t, 1, g.Nodes[1].Layer)
 	})
+	t.Run("LongEdgeBends", func(t *testing.T) {
+		t.Parallel()
+		g := &Graph{
+			Nodes: []*Node{
+				{ID: "A", Width: 100, Height: 50},
+				{ID: "B", Width: 100, Height: 50},
+				{ID: "C", Width: 100, Height: 50},
+			},
+			Edges: []*Edge{
+				{From: "A", To: "B"},
+				{From: "B", To: "C"},
+				{From: "A", To: "C"},
+				{From: "C", To: "A"},
+			},
+		}
+		Layout(g, DefaultOptions())
+		assert.Empty(t, g.Edges[0].Bends)
+		assert.Empty(t, g.Edges[1].Bends)
+		require.Len(t, g.Edges[2].Bends, 1)
+		// The bend lies on the middle layer, level with the top of B.
+		assert.Equal(t, g.Nodes[1].Y, g.Edges[2].Bends[0].Y)
+		// The reversed edge runs back through a bend of its own.
+		require.True(t, g.Edges[3].Reversed)
+		require.Len(t, g.Edges[3].Bends, 1)
+	})
+}
+
+func TestLayoutDirection(t *testing.T) {
+	t.Parallel()
+	t.Run("DefaultIsTopToBottom", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, TopDown, DefaultOptions().Direction)
+	})
+	t.Run("LeftToRightChain", func(t *testing.T) {
+		t.Parallel()
+		g := &Graph{
+			Nodes: []*Node{
+				{ID: "A", Width: 100, Height: 50},
+				{ID: "B", Width: 100, Height: 50},
+			},
+			Edges: []*Edge{{From: "A", To: "B"}},
+		}
+		opts := DefaultOptions()
+		opts.Direction = LeftRight
+		Layout(g, opts)
+		assert.Less(t, g.Nodes[0].X, g.Nodes[1].X)
+		assert.Equal(t, g.Nodes[0].Y, g.Nodes[1].Y)
+		assert.Equal(t, 100+opts.LayerSpacing, g.Nodes[1].X)
+	})
+	t.Run("LeftToRightStacksLayerVertically", func(t *testing.T) {
+		t.Parallel()
+		g := &Graph{
+			Nodes: []*Node{
+				{ID: "A", Width: 100, Height: 50},
+				{ID: "B", Width: 100, Height: 50},
+				{ID: "C", Width: 100, Height: 50},
+			},
+			Edges: []*Edge{{From: "A", To: "B"}, {From: "A", To: "C"}},
+		}
+		opts := DefaultOptions()
+		opts.Direction = LeftRight
+		Layout(g, opts)
+		assert.Equal(t, g.Nodes[1].X, g.Nodes[2].X, "same layer = same X")
+		assert.NotEqual(t, g.Nodes[1].Y, g.Nodes[2].Y)
+		// A is centered against the taller second layer.
+		assert.Equal(t, (g.Nodes[1].Y+g.Nodes[2].Y)/2, g.Nodes[0].Y)
+	})
+}
+
+func TestLayoutOptions(t *testing.T) {
+	t.Parallel()
+	chain := func() *Graph {
+		return &Graph{
+			Nodes: []*Node{
+				{ID: "A", Width: 100, Height: 50},
+				{ID: "B", Width: 80, Height: 40},
+				{ID: "C", Width: 60, Height: 30},
+			},
+			Edges: []*Edge{{From: "A", To: "B"}, {From: "B", To: "C"}},
+		}
+	}
+	t.Run("LayerSpacingsOverride", func(t *testing.T) {
+		t.Parallel()
+		g := chain()
+		opts := DefaultOptions()
+		opts.LayerSpacings = []float64{10}
+		Layout(g, opts)
+		assert.Equal(t, 50+10.0, g.Nodes[1].Y, "first gap overridden")
+		assert.Equal(t, 50+10+40+opts.LayerSpacing, g.Nodes[2].Y, "later gaps fall back")
+	})
+	t.Run("LayerSpacingsLeftRight", func(t *testing.T) {
+		t.Parallel()
+		g := chain()
+		opts := DefaultOptions()
+		opts.Direction = LeftRight
+		opts.LayerSpacings = []float64{0, 25}
+		Layout(g, opts)
+		assert.Equal(t, 100+opts.LayerSpacing, g.Nodes[1].X, "zero entry falls back")
+		assert.Equal(t, 100+opts.LayerSpacing+80+25, g.Nodes[2].X)
+	})
+	t.Run("MinLayerWidth", func(t *testing.T) {
+		t.Parallel()
+		g := chain()
+		opts := DefaultOptions()
+		opts.MinLayerWidth = 300
+		Layout(g, opts)
+		for _, n := range g.Nodes {
+			assert.Equal(t, 150.0, n.X+n.Width/2, "%s centered in the minimum width", n.ID)
+		}
+	})
+	t.Run("MinLayerWidthLeftRight", func(t *testing.T) {
+		t.Parallel()
+		g := chain()
+		opts := DefaultOptions()
+		opts.Direction = LeftRight
+		opts.MinLayerWidth = 200
+		Layout(g, opts)
+		for _, n := range g.Nodes {
+			assert.Equal(t, 100.0, n.Y+n.Height/2, "%s centered in the minimum height", n.ID)
+		}
+	})
+	t.Run("ZeroValueMatchesDefault", func(t *testing.T) {
+		t.Parallel()
+		g1, g2 := chain(), chain()
+		Layout(g1, DefaultOptions())
+		opts := DefaultOptions()
+		opts.LayerSpacings = []float64{}
+		Layout(g2, opts)
+		for i := range g1.Nodes {
+			assert.Equal(t, g1.Nodes[i].X, g2.Nodes[i].X)
+			assert.Equal(t, g1.Nodes[i].Y, g2.Nodes[i].Y)
+		}
+	})
 }
 
 func TestNoOverlap(t *testing.T) {
@@ -205,6 +341,22 @@ func TestNoOverlap(t *testing.T) {
 	})
 }
 
+func TestParseRouting(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		linetype string
+		want     Routing
+	}{
+		{"ortho", RoutingOrtho},
+		{"Polyline", RoutingPolyline},
+		{"spline", RoutingStraight},
+		{"", RoutingStraight},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseRouting(tt.linetype), tt.linetype)
+	}
+}
+
 func TestDefaultOptions(t *testing.T) {
 	t.Parallel()
 	opts := DefaultOptions()
@@ -212,6 +364,58 @@ func TestDefaultOptions(t *testing.T) {
 	assert.Greater(t, opts.LayerSpacing, 0.0)
 }
 
+func TestDump(t *testing.T) {
+	t.Parallel()
+	t.Run("Columns", func(t *testing.T) {
+		t.Parallel()
+		g := &Graph{
+			Nodes: []*Node{
+				{ID: "Second", Layer: 1, Order: 0, X: 10, Y: 120, Width: 80, Height: 40},
+				{ID: "Right", Layer: 0, Order: 1, X: 130, Width: 100, Height: 50},
+				{ID: "Left", Layer: 0, Order: 0, Width: 100, Height: 50},
+				{Layer: 1, Order: 1, X: 180, Y: 145, Virtual: true},
+			},
+			Edges: []*Edge{{From: "Left", To: "Second"}, {From: "Second", To: "Right", Reversed: true}},
+		}
+		var buf bytes.Buffer
+		require.NoError(t, Dump(g, &buf))
+		want := `NODE    LAYER  ORDER  X      Y      WIDTH  HEIGHT  VIRTUAL
+Left    0      0      0.0    0.0    100.0  50.0    false
+Right   0      1      130.0  0.0    100.0  50.0    false
+Second  1      0      10.0   120.0  80.0   40.0    false
+-       1      1      180.0  145.0  0.0    0.0     true
+
+FROM    TO      REVERSED
+Left    Second  false
+Second  Right   true
+`
+		assert.Equal(t, want, buf.String())
+	})
+	t.Run("AfterLayout", func(t *testing.T) {
+		t.Parallel()
+		g := &Graph{
+			Nodes: []*Node{
+				{ID: "A", Width: 100, Height: 50},
+				{ID: "B", Width: 100, Height: 50},
+				{ID: "C", Width: 100, Height: 50},
+			},
+			Edges: []*Edge{{From: "A", To: "B"}, {From: "B", To: "C"}, {From: "A", To: "C"}},
+		}
+		Layout(g, DefaultOptions())
+		var buf bytes.Buffer
+		require.NoError(t, Dump(g, &buf))
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		// A header, three nodes and the virtual node on the long edge, a
+		// blank line, and a header and three edges.
+		require.Len(t, lines, 10)
+		assert.True(t, strings.HasPrefix(lines[1], "A "))
+		assert.True(t, strings.HasPrefix(lines[4], "C "))
+		virtual := slices.IndexFunc(lines, func(l string) bool { return strings.HasPrefix(l, "- ") })
+		require.NotEqual(t, -1, virtual)
+		assert.True(t, strings.HasSuffix(lines[virtual], "true"), lines[virtual])
+	})
+}
+
 func assertNoOverlap(t *testing.T, g *Graph) {
 	t.Helper()
 	realNodes := make([]*Node, 0)
@@ -2,6 +2,7 @@
 package lexer
 
 import (
+	"slices"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -12,6 +13,8 @@ var keywords = map[string]TokenType{
 	"class":       TokenClass,
 	"interface":   TokenInterface,
 	"enum":        TokenEnum,
+	"object":      TokenObject,
+	"annotation":  TokenAnnotation,
 	"abstract":    TokenAbstract,
 	"extends":     TokenExtends,
 	"implements":  TokenImplements,
@@ -42,13 +45,28 @@ var keywords = map[string]TokenType{
 	"par":         TokenPar,
 	"break":       TokenBreak,
 	"ref":         TokenRef,
+	"opt":         TokenOpt,
+	"critical":    TokenCritical,
+	"ignore":      TokenIgnore,
+	"consider":    TokenConsider,
 	"autonumber":  TokenAutonumber,
+	"create":      TokenCreate,
+	"destroy":     TokenDestroy,
+	"order":       TokenOrder,
+	"start":       TokenStart,
+	"stop":        TokenStop,
+	"if":          TokenIf,
+	"then":        TokenThen,
+	"endif":       TokenEndif,
+	"component":   TokenComponent,
+	"usecase":     TokenUsecase,
 	"skinparam":   TokenSkinparam,
 	"hide":        TokenHide,
 	"show":        TokenShow,
 	"title":       TokenTitle,
 	"header":      TokenHeader,
 	"footer":      TokenFooter,
+	"legend":      TokenLegend,
 }
 
 // Lexer tokenizes PlantUML source text.
@@ -101,6 +119,9 @@ func (l *Lexer) NextToken() Token {
 	case l.ch == '{':
 		return l.readBraceOrModifier(pos)
 	case l.ch == '}':
+		if n := crowFootLen(l.input[l.pos-1:]); n > 0 {
+			return l.readCrowFoot(pos, n)
+		}
 		l.readChar()
 		return Token{Type: TokenRBrace, Literal: "}", Pos: pos}
 	case l.ch == '(':
@@ -110,24 +131,35 @@ func (l *Lexer) NextToken() Token {
 		l.readChar()
 		return Token{Type: TokenRParen, Literal: ")", Pos: pos}
 	case l.ch == '[':
+		if tok, ok := l.readComponentRef(pos); ok {
+			return tok
+		}
 		l.readChar()
 		return Token{Type: TokenLBracket, Literal: "[", Pos: pos}
 	case l.ch == ']':
 		l.readChar()
 		return Token{Type: TokenRBracket, Literal: "]", Pos: pos}
 	case l.ch == ':':
+		if l.atLineStart() {
+			if n := actionLen(l.input[l.pos-1:]); n > 0 {
+				return l.readAction(pos, n)
+			}
+		}
 		l.readChar()
 		return Token{Type: TokenColon, Literal: ":", Pos: pos}
 	case l.ch == ',':
 		l.readChar()
 		return Token{Type: TokenComma, Literal: ",", Pos: pos}
 	case l.ch == '.':
-		if l.peekChar() == '.' {
+		if l.peekChar() == '.' || (l.peekChar() == '[' && isArrowStyle(l.input[l.pos+1:])) {
 			return l.readArrowOrDots(pos)
 		}
 		l.readChar()
 		return Token{Type: TokenDot, Literal: ".", Pos: pos}
 	case l.ch == '|':
+		if n := crowFootLen(l.input[l.pos-1:]); n > 0 {
+			return l.readCrowFoot(pos, n)
+		}
 		l.readChar()
 		return Token{Type: TokenPipe, Literal: "|", Pos: pos}
 	case l.ch == '=':
@@ -235,7 +267,7 @@ func (l *Lexer) readDirective(pos Pos) Token {
 }
 
 func (l *Lexer) readBraceOrModifier(pos Pos) Token {
-	// Check for {static}, {field}, {method} modifiers.
+	// Check for {static}, {field}, {method}, {abstract} and {readOnly} modifiers.
 	rest := l.input[l.pos:] // text after '{'
 	for _, kw := range []struct {
 		text string
@@ -244,6 +276,8 @@ func (l *Lexer) readBraceOrModifier(pos Pos) Token {
 		{"static}", TokenStatic},
 		{"field}", TokenField},
 		{"method}", TokenMethod},
+		{"abstract}", TokenAbstractMod},
+		{"readOnly}", TokenReadOnly},
 	} {
 		if strings.HasPrefix(rest, kw.text) {
 			lit := "{" + kw.text
@@ -330,6 +364,84 @@ func (l *Lexer) readIdentOrKeyword(pos Pos) Token {
 	return Token{Type: TokenIdent, Literal: lit, Pos: pos}
 }
 
+// atLineStart reports whether the current character is the first
+// non-blank character on its line.
+func (l *Lexer) atLineStart() bool {
+	i := l.pos - utf8.RuneLen(l.ch) - 1
+	for i >= 0 && (l.input[i] == ' ' || l.input[i] == '\t') {
+		i--
+	}
+	return i < 0 || l.input[i] == '\n'
+}
+
+// readComponentRef reads a component reference of the form [Name]. Brackets
+// are only treated this way at the start of a line, after an arrow, or after
+// the component keyword, so fragment conditions like "alt [ok]" keep their
+// ordinary tokens. Brackets inside an arrow, as in "-[#red]->", are read as
+// part of the arrow by readArrowStyle.
+func (l *Lexer) readComponentRef(pos Pos) (Token, bool) {
+	rest := l.input[l.pos:]
+	end := strings.IndexAny(rest, "]\n")
+	if end < 0 || rest[end] != ']' {
+		return Token{}, false
+	}
+	name := strings.TrimSpace(rest[:end])
+	if name == "" || strings.HasPrefix(name, "#") {
+		return Token{}, false
+	}
+	before := strings.TrimRight(l.input[:l.pos-1], " \t")
+	switch {
+	case before == "" || strings.HasSuffix(before, "\n"):
+	case strings.HasSuffix(before, "component"):
+	case strings.ContainsAny(before[len(before)-1:], "->."):
+	default:
+		return Token{}, false
+	}
+	for l.ch != ']' {
+		l.readChar()
+	}
+	l.readChar()
+	return Token{Type: TokenComponentRef, Literal: name, Pos: pos}, true
+}
+
+// actionLen returns the length in bytes of the activity action at the start
+// of s, from its ':' up to the ';' that ends one of its lines, or 0 if s does
+// not start one. An action does not run past a blank line or into a line
+// that starts another action or directive, so a stray ':' cannot swallow
+// the statements after it.
+func actionLen(s string) int {
+	for start := 0; start < len(s); {
+		end := strings.IndexByte(s[start:], '\n')
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += start
+		}
+		line := strings.TrimRight(s[start:end], " \t\r")
+		if start > 0 {
+			trimmed := strings.TrimLeft(line, " \t")
+			if trimmed == "" || trimmed[0] == ':' || trimmed[0] == '@' {
+				return 0
+			}
+		}
+		if strings.HasSuffix(line, ";") {
+			return start + len(line)
+		}
+		start = end + 1
+	}
+	return 0
+}
+
+// readAction reads an activity action of n bytes, as measured by actionLen.
+func (l *Lexer) readAction(pos Pos, n int) Token {
+	end := l.pos - 1 + n
+	lit := l.input[l.pos-1 : end]
+	for l.pos <= end && !l.eof {
+		l.readChar()
+	}
+	return Token{Type: TokenAction, Literal: lit, Pos: pos}
+}
+
 func (l *Lexer) readNumber(pos Pos) Token {
 	var b strings.Builder
 	b.WriteRune(l.ch)
@@ -368,10 +480,7 @@ func (l *Lexer) readDashStart(pos Pos) Token {
 	b.WriteRune(l.ch) // -
 	l.readChar()
 	// Consume consecutive dashes/dots to form arrow shaft.
-	for !l.eof && (l.ch == '-' || l.ch == '.') {
-		b.WriteRune(l.ch)
-		l.readChar()
-	}
+	l.readShaft(&b)
 	// Check for arrowhead at end: >, |>, >>, etc.
 	if l.eof {
 		return l.finishArrowOrMinus(&b, pos)
@@ -444,10 +553,7 @@ func (l *Lexer) readArrowOrDots(pos Pos) Token {
 	var b strings.Builder
 	b.WriteRune(l.ch)
 	l.readChar()
-	for !l.eof && (l.ch == '.' || l.ch == '-') {
-		b.WriteRune(l.ch)
-		l.readChar()
-	}
+	l.readShaft(&b)
 	// Check for arrowhead.
 	if !l.eof {
 		switch l.ch {
@@ -476,12 +582,86 @@ func (l *Lexer) readArrowOrDots(pos Pos) Token {
 	return Token{Type: TokenArrow, Literal: b.String(), Pos: pos}
 }
 
-// continueArrow reads the shaft and optional arrowhead after a prefix.
-func (l *Lexer) continueArrow(b *strings.Builder, pos Pos) Token {
-	for !l.eof && (l.ch == '-' || l.ch == '.') {
+// readShaft reads the dashes and dots of an arrow shaft, along with any
+// inline style such as "[dashed]" between them.
+func (l *Lexer) readShaft(b *strings.Builder) {
+	for !l.eof {
+		if l.ch == '-' || l.ch == '.' {
+			b.WriteRune(l.ch)
+			l.readChar()
+			continue
+		}
+		if !l.readArrowStyle(b) {
+			return
+		}
+	}
+}
+
+// readArrowStyle reads a bracketed style inside an arrow shaft, as in
+// "-[dashed]->" or "-[#red]->". Brackets not followed by more shaft or an
+// arrowhead are left alone, so "A -- [Web]" still ends the arrow before the
+// component.
+func (l *Lexer) readArrowStyle(b *strings.Builder) bool {
+	if l.ch != '[' || !isArrowStyle(l.input[l.pos:]) {
+		return false
+	}
+	for l.ch != ']' {
 		b.WriteRune(l.ch)
 		l.readChar()
 	}
+	b.WriteRune(l.ch)
+	l.readChar()
+	return true
+}
+
+// isArrowStyle reports whether rest, the text after a '[', closes the bracket
+// on the same line and continues with more arrow.
+func isArrowStyle(rest string) bool {
+	end := strings.IndexAny(rest, "]\n")
+	return end > 0 && rest[end] == ']' && end+1 < len(rest) && strings.ContainsRune("-.>", rune(rest[end+1]))
+}
+
+// crowFootLeft and crowFootRight are the ends of an entity relationship
+// arrow in crow's-foot notation, as in "||--o{".
+var (
+	crowFootLeft  = []string{"||", "|o", "}|", "}o"}
+	crowFootRight = []string{"||", "o|", "|{", "o{"}
+)
+
+// crowFootLen returns the length of the crow's-foot arrow at the start of s,
+// such as "||--o{" or "}|..|{", or 0 if s does not start with one.
+func crowFootLen(s string) int {
+	if !slices.ContainsFunc(crowFootLeft, func(end string) bool { return strings.HasPrefix(s, end) }) {
+		return 0
+	}
+	n := 2
+	for n < len(s) && (s[n] == '-' || s[n] == '.') {
+		n++
+	}
+	if n == 2 {
+		return 0
+	}
+	for _, end := range crowFootRight {
+		if strings.HasPrefix(s[n:], end) {
+			return n + len(end)
+		}
+	}
+	return 0
+}
+
+// readCrowFoot reads a crow's-foot arrow of n bytes starting at the current
+// character.
+func (l *Lexer) readCrowFoot(pos Pos, n int) Token {
+	lit := l.input[l.pos-1 : l.pos-1+n]
+	for range n {
+		l.readChar()
+	}
+	return Token{Type: TokenArrow, Literal: lit, Pos: pos}
+}
+
+// continueArrow reads the shaft and optional arrowhead after a prefix.
+func (l *Lexer) continueArrow(b *strings.Builder, pos Pos) Token {
+	l.readShaft(b)
 	// Check for arrowhead at end.
 	if !l.eof {
 		switch l.ch {
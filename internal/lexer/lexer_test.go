@@ -55,6 +55,8 @@ func TestNextToken_Keywords(t *testing.T) {
 		{"class", "class", TokenClass},
 		{"interface", "interface", TokenInterface},
 		{"enum", "enum", TokenEnum},
+		{"object", "object", TokenObject},
+		{"annotation", "annotation", TokenAnnotation},
 		{"abstract", "abstract", TokenAbstract},
 		{"extends", "extends", TokenExtends},
 		{"implements", "implements", TokenImplements},
@@ -88,10 +90,25 @@ func TestNextToken_Keywords(t *testing.T) {
 		{"title", "title", TokenTitle},
 		{"header", "header", TokenHeader},
 		{"footer", "footer", TokenFooter},
+		{"legend", "legend", TokenLegend},
 		{"par", "par", TokenPar},
 		{"break", "break", TokenBreak},
 		{"ref", "ref", TokenRef},
+		{"opt", "opt", TokenOpt},
+		{"critical", "critical", TokenCritical},
+		{"ignore", "ignore", TokenIgnore},
+		{"consider", "consider", TokenConsider},
 		{"autonumber", "autonumber", TokenAutonumber},
+		{"create", "create", TokenCreate},
+		{"destroy", "destroy", TokenDestroy},
+		{"order", "order", TokenOrder},
+		{"start", "start", TokenStart},
+		{"stop", "stop", TokenStop},
+		{"if", "if", TokenIf},
+		{"then", "then", TokenThen},
+		{"endif", "endif", TokenEndif},
+		{"component", "component", TokenComponent},
+		{"usecase", "usecase", TokenUsecase},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -246,6 +263,8 @@ func TestNextToken_Modifiers(t *testing.T) {
 		{"static", "{static}", TokenStatic, "{static}"},
 		{"field", "{field}", TokenField, "{field}"},
 		{"method", "{method}", TokenMethod, "{method}"},
+		{"abstract", "{abstract}", TokenAbstractMod, "{abstract}"},
+		{"readOnly", "{readOnly}", TokenReadOnly, "{readOnly}"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -281,6 +300,13 @@ func TestNextToken_Arrows(t *testing.T) {
 		{"bare solid", "--", "--"},
 		{"bare dotted", "..", ".."},
 		{"right inheritance", "--|>", "--|>"},
+		{"inline style", "-[dashed]->", "-[dashed]->"},
+		{"inline style dotted shaft", "..[bold]..|>", "..[bold]..|>"},
+		{"inline colour before head", "-[#red]>", "-[#red]>"},
+		{"inline style single dot", ".[bold].>", ".[bold].>"},
+		{"crow's foot one to many", "||--o{", "||--o{"},
+		{"crow's foot dotted", "}|..|{", "}|..|{"},
+		{"crow's foot zero or one", "|o-o|", "|o-o|"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -317,6 +343,98 @@ func TestNextToken_Comments(t *testing.T) {
 	})
 }
 
+func TestNextToken_Actions(t *testing.T) {
+	t.Parallel()
+	t.Run("SingleLine", func(t *testing.T) {
+		t.Parallel()
+		tokens := New(":do something;").Tokenize()
+		require.Len(t, tokens, 2)
+		assert.Equal(t, TokenAction, tokens[0].Type)
+		assert.Equal(t, ":do something;", tokens[0].Literal)
+	})
+	t.Run("MultiLine", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("  :first\nsecond;\nstop").Tokenize()
+		require.Len(t, tokens, 4)
+		assert.Equal(t, TokenAction, tokens[0].Type)
+		assert.Equal(t, ":first\nsecond;", tokens[0].Literal)
+		assert.Equal(t, TokenNewline, tokens[1].Type)
+		assert.Equal(t, TokenStop, tokens[2].Type)
+	})
+	t.Run("ColonMidLine", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("A -> B : hi;").Tokenize()
+		assert.Equal(t, TokenColon, tokens[3].Type)
+	})
+	t.Run("SemicolonInsideLine", func(t *testing.T) {
+		t.Parallel()
+		tokens := New(":a; then b;").Tokenize()
+		require.Len(t, tokens, 2)
+		assert.Equal(t, ":a; then b;", tokens[0].Literal)
+	})
+	t.Run("ColonOnOtherLine", func(t *testing.T) {
+		t.Parallel()
+		// The stray ':' must not run on to the ';' of the later action.
+		tokens := New(": label\nclass Foo\n\n:act;").Tokenize()
+		assert.Equal(t, TokenColon, tokens[0].Type)
+		assert.Equal(t, TokenClass, tokens[3].Type)
+		last := tokens[len(tokens)-2]
+		assert.Equal(t, TokenAction, last.Type)
+		assert.Equal(t, ":act;", last.Literal)
+		assert.Equal(t, Pos{Line: 4, Column: 1}, last.Pos)
+	})
+	t.Run("ColonBeforeNextAction", func(t *testing.T) {
+		t.Parallel()
+		tokens := New(":unterminated\n:act;").Tokenize()
+		assert.Equal(t, TokenColon, tokens[0].Type)
+		last := tokens[len(tokens)-2]
+		assert.Equal(t, TokenAction, last.Type)
+		assert.Equal(t, ":act;", last.Literal)
+	})
+}
+
+func TestNextToken_ComponentRefs(t *testing.T) {
+	t.Parallel()
+	t.Run("LineStart", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("[Web Server]").Tokenize()
+		require.Len(t, tokens, 2)
+		assert.Equal(t, TokenComponentRef, tokens[0].Type)
+		assert.Equal(t, "Web Server", tokens[0].Literal)
+	})
+	t.Run("AfterArrow", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("[A] --> [B]").Tokenize()
+		require.Len(t, tokens, 4)
+		assert.Equal(t, TokenComponentRef, tokens[0].Type)
+		assert.Equal(t, TokenArrow, tokens[1].Type)
+		assert.Equal(t, TokenComponentRef, tokens[2].Type)
+		assert.Equal(t, "B", tokens[2].Literal)
+	})
+	t.Run("AfterComponentKeyword", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("component [Store]").Tokenize()
+		require.Len(t, tokens, 3)
+		assert.Equal(t, TokenComponentRef, tokens[1].Type)
+	})
+	t.Run("FragmentConditionKeepsBrackets", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("alt [ok]").Tokenize()
+		assert.Equal(t, TokenLBracket, tokens[1].Type)
+	})
+	t.Run("AfterArrowWithSpace", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("A -- [Web]").Tokenize()
+		assert.Equal(t, "--", tokens[1].Literal)
+		assert.Equal(t, TokenComponentRef, tokens[2].Type)
+	})
+	t.Run("ColorIsNotComponent", func(t *testing.T) {
+		t.Parallel()
+		tokens := New("[#red]").Tokenize()
+		assert.Equal(t, TokenLBracket, tokens[0].Type)
+	})
+}
+
 func TestNextToken_Newlines(t *testing.T) {
 	t.Parallel()
 	l := New("a\nb")
@@ -38,18 +38,22 @@ const (
 	TokenEndUML   // @enduml
 
 	// Class diagram keywords.
-	TokenClass      // class
-	TokenInterface  // interface
-	TokenEnum       // enum
-	TokenAbstract   // abstract
-	TokenExtends    // extends
-	TokenImplements // implements
-	TokenPackage    // package
-	TokenNamespace  // namespace
-	TokenAs         // as
-	TokenStatic     // {static}
-	TokenField      // {field}
-	TokenMethod     // {method}
+	TokenClass       // class
+	TokenInterface   // interface
+	TokenEnum        // enum
+	TokenObject      // object
+	TokenAnnotation  // annotation
+	TokenAbstract    // abstract
+	TokenExtends     // extends
+	TokenImplements  // implements
+	TokenPackage     // package
+	TokenNamespace   // namespace
+	TokenAs          // as
+	TokenStatic      // {static}
+	TokenField       // {field}
+	TokenMethod      // {method}
+	TokenAbstractMod // {abstract}
+	TokenReadOnly    // {readOnly}
 
 	// Sequence diagram keywords.
 	TokenParticipant // participant
@@ -76,7 +80,29 @@ const (
 	TokenPar         // par
 	TokenBreak       // break
 	TokenRef         // ref
+	TokenOpt         // opt
+	TokenCritical    // critical
+	TokenIgnore      // ignore
+	TokenConsider    // consider
 	TokenAutonumber  // autonumber
+	TokenCreate      // create
+	TokenDestroy     // destroy
+	TokenOrder       // order
+
+	// Activity diagram keywords.
+	TokenStart  // start
+	TokenStop   // stop
+	TokenIf     // if
+	TokenThen   // then
+	TokenEndif  // endif
+	TokenAction // :text;
+
+	// Component diagram keywords.
+	TokenComponent    // component
+	TokenComponentRef // [Name]
+
+	// Usecase diagram keywords.
+	TokenUsecase // usecase
 
 	// Arrows.
 	TokenArrow // ->, -->, <-, <--, <|--,  *--, o--, etc.
@@ -88,6 +114,7 @@ const (
 	TokenTitle     // title
 	TokenHeader    // header
 	TokenFooter    // footer
+	TokenLegend    // legend
 
 	// Literals.
 	TokenIdent  // identifiers
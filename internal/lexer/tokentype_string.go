@@ -34,57 +34,78 @@ func _() {
 	_ = x[TokenClass-23]
 	_ = x[TokenInterface-24]
 	_ = x[TokenEnum-25]
-	_ = x[TokenAbstract-26]
-	_ = x[TokenExtends-27]
-	_ = x[TokenImplements-28]
-	_ = x[TokenPackage-29]
-	_ = x[TokenNamespace-30]
-	_ = x[TokenAs-31]
-	_ = x[TokenStatic-32]
-	_ = x[TokenField-33]
-	_ = x[TokenMethod-34]
-	_ = x[TokenParticipant-35]
-	_ = x[TokenActor-36]
-	_ = x[TokenBoundary-37]
-	_ = x[TokenControl-38]
-	_ = x[TokenEntity-39]
-	_ = x[TokenDatabase-40]
-	_ = x[TokenCollections-41]
-	_ = x[TokenQueue-42]
-	_ = x[TokenActivate-43]
-	_ = x[TokenDeactivate-44]
-	_ = x[TokenReturn-45]
-	_ = x[TokenAlt-46]
-	_ = x[TokenElse-47]
-	_ = x[TokenEnd-48]
-	_ = x[TokenLoop-49]
-	_ = x[TokenGroup-50]
-	_ = x[TokenNote-51]
-	_ = x[TokenOf-52]
-	_ = x[TokenOver-53]
-	_ = x[TokenLeft-54]
-	_ = x[TokenRight-55]
-	_ = x[TokenPar-56]
-	_ = x[TokenBreak-57]
-	_ = x[TokenRef-58]
-	_ = x[TokenAutonumber-59]
-	_ = x[TokenArrow-60]
-	_ = x[TokenSkinparam-61]
-	_ = x[TokenHide-62]
-	_ = x[TokenShow-63]
-	_ = x[TokenTitle-64]
-	_ = x[TokenHeader-65]
-	_ = x[TokenFooter-66]
-	_ = x[TokenIdent-67]
-	_ = x[TokenString-68]
-	_ = x[TokenNumber-69]
-	_ = x[TokenLineComment-70]
-	_ = x[TokenBlockComment-71]
+	_ = x[TokenObject-26]
+	_ = x[TokenAnnotation-27]
+	_ = x[TokenAbstract-28]
+	_ = x[TokenExtends-29]
+	_ = x[TokenImplements-30]
+	_ = x[TokenPackage-31]
+	_ = x[TokenNamespace-32]
+	_ = x[TokenAs-33]
+	_ = x[TokenStatic-34]
+	_ = x[TokenField-35]
+	_ = x[TokenMethod-36]
+	_ = x[TokenAbstractMod-37]
+	_ = x[TokenReadOnly-38]
+	_ = x[TokenParticipant-39]
+	_ = x[TokenActor-40]
+	_ = x[TokenBoundary-41]
+	_ = x[TokenControl-42]
+	_ = x[TokenEntity-43]
+	_ = x[TokenDatabase-44]
+	_ = x[TokenCollections-45]
+	_ = x[TokenQueue-46]
+	_ = x[TokenActivate-47]
+	_ = x[TokenDeactivate-48]
+	_ = x[TokenReturn-49]
+	_ = x[TokenAlt-50]
+	_ = x[TokenElse-51]
+	_ = x[TokenEnd-52]
+	_ = x[TokenLoop-53]
+	_ = x[TokenGroup-54]
+	_ = x[TokenNote-55]
+	_ = x[TokenOf-56]
+	_ = x[TokenOver-57]
+	_ = x[TokenLeft-58]
+	_ = x[TokenRight-59]
+	_ = x[TokenPar-60]
+	_ = x[TokenBreak-61]
+	_ = x[TokenRef-62]
+	_ = x[TokenOpt-63]
+	_ = x[TokenCritical-64]
+	_ = x[TokenIgnore-65]
+	_ = x[TokenConsider-66]
+	_ = x[TokenAutonumber-67]
+	_ = x[TokenCreate-68]
+	_ = x[TokenDestroy-69]
+	_ = x[TokenOrder-70]
+	_ = x[TokenStart-71]
+	_ = x[TokenStop-72]
+	_ = x[TokenIf-73]
+	_ = x[TokenThen-74]
+	_ = x[TokenEndif-75]
+	_ = x[TokenAction-76]
+	_ = x[TokenComponent-77]
+	_ = x[TokenComponentRef-78]
+	_ = x[TokenUsecase-79]
+	_ = x[TokenArrow-80]
+	_ = x[TokenSkinparam-81]
+	_ = x[TokenHide-82]
+	_ = x[TokenShow-83]
+	_ = x[TokenTitle-84]
+	_ = x[TokenHeader-85]
+	_ = x[TokenFooter-86]
+	_ = x[TokenLegend-87]
+	_ = x[TokenIdent-88]
+	_ = x[TokenString-89]
+	_ = x[TokenNumber-90]
+	_ = x[TokenLineComment-91]
+	_ = x[TokenBlockComment-92]
 }
 
-const _TokenType_name = "ErrorEOFLBraceRBraceLParenRParenLBracketRBracketColonCommaDotNewlinePipeHashLAngleRAngleEqualsSemicolonPlusMinusTildeStartUMLEndUMLClassInterfaceEnumAbstractExtendsImplementsPackageNamespaceAsStaticFieldMethodParticipantActorBoundaryControlEntityDatabaseCollectionsQueueActivateDeactivateReturnAltElseEndLoopGroupNoteOfOverLeftRightParBreakRefAutonumberArrowSkinparamHideShowTitleHeaderFooterIdentStringNumberLineCommentBlockComment"
+const _TokenType_name = "ErrorEOFLBraceRBraceLParenRParenLBracketRBracketColonCommaDotNewlinePipeHashLAngleRAngleEqualsSemicolonPlusMinusTildeStartUMLEndUMLClassInterfaceEnumObjectAnnotationAbstractExtendsImplementsPackageNamespaceAsStaticFieldMethodAbstractModReadOnlyParticipantActorBoundaryControlEntityDatabaseCollectionsQueueActivateDeactivateReturnAltElseEndLoopGroupNoteOfOverLeftRightParBreakRefOptCriticalIgnoreConsiderAutonumberCreateDestroyOrderStartStopIfThenEndifActionComponentComponentRefUsecaseArrowSkinparamHideShowTitleHeaderFooterLegendIdentStringNumberLineCommentBlockComment"
 
-var _TokenType_index = [...]uint16{0, 5, 8, 14, 20, 26, 32, 40, 48, 53, 58, 61, 68, 72, 76, 82, 88, 94, 103, 107, 112, 117, 125, 131, 136, 145, 149, 157, 164, 174, 181, 190, 192, 198, 203, 209, 220, 225, 233, 240, 246, 254, 265, 270, 278, 288, 294, 297, 301, 304, 308, 313, 317, 319, 323, 327, 332, 335, 340, 343, 353, 358, 367, 371, 375, 380, 386, 392, 397, 403, 409, 420, 432}
+var _TokenType_index = [...]uint16{0, 5, 8, 14, 20, 26, 32, 40, 48, 53, 58, 61, 68, 72, 76, 82, 88, 94, 103, 107, 112, 117, 125, 131, 136, 145, 149, 155, 165, 173, 180, 190, 197, 206, 208, 214, 219, 225, 236, 244, 255, 260, 268, 275, 281, 289, 300, 305, 313, 323, 329, 332, 336, 339, 343, 348, 352, 354, 358, 362, 367, 370, 375, 378, 381, 389, 395, 403, 413, 419, 426, 431, 436, 440, 442, 446, 451, 457, 466, 478, 485, 490, 499, 503, 507, 512, 518, 524, 530, 535, 541, 547, 558, 570}
 
 func (i TokenType) String() string {
 	idx := int(i) - 0
@@ -0,0 +1,289 @@
+package mermaid
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// mermaidClass collects what is known about a class. Mermaid may add members
+// and annotations to a class anywhere in the diagram, so classes are only
+// turned into AST nodes once the whole diagram has been read.
+type mermaidClass struct {
+	pos        lexer.Pos
+	name       string
+	label      string // name with generics, e.g. Box<T>
+	annotation string
+	members    []ast.Member
+	values     []string // enumeration values
+}
+
+// classDiagram holds the state of a class diagram being parsed.
+type classDiagram struct {
+	classes map[string]*mermaidClass
+	stmts   []any // *mermaidClass or ast.Statement, in source order
+}
+
+func (p *Parser) parseClassDiagram() {
+	cd := &classDiagram{classes: map[string]*mermaidClass{}}
+	for {
+		toks, lineNo, ok := p.nextLine()
+		if !ok {
+			break
+		}
+		p.parseClassLine(cd, toks, lineNo)
+	}
+	for _, s := range cd.stmts {
+		switch s := s.(type) {
+		case *mermaidClass:
+			p.diagram.Statements = append(p.diagram.Statements, s.node())
+		case ast.Statement:
+			p.diagram.Statements = append(p.diagram.Statements, s)
+		}
+	}
+}
+
+// class returns the class called name, declaring it at pos if it is new.
+func (cd *classDiagram) class(name string, pos lexer.Pos) *mermaidClass {
+	id, _, _ := strings.Cut(name, "~")
+	if c, ok := cd.classes[id]; ok {
+		if c.label == c.name {
+			c.label = convertGenerics(name)
+		}
+		return c
+	}
+	c := &mermaidClass{pos: pos, name: id, label: convertGenerics(name)}
+	cd.classes[id] = c
+	cd.stmts = append(cd.stmts, c)
+	return c
+}
+
+func (p *Parser) parseClassLine(cd *classDiagram, toks []token, lineNo int) {
+	first := toks[0]
+	switch {
+	case first.Type == tokIdent && first.Literal == "class":
+		p.parseClassDecl(cd, toks, lineNo)
+	case first.Type == tokIdent && first.Literal == "direction":
+		if len(toks) != 2 {
+			p.addError(first.Pos, "expected direction TB, BT, LR or RL")
+			return
+		}
+		dir := toks[1].Literal
+		cd.stmts = append(cd.stmts, &ast.LayoutDirection{Pos: first.Pos, LeftToRight: dir == "LR" || dir == "RL"})
+	case first.Type == tokAnnotation:
+		if len(toks) != 2 || toks[1].Type != tokIdent {
+			p.addError(first.Pos, "expected class name after annotation")
+			return
+		}
+		cd.class(toks[1].Literal, toks[1].Pos).annotation = first.Literal
+	case first.Type == tokIdent && len(toks) == 3 && toks[1].Type == tokColon:
+		p.addMember(cd.class(first.Literal, first.Pos), toks[2].Literal, toks[2].Pos)
+	case first.Type == tokIdent:
+		p.parseRelationship(cd, toks)
+	default:
+		p.addError(first.Pos, fmt.Sprintf("unexpected %q in class diagram", first.Literal))
+	}
+}
+
+// parseClassDecl parses "class Name", optionally followed by a body in braces
+// that may span several lines.
+func (p *Parser) parseClassDecl(cd *classDiagram, toks []token, lineNo int) {
+	if len(toks) < 2 || toks[1].Type != tokIdent {
+		p.addError(toks[0].Pos, "expected class name")
+		return
+	}
+	c := cd.class(toks[1].Literal, toks[1].Pos)
+	rest := toks[2:]
+	if len(rest) == 0 {
+		return
+	}
+	if rest[0].Type != tokLBrace {
+		p.addError(rest[0].Pos, fmt.Sprintf("unexpected %q after class name", rest[0].Literal))
+		return
+	}
+	// A body may open and close on the declaration line: class A { }.
+	if body := p.restOfLine(lineNo, rest[0])[1:]; strings.TrimSpace(body) != "" {
+		if inner, ok := strings.CutSuffix(strings.TrimSpace(body), "}"); ok {
+			if inner = strings.TrimSpace(inner); inner != "" {
+				p.addMember(c, inner, rest[0].Pos)
+			}
+			return
+		}
+		p.addError(rest[0].Pos, "expected class body on the lines after {")
+	}
+	for p.next < len(p.lines) {
+		p.next++
+		line := strings.TrimSpace(p.lines[p.next-1])
+		pos := lexer.Pos{Line: p.next, Column: strings.Index(p.lines[p.next-1], line) + 1}
+		switch {
+		case line == "" || strings.HasPrefix(line, "%%"):
+		case line == "}":
+			return
+		case strings.HasPrefix(line, "<<") && strings.HasSuffix(line, ">>"):
+			c.annotation = strings.TrimSpace(line[2 : len(line)-2])
+		default:
+			p.addMember(c, line, pos)
+		}
+	}
+	p.addError(rest[0].Pos, fmt.Sprintf("unterminated body of class %s", c.name))
+}
+
+// addMember parses a member written in Mermaid form, such as "+String name",
+// "-List~int~ ids$" or "+getName(id int) String", and adds it to c.
+func (p *Parser) addMember(c *mermaidClass, text string, pos lexer.Pos) {
+	vis := ast.VisibilityNone
+	switch text[0] {
+	case '+':
+		vis = ast.VisibilityPublic
+	case '-':
+		vis = ast.VisibilityPrivate
+	case '#':
+		vis = ast.VisibilityProtected
+	case '~':
+		vis = ast.VisibilityPackage
+	}
+	if vis != ast.VisibilityNone {
+		text = strings.TrimSpace(text[1:])
+	}
+	if text == "" {
+		p.addError(pos, "expected member after visibility")
+		return
+	}
+	mod := ast.ModifierNone
+	if open := strings.IndexByte(text, '('); open >= 0 {
+		closing := strings.LastIndexByte(text, ')')
+		if closing < open {
+			p.addError(pos, "expected ) after method parameters")
+			return
+		}
+		after := strings.TrimSpace(text[closing+1:])
+		// The $ static and * abstract classifiers may come before or
+		// after the return type.
+		after = strings.TrimLeft(after, "$*")
+		switch {
+		case strings.ContainsRune(text[closing+1:], '$'):
+			mod = ast.ModifierStatic
+		case strings.ContainsRune(text[closing+1:], '*'):
+			mod = ast.ModifierAbstract
+		}
+		after = strings.TrimSpace(strings.TrimRight(after, "$*"))
+		c.members = append(c.members, &ast.Method{
+			Pos:        pos,
+			Name:       strings.TrimSpace(text[:open]),
+			Params:     convertGenerics(strings.TrimSpace(text[open+1 : closing])),
+			ReturnType: convertGenerics(after),
+			Visibility: vis,
+			Modifier:   mod,
+		})
+		return
+	}
+	if trimmed, ok := strings.CutSuffix(text, "$"); ok {
+		mod = ast.ModifierStatic
+		text = strings.TrimSpace(trimmed)
+	}
+	f := &ast.Field{Pos: pos, Name: text, Visibility: vis, Modifier: mod}
+	if i := strings.LastIndexByte(text, ' '); i >= 0 {
+		f.Type = convertGenerics(strings.TrimSpace(text[:i]))
+		f.Name = text[i+1:]
+	}
+	if f.Type == "" && vis == ast.VisibilityNone && mod == ast.ModifierNone {
+		c.values = append(c.values, f.Name)
+	}
+	c.members = append(c.members, f)
+}
+
+// parseRelationship parses a relationship such as A "1" *-- "many" B : label.
+func (p *Parser) parseRelationship(cd *classDiagram, toks []token) {
+	rel := &ast.Relationship{Pos: toks[0].Pos, Left: toks[0].Literal}
+	i := 1
+	if i < len(toks) && toks[i].Type == tokString {
+		rel.LeftCard = toks[i].Literal
+		i++
+	}
+	if i >= len(toks) || toks[i].Type != tokArrow {
+		p.addError(toks[0].Pos, fmt.Sprintf("expected relationship arrow after %q", toks[0].Literal))
+		return
+	}
+	rel.Arrow = toks[i].Literal
+	rel.Type, rel.Direction = classifyArrow(rel.Arrow)
+	i++
+	if i < len(toks) && toks[i].Type == tokString {
+		rel.RightCard = toks[i].Literal
+		i++
+	}
+	if i >= len(toks) || toks[i].Type != tokIdent {
+		p.addError(toks[i-1].Pos, "expected class name after relationship arrow")
+		return
+	}
+	rel.Right = toks[i].Literal
+	i++
+	if i+1 < len(toks) && toks[i].Type == tokColon {
+		rel.Label = toks[i+1].Literal
+		i += 2
+	}
+	if i < len(toks) {
+		p.addError(toks[i].Pos, fmt.Sprintf("unexpected %q after relationship", toks[i].Literal))
+		return
+	}
+	// Either end may be written with its generics, as in Box~T~ --> Item.
+	rel.Left = cd.class(rel.Left, rel.Pos).name
+	rel.Right = cd.class(rel.Right, rel.Pos).name
+	cd.stmts = append(cd.stmts, rel)
+}
+
+// classifyArrow determines the relationship type and direction from an
+// arrow. Mermaid spells its class diagram arrows the same way PlantUML does;
+// for composition and aggregation the direction records the diamond end.
+func classifyArrow(arrow string) (ast.RelationshipType, ast.ArrowDirection) {
+	dir := ast.ArrowNone
+	left := strings.HasPrefix(arrow, "<")
+	right := strings.HasSuffix(arrow, ">")
+	switch {
+	case left && right:
+		dir = ast.ArrowBoth
+	case left:
+		dir = ast.ArrowLeft
+	case right:
+		dir = ast.ArrowRight
+	}
+	switch {
+	case strings.Contains(arrow, "|>") || strings.Contains(arrow, "<|"):
+		if strings.Contains(arrow, "..") {
+			return ast.RelRealization, dir
+		}
+		return ast.RelInheritance, dir
+	case strings.HasPrefix(arrow, "*"):
+		return ast.RelComposition, ast.ArrowLeft
+	case strings.HasSuffix(arrow, "*"):
+		return ast.RelComposition, ast.ArrowRight
+	case strings.HasPrefix(arrow, "o"):
+		return ast.RelAggregation, ast.ArrowLeft
+	case strings.HasSuffix(arrow, "o"):
+		return ast.RelAggregation, ast.ArrowRight
+	case strings.Contains(arrow, ".."):
+		return ast.RelDependency, dir
+	default:
+		return ast.RelAssociation, dir
+	}
+}
+
+// node converts the collected class into the AST node its annotation calls
+// for: an interface, an enum or a class.
+func (c *mermaidClass) node() ast.Statement {
+	alias := ""
+	if c.label != c.name {
+		alias = c.name
+	}
+	switch strings.ToLower(c.annotation) {
+	case "interface":
+		return &ast.InterfaceDef{Pos: c.pos, Name: c.label, Alias: alias, Members: c.members}
+	case "enumeration", "enum":
+		return &ast.EnumDef{Pos: c.pos, Name: c.label, Alias: alias, Values: c.values}
+	case "abstract":
+		return &ast.ClassDef{Pos: c.pos, Name: c.label, Alias: alias, Abstract: true, Members: c.members}
+	default:
+		return &ast.ClassDef{Pos: c.pos, Name: c.label, Alias: alias, Members: c.members, Stereotype: c.annotation}
+	}
+}
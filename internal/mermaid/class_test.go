@@ -0,0 +1,123 @@
+package mermaid
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClassDiagram(t *testing.T) {
+	t.Parallel()
+	t.Run("ClassWithBody", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("classDiagram\nclass Animal {\n  +String name\n  -int age$\n  +isMammal() bool\n  +mate(Animal other)$ void\n  +move()* void\n}")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		class := diagram.Statements[0].(*ast.ClassDef)
+		assert.Equal(t, "Animal", class.Name)
+		require.Len(t, class.Members, 5)
+		assert.Equal(t, &ast.Field{Pos: class.Members[0].Position(), Name: "name", Type: "String", Visibility: ast.VisibilityPublic}, class.Members[0])
+		age := class.Members[1].(*ast.Field)
+		assert.Equal(t, ast.VisibilityPrivate, age.Visibility)
+		assert.Equal(t, ast.ModifierStatic, age.Modifier)
+		isMammal := class.Members[2].(*ast.Method)
+		assert.Equal(t, "isMammal", isMammal.Name)
+		assert.Equal(t, "bool", isMammal.ReturnType)
+		mate := class.Members[3].(*ast.Method)
+		assert.Equal(t, "Animal other", mate.Params)
+		assert.Equal(t, "void", mate.ReturnType)
+		assert.Equal(t, ast.ModifierStatic, mate.Modifier)
+		assert.Equal(t, ast.ModifierAbstract, class.Members[4].(*ast.Method).Modifier)
+	})
+	t.Run("MemberStatements", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("classDiagram\nBankAccount : +String owner\nBankAccount : +deposit(amount) bool")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		assert.Len(t, diagram.Statements[0].(*ast.ClassDef).Members, 2)
+	})
+	t.Run("Relationships", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow   string
+			relType ast.RelationshipType
+			dir     ast.ArrowDirection
+		}{
+			{"<|--", ast.RelInheritance, ast.ArrowLeft},
+			{"--|>", ast.RelInheritance, ast.ArrowRight},
+			{"..|>", ast.RelRealization, ast.ArrowRight},
+			{"*--", ast.RelComposition, ast.ArrowLeft},
+			{"--*", ast.RelComposition, ast.ArrowRight},
+			{"o--", ast.RelAggregation, ast.ArrowLeft},
+			{"--o", ast.RelAggregation, ast.ArrowRight},
+			{"-->", ast.RelAssociation, ast.ArrowRight},
+			{"..>", ast.RelDependency, ast.ArrowRight},
+			{"--", ast.RelAssociation, ast.ArrowNone},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("classDiagram\nA " + tt.arrow + " B")
+			require.Empty(t, errs, tt.arrow)
+			require.Len(t, diagram.Statements, 3, tt.arrow)
+			rel := diagram.Statements[2].(*ast.Relationship)
+			assert.Equal(t, tt.relType, rel.Type, tt.arrow)
+			assert.Equal(t, tt.dir, rel.Direction, tt.arrow)
+			assert.Equal(t, tt.arrow, rel.Arrow)
+		}
+	})
+	t.Run("CardinalityAndLabel", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("classDiagram\nCustomer \"1\" --> \"*\" Ticket : buys")
+		require.Empty(t, errs)
+		rel := diagram.Statements[2].(*ast.Relationship)
+		assert.Equal(t, "Customer", rel.Left)
+		assert.Equal(t, "Ticket", rel.Right)
+		assert.Equal(t, "1", rel.LeftCard)
+		assert.Equal(t, "*", rel.RightCard)
+		assert.Equal(t, "buys", rel.Label)
+	})
+	t.Run("Annotations", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("classDiagram\n<<interface>> Shape\nShape : +area() double\nclass Color {\n  <<enumeration>>\n  RED\n  GREEN\n}\nclass Service\n<<service>> Service")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		iface := diagram.Statements[0].(*ast.InterfaceDef)
+		assert.Equal(t, "Shape", iface.Name)
+		assert.Len(t, iface.Members, 1)
+		enum := diagram.Statements[1].(*ast.EnumDef)
+		assert.Equal(t, []string{"RED", "GREEN"}, enum.Values)
+		assert.Equal(t, "service", diagram.Statements[2].(*ast.ClassDef).Stereotype)
+	})
+	t.Run("Generics", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("classDiagram\nclass Box~T~ {\n  +List~T~ items\n}\nBox --> Item")
+		require.Empty(t, errs)
+		box := diagram.Statements[0].(*ast.ClassDef)
+		assert.Equal(t, "Box<T>", box.Name)
+		assert.Equal(t, "Box", box.Alias)
+		assert.Equal(t, "List<T>", box.Members[0].(*ast.Field).Type)
+		assert.Equal(t, "Box", diagram.Statements[2].(*ast.Relationship).Left)
+	})
+	t.Run("Direction", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("classDiagram\ndirection LR\nclass A")
+		require.Empty(t, errs)
+		assert.Equal(t, &ast.LayoutDirection{Pos: diagram.Statements[0].Position(), LeftToRight: true}, diagram.Statements[0])
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("classDiagram\nA ??? B\nclass Open {\n  +int x")
+		require.Len(t, errs, 2)
+		assert.Equal(t, 2, errs[0].Pos.Line)
+		assert.Contains(t, errs[1].Message, "unterminated body of class Open")
+	})
+}
+
+func TestConvertGenerics(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "List<int>", convertGenerics("List~int~"))
+	assert.Equal(t, "List<List<int>>", convertGenerics("List~List~int~~"))
+	assert.Equal(t, "Map<K, V>", convertGenerics("Map~K, V~"))
+	assert.Equal(t, "plain", convertGenerics("plain"))
+}
@@ -0,0 +1,145 @@
+package mermaid
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// tokenType classifies a token within a line of Mermaid source.
+type tokenType int
+
+const (
+	tokIllegal    tokenType = iota
+	tokIdent                // Alice, Animal, Box~T~
+	tokString               // "1..*"
+	tokArrow                // -->, <|--, ->>, --x
+	tokAnnotation           // <<interface>>, without the angle brackets
+	tokColon                // :
+	tokText                 // text after a colon, up to the end of the line
+	tokLBrace               // {
+	tokRBrace               // }
+	tokComma                // ,
+	tokPlus                 // +
+)
+
+// token is a lexical token with its literal text and source position.
+type token struct {
+	Type    tokenType
+	Literal string
+	Pos     lexer.Pos
+}
+
+// arrowChars are the runes that make up the line of an arrow and its heads.
+const arrowChars = "-.<>|*"
+
+// lexLine splits one line of Mermaid source into tokens. Mermaid is line
+// oriented, so the parser lexes each line on its own; a "%%" comment ends
+// the line, and everything after a colon is a single text token.
+func lexLine(line string, lineNo int) []token {
+	var toks []token
+	i := 0
+	emit := func(typ tokenType, start, end int, lit string) {
+		toks = append(toks, token{Type: typ, Literal: lit, Pos: lexer.Pos{Line: lineNo, Column: start + 1}})
+		i = end
+	}
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case strings.HasPrefix(line[i:], "%%"):
+			return toks
+		case r == ':':
+			emit(tokColon, i, i+1, ":")
+			emit(tokText, i, len(line), strings.TrimSpace(line[i:]))
+		case r == '"':
+			end := strings.IndexByte(line[i+1:], '"')
+			if end < 0 {
+				emit(tokIllegal, i, len(line), line[i:])
+				continue
+			}
+			emit(tokString, i, i+end+2, line[i+1:i+1+end])
+		case r == '{':
+			emit(tokLBrace, i, i+1, "{")
+		case r == '}':
+			emit(tokRBrace, i, i+1, "}")
+		case r == ',':
+			emit(tokComma, i, i+1, ",")
+		case r == '+':
+			emit(tokPlus, i, i+1, "+")
+		case r == '<' && annotationEnd(line[i:]) > 0:
+			end := i + annotationEnd(line[i:])
+			emit(tokAnnotation, i, end, strings.TrimSpace(line[i+2:end-2]))
+		case r == 'o' && strings.ContainsRune("-.", runeAt(line, i+1)):
+			n := 1 + arrowLen(line[i+1:])
+			emit(tokArrow, i, i+n, line[i:i+n])
+		case strings.ContainsRune(arrowChars, r):
+			n := arrowLen(line[i:])
+			emit(tokArrow, i, i+n, line[i:i+n])
+		case isIdentRune(r):
+			end := i
+			for end < len(line) {
+				c, s := utf8.DecodeRuneInString(line[end:])
+				if !isIdentRune(c) && c != '~' {
+					break
+				}
+				end += s
+			}
+			emit(tokIdent, i, end, line[i:end])
+		default:
+			emit(tokIllegal, i, i+size, string(r))
+		}
+	}
+	return toks
+}
+
+// arrowLen returns the length of the arrow at the start of s. Besides the
+// arrow characters, an arrow may end in an "o" aggregation head, an "x"
+// cross or a ")" async head when no identifier follows directly.
+func arrowLen(s string) int {
+	n := 0
+	for n < len(s) && strings.IndexByte(arrowChars, s[n]) >= 0 {
+		n++
+	}
+	if n == 0 || n >= len(s) {
+		return n
+	}
+	switch s[n] {
+	case ')':
+		return n + 1
+	case 'o', 'x':
+		if !isIdentRune(runeAt(s, n+1)) {
+			return n + 1
+		}
+	}
+	return n
+}
+
+// annotationEnd returns the length of a "<<name>>" annotation at the start
+// of s, or 0 if s does not start with one.
+func annotationEnd(s string) int {
+	if !strings.HasPrefix(s, "<<") {
+		return 0
+	}
+	end := strings.Index(s, ">>")
+	if end < 3 || strings.ContainsAny(s[2:end], "<>-") {
+		return 0
+	}
+	return end + 2
+}
+
+// runeAt returns the rune at byte offset i of s, or 0 past the end.
+func runeAt(s string, i int) rune {
+	if i >= len(s) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return r
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
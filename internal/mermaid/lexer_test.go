@@ -0,0 +1,50 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexLine(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		want  []tokenType
+		lits  []string
+	}{
+		{"Message", "Alice->>+Bob: Hi: there", []tokenType{tokIdent, tokArrow, tokPlus, tokIdent, tokColon, tokText}, []string{"Alice", "->>", "+", "Bob", ":", "Hi: there"}},
+		{"Deactivate", "Bob-->>-Alice", []tokenType{tokIdent, tokArrow, tokIdent}, []string{"Bob", "-->>-", "Alice"}},
+		{"CrossAndAsync", "A--x B\nA-) B", []tokenType{tokIdent, tokArrow, tokIdent, tokIdent, tokArrow, tokIdent}, []string{"A", "--x", "B", "A", "-)", "B"}},
+		{"Inheritance", "Animal <|-- Duck", []tokenType{tokIdent, tokArrow, tokIdent}, []string{"Animal", "<|--", "Duck"}},
+		{"AggregationPrefix", "A o-- B", []tokenType{tokIdent, tokArrow, tokIdent}, []string{"A", "o--", "B"}},
+		{"AggregationSuffix", "A --o B", []tokenType{tokIdent, tokArrow, tokIdent}, []string{"A", "--o", "B"}},
+		{"Cardinality", `A "1" --> "*" B`, []tokenType{tokIdent, tokString, tokArrow, tokString, tokIdent}, []string{"A", "1", "-->", "*", "B"}},
+		{"Annotation", "<<interface>> Shape", []tokenType{tokAnnotation, tokIdent}, []string{"interface", "Shape"}},
+		{"Generic", "class Box~T~ {", []tokenType{tokIdent, tokIdent, tokLBrace}, []string{"class", "Box~T~", "{"}},
+		{"Comment", "A --> B %% trailing", []tokenType{tokIdent, tokArrow, tokIdent}, []string{"A", "-->", "B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var types []tokenType
+			var lits []string
+			for i, line := range strings.Split(tt.input, "\n") {
+				for _, tok := range lexLine(line, i+1) {
+					types = append(types, tok.Type)
+					lits = append(lits, tok.Literal)
+				}
+			}
+			assert.Equal(t, tt.want, types)
+			assert.Equal(t, tt.lits, lits)
+		})
+	}
+	t.Run("Positions", func(t *testing.T) {
+		t.Parallel()
+		toks := lexLine("  A --> B", 3)
+		assert.Equal(t, 3, toks[1].Pos.Line)
+		assert.Equal(t, 5, toks[1].Pos.Column)
+	})
+}
@@ -0,0 +1,121 @@
+// Package mermaid parses a subset of Mermaid diagram syntax into the same
+// AST the PlantUML parser produces, so that Mermaid class and sequence
+// diagrams can be rendered by the existing renderers.
+package mermaid
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/bobcob7/go-uml/internal/parser"
+)
+
+// Parser parses Mermaid source one line at a time.
+type Parser struct {
+	lines   []string
+	next    int // index of the next line to read
+	errors  []*parser.Error
+	diagram *ast.Diagram
+}
+
+// Parse parses a Mermaid class or sequence diagram and returns the AST root
+// plus any errors. Like the PlantUML parser it reports an error for each
+// line it cannot parse and carries on with the next one.
+func Parse(input string) (*ast.Diagram, []*parser.Error) {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	p := &Parser{
+		lines:   strings.Split(input, "\n"),
+		diagram: &ast.Diagram{Pos: lexer.Pos{Line: 1, Column: 1}},
+	}
+	p.parseDiagram()
+	return p.diagram, p.errors
+}
+
+func (p *Parser) addError(pos lexer.Pos, msg string) {
+	p.errors = append(p.errors, &parser.Error{Pos: pos, Message: msg})
+}
+
+// nextLine returns the tokens of the next line that holds any, along with
+// its 1-based line number. It returns ok == false at the end of the input.
+func (p *Parser) nextLine() (toks []token, lineNo int, ok bool) {
+	for p.next < len(p.lines) {
+		p.next++
+		if toks := lexLine(p.lines[p.next-1], p.next); len(toks) > 0 {
+			return toks, p.next, true
+		}
+	}
+	return nil, 0, false
+}
+
+// parseDiagram reads the optional front matter and the diagram type line,
+// then hands the rest of the input to the parser for that diagram type.
+func (p *Parser) parseDiagram() {
+	p.parseFrontMatter()
+	toks, lineNo, ok := p.nextLine()
+	if !ok {
+		p.addError(lexer.Pos{Line: len(p.lines), Column: 1}, "expected classDiagram or sequenceDiagram")
+		return
+	}
+	p.diagram.Pos = toks[0].Pos
+	switch strings.TrimSpace(p.lines[lineNo-1]) {
+	case "classDiagram", "classDiagram-v2":
+		p.parseClassDiagram()
+	case "sequenceDiagram":
+		p.parseSequenceDiagram()
+	default:
+		p.addError(toks[0].Pos, fmt.Sprintf("expected classDiagram or sequenceDiagram, got %q", toks[0].Literal))
+	}
+}
+
+// parseFrontMatter reads a YAML front matter block delimited by "---" lines.
+// Only its title is used.
+func (p *Parser) parseFrontMatter() {
+	start := p.next
+	for start < len(p.lines) && strings.TrimSpace(p.lines[start]) == "" {
+		start++
+	}
+	if start >= len(p.lines) || strings.TrimSpace(p.lines[start]) != "---" {
+		return
+	}
+	for i := start + 1; i < len(p.lines); i++ {
+		line := strings.TrimSpace(p.lines[i])
+		if line == "---" {
+			p.next = i + 1
+			return
+		}
+		if title, ok := strings.CutPrefix(line, "title:"); ok {
+			p.diagram.Title = strings.Trim(strings.TrimSpace(title), `"'`)
+		}
+	}
+	p.addError(lexer.Pos{Line: start + 1, Column: 1}, "unterminated front matter")
+	p.next = len(p.lines)
+}
+
+// restOfLine returns the source text of line lineNo from tok onwards.
+func (p *Parser) restOfLine(lineNo int, tok token) string {
+	return strings.TrimSpace(p.lines[lineNo-1][tok.Pos.Column-1:])
+}
+
+// convertGenerics rewrites Mermaid generics such as List~int~ into the
+// PlantUML form List<int>. A tilde opens a parameter list when a name
+// follows it and closes one otherwise.
+func convertGenerics(s string) string {
+	if !strings.Contains(s, "~") {
+		return s
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if r != '~' {
+			b.WriteRune(r)
+			continue
+		}
+		if isIdentRune(runeAt(s, i+1)) {
+			b.WriteByte('<')
+		} else {
+			b.WriteByte('>')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package mermaid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	t.Run("FrontMatterTitle", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("---\ntitle: Animals\n---\nclassDiagram\nclass Duck")
+		require.Empty(t, errs)
+		assert.Equal(t, "Animals", diagram.Title)
+		assert.Len(t, diagram.Statements, 1)
+	})
+	t.Run("CommentsAndBlankLines", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("%% a comment\n\r\nsequenceDiagram\r\n%% another\r\nA->>B: hi\r\n")
+		require.Empty(t, errs)
+		assert.Len(t, diagram.Statements, 1)
+	})
+	t.Run("UnknownDiagram", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("flowchart TD\nA --> B")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "expected classDiagram or sequenceDiagram")
+	})
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("")
+		require.Len(t, errs, 1)
+	})
+}
@@ -0,0 +1,232 @@
+package mermaid
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+)
+
+// fragmentKinds maps the Mermaid block keywords to fragment kinds.
+var fragmentKinds = map[string]ast.FragmentKind{
+	"loop":     ast.FragmentLoop,
+	"alt":      ast.FragmentAlt,
+	"opt":      ast.FragmentOpt,
+	"par":      ast.FragmentPar,
+	"critical": ast.FragmentCritical,
+	"break":    ast.FragmentBreak,
+}
+
+// elseKeywords maps each fragment kind that has further branches to the
+// keyword that starts one: "else" in alt, "and" in par, "option" in critical.
+var elseKeywords = map[ast.FragmentKind]string{
+	ast.FragmentAlt:      "else",
+	ast.FragmentPar:      "and",
+	ast.FragmentCritical: "option",
+}
+
+// seqArrows maps Mermaid message arrows to the PlantUML arrows they are
+// drawn as.
+var seqArrows = map[string]string{
+	"->":   "->",
+	"-->":  "-->",
+	"->>":  "->",
+	"-->>": "-->",
+	"-x":   "->x",
+	"--x":  "-->x",
+	"-)":   "->>",
+	"--)":  "-->>",
+}
+
+func (p *Parser) parseSequenceDiagram() {
+	for {
+		stmts, end := p.parseSeqBlock(nil)
+		p.diagram.Statements = append(p.diagram.Statements, stmts...)
+		if end == nil {
+			return
+		}
+		p.addError(end[0].Pos, fmt.Sprintf("unexpected %q outside a block", end[0].Literal))
+	}
+}
+
+// parseSeqBlock parses statements until the end of the input or a line
+// starting with "end" or the else keyword of frag. It returns the statements
+// and the tokens of the line that ended the block, or nil at end of input.
+func (p *Parser) parseSeqBlock(frag *ast.Fragment) ([]ast.Statement, []token) {
+	var stmts []ast.Statement
+	for {
+		toks, _, ok := p.nextLine()
+		if !ok {
+			return stmts, nil
+		}
+		first := toks[0]
+		if first.Type == tokIdent && first.Literal == "end" {
+			return stmts, toks
+		}
+		if frag != nil && first.Type == tokIdent && first.Literal == elseKeywords[frag.Kind] {
+			return stmts, toks
+		}
+		if stmt := p.parseSeqLine(toks); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+}
+
+func (p *Parser) parseSeqLine(toks []token) ast.Statement {
+	first := toks[0]
+	if first.Type != tokIdent {
+		p.addError(first.Pos, fmt.Sprintf("unexpected %q in sequence diagram", first.Literal))
+		return nil
+	}
+	switch first.Literal {
+	case "participant", "actor":
+		return p.parseSeqParticipant(toks)
+	case "activate", "deactivate":
+		if len(toks) != 2 || toks[1].Type != tokIdent {
+			p.addError(first.Pos, fmt.Sprintf("expected participant after %s", first.Literal))
+			return nil
+		}
+		return &ast.Activate{Pos: first.Pos, Target: toks[1].Literal, Deactivate: first.Literal == "deactivate"}
+	case "autonumber":
+		return &ast.Autonumber{Pos: first.Pos}
+	case "title":
+		p.diagram.Title = p.restOfLine(first.Pos.Line, first)[len("title"):]
+		p.diagram.Title = strings.TrimSpace(strings.TrimPrefix(p.diagram.Title, ":"))
+		return nil
+	case "Note", "note":
+		return p.parseSeqNote(toks)
+	}
+	if kind, ok := fragmentKinds[first.Literal]; ok {
+		return p.parseSeqFragment(toks, kind)
+	}
+	if len(toks) > 1 && toks[1].Type == tokArrow {
+		return p.parseSeqMessage(toks)
+	}
+	p.addError(first.Pos, fmt.Sprintf("unexpected %q in sequence diagram", first.Literal))
+	return nil
+}
+
+// parseSeqParticipant parses "participant A" or "actor A as Display Name".
+func (p *Parser) parseSeqParticipant(toks []token) ast.Statement {
+	first := toks[0]
+	if len(toks) < 2 || toks[1].Type != tokIdent {
+		p.addError(first.Pos, fmt.Sprintf("expected name after %s", first.Literal))
+		return nil
+	}
+	part := &ast.Participant{Pos: first.Pos, Name: toks[1].Literal}
+	if first.Literal == "actor" {
+		part.Kind = ast.ParticipantActor
+	}
+	if len(toks) > 2 {
+		if toks[2].Literal != "as" || len(toks) < 4 {
+			p.addError(toks[2].Pos, fmt.Sprintf("expected \"as\" and a display name after %s", toks[1].Literal))
+			return nil
+		}
+		// Mermaid names the participant by its id and shows the text
+		// after "as"; in the AST the id is the alias.
+		part.Alias = part.Name
+		part.Name = p.restOfLine(first.Pos.Line, toks[3])
+	}
+	return part
+}
+
+// parseSeqMessage parses a message such as "Alice->>+Bob: Hello".
+func (p *Parser) parseSeqMessage(toks []token) ast.Statement {
+	first := toks[0]
+	arrow := toks[1].Literal
+	msg := &ast.Message{Pos: first.Pos, From: first.Literal}
+	// The "-" deactivation shorthand is lexed as part of the arrow.
+	if trimmed, ok := strings.CutSuffix(arrow, "-"); ok && seqArrows[trimmed] != "" {
+		msg.DeactivateSource = true
+		arrow = trimmed
+	}
+	plantArrow, ok := seqArrows[arrow]
+	if !ok {
+		p.addError(toks[1].Pos, fmt.Sprintf("unknown message arrow %q", toks[1].Literal))
+		return nil
+	}
+	msg.Arrow = plantArrow
+	msg.Dashed = strings.HasPrefix(arrow, "--")
+	i := 2
+	if i < len(toks) && toks[i].Type == tokPlus {
+		msg.ActivateTarget = true
+		i++
+	}
+	if i >= len(toks) || toks[i].Type != tokIdent {
+		p.addError(toks[1].Pos, "expected participant after message arrow")
+		return nil
+	}
+	msg.To = toks[i].Literal
+	i++
+	if i+1 < len(toks) && toks[i].Type == tokColon {
+		msg.Label = toks[i+1].Literal
+		i += 2
+	}
+	if i < len(toks) {
+		p.addError(toks[i].Pos, fmt.Sprintf("unexpected %q after message", toks[i].Literal))
+		return nil
+	}
+	return msg
+}
+
+// parseSeqNote parses "Note left of A: text", "Note right of A: text" or
+// "Note over A,B: text". A note over several participants is placed over
+// the first of them.
+func (p *Parser) parseSeqNote(toks []token) ast.Statement {
+	first := toks[0]
+	note := &ast.Note{Pos: first.Pos}
+	i := 1
+	switch {
+	case i+1 < len(toks) && toks[i].Literal == "left" && toks[i+1].Literal == "of":
+		note.Placement = ast.NoteLeft
+		i += 2
+	case i+1 < len(toks) && toks[i].Literal == "right" && toks[i+1].Literal == "of":
+		note.Placement = ast.NoteRight
+		i += 2
+	case i < len(toks) && toks[i].Literal == "over":
+		note.Placement = ast.NoteOver
+		i++
+	default:
+		p.addError(first.Pos, "expected left of, right of or over after Note")
+		return nil
+	}
+	if i >= len(toks) || toks[i].Type != tokIdent {
+		p.addError(first.Pos, "expected participant in note")
+		return nil
+	}
+	note.Target = toks[i].Literal
+	i++
+	for i+1 < len(toks) && toks[i].Type == tokComma {
+		i += 2
+	}
+	if i+1 >= len(toks) || toks[i].Type != tokColon {
+		p.addError(first.Pos, "expected : and text after note participant")
+		return nil
+	}
+	note.Text = strings.ReplaceAll(toks[i+1].Literal, "<br/>", "\n")
+	return note
+}
+
+// parseSeqFragment parses a block such as "loop Every minute" up to its
+// "end", including any else branches.
+func (p *Parser) parseSeqFragment(toks []token, kind ast.FragmentKind) ast.Statement {
+	first := toks[0]
+	frag := &ast.Fragment{Pos: first.Pos, Kind: kind}
+	if len(toks) > 1 {
+		frag.Condition = p.restOfLine(first.Pos.Line, toks[1])
+	}
+	var end []token
+	frag.Statements, end = p.parseSeqBlock(frag)
+	for end != nil && end[0].Literal != "end" {
+		part := ast.ElsePart{Pos: end[0].Pos}
+		if len(end) > 1 {
+			part.Condition = p.restOfLine(end[0].Pos.Line, end[1])
+		}
+		part.Statements, end = p.parseSeqBlock(frag)
+		frag.ElseParts = append(frag.ElseParts, part)
+	}
+	if end == nil {
+		p.addError(first.Pos, fmt.Sprintf("missing end for %s", first.Literal))
+	}
+	return frag
+}
@@ -0,0 +1,103 @@
+package mermaid
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSequenceDiagram(t *testing.T) {
+	t.Parallel()
+	t.Run("Participants", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("sequenceDiagram\nparticipant A as Alice Smith\nactor B")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		alice := diagram.Statements[0].(*ast.Participant)
+		assert.Equal(t, "Alice Smith", alice.Name)
+		assert.Equal(t, "A", alice.Alias)
+		assert.Equal(t, ast.ParticipantActor, diagram.Statements[1].(*ast.Participant).Kind)
+	})
+	t.Run("Messages", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow  string
+			want   string
+			dashed bool
+		}{
+			{"->", "->", false},
+			{"-->", "-->", true},
+			{"->>", "->", false},
+			{"-->>", "-->", true},
+			{"-x", "->x", false},
+			{"--x", "-->x", true},
+			{"-)", "->>", false},
+			{"--)", "-->>", true},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("sequenceDiagram\nAlice" + tt.arrow + " Bob: hello")
+			require.Empty(t, errs, tt.arrow)
+			msg := diagram.Statements[0].(*ast.Message)
+			assert.Equal(t, "Alice", msg.From, tt.arrow)
+			assert.Equal(t, "Bob", msg.To, tt.arrow)
+			assert.Equal(t, "hello", msg.Label, tt.arrow)
+			assert.Equal(t, tt.want, msg.Arrow, tt.arrow)
+			assert.Equal(t, tt.dashed, msg.Dashed, tt.arrow)
+		}
+	})
+	t.Run("ActivationShorthand", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("sequenceDiagram\nAlice->>+Bob: hi\nBob-->>-Alice: bye")
+		require.Empty(t, errs)
+		assert.True(t, diagram.Statements[0].(*ast.Message).ActivateTarget)
+		reply := diagram.Statements[1].(*ast.Message)
+		assert.True(t, reply.DeactivateSource)
+		assert.Equal(t, "Alice", reply.To)
+	})
+	t.Run("ActivateAndNotes", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("sequenceDiagram\nactivate Bob\nNote right of Bob: thinking\nNote over Alice,Bob: both\ndeactivate Bob")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 4)
+		assert.False(t, diagram.Statements[0].(*ast.Activate).Deactivate)
+		note := diagram.Statements[1].(*ast.Note)
+		assert.Equal(t, ast.NoteRight, note.Placement)
+		assert.Equal(t, "thinking", note.Text)
+		over := diagram.Statements[2].(*ast.Note)
+		assert.Equal(t, ast.NoteOver, over.Placement)
+		assert.Equal(t, "Alice", over.Target)
+		assert.True(t, diagram.Statements[3].(*ast.Activate).Deactivate)
+	})
+	t.Run("Fragments", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("sequenceDiagram\nloop Every minute\n  A->>B: ping\nend\nalt ok\n  B->>A: yes\nelse failed\n  B->>A: no\nend")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		loop := diagram.Statements[0].(*ast.Fragment)
+		assert.Equal(t, ast.FragmentLoop, loop.Kind)
+		assert.Equal(t, "Every minute", loop.Condition)
+		assert.Len(t, loop.Statements, 1)
+		alt := diagram.Statements[1].(*ast.Fragment)
+		assert.Equal(t, "ok", alt.Condition)
+		require.Len(t, alt.ElseParts, 1)
+		assert.Equal(t, "failed", alt.ElseParts[0].Condition)
+		assert.Len(t, alt.ElseParts[0].Statements, 1)
+	})
+	t.Run("TitleAndAutonumber", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("sequenceDiagram\ntitle Checkout\nautonumber\nA->>B: go")
+		require.Empty(t, errs)
+		assert.Equal(t, "Checkout", diagram.Title)
+		assert.IsType(t, &ast.Autonumber{}, diagram.Statements[0])
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("sequenceDiagram\nloop forever\nA->>B: x\nend\nend\nA=>B: bad\nB->>A: ok")
+		require.Len(t, errs, 2)
+		assert.Equal(t, 5, errs[0].Pos.Line)
+		assert.Equal(t, 6, errs[1].Pos.Line)
+		assert.Len(t, diagram.Statements, 2)
+	})
+}
@@ -2,17 +2,33 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/bobcob7/go-uml/internal/ast"
 	"github.com/bobcob7/go-uml/internal/lexer"
 )
 
+// Sentinel errors that parse errors match with errors.Is. Every *Error
+// matches ErrSyntax; errors for a missing @enduml or an unexpected token also
+// match the more specific sentinel.
+var (
+	ErrSyntax          = errors.New("syntax error")
+	ErrMissingEndUML   = errors.New("missing @enduml")
+	ErrUnexpectedToken = errors.New("unexpected token")
+)
+
 // Error represents a parse error with source position.
 type Error struct {
 	Pos     lexer.Pos
 	Message string
+	// Recoverable marks errors that lost nothing from the diagram, such as
+	// a package left open at the end of the input.
+	Recoverable bool
+	kind        error // sentinel returned by Unwrap; nil means ErrSyntax
 }
 
 // Error implements the error interface.
@@ -20,27 +36,71 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
 }
 
+// Unwrap returns the sentinel error describing the kind of e.
+func (e *Error) Unwrap() error {
+	if e.kind == nil {
+		return ErrSyntax
+	}
+	return e.kind
+}
+
+// Is reports whether target is ErrSyntax, which every parse error matches.
+func (e *Error) Is(target error) bool {
+	return target == ErrSyntax
+}
+
 // Parser is a recursive descent parser for PlantUML diagrams.
 type Parser struct {
-	tokens  []lexer.Token
-	pos     int
-	errors  []*Error
-	seqMode bool // true after a sequence-specific keyword is seen
+	tokens    []lexer.Token
+	pos       int
+	errors    []*Error
+	maxErrors int               // stop after this many errors; 0 means no limit
+	stopped   bool              // true once maxErrors is reached
+	seqMode   bool              // true after a sequence-specific keyword is seen
+	erMode    bool              // true when the input has ER entities or crow's-foot arrows
+	strict    bool              // report lines that would otherwise be skipped silently
+	pending   []ast.Statement   // extra statements produced by the last parse, e.g. skinparam blocks
+	lastRel   *ast.Relationship // the most recent relationship, which "note on link" annotates
+}
+
+// ParserOption configures a Parser.
+type ParserOption func(*Parser)
+
+// WithStrict makes the parser report an error for every line it would
+// otherwise skip silently, such as an unrecognised statement or trailing
+// tokens it does not understand. It suits CI checks, where diagram source is
+// expected to be canonical.
+func WithStrict() ParserOption {
+	return func(p *Parser) {
+		p.strict = true
+	}
+}
+
+// WithMaxErrors stops parsing once n errors have been reported and adds a
+// final "too many errors" error. The diagram then holds the statements
+// parsed so far. Zero means no limit.
+func WithMaxErrors(n int) ParserOption {
+	return func(p *Parser) {
+		p.maxErrors = n
+	}
 }
 
 // New creates a new Parser for the given token slice.
-func New(tokens []lexer.Token) *Parser {
-	return &Parser{
+func New(tokens []lexer.Token, opts ...ParserOption) *Parser {
+	p := &Parser{
 		tokens: tokens,
+		erMode: isERInput(tokens),
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // Parse parses a complete PlantUML diagram and returns the AST root plus any errors.
 // The parser uses error recovery to continue after errors and report multiple issues.
-func Parse(input string) (*ast.Diagram, []*Error) {
-	l := lexer.New(input)
-	tokens := l.Tokenize()
-	p := New(tokens)
+func Parse(input string, opts ...ParserOption) (*ast.Diagram, []*Error) {
+	p := New(lexer.New(input).Tokenize(), opts...)
 	diagram := p.parseDiagram()
 	return diagram, p.errors
 }
@@ -73,8 +133,34 @@ func (p *Parser) advance() lexer.Token {
 	return tok
 }
 
+// addError records an error at pos. Once the error limit is reached it adds
+// a final "too many errors" error and moves to the end of the input, so that
+// parsing stops and later errors are dropped.
 func (p *Parser) addError(pos lexer.Pos, msg string) {
-	p.errors = append(p.errors, &Error{Pos: pos, Message: msg})
+	p.addErrorKind(pos, ErrSyntax, msg)
+}
+
+// addErrorKind records an error like addError whose Unwrap returns kind.
+func (p *Parser) addErrorKind(pos lexer.Pos, kind error, msg string) {
+	if p.stopped {
+		return
+	}
+	p.errors = append(p.errors, &Error{Pos: pos, Message: msg, kind: kind})
+	if p.maxErrors > 0 && len(p.errors) >= p.maxErrors {
+		p.errors = append(p.errors, &Error{Pos: pos, Message: "too many errors"})
+		p.stopped = true
+		p.pos = len(p.tokens)
+	}
+}
+
+// addRecoverableError records an error like addError, marking it
+// Recoverable.
+func (p *Parser) addRecoverableError(pos lexer.Pos, msg string) {
+	n := len(p.errors)
+	p.addError(pos, msg)
+	if len(p.errors) > n {
+		p.errors[n].Recoverable = true
+	}
 }
 
 func (p *Parser) skipNewlines() {
@@ -84,7 +170,13 @@ func (p *Parser) skipNewlines() {
 }
 
 // skipToNextLine advances past all tokens until the next newline or EOF, used for error recovery.
+// In strict mode, skipping tokens that no error has been reported for on this line is an error.
 func (p *Parser) skipToNextLine() {
+	if tok := p.current(); p.strict && tok.Type != lexer.TokenNewline && tok.Type != lexer.TokenEOF {
+		if n := len(p.errors); n == 0 || p.errors[n-1].Pos.Line < tok.Pos.Line {
+			p.addErrorKind(tok.Pos, ErrUnexpectedToken, fmt.Sprintf("unexpected %s %q", tok.Type, tok.Literal))
+		}
+	}
 	for p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF {
 		p.advance()
 	}
@@ -103,6 +195,113 @@ func (p *Parser) readRestOfLine() string {
 	return strings.Join(parts, " ")
 }
 
+// readLabel reads the rest of the line as the text of a label or note.
+// Tokens written together stay together, and "\n" escapes become line
+// breaks.
+func (p *Parser) readLabel() string {
+	var b strings.Builder
+	var prev lexer.Token
+	for p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF {
+		tok := p.advance()
+		if b.Len() > 0 && !adjacent(prev, tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.Literal)
+		prev = tok
+	}
+	return unescapeNewlines(strings.TrimSpace(b.String()))
+}
+
+// unescapeNewlines turns each "\n" in s into a line break and each "\\"
+// into one backslash. Other backslashes, as in "C:\temp", are kept.
+func unescapeNewlines(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == 'n' || s[i+1] == '\\') {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// readColor reads a colour such as #FF0000 or #red. The lexer may split the
+// value into several tokens (e.g. "00" and "FF00"), so tokens directly
+// adjacent to the '#' are joined. Returns "" if no colour is present.
+func (p *Parser) readColor() string {
+	if p.current().Type != lexer.TokenHash {
+		return ""
+	}
+	prev := p.advance()
+	color := prev.Literal
+	for {
+		tok := p.current()
+		if tok.Type == lexer.TokenNewline || tok.Type == lexer.TokenEOF ||
+			tok.Type == lexer.TokenLBrace || tok.Type == lexer.TokenRBrace || !adjacent(prev, tok) {
+			break
+		}
+		color += tok.Literal
+		prev = p.advance()
+	}
+	return color
+}
+
+// adjacent reports whether tok starts right where prev ends, with no
+// whitespace between them.
+func adjacent(prev, tok lexer.Token) bool {
+	return tok.Pos.Line == prev.Pos.Line && tok.Pos.Column == prev.Pos.Column+utf8.RuneCountInString(prev.Literal)
+}
+
+// contextualKeywords holds the keywords that only have meaning at the start
+// of a statement. Elsewhere they are read as plain names, so "class stop",
+// "+start()" and "Item --> start" keep working.
+var contextualKeywords = map[lexer.TokenType]bool{
+	lexer.TokenAnnotation: true,
+	lexer.TokenObject:     true,
+	lexer.TokenOpt:        true,
+	lexer.TokenCritical:   true,
+	lexer.TokenIgnore:     true,
+	lexer.TokenConsider:   true,
+	lexer.TokenCreate:     true,
+	lexer.TokenDestroy:    true,
+	lexer.TokenOrder:      true,
+	lexer.TokenStart:      true,
+	lexer.TokenStop:       true,
+	lexer.TokenIf:         true,
+	lexer.TokenThen:       true,
+	lexer.TokenEndif:      true,
+	lexer.TokenComponent:  true,
+	lexer.TokenUsecase:    true,
+	lexer.TokenLegend:     true,
+}
+
+// isName reports whether tok can be read as a name: an identifier or a
+// contextual keyword.
+func isName(tok lexer.Token) bool {
+	return tok.Type == lexer.TokenIdent || contextualKeywords[tok.Type]
+}
+
+// usedAsName reports whether the contextual keyword at the start of a
+// statement names an element instead, as in "start --> Item". That is the
+// case when a dotted name or a link follows it.
+func (p *Parser) usedAsName() bool {
+	if !contextualKeywords[p.current().Type] {
+		return false
+	}
+	switch p.peek().Type {
+	case lexer.TokenArrow, lexer.TokenMinus, lexer.TokenDot:
+		return true
+	}
+	return false
+}
+
 func (p *Parser) parseDiagram() *ast.Diagram {
 	p.skipNewlines()
 	diagram := &ast.Diagram{}
@@ -111,7 +310,7 @@ func (p *Parser) parseDiagram() *ast.Diagram {
 	case lexer.TokenStartUML:
 		diagram.Pos = tok.Pos
 		p.advance()
-		if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+		if isName(p.current()) || p.current().Type == lexer.TokenString {
 			diagram.Name = p.current().Literal
 			p.advance()
 		}
@@ -128,24 +327,43 @@ func (p *Parser) parseDiagram() *ast.Diagram {
 			break
 		}
 		stmt := p.parseStatement()
-		if stmt != nil {
-			diagram.Statements = append(diagram.Statements, stmt)
+		switch s := stmt.(type) {
+		case *ast.Title:
+			diagram.Title = s.Text
+		case *ast.Header:
+			diagram.Header = s.Text
+		case *ast.Footer:
+			diagram.Footer = s.Text
 		}
+		diagram.Statements = p.appendStatement(diagram.Statements, stmt)
 	}
 	if p.current().Type == lexer.TokenEndUML {
 		p.advance()
 	} else if p.current().Type == lexer.TokenEOF {
-		p.addError(p.current().Pos, "expected @enduml before end of input")
+		p.addErrorKind(p.current().Pos, ErrMissingEndUML, "expected @enduml before end of input")
 	}
 	return diagram
 }
 
+// appendStatement appends stmt (if non-nil) and any statements queued while parsing it.
+func (p *Parser) appendStatement(stmts []ast.Statement, stmt ast.Statement) []ast.Statement {
+	if stmt != nil {
+		stmts = append(stmts, stmt)
+	}
+	stmts = append(stmts, p.pending...)
+	p.pending = nil
+	return stmts
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	return p.parseStatementInContext(false)
 }
 
 func (p *Parser) parseStatementInContext(inFragment bool) ast.Statement {
 	tok := p.current()
+	if p.usedAsName() {
+		return p.parseIdentStatement()
+	}
 	switch tok.Type {
 	case lexer.TokenLineComment:
 		return p.parseComment()
@@ -169,8 +387,12 @@ func (p *Parser) parseStatementInContext(inFragment bool) ast.Statement {
 		return p.parseAbstract()
 	case lexer.TokenInterface:
 		return p.parseInterfaceDef()
+	case lexer.TokenAnnotation:
+		return p.parseAnnotationDef()
 	case lexer.TokenEnum:
 		return p.parseEnumDef()
+	case lexer.TokenObject:
+		return p.parseObjectDef()
 	case lexer.TokenPackage, lexer.TokenNamespace:
 		return p.parsePackage()
 	case lexer.TokenParticipant:
@@ -186,6 +408,9 @@ func (p *Parser) parseStatementInContext(inFragment bool) ast.Statement {
 		p.seqMode = true
 		return p.parseParticipant(ast.ParticipantControl)
 	case lexer.TokenEntity:
+		if p.erMode {
+			return p.parseEntity()
+		}
 		p.seqMode = true
 		return p.parseParticipant(ast.ParticipantEntity)
 	case lexer.TokenDatabase:
@@ -224,31 +449,78 @@ func (p *Parser) parseStatementInContext(inFragment bool) ast.Statement {
 	case lexer.TokenGroup:
 		p.seqMode = true
 		return p.parseFragment(ast.FragmentGroup)
+	case lexer.TokenOpt:
+		p.seqMode = true
+		return p.parseFragment(ast.FragmentOpt)
+	case lexer.TokenCritical:
+		p.seqMode = true
+		return p.parseFragment(ast.FragmentCritical)
+	case lexer.TokenIgnore:
+		p.seqMode = true
+		return p.parseFragment(ast.FragmentIgnore)
+	case lexer.TokenConsider:
+		p.seqMode = true
+		return p.parseFragment(ast.FragmentConsider)
 	case lexer.TokenAutonumber:
 		p.seqMode = true
 		return p.parseAutonumber()
+	case lexer.TokenCreate:
+		p.seqMode = true
+		return p.parseCreate()
+	case lexer.TokenDestroy:
+		p.seqMode = true
+		return p.parseLifecycle(true)
+	case lexer.TokenStart:
+		return p.parseActivityStart()
+	case lexer.TokenStop:
+		return p.parseActivityStop()
+	case lexer.TokenAction:
+		return p.parseAction()
+	case lexer.TokenIf:
+		return p.parseDecision()
+	case lexer.TokenComponent:
+		return p.parseComponent()
+	case lexer.TokenComponentRef:
+		return p.parseComponentRef()
+	case lexer.TokenLParen:
+		if p.peek().Type == lexer.TokenRParen {
+			return p.parseComponentInterface()
+		}
+		if p.parenHasComma() {
+			return p.parseAssociationClass()
+		}
+		return p.parseUsecaseRef()
+	case lexer.TokenUsecase:
+		return p.parseUsecase()
+	case lexer.TokenLeft:
+		return p.parseLayoutDirection()
 	case lexer.TokenNote:
 		return p.parseNote()
+	case lexer.TokenLegend:
+		return p.parseLegend()
 	case lexer.TokenEquals:
 		return p.parseDivider()
 	case lexer.TokenArrow:
 		if isDelayArrow(tok.Literal) {
 			return p.parseDelay()
 		}
-		p.addError(tok.Pos, fmt.Sprintf("unexpected arrow %q", tok.Literal))
+		p.addErrorKind(tok.Pos, ErrUnexpectedToken, fmt.Sprintf("unexpected arrow %q", tok.Literal))
 		p.skipToNextLine()
 		return nil
 	case lexer.TokenIdent:
+		if tok.Literal == "top" && p.peek().Literal == "to" {
+			return p.parseLayoutDirection()
+		}
 		return p.parseIdentStatement()
 	case lexer.TokenError:
-		p.addError(tok.Pos, fmt.Sprintf("unexpected token: %s", tok.Literal))
+		p.addErrorKind(tok.Pos, ErrUnexpectedToken, fmt.Sprintf("unexpected token: %s", tok.Literal))
 		p.skipToNextLine()
 		return nil
 	default:
 		if inFragment && (tok.Type == lexer.TokenElse || tok.Type == lexer.TokenEnd) {
 			return nil
 		}
-		p.addError(tok.Pos, fmt.Sprintf("unexpected %s %q", tok.Type, tok.Literal))
+		p.addErrorKind(tok.Pos, ErrUnexpectedToken, fmt.Sprintf("unexpected %s %q", tok.Type, tok.Literal))
 		p.skipToNextLine()
 		return nil
 	}
@@ -267,38 +539,134 @@ func (p *Parser) parseBlockComment() ast.Statement {
 func (p *Parser) parseTitle() ast.Statement {
 	tok := p.advance()
 	text := p.readRestOfLine()
-	return &ast.Comment{Pos: tok.Pos, Text: "title " + text}
+	return &ast.Title{Pos: tok.Pos, Text: text}
 }
 
 func (p *Parser) parseHeader() ast.Statement {
 	tok := p.advance()
 	text := p.readRestOfLine()
-	return &ast.Comment{Pos: tok.Pos, Text: "header " + text}
+	return &ast.Header{Pos: tok.Pos, Text: text}
 }
 
 func (p *Parser) parseFooter() ast.Statement {
 	tok := p.advance()
 	text := p.readRestOfLine()
-	return &ast.Comment{Pos: tok.Pos, Text: "footer " + text}
+	return &ast.Footer{Pos: tok.Pos, Text: text}
 }
 
-func (p *Parser) parseSkinparam() *ast.Skinparam {
+func (p *Parser) parseSkinparam() ast.Statement {
 	tok := p.advance()
 	name := ""
-	if p.current().Type == lexer.TokenIdent {
+	if isWord(p.current()) {
 		name = p.current().Literal
 		p.advance()
 	}
-	value := p.readRestOfLine()
+	if p.current().Type == lexer.TokenLBrace {
+		params := p.parseSkinparamBlock(name)
+		if len(params) == 0 {
+			return nil
+		}
+		p.pending = append(p.pending, params[1:]...)
+		return params[0]
+	}
+	value := p.readSkinparamValue()
 	return &ast.Skinparam{Pos: tok.Pos, Name: name, Value: value}
 }
 
+// readSkinparamValue reads a skinparam value up to the end of the line or a
+// closing '}'. Words are joined with spaces, but colours keep their '#'
+// attached, so "#FF0000" is not split into "# FF0000".
+func (p *Parser) readSkinparamValue() string {
+	var parts []string
+	for {
+		tok := p.current()
+		switch tok.Type {
+		case lexer.TokenNewline, lexer.TokenEOF, lexer.TokenRBrace:
+			return strings.Join(parts, " ")
+		case lexer.TokenHash:
+			parts = append(parts, p.readColor())
+		default:
+			parts = append(parts, tok.Literal)
+			p.advance()
+		}
+	}
+}
+
+// parseSkinparamBlock parses `{ Key Value ... }` after a skinparam element name,
+// returning one Skinparam per pair keyed by the element and key, e.g. ClassBackgroundColor.
+func (p *Parser) parseSkinparamBlock(element string) []ast.Statement {
+	p.advance() // consume '{'
+	var params []ast.Statement
+	for {
+		p.skipNewlines()
+		tok := p.current()
+		switch tok.Type {
+		case lexer.TokenRBrace:
+			p.advance()
+			p.skipToNextLine()
+			return params
+		case lexer.TokenEOF, lexer.TokenEndUML:
+			p.addError(tok.Pos, "expected '}' to close skinparam block")
+			return params
+		}
+		if isWord(tok) {
+			p.advance()
+			params = append(params, &ast.Skinparam{
+				Pos:   tok.Pos,
+				Name:  capitalize(element) + capitalize(tok.Literal),
+				Value: p.readSkinparamValue(),
+			})
+			continue
+		}
+		p.addErrorKind(tok.Pos, ErrUnexpectedToken, fmt.Sprintf("unexpected %s %q in skinparam block", tok.Type, tok.Literal))
+		p.skipToNextLine()
+	}
+}
+
+// isWord reports whether tok is an identifier or keyword, which skinparam
+// element names such as "class" or "participant" lex as.
+func isWord(tok lexer.Token) bool {
+	if tok.Type == lexer.TokenIdent {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(tok.Literal)
+	return tok.Type != lexer.TokenString && tok.Type != lexer.TokenAction && unicode.IsLetter(r)
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
 func (p *Parser) parseHideShow(isHide bool) *ast.HideShow {
 	tok := p.advance()
 	target := p.readRestOfLine()
 	return &ast.HideShow{Pos: tok.Pos, IsHide: isHide, Target: target}
 }
 
+// parseLayoutDirection parses "left to right direction" or "top to bottom direction".
+func (p *Parser) parseLayoutDirection() ast.Statement {
+	tok := p.advance() // consume 'left' or 'top'
+	want := []string{"to", "right", "direction"}
+	if tok.Literal == "top" {
+		want = []string{"to", "bottom", "direction"}
+	}
+	for _, w := range want {
+		if p.current().Literal != w {
+			p.addError(p.current().Pos, fmt.Sprintf("expected %q in %s direction directive", w, tok.Literal))
+			p.skipToNextLine()
+			return nil
+		}
+		p.advance()
+	}
+	p.skipToNextLine()
+	return &ast.LayoutDirection{Pos: tok.Pos, LeftToRight: tok.Literal == "left"}
+}
+
 func (p *Parser) parseNote() *ast.Note {
 	tok := p.advance() // consume 'note'
 	placement := ast.NoteOver
@@ -321,25 +689,56 @@ func (p *Parser) parseNote() *ast.Note {
 	case lexer.TokenOver:
 		p.advance()
 		target = p.readNoteTarget()
+	case lexer.TokenIdent:
+		if p.current().Literal != "on" || p.peek().Literal != "link" {
+			break
+		}
+		placement = ast.NoteOnLink
+		p.advance() // consume 'on'
+		p.advance() // consume 'link'
+		if p.lastRel == nil {
+			p.addError(tok.Pos, "'note on link' must follow a relationship")
+		} else {
+			target = p.lastRel.LinkID()
+		}
 	}
 	text := ""
 	if p.current().Type == lexer.TokenColon {
 		p.advance()
-		text = strings.TrimSpace(p.readRestOfLine())
+		text = p.readLabel()
 	} else {
 		text = p.readMultiLineNote()
 	}
 	return &ast.Note{Pos: tok.Pos, Placement: placement, Target: target, Text: text}
 }
 
+// parseLegend parses a legend [left|right|center] ... end legend block.
+func (p *Parser) parseLegend() *ast.Legend {
+	tok := p.advance() // consume 'legend'
+	legend := &ast.Legend{Pos: tok.Pos, Alignment: ast.LegendRight}
+	switch cur := p.current(); {
+	case cur.Type == lexer.TokenLeft:
+		legend.Alignment = ast.LegendLeft
+		p.advance()
+	case cur.Type == lexer.TokenRight:
+		p.advance()
+	case cur.Type == lexer.TokenIdent && cur.Literal == "center":
+		legend.Alignment = ast.LegendCenter
+		p.advance()
+	}
+	p.skipToNextLine()
+	legend.Text = p.readTextBlock(lexer.TokenLegend, "legend")
+	return legend
+}
+
 func (p *Parser) readNoteTarget() string {
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		name := stripQuotes(p.current().Literal)
 		p.advance()
 		for p.current().Type == lexer.TokenComma {
 			name += ","
 			p.advance()
-			if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+			if isName(p.current()) || p.current().Type == lexer.TokenString {
 				name += stripQuotes(p.current().Literal)
 				p.advance()
 			}
@@ -350,21 +749,46 @@ func (p *Parser) readNoteTarget() string {
 }
 
 func (p *Parser) readMultiLineNote() string {
+	return p.readTextBlock(lexer.TokenNote, "note")
+}
+
+// readTextBlock collects the lines of a block body up to "end <kind>", where
+// closer is the token type of kind.
+func (p *Parser) readTextBlock(closer lexer.TokenType, kind string) string {
 	var lines []string
 	for p.current().Type != lexer.TokenEOF && p.current().Type != lexer.TokenEndUML {
 		if p.current().Type == lexer.TokenNewline {
 			p.advance()
 			continue
 		}
-		if p.current().Type == lexer.TokenEnd {
+		line, closed := p.readBlockLine(closer)
+		if line != "" {
+			lines = append(lines, line)
+		}
+		if closed {
+			return strings.Join(lines, "\n")
+		}
+	}
+	p.addError(p.current().Pos, fmt.Sprintf("expected 'end %s' to close %s", kind, kind))
+	return strings.Join(lines, "\n")
+}
+
+// readBlockLine reads one line of a block body, stopping at "end <closer>" even
+// when it trails content on the same line. It reports whether the block was closed.
+func (p *Parser) readBlockLine(closer lexer.TokenType) (string, bool) {
+	var parts []string
+	for p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF {
+		tok := p.current()
+		if tok.Type == lexer.TokenEnd && (len(parts) == 0 || p.peek().Type == closer) {
 			p.advance()
-			if p.current().Type == lexer.TokenNote {
+			if p.current().Type == closer {
 				p.advance()
 			}
 			p.skipToNextLine()
-			break
+			return strings.Join(parts, " "), true
 		}
-		lines = append(lines, p.readRestOfLine())
+		parts = append(parts, tok.Literal)
+		p.advance()
 	}
-	return strings.Join(lines, "\n")
+	return strings.Join(parts, " "), false
 }
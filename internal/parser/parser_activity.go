@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+func (p *Parser) parseActivityStart() *ast.ActivityStart {
+	tok := p.advance() // consume 'start'
+	p.skipToNextLine()
+	return &ast.ActivityStart{Pos: tok.Pos}
+}
+
+func (p *Parser) parseActivityStop() *ast.ActivityStop {
+	tok := p.advance() // consume 'stop'
+	p.skipToNextLine()
+	return &ast.ActivityStop{Pos: tok.Pos}
+}
+
+func (p *Parser) parseAction() *ast.Action {
+	tok := p.advance()
+	text := strings.TrimSuffix(strings.TrimPrefix(tok.Literal, ":"), ";")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	p.skipToNextLine()
+	return &ast.Action{Pos: tok.Pos, Text: strings.Join(lines, "\n")}
+}
+
+func (p *Parser) parseDecision() *ast.Decision {
+	tok := p.advance() // consume 'if'
+	d := &ast.Decision{Pos: tok.Pos, Condition: p.readParenText()}
+	if p.current().Type == lexer.TokenThen {
+		p.advance()
+		d.ThenLabel = p.readParenText()
+	}
+	p.skipToNextLine()
+	d.Then = p.parseDecisionBranch()
+	if p.current().Type == lexer.TokenElse {
+		p.advance()
+		d.ElseLabel = p.readParenText()
+		p.skipToNextLine()
+		d.Else = p.parseDecisionBranch()
+	}
+	if p.current().Type == lexer.TokenEndif {
+		p.advance()
+		p.skipToNextLine()
+	} else {
+		p.addError(p.current().Pos, "expected 'endif' to close if")
+	}
+	return d
+}
+
+// parseDecisionBranch parses statements until else, endif, or end of input.
+func (p *Parser) parseDecisionBranch() []ast.Statement {
+	var stmts []ast.Statement
+	for {
+		p.skipNewlines()
+		switch p.current().Type {
+		case lexer.TokenElse, lexer.TokenEndif, lexer.TokenEnd, lexer.TokenEndUML, lexer.TokenEOF:
+			return stmts
+		}
+		stmts = p.appendStatement(stmts, p.parseStatementInContext(true))
+	}
+}
+
+// readParenText reads a parenthesized label such as (yes) and returns its text.
+// Tokens are rejoined using their source columns so punctuation keeps its spacing.
+func (p *Parser) readParenText() string {
+	if p.current().Type != lexer.TokenLParen {
+		return ""
+	}
+	p.advance()
+	var b strings.Builder
+	depth := 0
+	end := 0
+	for {
+		tok := p.current()
+		if tok.Type == lexer.TokenNewline || tok.Type == lexer.TokenEOF {
+			p.addError(tok.Pos, "expected ')'")
+			break
+		}
+		if tok.Type == lexer.TokenRParen {
+			if depth == 0 {
+				p.advance()
+				break
+			}
+			depth--
+		}
+		if tok.Type == lexer.TokenLParen {
+			depth++
+		}
+		if b.Len() > 0 && tok.Pos.Column > end {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.Literal)
+		end = tok.Pos.Column + utf8.RuneCountInString(tok.Literal)
+		p.advance()
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseActivity(t *testing.T) {
+	t.Parallel()
+	t.Run("StartActionStop", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nstart\n:do something;\nstop\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		_, ok := diagram.Statements[0].(*ast.ActivityStart)
+		assert.True(t, ok)
+		a, ok := diagram.Statements[1].(*ast.Action)
+		require.True(t, ok)
+		assert.Equal(t, "do something", a.Text)
+		_, ok = diagram.Statements[2].(*ast.ActivityStop)
+		assert.True(t, ok)
+	})
+	t.Run("MultiLineAction", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n:first line\n  second line;\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		a, ok := diagram.Statements[0].(*ast.Action)
+		require.True(t, ok)
+		assert.Equal(t, "first line\nsecond line", a.Text)
+	})
+	t.Run("IfElseEndif", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nstart\nif (is valid?) then (yes)\n:accept;\nelse (no)\n:reject;\nstop\nendif\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		d, ok := diagram.Statements[1].(*ast.Decision)
+		require.True(t, ok)
+		assert.Equal(t, "is valid?", d.Condition)
+		assert.Equal(t, "yes", d.ThenLabel)
+		assert.Equal(t, "no", d.ElseLabel)
+		require.Len(t, d.Then, 1)
+		require.Len(t, d.Else, 2)
+		_, ok = d.Else[1].(*ast.ActivityStop)
+		assert.True(t, ok)
+	})
+	t.Run("IfWithoutElse", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nif (ready) then\n:go;\nendif\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		d, ok := diagram.Statements[0].(*ast.Decision)
+		require.True(t, ok)
+		assert.Equal(t, "ready", d.Condition)
+		assert.Empty(t, d.ThenLabel)
+		assert.Len(t, d.Then, 1)
+		assert.Empty(t, d.Else)
+	})
+	t.Run("NestedIf", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nif (a) then\nif (b) then\n:both;\nendif\nendif\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		outer, ok := diagram.Statements[0].(*ast.Decision)
+		require.True(t, ok)
+		require.Len(t, outer.Then, 1)
+		_, ok = outer.Then[0].(*ast.Decision)
+		assert.True(t, ok)
+	})
+	t.Run("MissingEndif", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nif (a) then\n:x;\n@enduml")
+		require.NotEmpty(t, errs)
+		assert.Contains(t, errs[0].Message, "expected 'endif'")
+	})
+}
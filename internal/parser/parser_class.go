@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/bobcob7/go-uml/internal/ast"
@@ -14,10 +15,11 @@ func (p *Parser) parseAbstract() ast.Statement {
 	}
 	// "abstract" alone is treated as an abstract class with the next identifier as name.
 	cd := &ast.ClassDef{Pos: tok.Pos, Abstract: true}
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		cd.Name = p.readClassName()
 	}
-	cd.Stereotype = p.tryStereotype()
+	cd.Stereotype, cd.SpotLetter, cd.SpotColor = p.tryStereotypeSpot()
+	cd.ExtraStereotypes = p.tryExtraStereotypes()
 	if p.current().Type == lexer.TokenLBrace {
 		cd.Members = p.parseClassBody()
 	}
@@ -30,21 +32,26 @@ func (p *Parser) parseClassDef(abstract bool) *ast.ClassDef {
 		tok.Pos = lexer.Pos{Line: tok.Pos.Line, Column: tok.Pos.Column}
 	}
 	cd := &ast.ClassDef{Pos: tok.Pos, Abstract: abstract}
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		cd.Name = p.readClassName()
 	} else {
 		p.addError(p.current().Pos, "expected class name")
 		p.skipToNextLine()
 		return cd
 	}
-	cd.Stereotype = p.tryStereotype()
+	cd.Stereotype, cd.SpotLetter, cd.SpotColor = p.tryStereotypeSpot()
+	cd.ExtraStereotypes = p.tryExtraStereotypes()
+	cd.BackgroundColor = p.readColor()
 	if p.current().Type == lexer.TokenAs {
 		p.advance()
-		if p.current().Type == lexer.TokenIdent {
+		if isName(p.current()) {
 			cd.Alias = p.current().Literal
 			p.advance()
 		}
 	}
+	if cd.BackgroundColor == "" {
+		cd.BackgroundColor = p.readColor()
+	}
 	if p.current().Type == lexer.TokenExtends || p.current().Type == lexer.TokenImplements {
 		p.skipToNextLine()
 		return cd
@@ -58,7 +65,7 @@ func (p *Parser) parseClassDef(abstract bool) *ast.ClassDef {
 func (p *Parser) parseInterfaceDef() *ast.InterfaceDef {
 	tok := p.advance() // consume 'interface'
 	idef := &ast.InterfaceDef{Pos: tok.Pos}
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		idef.Name = p.readClassName()
 	} else {
 		p.addError(p.current().Pos, "expected interface name")
@@ -68,7 +75,7 @@ func (p *Parser) parseInterfaceDef() *ast.InterfaceDef {
 	idef.Stereotype = p.tryStereotype()
 	if p.current().Type == lexer.TokenAs {
 		p.advance()
-		if p.current().Type == lexer.TokenIdent {
+		if isName(p.current()) {
 			idef.Alias = p.current().Literal
 			p.advance()
 		}
@@ -79,10 +86,36 @@ func (p *Parser) parseInterfaceDef() *ast.InterfaceDef {
 	return idef
 }
 
+// parseAnnotationDef parses an annotation type such as
+// annotation Deprecated { +since() : String }.
+func (p *Parser) parseAnnotationDef() *ast.AnnotationDef {
+	tok := p.advance() // consume 'annotation'
+	adef := &ast.AnnotationDef{Pos: tok.Pos}
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
+		adef.Name = p.readClassName()
+	} else {
+		p.addError(p.current().Pos, "expected annotation name")
+		p.skipToNextLine()
+		return adef
+	}
+	adef.Stereotype = p.tryStereotype()
+	if p.current().Type == lexer.TokenAs {
+		p.advance()
+		if isName(p.current()) {
+			adef.Alias = p.current().Literal
+			p.advance()
+		}
+	}
+	if p.current().Type == lexer.TokenLBrace {
+		adef.Members = p.parseClassBody()
+	}
+	return adef
+}
+
 func (p *Parser) parseEnumDef() *ast.EnumDef {
 	tok := p.advance() // consume 'enum'
 	edef := &ast.EnumDef{Pos: tok.Pos}
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		edef.Name = p.readClassName()
 	} else {
 		p.addError(p.current().Pos, "expected enum name")
@@ -96,6 +129,65 @@ func (p *Parser) parseEnumDef() *ast.EnumDef {
 	return edef
 }
 
+// parseObjectDef parses an object definition such as
+// object "o1 : Foo" as o1 { field = value }.
+func (p *Parser) parseObjectDef() *ast.ObjectDef {
+	tok := p.advance() // consume 'object'
+	od := &ast.ObjectDef{Pos: tok.Pos}
+	if !isName(p.current()) && p.current().Type != lexer.TokenString {
+		p.addError(p.current().Pos, "expected object name")
+		p.skipToNextLine()
+		return od
+	}
+	od.Name = p.readClassName()
+	if name, class, ok := strings.Cut(od.Name, ":"); ok {
+		od.Name = strings.TrimSpace(name)
+		od.InstanceOf = strings.TrimSpace(class)
+	} else if p.current().Type == lexer.TokenColon && isName(p.peek()) {
+		p.advance()
+		od.InstanceOf = p.readClassName()
+	}
+	od.Stereotype = p.tryStereotype()
+	if p.current().Type == lexer.TokenAs {
+		p.advance()
+		if isName(p.current()) {
+			od.Alias = p.current().Literal
+			p.advance()
+		}
+	}
+	if p.current().Type == lexer.TokenLBrace {
+		od.Members = p.parseObjectBody()
+	}
+	return od
+}
+
+// parseObjectBody parses the { ... } body of an object. Each line, typically
+// "field = value", becomes a Field whose Name is the whole line.
+func (p *Parser) parseObjectBody() []ast.Member {
+	p.advance() // consume '{'
+	var members []ast.Member
+	for {
+		p.skipNewlines()
+		tok := p.current()
+		if tok.Type == lexer.TokenRBrace || tok.Type == lexer.TokenEOF || tok.Type == lexer.TokenEndUML {
+			break
+		}
+		if tok.Type == lexer.TokenLineComment || tok.Type == lexer.TokenBlockComment {
+			p.advance()
+			continue
+		}
+		if text := strings.TrimSpace(p.readRestOfLine()); text != "" {
+			members = append(members, &ast.Field{Pos: tok.Pos, Name: text})
+		}
+	}
+	if p.current().Type == lexer.TokenRBrace {
+		p.advance()
+	} else {
+		p.addError(p.current().Pos, "expected closing }")
+	}
+	return members
+}
+
 // readClassName reads a class name, which may be a dotted identifier (e.g. "com.example.Foo").
 func (p *Parser) readClassName() string {
 	if p.current().Type == lexer.TokenString {
@@ -103,27 +195,81 @@ func (p *Parser) readClassName() string {
 		return strings.Trim(tok.Literal, "\"")
 	}
 	var b strings.Builder
-	b.WriteString(p.current().Literal)
-	p.advance()
-	for p.current().Type == lexer.TokenDot && p.peek().Type == lexer.TokenIdent {
+	prev := p.advance()
+	b.WriteString(prev.Literal)
+	for p.current().Type == lexer.TokenDot && isName(p.peek()) {
 		b.WriteRune('.')
 		p.advance() // consume dot
-		b.WriteString(p.current().Literal)
-		p.advance() // consume ident
+		prev = p.advance()
+		b.WriteString(prev.Literal)
 	}
+	b.WriteString(p.readGenerics(prev))
 	return b.String()
 }
 
+// readGenerics reads a generic parameter list such as <K, V> written directly
+// after prev, keeping the spacing of the source. A "<<" is left alone, as it
+// opens a stereotype. Returns "" if no parameter list is present.
+func (p *Parser) readGenerics(prev lexer.Token) string {
+	if p.current().Type != lexer.TokenLAngle || p.peek().Type == lexer.TokenLAngle || !adjacent(prev, p.current()) {
+		return ""
+	}
+	var b strings.Builder
+	depth := 0
+	for {
+		tok := p.current()
+		switch tok.Type {
+		case lexer.TokenNewline, lexer.TokenEOF, lexer.TokenLBrace:
+			return b.String()
+		case lexer.TokenLAngle:
+			depth++
+		case lexer.TokenRAngle:
+			depth--
+		}
+		if b.Len() > 0 && !adjacent(prev, tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.Literal)
+		prev = p.advance()
+		if depth == 0 {
+			return b.String()
+		}
+	}
+}
+
 // tryStereotype checks for <<stereotype>> and returns the text, or "" if none.
 func (p *Parser) tryStereotype() string {
+	stereotype, _, _ := p.tryStereotypeSpot()
+	return stereotype
+}
+
+// tryExtraStereotypes reads the stereotypes that follow the first one, as
+// <<entity>> does in "class Foo <<service>> <<entity>>".
+func (p *Parser) tryExtraStereotypes() []string {
+	var stereotypes []string
+	for p.current().Type == lexer.TokenLAngle && p.peek().Type == lexer.TokenLAngle {
+		if s := p.tryStereotype(); s != "" {
+			stereotypes = append(stereotypes, s)
+		}
+	}
+	return stereotypes
+}
+
+// tryStereotypeSpot reads a stereotype like tryStereotype, also returning
+// the letter and colour of a spot given before the text, as in
+// <<(S,#FF7700) Service>>. The colour may be a hex value or a name.
+func (p *Parser) tryStereotypeSpot() (stereotype, spotLetter, spotColor string) {
 	if p.current().Type != lexer.TokenLAngle {
-		return ""
+		return "", "", ""
 	}
 	if p.peek().Type != lexer.TokenLAngle {
-		return ""
+		return "", "", ""
 	}
 	p.advance() // first <
 	p.advance() // second <
+	if p.current().Type == lexer.TokenLParen {
+		spotLetter, spotColor = p.readSpot()
+	}
 	var parts []string
 	for p.current().Type != lexer.TokenRAngle && p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF {
 		parts = append(parts, p.current().Literal)
@@ -135,7 +281,32 @@ func (p *Parser) tryStereotype() string {
 	if p.current().Type == lexer.TokenRAngle {
 		p.advance() // second >
 	}
-	return strings.Join(parts, " ")
+	return strings.Join(parts, " "), spotLetter, spotColor
+}
+
+// readSpot reads the "(S,#FF7700)" spot at the start of a stereotype. The
+// colour is optional; the lexer splits it into several tokens, which are
+// joined up to the closing parenthesis.
+func (p *Parser) readSpot() (letter, color string) {
+	p.advance() // consume '('
+	if isName(p.current()) {
+		letter = p.advance().Literal
+	}
+	comma := false
+	for p.current().Type != lexer.TokenRParen && p.current().Type != lexer.TokenRAngle &&
+		p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF {
+		tok := p.advance()
+		switch {
+		case tok.Type == lexer.TokenComma:
+			comma = true
+		case comma:
+			color += tok.Literal
+		}
+	}
+	if p.current().Type == lexer.TokenRParen {
+		p.advance()
+	}
+	return letter, color
 }
 
 func (p *Parser) parseClassBody() []ast.Member {
@@ -165,14 +336,21 @@ func (p *Parser) parseClassBody() []ast.Member {
 }
 
 func (p *Parser) parseMember() ast.Member {
+	if sep := p.parseSeparator(); sep != nil {
+		return sep
+	}
 	pos := p.current().Pos
 	vis := p.tryVisibility()
 	mod := p.tryModifier()
+	if vis == ast.VisibilityNone && mod != ast.ModifierNone {
+		// The visibility may also follow the modifier: "{readOnly} -id".
+		vis = p.tryVisibility()
+	}
 	if p.current().Type == lexer.TokenNewline || p.current().Type == lexer.TokenRBrace || p.current().Type == lexer.TokenEOF {
 		return nil
 	}
 	name := ""
-	if p.current().Type == lexer.TokenIdent {
+	if isName(p.current()) {
 		name = p.current().Literal
 		p.advance()
 	} else {
@@ -194,16 +372,68 @@ func (p *Parser) parseMember() ast.Member {
 		p.advance()
 		typeName = p.readTypeUntilNewline()
 	}
+	// An explicit value, as in the enum constant "RED = 0".
+	value := ""
+	if p.current().Type == lexer.TokenEquals {
+		p.advance()
+		value = p.readTypeUntilNewline()
+	}
+	p.consumeOptionalNewline()
+	return &ast.Field{Pos: pos, Name: name, Type: typeName, Visibility: vis, Modifier: mod, Value: value}
+}
+
+// separatorMarkers maps the characters a separator line is drawn with to its
+// style.
+var separatorMarkers = map[byte]ast.SeparatorStyle{
+	'-': ast.SeparatorSolid,
+	'.': ast.SeparatorDotted,
+	'=': ast.SeparatorDouble,
+	'_': ast.SeparatorUnderline,
+}
+
+// parseSeparator parses a separator line such as "..", "==" or
+// "-- Section --" at the start of a member, or returns nil and consumes
+// nothing if the line is not one. The closing marker after a label is
+// optional.
+func (p *Parser) parseSeparator() *ast.Separator {
+	tok := p.current()
+	marker := tok.Literal
+	if tok.Type == lexer.TokenEquals {
+		// The lexer splits "==" into single '=' tokens.
+		next := p.peek()
+		if next.Type != lexer.TokenEquals || !adjacent(tok, next) {
+			return nil
+		}
+		marker = "=="
+	} else if tok.Type != lexer.TokenArrow && tok.Type != lexer.TokenIdent {
+		return nil
+	}
+	if len(marker) < 2 || strings.Trim(marker, marker[:1]) != "" {
+		return nil
+	}
+	style, ok := separatorMarkers[marker[0]]
+	if !ok {
+		return nil
+	}
+	prev := p.advance()
+	for p.current().Type == lexer.TokenEquals && adjacent(prev, p.current()) {
+		prev = p.advance()
+	}
+	label := strings.TrimRight(p.readTypeUntilNewline(), marker[:1])
 	p.consumeOptionalNewline()
-	return &ast.Field{Pos: pos, Name: name, Type: typeName, Visibility: vis, Modifier: mod}
+	return &ast.Separator{Pos: tok.Pos, Style: style, Label: strings.TrimSpace(label)}
 }
 
 func (p *Parser) parseMethodAfterName(pos lexer.Pos, vis ast.Visibility, mod ast.Modifier, name string) *ast.Method {
 	p.advance() // consume '('
-	var params []string
+	var params strings.Builder
+	var prev lexer.Token
 	for p.current().Type != lexer.TokenRParen && p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF {
-		params = append(params, p.current().Literal)
-		p.advance()
+		if params.Len() > 0 && !adjacent(prev, p.current()) {
+			params.WriteByte(' ')
+		}
+		params.WriteString(p.current().Literal)
+		prev = p.advance()
 	}
 	if p.current().Type == lexer.TokenRParen {
 		p.advance()
@@ -217,7 +447,7 @@ func (p *Parser) parseMethodAfterName(pos lexer.Pos, vis ast.Visibility, mod ast
 	return &ast.Method{
 		Pos:        pos,
 		Name:       name,
-		Params:     strings.Join(params, " "),
+		Params:     params.String(),
 		ReturnType: retType,
 		Visibility: vis,
 		Modifier:   mod,
@@ -254,19 +484,31 @@ func (p *Parser) tryModifier() ast.Modifier {
 	case lexer.TokenMethod:
 		p.advance()
 		return ast.ModifierMethod
+	case lexer.TokenAbstractMod:
+		p.advance()
+		return ast.ModifierAbstract
+	case lexer.TokenReadOnly:
+		p.advance()
+		return ast.ModifierReadOnly
 	default:
 		return ast.ModifierNone
 	}
 }
 
+// readTypeUntilNewline reads a member type up to the end of the line, keeping
+// the spacing of the source so generic types such as Map<K, V> stay intact.
 func (p *Parser) readTypeUntilNewline() string {
-	var parts []string
+	var b strings.Builder
+	var prev lexer.Token
 	for p.current().Type != lexer.TokenNewline && p.current().Type != lexer.TokenEOF &&
 		p.current().Type != lexer.TokenRBrace {
-		parts = append(parts, p.current().Literal)
-		p.advance()
+		if b.Len() > 0 && !adjacent(prev, p.current()) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.current().Literal)
+		prev = p.advance()
 	}
-	return strings.TrimSpace(strings.Join(parts, " "))
+	return strings.TrimSpace(b.String())
 }
 
 func (p *Parser) consumeOptionalNewline() {
@@ -290,47 +532,141 @@ func (p *Parser) parseIdentStatement() ast.Statement {
 		p.advance()
 	}
 	if p.current().Type == lexer.TokenArrow {
-		if leftCard == "" && isSequenceArrow(p.current().Literal) {
+		arrow, _ := splitArrowStyle(p.current().Literal)
+		if leftCard == "" && isSequenceArrow(arrow) && p.peek().Type != lexer.TokenLParen {
 			p.seqMode = true
 			return p.parseMessage(pos, leftName)
 		}
 		return p.parseRelationship(pos, leftName, leftCard)
 	}
+	// A lone "-" lexes as a minus but is the short link, as in "H - [Web]".
+	if p.current().Type == lexer.TokenMinus {
+		return p.parseRelationship(pos, leftName, leftCard)
+	}
+	if p.strict {
+		p.addError(pos, fmt.Sprintf("unrecognised statement %q", leftName))
+	}
 	p.skipToNextLine()
 	return &ast.Comment{Pos: pos, Text: leftName}
 }
 
 func (p *Parser) parseRelationship(pos lexer.Pos, leftName, leftCard string) *ast.Relationship {
 	arrowTok := p.advance() // consume arrow
-	relType, dir := classifyArrow(arrowTok.Literal)
+	arrow, styles := splitArrowStyle(arrowTok.Literal)
+	relType, dir := classifyArrow(arrow)
 	rightCard := ""
 	if p.current().Type == lexer.TokenString {
 		rightCard = strings.Trim(p.current().Literal, "\"")
 		p.advance()
 	}
 	rightName := ""
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	switch tok := p.current(); {
+	case isName(tok), tok.Type == lexer.TokenString:
 		rightName = p.readClassName()
+	case tok.Type == lexer.TokenComponentRef:
+		rightName = p.advance().Literal
+	case tok.Type == lexer.TokenLParen:
+		usecasePos := p.current().Pos
+		if name, ok := p.readUsecaseName(); ok {
+			rightName = name
+			p.pending = append(p.pending, &ast.Usecase{Pos: usecasePos, Name: name})
+		}
 	}
 	label := ""
 	if p.current().Type == lexer.TokenColon {
 		p.advance()
-		label = strings.TrimSpace(p.readRestOfLine())
-	}
-	return &ast.Relationship{
-		Pos:       pos,
-		Left:      leftName,
-		Right:     rightName,
-		Type:      relType,
-		Direction: dir,
-		Label:     label,
-		LeftCard:  leftCard,
-		RightCard: rightCard,
-		Arrow:     arrowTok.Literal,
+		label = p.readLabel()
+	}
+	leftFoot, rightFoot := crowFoot(arrow)
+	rel := &ast.Relationship{
+		Pos:           pos,
+		Left:          leftName,
+		Right:         rightName,
+		Type:          relType,
+		Direction:     dir,
+		Label:         label,
+		LeftCard:      leftCard,
+		RightCard:     rightCard,
+		Arrow:         arrow,
+		LineStyle:     lineStyle(styles),
+		LeftCrowFoot:  leftFoot,
+		RightCrowFoot: rightFoot,
+	}
+	p.lastRel = rel
+	return rel
+}
+
+// splitArrowStyle removes an inline style such as "[dashed]" or
+// "[#red,bold]" from an arrow, returning the plain arrow and the
+// comma-separated style entries.
+func splitArrowStyle(arrow string) (string, []string) {
+	open := strings.IndexByte(arrow, '[')
+	if open < 0 {
+		return arrow, nil
+	}
+	end := open + strings.IndexByte(arrow[open:], ']')
+	return arrow[:open] + arrow[end+1:], strings.Split(arrow[open+1:end], ",")
+}
+
+// lineStyle returns the line style named among the inline style entries.
+// Colours and other entries are ignored.
+func lineStyle(styles []string) ast.LineStyle {
+	style := ast.LineDefault
+	for _, s := range styles {
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "dashed":
+			style = ast.LineDashed
+		case "dotted":
+			style = ast.LineDotted
+		case "bold":
+			style = ast.LineBold
+		}
+	}
+	return style
+}
+
+// parseAssociationClass parses "(Left, Right) .. Class", which attaches Class
+// to the relationship between Left and Right.
+func (p *Parser) parseAssociationClass() ast.Statement {
+	tok := p.advance() // consume '('
+	left := p.readClassName()
+	if p.current().Type != lexer.TokenComma {
+		p.addError(p.current().Pos, "expected ',' in association class")
+		p.skipToNextLine()
+		return nil
+	}
+	p.advance()
+	if !isName(p.current()) && p.current().Type != lexer.TokenString {
+		p.addError(p.current().Pos, "expected class name in association class")
+		p.skipToNextLine()
+		return nil
 	}
+	right := p.readClassName()
+	if p.current().Type != lexer.TokenRParen {
+		p.addError(p.current().Pos, "expected ')' in association class")
+		p.skipToNextLine()
+		return nil
+	}
+	p.advance()
+	if p.current().Type != lexer.TokenArrow {
+		p.addError(p.current().Pos, "expected arrow after association class pair")
+		p.skipToNextLine()
+		return nil
+	}
+	p.advance()
+	if !isName(p.current()) && p.current().Type != lexer.TokenString {
+		p.addError(p.current().Pos, "expected association class name")
+		p.skipToNextLine()
+		return nil
+	}
+	a := &ast.AssociationClass{Pos: tok.Pos, Left: left, Right: right, Class: p.readClassName()}
+	p.skipToNextLine()
+	return a
 }
 
 // classifyArrow determines the relationship type and direction from an arrow literal.
+// For composition and aggregation the direction records which end carries the
+// diamond, so that "A *-- B" and "B --* A" both mark A as the owner.
 func classifyArrow(arrow string) (ast.RelationshipType, ast.ArrowDirection) {
 	dir := arrowDirection(arrow)
 	switch {
@@ -339,10 +675,14 @@ func classifyArrow(arrow string) (ast.RelationshipType, ast.ArrowDirection) {
 			return ast.RelRealization, dir
 		}
 		return ast.RelInheritance, dir
-	case strings.HasSuffix(arrow, "*") || strings.HasPrefix(arrow, "*"):
-		return ast.RelComposition, dir
-	case (strings.HasSuffix(arrow, "o") && len(arrow) > 1) || strings.HasPrefix(arrow, "o"):
-		return ast.RelAggregation, dir
+	case strings.HasPrefix(arrow, "*"):
+		return ast.RelComposition, ast.ArrowLeft
+	case strings.HasSuffix(arrow, "*"):
+		return ast.RelComposition, ast.ArrowRight
+	case strings.HasPrefix(arrow, "o"):
+		return ast.RelAggregation, ast.ArrowLeft
+	case strings.HasSuffix(arrow, "o") && len(arrow) > 1:
+		return ast.RelAggregation, ast.ArrowRight
 	case strings.Contains(arrow, ".."):
 		return ast.RelDependency, dir
 	case strings.Contains(arrow, ">") || strings.Contains(arrow, "<"):
@@ -371,13 +711,13 @@ func (p *Parser) parsePackage() ast.Statement {
 	tok := p.advance() // consume 'package' or 'namespace'
 	isNamespace := tok.Type == lexer.TokenNamespace
 	pkg := &ast.Package{Pos: tok.Pos, IsNamespace: isNamespace}
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		pkg.Name = p.readClassName()
 	}
 	pkg.Alias = ""
 	if p.current().Type == lexer.TokenAs {
 		p.advance()
-		if p.current().Type == lexer.TokenIdent {
+		if isName(p.current()) {
 			pkg.Alias = p.current().Literal
 			p.advance()
 		}
@@ -385,22 +725,26 @@ func (p *Parser) parsePackage() ast.Statement {
 	if p.current().Type == lexer.TokenLBrace {
 		p.advance()
 		p.skipNewlines()
-		for p.current().Type != lexer.TokenRBrace && p.current().Type != lexer.TokenEOF {
+		// An unclosed package ends at @enduml, keeping its statements.
+		done := func() bool {
+			switch p.current().Type {
+			case lexer.TokenRBrace, lexer.TokenEndUML, lexer.TokenEOF:
+				return true
+			}
+			return false
+		}
+		for !done() {
 			p.skipNewlines()
-			if p.current().Type == lexer.TokenRBrace || p.current().Type == lexer.TokenEOF {
+			if done() {
 				break
 			}
-			stmt := p.parseStatement()
-			if stmt != nil {
-				pkg.Statements = append(pkg.Statements, stmt)
-			}
+			pkg.Statements = p.appendStatement(pkg.Statements, p.parseStatement())
 		}
 		if p.current().Type == lexer.TokenRBrace {
 			p.advance()
 		} else {
-			p.addError(p.current().Pos, "expected closing } for package")
+			p.addRecoverableError(p.current().Pos, "expected closing } for package")
 		}
 	}
 	return pkg
 }
-
@@ -72,6 +72,21 @@ func TestParseClassDef(t *testing.T) {
 		f := cd.Members[0].(*ast.Field)
 		assert.Equal(t, ast.ModifierStatic, f.Modifier)
 	})
+	t.Run("AbstractAndReadOnlyModifiers", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nclass S {\n{readOnly} -id : int\n{abstract} +area() : double\n}\n@enduml")
+		require.Empty(t, errs)
+		cd := diagram.Statements[0].(*ast.ClassDef)
+		require.Len(t, cd.Members, 2)
+		f := cd.Members[0].(*ast.Field)
+		assert.Equal(t, ast.ModifierReadOnly, f.Modifier)
+		assert.Equal(t, ast.VisibilityPrivate, f.Visibility)
+		assert.Equal(t, "id", f.Name)
+		m := cd.Members[1].(*ast.Method)
+		assert.Equal(t, ast.ModifierAbstract, m.Modifier)
+		assert.Equal(t, ast.VisibilityPublic, m.Visibility)
+		assert.Equal(t, "area", m.Name)
+	})
 	t.Run("AbstractClass", func(t *testing.T) {
 		t.Parallel()
 		diagram, errs := Parse("@startuml\nabstract class Shape {\n+area() : double\n}\n@enduml")
@@ -94,6 +109,38 @@ func TestParseClassDef(t *testing.T) {
 		require.Empty(t, errs)
 		cd := diagram.Statements[0].(*ast.ClassDef)
 		assert.Equal(t, "service", cd.Stereotype)
+		assert.Empty(t, cd.SpotLetter)
+	})
+	t.Run("MultipleStereotypes", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nclass Foo <<service>> <<entity>> <<audited>> as F {\n}\n@enduml")
+		require.Empty(t, errs)
+		cd := diagram.Statements[0].(*ast.ClassDef)
+		assert.Equal(t, "service", cd.Stereotype)
+		assert.Equal(t, []string{"entity", "audited"}, cd.ExtraStereotypes)
+		assert.Equal(t, "F", cd.Alias)
+	})
+	t.Run("StereotypeSpot", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input      string
+			letter     string
+			color      string
+			stereotype string
+		}{
+			{"class Foo <<(S,#FF7700) Service>>", "S", "#FF7700", "Service"},
+			{"class Foo <<(S,orange)>>", "S", "orange", ""},
+			{"class Foo <<(X)>> {\n}", "X", "", ""},
+			{"abstract Foo <<(A,#A9DCDF) Base>>", "A", "#A9DCDF", "Base"},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\n" + tt.input + "\n@enduml")
+			require.Empty(t, errs, tt.input)
+			cd := diagram.Statements[0].(*ast.ClassDef)
+			assert.Equal(t, tt.letter, cd.SpotLetter, tt.input)
+			assert.Equal(t, tt.color, cd.SpotColor, tt.input)
+			assert.Equal(t, tt.stereotype, cd.Stereotype, tt.input)
+		}
 	})
 	t.Run("ClassNoBody", func(t *testing.T) {
 		t.Parallel()
@@ -111,6 +158,114 @@ func TestParseClassDef(t *testing.T) {
 		assert.Equal(t, "Long Name", cd.Name)
 		assert.Equal(t, "LN", cd.Alias)
 	})
+	t.Run("Generics", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Map<K, V> <<collection>> {\n+items : List<String>\n+get(key : K) : Optional<V>\n}\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		cd := diagram.Statements[0].(*ast.ClassDef)
+		assert.Equal(t, "Map<K, V>", cd.Name)
+		assert.Equal(t, "collection", cd.Stereotype)
+		require.Len(t, cd.Members, 2)
+		assert.Equal(t, "List<String>", cd.Members[0].(*ast.Field).Type)
+		m := cd.Members[1].(*ast.Method)
+		assert.Equal(t, "key : K", m.Params)
+		assert.Equal(t, "Optional<V>", m.ReturnType)
+	})
+	t.Run("GenericRelationship", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nList<T> --> Node<T>\n@enduml")
+		require.Empty(t, errs)
+		rel := diagram.Statements[0].(*ast.Relationship)
+		assert.Equal(t, "List<T>", rel.Left)
+		assert.Equal(t, "Node<T>", rel.Right)
+	})
+	t.Run("ClassWithColor", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{"class Foo #LightBlue", "#LightBlue"},
+			{"class Foo #FF0000 {\n}", "#FF0000"},
+			{"class Foo #red/green", "#red/green"},
+			{"class Foo <<entity>> #00FF00", "#00FF00"},
+			{"class \"Long Name\" as LN #pink", "#pink"},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\n" + tt.input + "\n@enduml")
+			require.Empty(t, errs, tt.input)
+			cd := diagram.Statements[0].(*ast.ClassDef)
+			assert.Equal(t, tt.want, cd.BackgroundColor, tt.input)
+			assert.NotContains(t, cd.Name, "#", tt.input)
+		}
+	})
+	t.Run("Separators", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			line  string
+			style ast.SeparatorStyle
+			label string
+		}{
+			{"--", ast.SeparatorSolid, ""},
+			{"..", ast.SeparatorDotted, ""},
+			{"==", ast.SeparatorDouble, ""},
+			{"__", ast.SeparatorUnderline, ""},
+			{"-- Section --", ast.SeparatorSolid, "Section"},
+			{".. Getters ..", ast.SeparatorDotted, "Getters"},
+			{"== Title ==", ast.SeparatorDouble, "Title"},
+			{"__ Static members __", ast.SeparatorUnderline, "Static members"},
+			{"-- Open", ast.SeparatorSolid, "Open"},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\nclass Foo {\n  id : int\n  " + tt.line + "\n  name\n}\n@enduml")
+			require.Empty(t, errs, tt.line)
+			cd := diagram.Statements[0].(*ast.ClassDef)
+			require.Len(t, cd.Members, 3, tt.line)
+			sep, ok := cd.Members[1].(*ast.Separator)
+			require.True(t, ok, tt.line)
+			assert.Equal(t, tt.style, sep.Style, tt.line)
+			assert.Equal(t, tt.label, sep.Label, tt.line)
+			assert.Equal(t, "name", cd.Members[2].(*ast.Field).Name, tt.line)
+		}
+	})
+}
+
+func TestParseObjectDef(t *testing.T) {
+	t.Parallel()
+	t.Run("InstanceOf", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{`object "o1 : Foo"`, "object o1 : Foo"} {
+			diagram, errs := Parse("@startuml\n" + input + "\n@enduml")
+			require.Empty(t, errs, input)
+			od, ok := diagram.Statements[0].(*ast.ObjectDef)
+			require.True(t, ok, input)
+			assert.Equal(t, "o1", od.Name, input)
+			assert.Equal(t, "Foo", od.InstanceOf, input)
+		}
+	})
+	t.Run("WithBody", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nobject user {\nname = \"Dummy\"\nid = 123\n}\n@enduml")
+		require.Empty(t, errs)
+		od := diagram.Statements[0].(*ast.ObjectDef)
+		assert.Equal(t, "user", od.Name)
+		require.Len(t, od.Members, 2)
+		assert.Equal(t, "id = 123", od.Members[1].(*ast.Field).Name)
+	})
+	t.Run("WithAlias", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nobject \"Long Name\" as LN\n@enduml")
+		require.Empty(t, errs)
+		od := diagram.Statements[0].(*ast.ObjectDef)
+		assert.Equal(t, "Long Name", od.Name)
+		assert.Equal(t, "LN", od.Alias)
+	})
+	t.Run("MissingName", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nobject\n@enduml")
+		assert.NotEmpty(t, errs)
+	})
 }
 
 func TestParseInterfaceDef(t *testing.T) {
@@ -133,6 +288,28 @@ func TestParseInterfaceDef(t *testing.T) {
 	})
 }
 
+func TestParseAnnotationDef(t *testing.T) {
+	t.Parallel()
+	t.Run("Basic", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nannotation MyAnno <<Runtime>> as A {\n+value() : String\n}\n@enduml")
+		require.Empty(t, errs)
+		adef, ok := diagram.Statements[0].(*ast.AnnotationDef)
+		require.True(t, ok)
+		assert.Equal(t, "MyAnno", adef.Name)
+		assert.Equal(t, "A", adef.Alias)
+		assert.Equal(t, "Runtime", adef.Stereotype)
+		require.Len(t, adef.Members, 1)
+		assert.Equal(t, "value", adef.Members[0].(*ast.Method).Name)
+	})
+	t.Run("MissingName", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nannotation {\n}\n@enduml")
+		require.NotEmpty(t, errs)
+		assert.Equal(t, "expected annotation name", errs[0].Message)
+	})
+}
+
 func TestParseEnumDef(t *testing.T) {
 	t.Parallel()
 	t.Run("Basic", func(t *testing.T) {
@@ -144,6 +321,41 @@ func TestParseEnumDef(t *testing.T) {
 		assert.Equal(t, "Color", edef.Name)
 		require.Len(t, edef.Members, 3)
 	})
+	t.Run("ExplicitValues", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nenum Color {\nRED = 0\nGREEN = 1\nBLUE\n}\n@enduml")
+		require.Empty(t, errs)
+		edef := diagram.Statements[0].(*ast.EnumDef)
+		require.Len(t, edef.Members, 3)
+		for i, want := range []struct{ name, value string }{{"RED", "0"}, {"GREEN", "1"}, {"BLUE", ""}} {
+			f, ok := edef.Members[i].(*ast.Field)
+			require.True(t, ok)
+			assert.Equal(t, want.name, f.Name)
+			assert.Equal(t, want.value, f.Value)
+		}
+	})
+}
+
+func TestParseAssociationClass(t *testing.T) {
+	t.Parallel()
+	t.Run("Basic", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nStudent -- Course\n(Student, Course) .. Enrollment\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		a, ok := diagram.Statements[1].(*ast.AssociationClass)
+		require.True(t, ok)
+		assert.Equal(t, "Student", a.Left)
+		assert.Equal(t, "Course", a.Right)
+		assert.Equal(t, "Enrollment", a.Class)
+	})
+	t.Run("Malformed", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"(Student, ) .. Enrollment", "(Student, Course .. Enrollment", "(Student, Course) Enrollment"} {
+			_, errs := Parse("@startuml\n" + input + "\n@enduml")
+			assert.NotEmpty(t, errs, input)
+		}
+	})
 }
 
 func TestParseRelationship(t *testing.T) {
@@ -180,6 +392,53 @@ func TestParseRelationship(t *testing.T) {
 		rel := diagram.Statements[0].(*ast.Relationship)
 		assert.Equal(t, ast.RelAggregation, rel.Type)
 	})
+	t.Run("DiamondSide", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow   string
+			relType ast.RelationshipType
+			dir     ast.ArrowDirection
+		}{
+			{"*--", ast.RelComposition, ast.ArrowLeft},
+			{"--*", ast.RelComposition, ast.ArrowRight},
+			{"o--", ast.RelAggregation, ast.ArrowLeft},
+			{"--o", ast.RelAggregation, ast.ArrowRight},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\nA " + tt.arrow + " B\n@enduml")
+			require.Empty(t, errs, tt.arrow)
+			rel := diagram.Statements[0].(*ast.Relationship)
+			assert.Equal(t, tt.relType, rel.Type, tt.arrow)
+			assert.Equal(t, tt.dir, rel.Direction, tt.arrow)
+		}
+	})
+	t.Run("InlineLineStyle", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow   string
+			plain   string
+			relType ast.RelationshipType
+			style   ast.LineStyle
+		}{
+			{"-->", "-->", ast.RelAssociation, ast.LineDefault},
+			{"-[dashed]->", "-->", ast.RelAssociation, ast.LineDashed},
+			{"-[dotted]-|>", "--|>", ast.RelInheritance, ast.LineDotted},
+			{".[bold].>", "..>", ast.RelDependency, ast.LineBold},
+			{"*-[#red,dashed]-", "*--", ast.RelComposition, ast.LineDashed},
+			{"-[#red]->", "-->", ast.RelAssociation, ast.LineDefault},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\nA " + tt.arrow + " B : uses\n@enduml")
+			require.Empty(t, errs, tt.arrow)
+			require.Len(t, diagram.Statements, 1, tt.arrow)
+			rel := diagram.Statements[0].(*ast.Relationship)
+			assert.Equal(t, "B", rel.Right, tt.arrow)
+			assert.Equal(t, "uses", rel.Label, tt.arrow)
+			assert.Equal(t, tt.plain, rel.Arrow, tt.arrow)
+			assert.Equal(t, tt.relType, rel.Type, tt.arrow)
+			assert.Equal(t, tt.style, rel.LineStyle, tt.arrow)
+		}
+	})
 	t.Run("Association", func(t *testing.T) {
 		t.Parallel()
 		diagram, errs := Parse("@startuml\nA --> B\n@enduml")
@@ -247,6 +506,13 @@ func TestParsePackage(t *testing.T) {
 		pkg := diagram.Statements[0].(*ast.Package)
 		assert.True(t, pkg.IsNamespace)
 	})
+	t.Run("UnclosedIsRecoverable", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\npackage app {\nclass Foo\n@enduml")
+		require.Len(t, errs, 1)
+		assert.True(t, errs[0].Recoverable)
+		assert.Len(t, diagram.Statements[0].(*ast.Package).Statements, 1)
+	})
 }
 
 func TestParseNote(t *testing.T) {
@@ -269,6 +535,32 @@ func TestParseNote(t *testing.T) {
 		assert.Equal(t, ast.NoteRight, n.Placement)
 		assert.Equal(t, "Bar", n.Target)
 	})
+	t.Run("OnLink", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nA --> B\nA --> C\nnote on link : second\nclass D\nnote on link\nstill C\nend note\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 5)
+		rel := diagram.Statements[1].(*ast.Relationship)
+		n, ok := diagram.Statements[2].(*ast.Note)
+		require.True(t, ok)
+		assert.Equal(t, ast.NoteOnLink, n.Placement)
+		assert.Equal(t, rel.LinkID(), n.Target)
+		assert.Equal(t, "second", n.Text)
+		// The latest relationship is annotated even after other statements.
+		n = diagram.Statements[4].(*ast.Note)
+		assert.Equal(t, ast.NoteOnLink, n.Placement)
+		assert.Equal(t, rel.LinkID(), n.Target)
+		assert.Equal(t, "still C", n.Text)
+	})
+	t.Run("OnLinkWithoutRelationship", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nclass A\nnote on link : orphan\n@enduml")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "'note on link' must follow a relationship")
+		n := diagram.Statements[1].(*ast.Note)
+		assert.Equal(t, ast.NoteOnLink, n.Placement)
+		assert.Empty(t, n.Target)
+	})
 }
 
 func TestParseFixture(t *testing.T) {
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// parseComponent parses "component Name [as Alias]" or "component [Name] [as Alias]".
+func (p *Parser) parseComponent() ast.Statement {
+	tok := p.advance() // consume 'component'
+	name := ""
+	switch tok := p.current(); {
+	case isName(tok), tok.Type == lexer.TokenComponentRef, tok.Type == lexer.TokenString:
+		name = stripQuotes(p.advance().Literal)
+	default:
+		p.addError(p.current().Pos, "expected component name")
+		p.skipToNextLine()
+		return nil
+	}
+	c := &ast.Component{Pos: tok.Pos, Name: name, Alias: p.readComponentAlias()}
+	p.skipToNextLine()
+	return c
+}
+
+// parseComponentRef parses a statement starting with [Name]: either a
+// declaration with an optional alias or a connection such as [A] --> [B].
+func (p *Parser) parseComponentRef() ast.Statement {
+	tok := p.advance()
+	// A lone "-" lexes as a minus but is the usual short link in component diagrams.
+	if p.current().Type == lexer.TokenArrow || p.current().Type == lexer.TokenMinus {
+		return p.parseRelationship(tok.Pos, tok.Literal, "")
+	}
+	c := &ast.Component{Pos: tok.Pos, Name: tok.Literal, Alias: p.readComponentAlias()}
+	p.skipToNextLine()
+	return c
+}
+
+// parseComponentInterface parses an interface declaration: () "Name" [as Alias].
+func (p *Parser) parseComponentInterface() ast.Statement {
+	tok := p.advance() // consume '('
+	p.advance()        // consume ')'
+	if !isName(p.current()) && p.current().Type != lexer.TokenString {
+		p.addError(p.current().Pos, "expected interface name after ()")
+		p.skipToNextLine()
+		return nil
+	}
+	name := stripQuotes(p.advance().Literal)
+	c := &ast.Component{Pos: tok.Pos, Name: name, Alias: p.readComponentAlias(), IsInterface: true}
+	p.skipToNextLine()
+	return c
+}
+
+// readComponentAlias reads an optional "as Alias" suffix.
+func (p *Parser) readComponentAlias() string {
+	if p.current().Type != lexer.TokenAs {
+		return ""
+	}
+	p.advance()
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
+		return stripQuotes(p.advance().Literal)
+	}
+	return ""
+}
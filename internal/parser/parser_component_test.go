@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseComponent(t *testing.T) {
+	t.Parallel()
+	t.Run("BracketDeclaration", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n[Web Server] as WS\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		c, ok := diagram.Statements[0].(*ast.Component)
+		require.True(t, ok)
+		assert.Equal(t, "Web Server", c.Name)
+		assert.Equal(t, "WS", c.Alias)
+		assert.False(t, c.IsInterface)
+	})
+	t.Run("KeywordDeclaration", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\ncomponent Api\ncomponent [Data Store] as DB\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		c, ok := diagram.Statements[0].(*ast.Component)
+		require.True(t, ok)
+		assert.Equal(t, "Api", c.Name)
+		c, ok = diagram.Statements[1].(*ast.Component)
+		require.True(t, ok)
+		assert.Equal(t, "Data Store", c.Name)
+		assert.Equal(t, "DB", c.Alias)
+	})
+	t.Run("Interface", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n() \"HTTP API\" as HTTP\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		c, ok := diagram.Statements[0].(*ast.Component)
+		require.True(t, ok)
+		assert.Equal(t, "HTTP API", c.Name)
+		assert.Equal(t, "HTTP", c.Alias)
+		assert.True(t, c.IsInterface)
+	})
+	t.Run("Connection", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n[Web] --> [API] : calls\n[API] ..> [DB]\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		rel, ok := diagram.Statements[0].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "Web", rel.Left)
+		assert.Equal(t, "API", rel.Right)
+		assert.Equal(t, "calls", rel.Label)
+		assert.Equal(t, ast.ArrowRight, rel.Direction)
+		rel, ok = diagram.Statements[1].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "..>", rel.Arrow)
+	})
+	t.Run("InterfaceToComponent", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nHTTP -- [Web]\n[Web] - HTTP\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		rel, ok := diagram.Statements[0].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "HTTP", rel.Left)
+		assert.Equal(t, "Web", rel.Right)
+		rel, ok = diagram.Statements[1].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "Web", rel.Left)
+		assert.Equal(t, "HTTP", rel.Right)
+		assert.Equal(t, ast.ArrowNone, rel.Direction)
+	})
+	t.Run("ShortLinkFromName", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nHTTP - [Web]\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		rel, ok := diagram.Statements[0].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "HTTP", rel.Left)
+		assert.Equal(t, "Web", rel.Right)
+	})
+	t.Run("MissingInterfaceName", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\n()\n@enduml")
+		require.NotEmpty(t, errs)
+		assert.Contains(t, errs[0].Message, "interface name")
+	})
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// crowFootEnds maps the ends of a crow's-foot arrow to their cardinalities.
+// The left end is read as written and the right end mirrored, so "|o" and
+// "o|" both mean zero or one.
+var crowFootEnds = map[string]ast.CrowFoot{
+	"||": ast.CrowFootExactlyOne,
+	"|o": ast.CrowFootZeroOrOne,
+	"o|": ast.CrowFootZeroOrOne,
+	"}|": ast.CrowFootOneOrMany,
+	"|{": ast.CrowFootOneOrMany,
+	"}o": ast.CrowFootZeroOrMany,
+	"o{": ast.CrowFootZeroOrMany,
+}
+
+// crowFoot returns the cardinalities at the ends of a crow's-foot arrow such
+// as "||--o{", or CrowFootNone for both if arrow is not one.
+func crowFoot(arrow string) (left, right ast.CrowFoot) {
+	if len(arrow) < 5 {
+		return ast.CrowFootNone, ast.CrowFootNone
+	}
+	left, right = crowFootEnds[arrow[:2]], crowFootEnds[arrow[len(arrow)-2:]]
+	if left == ast.CrowFootNone || right == ast.CrowFootNone {
+		return ast.CrowFootNone, ast.CrowFootNone
+	}
+	return left, right
+}
+
+// isERInput reports whether tokens hold an entity-relationship diagram: an
+// entity declared with a body or a crow's-foot arrow. In such input every
+// "entity" declares an ER entity rather than a sequence participant.
+func isERInput(tokens []lexer.Token) bool {
+	for i, tok := range tokens {
+		switch tok.Type {
+		case lexer.TokenArrow:
+			if left, _ := crowFoot(tok.Literal); left != ast.CrowFootNone {
+				return true
+			}
+		case lexer.TokenEntity:
+			if entityHasBody(tokens[i+1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// entityHasBody reports whether the rest of an entity declaration opens a
+// body on the same line.
+func entityHasBody(tokens []lexer.Token) bool {
+	for _, tok := range tokens {
+		switch tok.Type {
+		case lexer.TokenLBrace:
+			return true
+		case lexer.TokenNewline, lexer.TokenEOF:
+			return false
+		}
+	}
+	return false
+}
+
+// parseEntity parses "entity Name [as Alias] [{ attributes }]".
+func (p *Parser) parseEntity() ast.Statement {
+	tok := p.advance() // consume 'entity'
+	if !isName(p.current()) && p.current().Type != lexer.TokenString {
+		p.addError(p.current().Pos, "expected entity name")
+		p.skipToNextLine()
+		return nil
+	}
+	e := &ast.Entity{Pos: tok.Pos, Name: stripQuotes(p.advance().Literal), Alias: p.readComponentAlias()}
+	if p.current().Type != lexer.TokenLBrace {
+		p.skipToNextLine()
+		return e
+	}
+	e.Attributes = p.parseEntityBody(e.Name)
+	return e
+}
+
+// parseEntityBody parses the attribute lines of an entity up to the closing
+// brace. A "--" line marks the attributes above it as the key.
+func (p *Parser) parseEntityBody(name string) []ast.EntityAttribute {
+	p.advance() // consume '{'
+	var attrs []ast.EntityAttribute
+	for {
+		p.skipNewlines()
+		tok := p.current()
+		switch {
+		case tok.Type == lexer.TokenRBrace:
+			p.advance()
+			return attrs
+		case tok.Type == lexer.TokenEOF || tok.Type == lexer.TokenEndUML:
+			p.addError(tok.Pos, "expected closing } for entity "+name)
+			return attrs
+		case tok.Type == lexer.TokenLineComment || tok.Type == lexer.TokenBlockComment:
+			p.advance()
+		case tok.Type == lexer.TokenArrow && strings.Trim(tok.Literal, "-.") == "":
+			for i := range attrs {
+				attrs[i].Key = true
+			}
+			p.skipToNextLine()
+		default:
+			if attr, ok := p.parseEntityAttribute(); ok {
+				attrs = append(attrs, attr)
+			}
+		}
+	}
+}
+
+// parseEntityAttribute parses an attribute line such as "* id : int". The
+// leading '*' marks the attribute mandatory; the type is optional.
+func (p *Parser) parseEntityAttribute() (ast.EntityAttribute, bool) {
+	attr := ast.EntityAttribute{Pos: p.current().Pos}
+	if isErrorChar(p.current(), "*") {
+		attr.Mandatory = true
+		p.advance()
+	}
+	var name strings.Builder
+	var prev lexer.Token
+	for tok := p.current(); tok.Type != lexer.TokenColon && tok.Type != lexer.TokenNewline &&
+		tok.Type != lexer.TokenRBrace && tok.Type != lexer.TokenEOF; tok = p.current() {
+		if name.Len() > 0 && !adjacent(prev, tok) {
+			name.WriteByte(' ')
+		}
+		name.WriteString(tok.Literal)
+		prev = p.advance()
+	}
+	attr.Name = stripQuotes(name.String())
+	if p.current().Type == lexer.TokenColon {
+		p.advance()
+		attr.Type = p.readTypeUntilNewline()
+	}
+	if attr.Name == "" {
+		p.addError(attr.Pos, "expected attribute name")
+		p.skipToNextLine()
+		return attr, false
+	}
+	p.consumeOptionalNewline()
+	return attr, true
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntity(t *testing.T) {
+	t.Parallel()
+	t.Run("Attributes", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nentity \"User Account\" as User {\n  * id : int\n  --\n  * email : text\n  nickname\n}\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		e, ok := diagram.Statements[0].(*ast.Entity)
+		require.True(t, ok)
+		assert.Equal(t, "User Account", e.Name)
+		assert.Equal(t, "User", e.Alias)
+		require.Len(t, e.Attributes, 3)
+		assert.Equal(t, ast.EntityAttribute{Pos: e.Attributes[0].Pos, Name: "id", Type: "int", Mandatory: true, Key: true}, e.Attributes[0])
+		assert.Equal(t, ast.EntityAttribute{Pos: e.Attributes[1].Pos, Name: "email", Type: "text", Mandatory: true}, e.Attributes[1])
+		assert.Equal(t, ast.EntityAttribute{Pos: e.Attributes[2].Pos, Name: "nickname"}, e.Attributes[2])
+	})
+	t.Run("OneLineBody", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nentity User { id : int }\n@enduml")
+		require.Empty(t, errs)
+		e := diagram.Statements[0].(*ast.Entity)
+		require.Len(t, e.Attributes, 1)
+		assert.Equal(t, "int", e.Attributes[0].Type)
+	})
+	t.Run("ParticipantWithoutBody", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nentity Store\nStore -> Store : save\n@enduml")
+		require.Empty(t, errs)
+		p, ok := diagram.Statements[0].(*ast.Participant)
+		require.True(t, ok)
+		assert.Equal(t, ast.ParticipantEntity, p.Kind)
+	})
+	t.Run("WithoutBodyInERDiagram", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nentity Address\nentity User\nUser ||--o{ Address\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		assert.Equal(t, "Address", diagram.Statements[0].(*ast.Entity).Name)
+		assert.Empty(t, diagram.Statements[1].(*ast.Entity).Attributes)
+		assert.IsType(t, &ast.Relationship{}, diagram.Statements[2])
+	})
+	t.Run("UnterminatedBody", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nentity User {\n  id : int\n@enduml")
+		require.Len(t, errs, 1)
+		assert.Equal(t, "expected closing } for entity User", errs[0].Message)
+	})
+	t.Run("CrowFoot", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow       string
+			left, right ast.CrowFoot
+		}{
+			{"||--o{", ast.CrowFootExactlyOne, ast.CrowFootZeroOrMany},
+			{"}|..|{", ast.CrowFootOneOrMany, ast.CrowFootOneOrMany},
+			{"|o--o|", ast.CrowFootZeroOrOne, ast.CrowFootZeroOrOne},
+			{"}o--||", ast.CrowFootZeroOrMany, ast.CrowFootExactlyOne},
+			{"-->", ast.CrowFootNone, ast.CrowFootNone},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\nUser " + tt.arrow + " Order : places\n@enduml")
+			require.Empty(t, errs, tt.arrow)
+			rel := diagram.Statements[0].(*ast.Relationship)
+			assert.Equal(t, "User", rel.Left, tt.arrow)
+			assert.Equal(t, "Order", rel.Right, tt.arrow)
+			assert.Equal(t, "places", rel.Label, tt.arrow)
+			assert.Equal(t, tt.left, rel.LeftCrowFoot, tt.arrow)
+			assert.Equal(t, tt.right, rel.RightCrowFoot, tt.arrow)
+		}
+	})
+}
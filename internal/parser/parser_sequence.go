@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/bobcob7/go-uml/internal/ast"
@@ -11,16 +12,42 @@ import (
 func (p *Parser) parseParticipant(kind ast.ParticipantKind) *ast.Participant {
 	tok := p.advance() // consume keyword
 	name := p.readParticipantName()
+	color := p.readColor()
 	alias := ""
 	if p.current().Type == lexer.TokenAs {
 		p.advance()
-		if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+		if isName(p.current()) || p.current().Type == lexer.TokenString {
 			alias = stripQuotes(p.current().Literal)
 			p.advance()
 		}
 	}
+	order := p.readParticipantOrder()
+	if c := p.readColor(); c != "" {
+		color = c
+	}
 	p.skipToNextLine()
-	return &ast.Participant{Pos: tok.Pos, Name: name, Alias: alias, Kind: kind}
+	return &ast.Participant{Pos: tok.Pos, Name: name, Alias: alias, Kind: kind, Color: color, Order: order}
+}
+
+// readParticipantOrder reads an optional "order N" suffix, where N may be
+// negative. Returns 0 if no order is given.
+func (p *Parser) readParticipantOrder() int {
+	if p.current().Type != lexer.TokenOrder {
+		return 0
+	}
+	orderTok := p.advance()
+	sign := 1
+	if p.current().Type == lexer.TokenMinus {
+		p.advance()
+		sign = -1
+	}
+	n, err := strconv.Atoi(p.current().Literal)
+	if p.current().Type != lexer.TokenNumber || err != nil {
+		p.addError(orderTok.Pos, "expected integer after order")
+		return 0
+	}
+	p.advance()
+	return sign * n
 }
 
 func (p *Parser) readParticipantName() string {
@@ -29,7 +56,7 @@ func (p *Parser) readParticipantName() string {
 		p.advance()
 		return name
 	}
-	if p.current().Type == lexer.TokenIdent {
+	if isName(p.current()) {
 		name := p.current().Literal
 		p.advance()
 		return name
@@ -40,7 +67,7 @@ func (p *Parser) readParticipantName() string {
 func (p *Parser) parseActivate(deactivate bool) *ast.Activate {
 	tok := p.advance() // consume 'activate' or 'deactivate'
 	target := ""
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		target = stripQuotes(p.current().Literal)
 		p.advance()
 	}
@@ -48,19 +75,56 @@ func (p *Parser) parseActivate(deactivate bool) *ast.Activate {
 	return &ast.Activate{Pos: tok.Pos, Target: target, Deactivate: deactivate}
 }
 
+// participantKeywords maps the keywords that declare a participant to the
+// kind they declare.
+var participantKeywords = map[lexer.TokenType]ast.ParticipantKind{
+	lexer.TokenParticipant: ast.ParticipantDefault,
+	lexer.TokenActor:       ast.ParticipantActor,
+	lexer.TokenBoundary:    ast.ParticipantBoundary,
+	lexer.TokenControl:     ast.ParticipantControl,
+	lexer.TokenEntity:      ast.ParticipantEntity,
+	lexer.TokenDatabase:    ast.ParticipantDatabase,
+	lexer.TokenCollections: ast.ParticipantCollections,
+	lexer.TokenQueue:       ast.ParticipantQueue,
+}
+
+// parseCreate parses "create Name" or "create <kind> Name [as Alias]". The
+// second form declares the participant too, and is returned as the
+// participant followed by a pending create lifecycle.
+func (p *Parser) parseCreate() ast.Statement {
+	kind, ok := participantKeywords[p.peek().Type]
+	if !ok {
+		return p.parseLifecycle(false)
+	}
+	tok := p.advance() // consume 'create'
+	participant := p.parseParticipant(kind)
+	p.pending = append(p.pending, &ast.Lifecycle{Pos: tok.Pos, Target: participant.Name})
+	return participant
+}
+
+func (p *Parser) parseLifecycle(destroy bool) *ast.Lifecycle {
+	tok := p.advance() // consume 'create' or 'destroy'
+	target := ""
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
+		target = stripQuotes(p.current().Literal)
+		p.advance()
+	}
+	p.skipToNextLine()
+	return &ast.Lifecycle{Pos: tok.Pos, Target: target, Destroy: destroy}
+}
+
 func (p *Parser) parseReturn() *ast.Return {
 	tok := p.advance() // consume 'return'
-	label := p.readRestOfLine()
+	label := p.readLabel()
 	return &ast.Return{Pos: tok.Pos, Label: label}
 }
 
 func (p *Parser) parseFragment(kind ast.FragmentKind) *ast.Fragment {
 	tok := p.advance() // consume keyword (alt, loop, par, etc.)
-	condition := p.readRestOfLine()
 	frag := &ast.Fragment{
 		Pos:       tok.Pos,
 		Kind:      kind,
-		Condition: strings.TrimSpace(condition),
+		Condition: fragmentCondition(p.readRestOfLine()),
 	}
 	p.skipNewlines()
 	for p.current().Type != lexer.TokenEnd && p.current().Type != lexer.TokenElse &&
@@ -70,12 +134,14 @@ func (p *Parser) parseFragment(kind ast.FragmentKind) *ast.Fragment {
 			p.current().Type == lexer.TokenEndUML || p.current().Type == lexer.TokenEOF {
 			break
 		}
-		stmt := p.parseStatementInContext(true)
-		if stmt != nil {
-			frag.Statements = append(frag.Statements, stmt)
-		}
+		frag.Statements = p.appendStatement(frag.Statements, p.parseStatementInContext(true))
 	}
 	for p.current().Type == lexer.TokenElse {
+		if kind == ast.FragmentOpt {
+			// An opt has a single guarded region; keep parsing the else
+			// part so that the statements after it are still checked.
+			p.addError(p.current().Pos, "'else' is not allowed in an opt fragment")
+		}
 		ep := p.parseElsePart()
 		frag.ElseParts = append(frag.ElseParts, ep)
 	}
@@ -90,10 +156,9 @@ func (p *Parser) parseFragment(kind ast.FragmentKind) *ast.Fragment {
 
 func (p *Parser) parseElsePart() ast.ElsePart {
 	tok := p.advance() // consume 'else'
-	condition := p.readRestOfLine()
 	ep := ast.ElsePart{
 		Pos:       tok.Pos,
-		Condition: strings.TrimSpace(condition),
+		Condition: fragmentCondition(p.readRestOfLine()),
 	}
 	p.skipNewlines()
 	for p.current().Type != lexer.TokenEnd && p.current().Type != lexer.TokenElse &&
@@ -103,23 +168,40 @@ func (p *Parser) parseElsePart() ast.ElsePart {
 			p.current().Type == lexer.TokenEndUML || p.current().Type == lexer.TokenEOF {
 			break
 		}
-		stmt := p.parseStatementInContext(true)
-		if stmt != nil {
-			ep.Statements = append(ep.Statements, stmt)
-		}
+		ep.Statements = p.appendStatement(ep.Statements, p.parseStatementInContext(true))
 	}
 	return ep
 }
 
+// fragmentCondition trims the guard of a fragment or else part and drops
+// the square brackets around it, so that "opt [x > 0]" and "opt x > 0"
+// give the same condition.
+func fragmentCondition(s string) string {
+	s = strings.TrimSpace(s)
+	if inner, ok := strings.CutPrefix(s, "["); ok {
+		if inner, ok := strings.CutSuffix(inner, "]"); ok {
+			return strings.TrimSpace(inner)
+		}
+	}
+	return s
+}
+
 func (p *Parser) parseAutonumber() *ast.Autonumber {
 	tok := p.advance() // consume 'autonumber'
-	start := ""
-	if p.current().Type == lexer.TokenNumber {
-		start = p.current().Literal
+	a := &ast.Autonumber{Pos: tok.Pos}
+	switch cur := p.current(); {
+	case cur.Type == lexer.TokenNumber:
+		a.Start = cur.Literal
+		p.advance()
+	case cur.Type == lexer.TokenStop:
+		a.Stop = true
+		p.advance()
+	case cur.Type == lexer.TokenIdent && cur.Literal == "resume":
+		a.Resume = true
 		p.advance()
 	}
 	p.skipToNextLine()
-	return &ast.Autonumber{Pos: tok.Pos, Start: start}
+	return a
 }
 
 func (p *Parser) parseDivider() *ast.Divider {
@@ -168,51 +250,70 @@ func (p *Parser) parseSequenceIdentStatement() ast.Statement {
 	name := tok.Literal
 	p.advance()
 	if p.current().Type == lexer.TokenArrow {
+		// An actor linked to a usecase, as in "User --> (Login)".
+		if p.peek().Type == lexer.TokenLParen {
+			return p.parseRelationship(tok.Pos, name, "")
+		}
 		return p.parseMessage(tok.Pos, name)
 	}
-	p.addError(tok.Pos, fmt.Sprintf("unexpected identifier %q", name))
+	p.addErrorKind(tok.Pos, ErrUnexpectedToken, fmt.Sprintf("unexpected identifier %q", name))
 	p.skipToNextLine()
 	return nil
 }
 
 func (p *Parser) parseMessage(pos lexer.Pos, from string) *ast.Message {
 	arrowTok := p.advance() // consume arrow
-	arrow := arrowTok.Literal
+	arrow, _ := splitArrowStyle(arrowTok.Literal)
 	dashed := isDashedArrow(arrow)
 	to := ""
-	if p.current().Type == lexer.TokenIdent || p.current().Type == lexer.TokenString {
+	if isName(p.current()) || p.current().Type == lexer.TokenString {
 		to = stripQuotes(p.current().Literal)
 		p.advance()
 	}
 	// Handle activation shorthand: ++ or --
-	activate := ""
+	activate, deactivate := false, false
 	if p.current().Type == lexer.TokenPlus {
 		p.advance()
 		if p.current().Type == lexer.TokenPlus {
 			p.advance()
-			activate = "++"
+			activate = true
 		}
 	} else if p.current().Type == lexer.TokenArrow && p.current().Literal == "--" {
 		p.advance()
-		activate = "--"
+		deactivate = true
+	}
+	// Handle destruction shorthand: !!
+	destroy := false
+	if isErrorChar(p.current(), "!") && isErrorChar(p.peek(), "!") {
+		p.advance()
+		p.advance()
+		destroy = true
 	}
 	label := ""
 	if p.current().Type == lexer.TokenColon {
 		p.advance()
-		label = strings.TrimSpace(p.readRestOfLine())
+		label = p.readLabel()
 	} else {
 		p.skipToNextLine()
 	}
-	msg := &ast.Message{
-		Pos:    pos,
-		From:   from,
-		To:     to,
-		Label:  label,
-		Arrow:  arrow,
-		Dashed: dashed,
+	return &ast.Message{
+		Pos:              pos,
+		From:             from,
+		To:               to,
+		Label:            label,
+		Arrow:            arrow,
+		Dashed:           dashed,
+		ActivateTarget:   activate,
+		DeactivateSource: deactivate,
+		DestroyTarget:    destroy,
+		Bidirectional:    isBidirectionalArrow(arrow),
 	}
-	_ = activate // activation shorthand tracked but not yet wired to AST
-	return msg
+}
+
+// isErrorChar reports whether tok is the single character c, which the
+// lexer has no token for.
+func isErrorChar(tok lexer.Token, c string) bool {
+	return tok.Type == lexer.TokenError && tok.Literal == c
 }
 
 // isSequenceArrow returns true if the arrow is unambiguously a sequence diagram
@@ -230,6 +331,12 @@ func isDashedArrow(arrow string) bool {
 	return strings.Contains(shaft, "..") || strings.Contains(shaft, "--")
 }
 
+// isBidirectionalArrow reports whether arrow has heads at both ends, as in
+// "<->" and "<-->".
+func isBidirectionalArrow(arrow string) bool {
+	return strings.HasPrefix(arrow, "<") && strings.HasSuffix(arrow, ">")
+}
+
 func isDelayArrow(literal string) bool {
 	for _, ch := range literal {
 		if ch != '.' {
@@ -253,6 +360,14 @@ func fragmentName(kind ast.FragmentKind) string {
 		return "ref"
 	case ast.FragmentGroup:
 		return "group"
+	case ast.FragmentOpt:
+		return "opt"
+	case ast.FragmentCritical:
+		return "critical"
+	case ast.FragmentIgnore:
+		return "ignore"
+	case ast.FragmentConsider:
+		return "consider"
 	default:
 		return "unknown"
 	}
@@ -31,6 +31,28 @@ func TestParseParticipant(t *testing.T) {
 		assert.Equal(t, "Bob", p.Name)
 		assert.Equal(t, ast.ParticipantActor, p.Kind)
 	})
+	t.Run("Order", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nparticipant Bob as B order 10 #red\nparticipant Alice order -5\nparticipant Carol\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		orders := make([]int, 0, 3)
+		for _, stmt := range diagram.Statements {
+			p, ok := stmt.(*ast.Participant)
+			require.True(t, ok)
+			orders = append(orders, p.Order)
+		}
+		assert.Equal(t, []int{10, -5, 0}, orders)
+		p := diagram.Statements[0].(*ast.Participant)
+		assert.Equal(t, "B", p.Alias)
+		assert.Equal(t, "#red", p.Color)
+	})
+	t.Run("OrderWithoutNumber", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nparticipant Bob order\n@enduml")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "expected integer after order")
+	})
 	t.Run("Boundary", func(t *testing.T) {
 		t.Parallel()
 		diagram, errs := Parse("@startuml\nboundary Web\n@enduml")
@@ -101,6 +123,26 @@ func TestParseParticipant(t *testing.T) {
 		assert.Equal(t, "Long Name", p.Name)
 		assert.Equal(t, "LN", p.Alias)
 	})
+	t.Run("WithColor", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nparticipant Alice #00FF00\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		p, ok := diagram.Statements[0].(*ast.Participant)
+		require.True(t, ok)
+		assert.Equal(t, "Alice", p.Name)
+		assert.Equal(t, "#00FF00", p.Color)
+	})
+	t.Run("WithAliasAndColor", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nparticipant \"Long Name\" as LN #lightblue\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		p, ok := diagram.Statements[0].(*ast.Participant)
+		require.True(t, ok)
+		assert.Equal(t, "LN", p.Alias)
+		assert.Equal(t, "#lightblue", p.Color)
+	})
 }
 
 func TestParseMessage(t *testing.T) {
@@ -118,6 +160,16 @@ func TestParseMessage(t *testing.T) {
 		assert.Equal(t, "->", m.Arrow)
 		assert.False(t, m.Dashed)
 	})
+	t.Run("InlineStyleIgnored", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nAlice -[#red]> Bob : hi\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		m, ok := diagram.Statements[0].(*ast.Message)
+		require.True(t, ok)
+		assert.Equal(t, "->", m.Arrow)
+		assert.Equal(t, "Bob", m.To)
+	})
 	t.Run("DashedArrow", func(t *testing.T) {
 		t.Parallel()
 		diagram, errs := Parse("@startuml\nparticipant Bob\nparticipant Alice\nBob --> Alice : response\n@enduml")
@@ -142,6 +194,29 @@ func TestParseMessage(t *testing.T) {
 		assert.Equal(t, "data", m.Label)
 		assert.Equal(t, "<-", m.Arrow)
 	})
+	t.Run("BidirectionalArrow", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nparticipant Alice\nparticipant Bob\nAlice <-> Bob : sync\nAlice <--> Bob\nAlice -> Bob\nAlice <- Bob\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 6)
+		want := []struct {
+			arrow  string
+			dashed bool
+			both   bool
+		}{
+			{"<->", false, true},
+			{"<-->", true, true},
+			{"->", false, false},
+			{"<-", false, false},
+		}
+		for i, w := range want {
+			m, ok := diagram.Statements[i+2].(*ast.Message)
+			require.True(t, ok)
+			assert.Equal(t, w.arrow, m.Arrow)
+			assert.Equal(t, w.dashed, m.Dashed, w.arrow)
+			assert.Equal(t, w.both, m.Bidirectional, w.arrow)
+		}
+	})
 	t.Run("DottedArrow", func(t *testing.T) {
 		t.Parallel()
 		diagram, errs := Parse("@startuml\nparticipant Alice\nparticipant Bob\nAlice ..> Bob : async\n@enduml")
@@ -175,6 +250,8 @@ func TestParseMessage(t *testing.T) {
 		m, ok := diagram.Statements[2].(*ast.Message)
 		require.True(t, ok)
 		assert.Equal(t, "activate", m.Label)
+		assert.True(t, m.ActivateTarget)
+		assert.False(t, m.DeactivateSource)
 	})
 	t.Run("ActivationShorthandMinusMinus", func(t *testing.T) {
 		t.Parallel()
@@ -184,6 +261,19 @@ func TestParseMessage(t *testing.T) {
 		m, ok := diagram.Statements[2].(*ast.Message)
 		require.True(t, ok)
 		assert.Equal(t, "deactivate", m.Label)
+		assert.False(t, m.ActivateTarget)
+		assert.True(t, m.DeactivateSource)
+	})
+	t.Run("DestroyShorthand", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nAlice -> Bob !! : bye\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		m, ok := diagram.Statements[0].(*ast.Message)
+		require.True(t, ok)
+		assert.Equal(t, "Bob", m.To)
+		assert.Equal(t, "bye", m.Label)
+		assert.True(t, m.DestroyTarget)
 	})
 }
 
@@ -297,6 +387,54 @@ func TestParseActivate(t *testing.T) {
 	})
 }
 
+func TestParseLifecycle(t *testing.T) {
+	t.Parallel()
+	t.Run("Create", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\ncreate Bob\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		l, ok := diagram.Statements[0].(*ast.Lifecycle)
+		require.True(t, ok)
+		assert.Equal(t, "Bob", l.Target)
+		assert.False(t, l.Destroy)
+	})
+	t.Run("CreateWithKind", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\ncreate control Bob as B\nAlice -> B : new\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		p, ok := diagram.Statements[0].(*ast.Participant)
+		require.True(t, ok)
+		assert.Equal(t, "Bob", p.Name)
+		assert.Equal(t, "B", p.Alias)
+		assert.Equal(t, ast.ParticipantControl, p.Kind)
+		l, ok := diagram.Statements[1].(*ast.Lifecycle)
+		require.True(t, ok)
+		assert.Equal(t, "Bob", l.Target)
+		assert.False(t, l.Destroy)
+		assert.IsType(t, &ast.Message{}, diagram.Statements[2])
+	})
+	t.Run("Destroy", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\ndestroy \"Data Store\"\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		l, ok := diagram.Statements[0].(*ast.Lifecycle)
+		require.True(t, ok)
+		assert.Equal(t, "Data Store", l.Target)
+		assert.True(t, l.Destroy)
+	})
+	t.Run("EnablesSequenceMode", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\ncreate Bob\nAlice -> Bob : new\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		_, ok := diagram.Statements[1].(*ast.Message)
+		assert.True(t, ok)
+	})
+}
+
 func TestParseReturn(t *testing.T) {
 	t.Parallel()
 	t.Run("WithLabel", func(t *testing.T) {
@@ -392,6 +530,49 @@ func TestParseFragment(t *testing.T) {
 		assert.Equal(t, ast.FragmentRef, f.Kind)
 		assert.Equal(t, "over Alice", f.Condition)
 	})
+	t.Run("OptCriticalIgnoreConsider", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			keyword string
+			want    ast.FragmentKind
+		}{
+			{"opt", ast.FragmentOpt},
+			{"critical", ast.FragmentCritical},
+			{"ignore", ast.FragmentIgnore},
+			{"consider", ast.FragmentConsider},
+		}
+		for _, tt := range tests {
+			input := "@startuml\nparticipant Alice\nparticipant Bob\n" + tt.keyword + " cached\nAlice -> Bob : msg\nend\n@enduml"
+			diagram, errs := Parse(input)
+			require.Empty(t, errs, tt.keyword)
+			require.Len(t, diagram.Statements, 3, tt.keyword)
+			f, ok := diagram.Statements[2].(*ast.Fragment)
+			require.True(t, ok, tt.keyword)
+			assert.Equal(t, tt.want, f.Kind, tt.keyword)
+			assert.Equal(t, "cached", f.Condition, tt.keyword)
+			require.Len(t, f.Statements, 1, tt.keyword)
+		}
+	})
+	t.Run("OptBracketedCondition", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nopt [x > 0]\nAlice -> Bob\nend\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		f, ok := diagram.Statements[0].(*ast.Fragment)
+		require.True(t, ok)
+		assert.Equal(t, ast.FragmentOpt, f.Kind)
+		assert.Equal(t, "x > 0", f.Condition)
+		require.Len(t, f.Statements, 1)
+		assert.Empty(t, f.ElseParts)
+	})
+	t.Run("OptRejectsElse", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nopt [x > 0]\nAlice -> Bob\nelse\nBob -> Alice\nend\nAlice -> Bob : after\n@enduml")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "'else' is not allowed in an opt fragment")
+		assert.Equal(t, 4, errs[0].Pos.Line)
+		require.Len(t, diagram.Statements, 2, "parsing resumes after the fragment")
+	})
 	t.Run("NestedFragments", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nparticipant Alice\nparticipant Bob\nalt outer\nloop 3 times\nAlice -> Bob : msg\nend\nend\n@enduml"
@@ -496,6 +677,18 @@ func TestParseAutonumber(t *testing.T) {
 		require.True(t, ok)
 		assert.Equal(t, "10", a.Start)
 	})
+	t.Run("StopAndResume", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nautonumber stop\nautonumber resume\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		stop := diagram.Statements[0].(*ast.Autonumber)
+		assert.True(t, stop.Stop)
+		assert.False(t, stop.Resume)
+		resume := diagram.Statements[1].(*ast.Autonumber)
+		assert.True(t, resume.Resume)
+		assert.False(t, resume.Stop)
+	})
 }
 
 func TestParseDivider(t *testing.T) {
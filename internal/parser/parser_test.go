@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/bobcob7/go-uml/internal/ast"
@@ -45,6 +47,24 @@ func TestParse(t *testing.T) {
 		diagram, errs := Parse("@startuml\ntitle My Title\n@enduml")
 		require.Empty(t, errs)
 		require.Len(t, diagram.Statements, 1)
+		title, ok := diagram.Statements[0].(*ast.Title)
+		require.True(t, ok)
+		assert.Equal(t, "My Title", title.Text)
+		assert.Equal(t, "My Title", diagram.Title)
+	})
+	t.Run("HeaderAndFooter", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nheader Draft copy\nfooter Page one\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		h, ok := diagram.Statements[0].(*ast.Header)
+		require.True(t, ok)
+		assert.Equal(t, "Draft copy", h.Text)
+		f, ok := diagram.Statements[1].(*ast.Footer)
+		require.True(t, ok)
+		assert.Equal(t, "Page one", f.Text)
+		assert.Equal(t, "Draft copy", diagram.Header)
+		assert.Equal(t, "Page one", diagram.Footer)
 	})
 	t.Run("SkinparamDirective", func(t *testing.T) {
 		t.Parallel()
@@ -54,6 +74,91 @@ func TestParse(t *testing.T) {
 		sp, ok := diagram.Statements[0].(*ast.Skinparam)
 		require.True(t, ok)
 		assert.Equal(t, "backgroundColor", sp.Name)
+		assert.Equal(t, "#FFF", sp.Value)
+	})
+	t.Run("SkinparamHexColor", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{"skinparam backgroundColor #FF0000", "#FF0000"},
+			{"skinparam backgroundColor #123456", "#123456"},
+			{"skinparam backgroundColor #LightBlue", "#LightBlue"},
+			{"skinparam class { BackgroundColor #00FF00 }", "#00FF00"},
+			{"skinparam defaultFontName Courier New", "Courier New"},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\n" + tt.input + "\n@enduml")
+			require.Empty(t, errs, tt.input)
+			sp := diagram.Statements[0].(*ast.Skinparam)
+			assert.Equal(t, tt.want, sp.Value, tt.input)
+		}
+	})
+	t.Run("SkinparamBlock", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nskinparam class { BackgroundColor #FFF\n BorderColor #000 }\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		first, ok := diagram.Statements[0].(*ast.Skinparam)
+		require.True(t, ok)
+		assert.Equal(t, "ClassBackgroundColor", first.Name)
+		second, ok := diagram.Statements[1].(*ast.Skinparam)
+		require.True(t, ok)
+		assert.Equal(t, "ClassBorderColor", second.Name)
+	})
+	t.Run("SkinparamBlockMultiLine", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nskinparam participant {\n  BackgroundColor red\n  FontColor blue\n}\nclass Foo\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		sp, ok := diagram.Statements[1].(*ast.Skinparam)
+		require.True(t, ok)
+		assert.Equal(t, "ParticipantFontColor", sp.Name)
+		assert.Equal(t, "blue", sp.Value)
+		_, ok = diagram.Statements[2].(*ast.ClassDef)
+		assert.True(t, ok)
+	})
+	t.Run("UnterminatedSkinparamBlock", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nskinparam class {\n BackgroundColor red\n@enduml")
+		require.NotEmpty(t, errs)
+		assert.Contains(t, errs[0].Message, "expected '}'")
+	})
+	t.Run("LeftToRightDirection", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nleft to right direction\nclass Foo\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		d, ok := diagram.Statements[0].(*ast.LayoutDirection)
+		require.True(t, ok)
+		assert.True(t, d.LeftToRight)
+	})
+	t.Run("TopToBottomDirection", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\ntop to bottom direction\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		d, ok := diagram.Statements[0].(*ast.LayoutDirection)
+		require.True(t, ok)
+		assert.False(t, d.LeftToRight)
+	})
+	t.Run("MalformedDirection", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nleft to up direction\n@enduml")
+		require.NotEmpty(t, errs)
+		assert.Contains(t, errs[0].Message, "direction directive")
+	})
+	t.Run("HideEmptyMembersDirective", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nhide empty members\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		hs, ok := diagram.Statements[0].(*ast.HideShow)
+		require.True(t, ok)
+		assert.True(t, hs.IsHide)
+		assert.Equal(t, "empty members", hs.Target)
 	})
 	t.Run("CaseInsensitiveStartUML", func(t *testing.T) {
 		t.Parallel()
@@ -88,6 +193,22 @@ func TestParseErrors(t *testing.T) {
 		_, errs := Parse("@startuml\n$one\n$two\n@enduml")
 		assert.GreaterOrEqual(t, len(errs), 2)
 	})
+	t.Run("MaxErrors", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\ntitle Kept\n" + strings.Repeat("$bad\n", 100) + "title Dropped\n@enduml"
+		diagram, errs := Parse(input, WithMaxErrors(3))
+		require.Len(t, errs, 4)
+		assert.Equal(t, 5, errs[2].Pos.Line)
+		assert.Equal(t, "too many errors", errs[3].Message)
+		assert.Equal(t, "Kept", diagram.Title)
+	})
+	t.Run("MaxErrorsNotReached", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\n$one\n$two\n@enduml"
+		_, want := Parse(input)
+		_, got := Parse(input, WithMaxErrors(10))
+		assert.Equal(t, want, got)
+	})
 	t.Run("ErrorPositions", func(t *testing.T) {
 		t.Parallel()
 		_, errs := Parse("@startuml\n$bad\n@enduml")
@@ -100,6 +221,81 @@ func TestParseErrors(t *testing.T) {
 		require.NotEmpty(t, errs)
 		assert.Contains(t, errs[0].Message, "expected closing }")
 	})
+	t.Run("Sentinels", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\n$bad\ntitle Hello")
+		require.Len(t, errs, 2)
+		assert.True(t, errors.Is(errs[0], ErrSyntax))
+		assert.True(t, errors.Is(errs[0], ErrUnexpectedToken))
+		assert.False(t, errors.Is(errs[0], ErrMissingEndUML))
+		assert.True(t, errors.Is(errs[1], ErrSyntax))
+		assert.True(t, errors.Is(errs[1], ErrMissingEndUML))
+		assert.False(t, errors.Is(errs[1], ErrUnexpectedToken))
+		_, errs = Parse("title Hello\n@enduml")
+		require.NotEmpty(t, errs)
+		assert.True(t, errors.Is(errs[0], ErrSyntax))
+		assert.Equal(t, ErrSyntax, errors.Unwrap(errs[0]))
+	})
+}
+
+func TestParseStrict(t *testing.T) {
+	t.Parallel()
+	t.Run("UnrecognisedLine", func(t *testing.T) {
+		t.Parallel()
+		const input = "@startuml\nfoo bar baz\n@enduml"
+		_, errs := Parse(input)
+		assert.Empty(t, errs, "permissive mode skips the line")
+		_, errs = Parse(input, WithStrict())
+		require.Len(t, errs, 1)
+		assert.Equal(t, lexer.Pos{Line: 2, Column: 1}, errs[0].Pos)
+		assert.Contains(t, errs[0].Message, `unrecognised statement "foo"`)
+	})
+	t.Run("TrailingTokens", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			want  lexer.Pos
+		}{
+			{"@startuml\nactivate Alice Bob\n@enduml", lexer.Pos{Line: 2, Column: 16}},
+			{"@startuml\nstart now\nstop\n@enduml", lexer.Pos{Line: 2, Column: 7}},
+			{"@startuml\nclass Foo extends Bar\n@enduml", lexer.Pos{Line: 2, Column: 11}},
+		}
+		for _, tt := range tests {
+			_, errs := Parse(tt.input)
+			assert.Empty(t, errs, tt.input)
+			_, errs = Parse(tt.input, WithStrict())
+			require.Len(t, errs, 1, tt.input)
+			assert.Equal(t, tt.want, errs[0].Pos, tt.input)
+		}
+	})
+	t.Run("NoDuplicateErrors", func(t *testing.T) {
+		t.Parallel()
+		const input = "@startuml\n$bad tokens here\nclass\n@enduml"
+		_, want := Parse(input)
+		_, got := Parse(input, WithStrict())
+		assert.Equal(t, want, got)
+	})
+	t.Run("ValidInputUnchanged", func(t *testing.T) {
+		t.Parallel()
+		inputs := []string{
+			"@startuml\nclass Foo {\n+name : String\n}\nFoo --> Bar : uses\n@enduml",
+			"@startuml\nparticipant Alice order 10\nAlice -> Bob : hi\nactivate Bob\n@enduml",
+			"@startuml\nlegend right\nKey\nend legend\n@enduml",
+			"@startuml\n[Web] - HTTP\nHTTP - [Web]\n@enduml",
+		}
+		for _, input := range inputs {
+			want, _ := Parse(input)
+			got, errs := Parse(input, WithStrict())
+			assert.Empty(t, errs, input)
+			assert.Equal(t, want, got, input)
+		}
+	})
+	t.Run("New", func(t *testing.T) {
+		t.Parallel()
+		p := New(lexer.New("@startuml\nfoo\n@enduml").Tokenize(), WithStrict())
+		p.parseDiagram()
+		assert.Len(t, p.Errors(), 1)
+	})
 }
 
 func TestSeqModeAutoDetection(t *testing.T) {
@@ -181,6 +377,21 @@ func TestParseNoteShared(t *testing.T) {
 		assert.Equal(t, "Alice", n.Target)
 		assert.Equal(t, "a seq note", n.Text)
 	})
+	t.Run("EscapedNewlines", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant A\nparticipant B\nnote over A : line1\\nline2\nA -> B : one\\ntwo\nreturn done\\nok\nA -> B : C:\\temp \\\\n\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 6)
+		assert.Equal(t, "line1\nline2", diagram.Statements[2].(*ast.Note).Text)
+		assert.Equal(t, "one\ntwo", diagram.Statements[3].(*ast.Message).Label)
+		assert.Equal(t, "done\nok", diagram.Statements[4].(*ast.Return).Label)
+		// Other backslashes are kept, and "\\" is a backslash of its own.
+		assert.Equal(t, `C:\temp \n`, diagram.Statements[5].(*ast.Message).Label)
+		diagram, errs = Parse("@startuml\nclass A\nclass B\nA --> B : has\\nmany\n@enduml")
+		require.Empty(t, errs)
+		assert.Equal(t, "has\nmany", diagram.Statements[2].(*ast.Relationship).Label)
+	})
 	t.Run("NoteOverWithCommaTargets", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nparticipant Alice\nparticipant Bob\nnote over Alice,Bob : shared note\n@enduml"
@@ -215,6 +426,228 @@ func TestParseNoteShared(t *testing.T) {
 		assert.Contains(t, n.Text, "First line")
 		assert.Contains(t, n.Text, "Second line")
 	})
+	t.Run("MultiLineNoteKeepsLineBreaks", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Foo\nnote left of Foo\nLine one\nLine two end note\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		n, ok := diagram.Statements[1].(*ast.Note)
+		require.True(t, ok)
+		assert.Equal(t, "Line one\nLine two", n.Text)
+	})
+	t.Run("EmptyMultiLineNote", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Foo\nnote left of Foo\nend note\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		n, ok := diagram.Statements[1].(*ast.Note)
+		require.True(t, ok)
+		assert.Empty(t, n.Text)
+	})
+	t.Run("UnterminatedMultiLineNote", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Foo\nnote left of Foo\nLine one\n"
+		_, errs := Parse(input)
+		require.NotEmpty(t, errs)
+		assert.Contains(t, errs[0].Message, "end note")
+	})
+}
+
+func TestParseLegend(t *testing.T) {
+	t.Parallel()
+	t.Run("DefaultAlignment", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Foo\nlegend\nFoo is a class\nBar is not\nend legend\n@enduml"
+		diagram, errs := Parse(input)
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		l, ok := diagram.Statements[1].(*ast.Legend)
+		require.True(t, ok)
+		assert.Equal(t, ast.LegendRight, l.Alignment)
+		assert.Equal(t, "Foo is a class\nBar is not", l.Text)
+	})
+	t.Run("Alignment", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			name string
+			want ast.LegendAlignment
+		}{
+			{"left", ast.LegendLeft},
+			{"right", ast.LegendRight},
+			{"center", ast.LegendCenter},
+		}
+		for _, tt := range tests {
+			diagram, errs := Parse("@startuml\nlegend " + tt.name + "\nkey\nend legend\n@enduml")
+			require.Empty(t, errs, tt.name)
+			require.Len(t, diagram.Statements, 1)
+			l, ok := diagram.Statements[0].(*ast.Legend)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, l.Alignment, tt.name)
+		}
+	})
+	t.Run("Unterminated", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\nlegend\nkey\n")
+		require.NotEmpty(t, errs)
+		assert.Contains(t, errs[0].Message, "end legend")
+	})
+}
+
+// TestParseKeywordsAsNames checks that keywords which only open a statement
+// can still be used as the names of classes, members and participants.
+func TestParseKeywordsAsNames(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		kind  ast.DiagramKind
+		check func(t *testing.T, stmt ast.Statement)
+	}{
+		{"MethodStart", "class Foo {\n+start() : void\n}", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			require.Len(t, cd.Members, 1)
+			m, ok := cd.Members[0].(*ast.Method)
+			require.True(t, ok)
+			assert.Equal(t, "start", m.Name)
+			assert.Equal(t, "void", m.ReturnType)
+		}},
+		{"ClassStop", "class stop", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			assert.Equal(t, "stop", cd.Name)
+		}},
+		{"LinkToStart", "Item --> start", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			rel, ok := stmt.(*ast.Relationship)
+			require.True(t, ok)
+			assert.Equal(t, "Item", rel.Left)
+			assert.Equal(t, "start", rel.Right)
+		}},
+		{"LinkFromIf", "if --> endif", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			rel, ok := stmt.(*ast.Relationship)
+			require.True(t, ok)
+			assert.Equal(t, "if", rel.Left)
+			assert.Equal(t, "endif", rel.Right)
+		}},
+		{"MessageToCreate", "Alice -> create : new", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			msg, ok := stmt.(*ast.Message)
+			require.True(t, ok)
+			assert.Equal(t, "create", msg.To)
+			assert.Equal(t, "new", msg.Label)
+		}},
+		{"MessageFromDestroy", "destroy -> Alice", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			msg, ok := stmt.(*ast.Message)
+			require.True(t, ok)
+			assert.Equal(t, "destroy", msg.From)
+			assert.Equal(t, "Alice", msg.To)
+		}},
+		{"ParticipantCreate", "participant create", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			p, ok := stmt.(*ast.Participant)
+			require.True(t, ok)
+			assert.Equal(t, "create", p.Name)
+		}},
+		{"FieldOrder", "class Item {\norder : int\n}", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			require.Len(t, cd.Members, 1)
+			f, ok := cd.Members[0].(*ast.Field)
+			require.True(t, ok)
+			assert.Equal(t, "order", f.Name)
+			assert.Equal(t, "int", f.Type)
+		}},
+		{"ParticipantOrder", "participant order order 2", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			p, ok := stmt.(*ast.Participant)
+			require.True(t, ok)
+			assert.Equal(t, "order", p.Name)
+			assert.Equal(t, 2, p.Order)
+		}},
+		{"LinkFromObject", "object --> Item", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			rel, ok := stmt.(*ast.Relationship)
+			require.True(t, ok)
+			assert.Equal(t, "object", rel.Left)
+			assert.Equal(t, "Item", rel.Right)
+		}},
+		{"ClassObject", "class object", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			assert.Equal(t, "object", cd.Name)
+		}},
+		{"MessageToOpt", "Alice -> opt : maybe", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			msg, ok := stmt.(*ast.Message)
+			require.True(t, ok)
+			assert.Equal(t, "opt", msg.To)
+		}},
+		{"MessageFromCritical", "critical -> consider", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			msg, ok := stmt.(*ast.Message)
+			require.True(t, ok)
+			assert.Equal(t, "critical", msg.From)
+			assert.Equal(t, "consider", msg.To)
+		}},
+		{"ParticipantIgnore", "participant ignore", ast.DiagramKindSequence, func(t *testing.T, stmt ast.Statement) {
+			p, ok := stmt.(*ast.Participant)
+			require.True(t, ok)
+			assert.Equal(t, "ignore", p.Name)
+		}},
+		{"LinkToComponent", "component --> component", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			rel, ok := stmt.(*ast.Relationship)
+			require.True(t, ok)
+			assert.Equal(t, "component", rel.Left)
+			assert.Equal(t, "component", rel.Right)
+		}},
+		{"ClassComponent", "class component", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			assert.Equal(t, "component", cd.Name)
+		}},
+		{"LinkToUsecase", "User --> usecase", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			rel, ok := stmt.(*ast.Relationship)
+			require.True(t, ok)
+			assert.Equal(t, "User", rel.Left)
+			assert.Equal(t, "usecase", rel.Right)
+		}},
+		{"ClassUsecase", "class usecase", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			assert.Equal(t, "usecase", cd.Name)
+		}},
+		{"LinkFromLegend", "legend --> Item", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			rel, ok := stmt.(*ast.Relationship)
+			require.True(t, ok)
+			assert.Equal(t, "legend", rel.Left)
+			assert.Equal(t, "Item", rel.Right)
+		}},
+		{"ClassLegend", "class legend", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			assert.Equal(t, "legend", cd.Name)
+		}},
+		{"FieldAnnotation", "class Item {\n-annotation : String\n}", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			require.Len(t, cd.Members, 1)
+			f, ok := cd.Members[0].(*ast.Field)
+			require.True(t, ok)
+			assert.Equal(t, "annotation", f.Name)
+			assert.Equal(t, "String", f.Type)
+		}},
+		{"ClassAnnotation", "class annotation", ast.DiagramKindClass, func(t *testing.T, stmt ast.Statement) {
+			cd, ok := stmt.(*ast.ClassDef)
+			require.True(t, ok)
+			assert.Equal(t, "annotation", cd.Name)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			diagram, errs := Parse("@startuml\n" + tt.input + "\n@enduml")
+			require.Empty(t, errs)
+			require.Len(t, diagram.Statements, 1)
+			tt.check(t, diagram.Statements[0])
+			assert.Equal(t, tt.kind, diagram.DiagramType())
+		})
+	}
 }
 
 func TestNew(t *testing.T) {
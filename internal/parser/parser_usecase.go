@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// parseUsecase parses "usecase Name [as Alias]", where Name may also be
+// quoted or written in parentheses.
+func (p *Parser) parseUsecase() ast.Statement {
+	tok := p.advance() // consume 'usecase'
+	name := ""
+	switch tok := p.current(); {
+	case tok.Type == lexer.TokenLParen:
+		var ok bool
+		if name, ok = p.readUsecaseName(); !ok {
+			return nil
+		}
+	case isName(tok), tok.Type == lexer.TokenString:
+		name = stripQuotes(p.advance().Literal)
+	default:
+		p.addError(p.current().Pos, "expected usecase name")
+		p.skipToNextLine()
+		return nil
+	}
+	u := &ast.Usecase{Pos: tok.Pos, Name: name, Alias: p.readComponentAlias()}
+	p.skipToNextLine()
+	return u
+}
+
+// parseUsecaseRef parses a statement starting with (Name): either a
+// declaration with an optional alias or a relationship such as (Login) <-- User.
+func (p *Parser) parseUsecaseRef() ast.Statement {
+	pos := p.current().Pos
+	name, ok := p.readUsecaseName()
+	if !ok {
+		return nil
+	}
+	if p.current().Type == lexer.TokenArrow || p.current().Type == lexer.TokenMinus {
+		p.pending = append(p.pending, &ast.Usecase{Pos: pos, Name: name})
+		return p.parseRelationship(pos, name, "")
+	}
+	u := &ast.Usecase{Pos: pos, Name: name, Alias: p.readComponentAlias()}
+	p.skipToNextLine()
+	return u
+}
+
+// readUsecaseName reads a parenthesized usecase name such as (Use Case),
+// keeping the spacing of the source. On a malformed name it records an error,
+// skips the line and returns false.
+func (p *Parser) readUsecaseName() (string, bool) {
+	open := p.advance() // consume '('
+	var b strings.Builder
+	prev := open
+	for {
+		tok := p.current()
+		switch tok.Type {
+		case lexer.TokenRParen:
+			p.advance()
+			if b.Len() == 0 {
+				p.addError(open.Pos, "expected usecase name in parentheses")
+				p.skipToNextLine()
+				return "", false
+			}
+			return b.String(), true
+		case lexer.TokenNewline, lexer.TokenEOF:
+			p.addError(tok.Pos, "expected ')' after usecase name")
+			p.skipToNextLine()
+			return "", false
+		}
+		if b.Len() > 0 && !adjacent(prev, tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(stripQuotes(tok.Literal))
+		prev = p.advance()
+	}
+}
+
+// parenHasComma reports whether the parenthesized group starting at the
+// current '(' contains a comma, as in the association class form (A, B).
+func (p *Parser) parenHasComma() bool {
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		switch p.tokens[i].Type {
+		case lexer.TokenComma:
+			return true
+		case lexer.TokenRParen, lexer.TokenNewline, lexer.TokenEOF:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUsecase(t *testing.T) {
+	t.Parallel()
+	t.Run("ParenDeclaration", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n(Use Case) as UC1\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		u, ok := diagram.Statements[0].(*ast.Usecase)
+		require.True(t, ok)
+		assert.Equal(t, "Use Case", u.Name)
+		assert.Equal(t, "UC1", u.Alias)
+	})
+	t.Run("KeywordDeclaration", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nusecase UC\nusecase (Check Out) as CO\nusecase \"Pay Bill\"\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		names := make([]string, 0, 3)
+		for _, stmt := range diagram.Statements {
+			u, ok := stmt.(*ast.Usecase)
+			require.True(t, ok)
+			names = append(names, u.Name)
+		}
+		assert.Equal(t, []string{"UC", "Check Out", "Pay Bill"}, names)
+		assert.Equal(t, "CO", diagram.Statements[1].(*ast.Usecase).Alias)
+	})
+	t.Run("ActorToUsecase", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nactor User\nUser --> (Log In) : starts\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 3)
+		p, ok := diagram.Statements[0].(*ast.Participant)
+		require.True(t, ok)
+		assert.Equal(t, ast.ParticipantActor, p.Kind)
+		rel, ok := diagram.Statements[1].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "User", rel.Left)
+		assert.Equal(t, "Log In", rel.Right)
+		assert.Equal(t, "starts", rel.Label)
+		u, ok := diagram.Statements[2].(*ast.Usecase)
+		require.True(t, ok)
+		assert.Equal(t, "Log In", u.Name)
+	})
+	t.Run("UndeclaredActorSingleDash", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\nGuest -> (Browse)\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		rel, ok := diagram.Statements[0].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "Guest", rel.Left)
+		assert.Equal(t, "Browse", rel.Right)
+	})
+	t.Run("UsecaseFirst", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n(Report) <.. Admin\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 2)
+		rel, ok := diagram.Statements[0].(*ast.Relationship)
+		require.True(t, ok)
+		assert.Equal(t, "Report", rel.Left)
+		assert.Equal(t, "Admin", rel.Right)
+		assert.Equal(t, ast.ArrowLeft, rel.Direction)
+		_, ok = diagram.Statements[1].(*ast.Usecase)
+		assert.True(t, ok)
+	})
+	t.Run("AssociationClassStillParsed", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := Parse("@startuml\n(Student, Course) .. Enrollment\n@enduml")
+		require.Empty(t, errs)
+		require.Len(t, diagram.Statements, 1)
+		_, ok := diagram.Statements[0].(*ast.AssociationClass)
+		assert.True(t, ok)
+	})
+	t.Run("Unclosed", func(t *testing.T) {
+		t.Parallel()
+		_, errs := Parse("@startuml\n(Log In\n@enduml")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "expected ')'")
+	})
+}
@@ -0,0 +1,377 @@
+// Package preprocess expands PlantUML preprocessor directives ahead of
+// lexing, so that the parser sees one flattened source:
+//
+//   - "!include file" is replaced by the contents of the file. Included
+//     files may be complete diagrams: their @startuml and @enduml lines are
+//     dropped.
+//   - "!define NAME value" makes later occurrences of the word NAME read
+//     value, until "!undef NAME".
+//   - "!$name = value" sets a variable that later lines reference as
+//     $name; "?=" only sets it if it is unset. Quoted values lose their
+//     quotes. References to unset variables are left as they are.
+//
+// Substituted text is scanned again, so definitions may refer to each
+// other, but a name is not replaced again inside its own replacement.
+// Directive lines become empty lines. Line numbers in the flattened source
+// count the included lines; the LineMap returned with it gives the file and
+// line each of them was read from.
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// DefaultMaxDepth is how deeply includes and definitions may nest before
+// the Preprocessor returned by New gives up.
+const DefaultMaxDepth = 16
+
+// maxLineLength bounds the length of a line after substitution, so that
+// definitions which each repeat the next one cannot exhaust memory.
+const maxLineLength = 1 << 16
+
+// ErrPreprocess is matched by every Error with errors.Is.
+var ErrPreprocess = errors.New("preprocessor error")
+
+// Error is a problem with the directive at Pos in File, such as a missing
+// include file or an include cycle.
+type Error struct {
+	File    string
+	Pos     lexer.Pos
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%s: %s", e.File, e.Pos, e.Message)
+}
+
+// Unwrap returns ErrPreprocess.
+func (e *Error) Unwrap() error {
+	return ErrPreprocess
+}
+
+// Origin is the file and 1-based line that a line of expanded source was
+// read from.
+type Origin struct {
+	File string
+	Line int
+}
+
+// LineMap holds the Origin of each line of an expanded source, the first
+// line at index 0.
+type LineMap []Origin
+
+// Locate returns the file and line that line of the expanded source was read
+// from. A line outside the map is returned as it is, with no file.
+func (m LineMap) Locate(line int) (string, int) {
+	if line < 1 || line > len(m) {
+		return "", line
+	}
+	return m[line-1].File, m[line-1].Line
+}
+
+// Preprocessor expands !include, !define and !$ variable directives.
+type Preprocessor struct {
+	// MaxDepth bounds how deeply includes may nest, a file included from
+	// the top-level source being at depth 1, and how deeply definitions
+	// may be nested in each other's replacements.
+	MaxDepth int
+	// ReadFile reads an included file.
+	ReadFile func(name string) ([]byte, error)
+}
+
+// New returns a Preprocessor that reads files from disk and allows
+// DefaultMaxDepth levels of includes and substitutions.
+func New() *Preprocessor {
+	return &Preprocessor{MaxDepth: DefaultMaxDepth, ReadFile: os.ReadFile}
+}
+
+// File reads the file at path and returns its source with directives
+// expanded, and where each of its lines came from. The error reports a
+// failure to read path itself; problems with its directives are returned as
+// Errors, each failed directive being left out of the source.
+func (p *Preprocessor) File(path string) (string, LineMap, []*Error, error) {
+	data, err := p.ReadFile(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	src, lines, errs := p.Source(string(data), path)
+	return src, lines, errs, nil
+}
+
+// Source returns src, which was read from path, with its directives
+// expanded, and where each of its lines came from. Relative include paths
+// are resolved against the directory of path.
+func (p *Preprocessor) Source(src, path string) (string, LineMap, []*Error) {
+	x := &expansion{p: p, defines: map[string]string{}, vars: map[string]string{}}
+	x.expand(src, path, []string{absPath(path)}, false)
+	return x.sb.String(), x.lines, x.errs
+}
+
+// expansion is the state of one Source call. Definitions made in an
+// included file hold for the rest of the including file.
+type expansion struct {
+	p       *Preprocessor
+	sb      strings.Builder
+	lines   LineMap
+	errs    []*Error
+	defines map[string]string
+	vars    map[string]string
+}
+
+// expand writes src to x.sb with its directives replaced, recording the
+// origin of each line written in x.lines. stack holds the absolute paths of
+// the files being expanded, outermost first, for cycle detection. The
+// @startuml and @enduml lines of an included file are dropped, since they
+// would otherwise end the including diagram early.
+func (x *expansion) expand(src, path string, stack []string, included bool) {
+	written := false
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if included && isDiagramMarker(trimmed) {
+			continue
+		}
+		if written {
+			x.sb.WriteByte('\n')
+		}
+		written = true
+		// An include records the lines of the included file; every other
+		// line, including a failed include, is one line of path.
+		before := len(x.lines)
+		x.expandLine(line, trimmed, i+1, path, stack)
+		if len(x.lines) == before {
+			x.lines = append(x.lines, Origin{File: path, Line: i + 1})
+		}
+	}
+}
+
+// expandLine writes line, line number n of path, with its directive
+// replaced. trimmed is line without its leading blanks.
+func (x *expansion) expandLine(line, trimmed string, n int, path string, stack []string) {
+	fail := func(format string, args ...any) {
+		pos := lexer.Pos{Line: n, Column: len(line) - len(trimmed) + 1}
+		x.errs = append(x.errs, &Error{File: path, Pos: pos, Message: fmt.Sprintf(format, args...)})
+	}
+	if args, ok := directive(trimmed, "!define"); ok {
+		name, body, _ := strings.Cut(args, " ")
+		x.define(name, strings.TrimSpace(body), fail)
+		return
+	}
+	if args, ok := directive(trimmed, "!undef"); ok {
+		delete(x.defines, args)
+		return
+	}
+	if rest, ok := strings.CutPrefix(trimmed, "!$"); ok {
+		x.assign(rest, fail)
+		return
+	}
+	expanded, err := x.substitute(line)
+	if err != "" {
+		fail("%s", err)
+	}
+	target, ok := directive(strings.TrimLeft(expanded, " \t"), "!include")
+	if !ok {
+		x.sb.WriteString(expanded)
+		return
+	}
+	if len(target) >= 2 && target[0] == '"' && target[len(target)-1] == '"' {
+		target = target[1 : len(target)-1]
+	}
+	x.include(target, path, stack, fail)
+}
+
+// directive reports whether line starts with the directive keyword and
+// returns the rest of the line, trimmed, with tabs turned into spaces.
+// Longer directives that start with keyword, such as !include_many for
+// !include, are not matched.
+func directive(line, keyword string) (string, bool) {
+	rest, found := strings.CutPrefix(line, keyword)
+	if !found || (rest != "" && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\r') {
+		return "", false
+	}
+	return strings.TrimSpace(strings.ReplaceAll(rest, "\t", " ")), true
+}
+
+// define records a !define of name as body.
+func (x *expansion) define(name, body string, fail func(string, ...any)) {
+	switch {
+	case name == "":
+		fail("!define needs a name")
+	case strings.Contains(name, "("):
+		fail("!define %s: macros with parameters are not supported", name[:strings.Index(name, "(")])
+	case !isIdent(name):
+		fail("!define: %q is not a valid name", name)
+	default:
+		x.defines[name] = body
+	}
+}
+
+// assign records a "$name = value" or "$name ?= value" variable
+// assignment, with the "!$" already removed.
+func (x *expansion) assign(rest string, fail func(string, ...any)) {
+	end := identEnd(rest, 0)
+	name := rest[:end]
+	if name == "" || !isIdentStart(name[0]) {
+		fail("!$ needs a variable name")
+		return
+	}
+	op := strings.TrimSpace(rest[end:])
+	value, ok := strings.CutPrefix(op, "=")
+	ifUnset := false
+	if !ok {
+		if value, ok = strings.CutPrefix(op, "?="); !ok {
+			fail("expected '=' after $%s", name)
+			return
+		}
+		ifUnset = true
+	}
+	if _, set := x.vars[name]; ifUnset && set {
+		return
+	}
+	value, err := x.substitute(strings.TrimSpace(value))
+	if err != "" {
+		fail("%s", err)
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	x.vars[name] = value
+}
+
+// substitute replaces the defined names and set variables in line. As in
+// the C preprocessor, a replacement is scanned again for other names but not
+// for the name being replaced, so a definition that refers to itself is only
+// expanded once. It returns a message instead of finishing if definitions
+// nest more than MaxDepth deep or the line grows past maxLineLength bytes.
+func (x *expansion) substitute(line string) (string, string) {
+	if len(x.defines) == 0 && len(x.vars) == 0 {
+		return line, ""
+	}
+	var sb strings.Builder
+	if err := x.substituteInto(&sb, line, nil); err != "" {
+		return line, err
+	}
+	return sb.String(), ""
+}
+
+// substituteInto writes text to sb with its names replaced. active holds the
+// names whose replacements are being scanned, outermost first; they are
+// written as they are.
+func (x *expansion) substituteInto(sb *strings.Builder, text string, active []string) string {
+	if len(active) > x.p.MaxDepth {
+		return fmt.Sprintf("%s is still being substituted after %d nested definitions", active[0], x.p.MaxDepth)
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		end := i + 1
+		value, ok := "", false
+		switch {
+		case c == '$' && i+1 < len(text) && isIdentStart(text[i+1]):
+			end = identEnd(text, i+1)
+			value, ok = x.vars[text[i+1:end]]
+		case isIdentStart(c) && (i == 0 || !isIdentPart(text[i-1])):
+			end = identEnd(text, i)
+			value, ok = x.defines[text[i:end]]
+		}
+		name := text[i:end]
+		if !ok || slices.Contains(active, name) {
+			sb.WriteString(name)
+		} else if err := x.substituteInto(sb, value, append(active, name)); err != "" {
+			return err
+		}
+		if sb.Len() > maxLineLength {
+			return fmt.Sprintf("line is longer than %d bytes after substitution", maxLineLength)
+		}
+		i = end
+	}
+	return ""
+}
+
+// include writes the expanded contents of the file target names, resolved
+// against the directory of path.
+func (x *expansion) include(target, path string, stack []string, fail func(string, ...any)) {
+	switch {
+	case target == "":
+		fail("!include needs a file name")
+		return
+	case strings.HasPrefix(target, "<"):
+		fail("cannot include %s: the standard library is not supported", target)
+		return
+	case len(stack) > x.p.MaxDepth:
+		fail("cannot include %q: includes are nested more than %d deep", target, x.p.MaxDepth)
+		return
+	}
+	name := target
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(path), name)
+	}
+	abs := absPath(name)
+	if idx := slices.Index(stack, abs); idx >= 0 {
+		fail("include cycle: %s", cycle(stack[idx:], abs))
+		return
+	}
+	data, err := x.p.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		fail("included file %q not found", target)
+		return
+	}
+	if err != nil {
+		fail("reading included file %q: %s", target, err)
+		return
+	}
+	x.expand(string(data), name, slices.Concat(stack, []string{abs}), true)
+}
+
+// isDiagramMarker reports whether line, without its leading blanks, is a
+// @startuml or @enduml line.
+func isDiagramMarker(line string) bool {
+	return strings.HasPrefix(line, "@startuml") || strings.HasPrefix(line, "@enduml")
+}
+
+// absPath returns the absolute form of path, or path cleaned if the working
+// directory is unknown.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// cycle formats the files of an include cycle by base name, such as
+// "a.puml -> b.puml -> a.puml".
+func cycle(files []string, back string) string {
+	names := make([]string, 0, len(files)+1)
+	for _, f := range slices.Concat(files, []string{back}) {
+		names = append(names, filepath.Base(f))
+	}
+	return strings.Join(names, " -> ")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9'
+}
+
+func isIdent(s string) bool {
+	return s != "" && isIdentStart(s[0]) && identEnd(s, 0) == len(s)
+}
+
+// identEnd returns the index just past the identifier characters of s
+// starting at i.
+func identEnd(s string, i int) int {
+	for i < len(s) && isIdentPart(s[i]) {
+		i++
+	}
+	return i
+}
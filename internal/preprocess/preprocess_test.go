@@ -0,0 +1,271 @@
+package preprocess_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/bobcob7/go-uml/internal/preprocess"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes files, keyed by slash-separated path, under a new
+// temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestPreprocessor(t *testing.T) {
+	t.Parallel()
+	t.Run("NoIncludes", func(t *testing.T) {
+		t.Parallel()
+		const src = "@startuml\nclass Foo\n@enduml\n"
+		got, _, errs := preprocess.New().Source(src, "diagram.puml")
+		assert.Empty(t, errs)
+		assert.Equal(t, src, got)
+	})
+	t.Run("IncludeRelativeToFile", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"main.puml":         "@startuml\n!include common/style.puml\nclass Foo\n@enduml",
+			"common/style.puml": "@startuml\n!include \"types.puml\"\nskinparam shadowing true\n@enduml",
+			"common/types.puml": "class Bar",
+		})
+		got, _, errs, err := preprocess.New().File(filepath.Join(dir, "main.puml"))
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		// The included files lose their own @startuml and @enduml.
+		assert.Equal(t, "@startuml\nclass Bar\nskinparam shadowing true\nclass Foo\n@enduml", got)
+	})
+	t.Run("LineMap", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"main.puml": "@startuml\n!define X Y\n!include inc.puml\nclass Foo\n@enduml",
+			"inc.puml":  "@startuml\nclass A\nclass B\n@enduml",
+		})
+		main, inc := filepath.Join(dir, "main.puml"), filepath.Join(dir, "inc.puml")
+		got, lines, errs, err := preprocess.New().File(main)
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "@startuml\n\nclass A\nclass B\nclass Foo\n@enduml", got)
+		assert.Equal(t, preprocess.LineMap{
+			{File: main, Line: 1},
+			{File: main, Line: 2},
+			{File: inc, Line: 2},
+			{File: inc, Line: 3},
+			{File: main, Line: 4},
+			{File: main, Line: 5},
+		}, lines)
+		file, line := lines.Locate(4)
+		assert.Equal(t, inc, file)
+		assert.Equal(t, 3, line)
+		file, line = lines.Locate(7)
+		assert.Empty(t, file)
+		assert.Equal(t, 7, line)
+	})
+	t.Run("IndentedDirective", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{"a.puml": "  !include b.puml\r\n", "b.puml": "class B"})
+		got, _, errs, err := preprocess.New().File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "class B\n", got)
+	})
+	t.Run("OtherDirectivesUntouched", func(t *testing.T) {
+		t.Parallel()
+		const src = "!include_many b.puml\n!includeurl http://example.com/x.puml"
+		got, _, errs := preprocess.New().Source(src, "a.puml")
+		assert.Empty(t, errs)
+		assert.Equal(t, src, got)
+	})
+	t.Run("MissingFile", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{"a.puml": "@startuml\nclass A\n  !include missing.puml\n@enduml"})
+		path := filepath.Join(dir, "a.puml")
+		got, _, errs, err := preprocess.New().File(path)
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, path, errs[0].File)
+		assert.Equal(t, lexer.Pos{Line: 3, Column: 3}, errs[0].Pos)
+		assert.Equal(t, `included file "missing.puml" not found`, errs[0].Message)
+		assert.ErrorIs(t, errs[0], preprocess.ErrPreprocess)
+		// The failed directive becomes an empty line.
+		assert.Equal(t, "@startuml\nclass A\n\n@enduml", got)
+	})
+	t.Run("ErrorInNestedFile", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{"a.puml": "!include b.puml", "b.puml": "class B\n!include c.puml"})
+		_, _, errs, err := preprocess.New().File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, filepath.Join(dir, "b.puml"), errs[0].File)
+		assert.Equal(t, lexer.Pos{Line: 2, Column: 1}, errs[0].Pos)
+	})
+	t.Run("Cycle", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"a.puml": "class A\n!include b.puml",
+			"b.puml": "class B\n!include a.puml",
+		})
+		got, _, errs, err := preprocess.New().File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "include cycle: a.puml -> b.puml -> a.puml", errs[0].Message)
+		assert.Equal(t, "class A\nclass B\n", got)
+	})
+	t.Run("SelfInclude", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{"a.puml": "!include ./a.puml"})
+		_, _, errs, err := preprocess.New().File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "include cycle: a.puml -> a.puml", errs[0].Message)
+	})
+	t.Run("RepeatedIncludeIsNotACycle", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{"a.puml": "!include b.puml\n!include b.puml", "b.puml": "class B"})
+		got, _, errs, err := preprocess.New().File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "class B\nclass B", got)
+	})
+	t.Run("MaxDepth", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"a.puml": "!include b.puml",
+			"b.puml": "!include c.puml",
+			"c.puml": "!include d.puml",
+			"d.puml": "class D",
+		})
+		p := preprocess.New()
+		p.MaxDepth = 2
+		_, _, errs, err := p.File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, filepath.Join(dir, "c.puml"), errs[0].File)
+		assert.Equal(t, `cannot include "d.puml": includes are nested more than 2 deep`, errs[0].Message)
+		p.MaxDepth = 3
+		got, _, errs, err := p.File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "class D", got)
+	})
+	t.Run("StandardLibrary", func(t *testing.T) {
+		t.Parallel()
+		_, _, errs := preprocess.New().Source("!include <C4/C4_Container>", "a.puml")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "standard library")
+	})
+	t.Run("Define", func(t *testing.T) {
+		t.Parallel()
+		const src = "!define BASE AbstractBase\nclass Foo\nFoo --|> BASE : BASE_NAME\n!undef BASE\nBASE"
+		got, _, errs := preprocess.New().Source(src, "a.puml")
+		assert.Empty(t, errs)
+		// Only whole words are replaced, and directive lines stay as empty lines.
+		assert.Equal(t, "\nclass Foo\nFoo --|> AbstractBase : BASE_NAME\n\nBASE", got)
+	})
+	t.Run("Variables", func(t *testing.T) {
+		t.Parallel()
+		const src = "!$name = \"Alice\"\n!$name ?= \"Bob\"\n!$count = 3\n!$other ?= 'Carol'\n$name -> $other : $count items, $unset"
+		got, _, errs := preprocess.New().Source(src, "a.puml")
+		assert.Empty(t, errs)
+		// Undefined variables are left for the parser.
+		assert.Equal(t, "\n\n\n\nAlice -> Carol : 3 items, $unset", got)
+	})
+	t.Run("NestedDefinitions", func(t *testing.T) {
+		t.Parallel()
+		const src = "!$first = \"Ada\"\n!$full = \"$first Lovelace\"\n!define GREETING Hello $first\n!define SHOUT GREETING!\nnote \"SHOUT $full\" as N"
+		got, _, errs := preprocess.New().Source(src, "a.puml")
+		assert.Empty(t, errs)
+		assert.Equal(t, "\n\n\n\nnote \"Hello Ada! Ada Lovelace\" as N", got)
+	})
+	t.Run("DefinitionsFromInclude", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"a.puml":        "!$lib = \"lib\"\n!include $lib/defs.puml\nclass ENTITY",
+			"lib/defs.puml": "!define ENTITY Customer",
+		})
+		got, _, errs, err := preprocess.New().File(filepath.Join(dir, "a.puml"))
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, "\n\nclass Customer", got)
+	})
+	t.Run("RecursiveDefinition", func(t *testing.T) {
+		t.Parallel()
+		// A name is not replaced again inside its own replacement.
+		const src = "!define A B\n!define B A x\nclass A"
+		got, _, errs := preprocess.New().Source(src, "a.puml")
+		assert.Empty(t, errs)
+		assert.Equal(t, "\n\nclass A x", got)
+	})
+	t.Run("SelfReferentialDefinition", func(t *testing.T) {
+		t.Parallel()
+		const src = "!define A A A A A A A A A A\nclass A"
+		got, _, errs := preprocess.New().Source(src, "a.puml")
+		assert.Empty(t, errs)
+		assert.Equal(t, "\nclass A A A A A A A A A", got)
+	})
+	t.Run("NestedTooDeep", func(t *testing.T) {
+		t.Parallel()
+		var src strings.Builder
+		for i := range 20 {
+			fmt.Fprintf(&src, "!define D%d D%d\n", i, i+1)
+		}
+		src.WriteString("class D0")
+		got, _, errs := preprocess.New().Source(src.String(), "a.puml")
+		require.Len(t, errs, 1)
+		assert.Equal(t, lexer.Pos{Line: 21, Column: 1}, errs[0].Pos)
+		assert.Contains(t, errs[0].Message, "after 16 nested definitions")
+		// The line is kept as it was written.
+		assert.True(t, strings.HasSuffix(got, "\nclass D0"))
+	})
+	t.Run("LineTooLong", func(t *testing.T) {
+		t.Parallel()
+		// Each name doubles the next, so D0 would expand to 2^20 words.
+		var src strings.Builder
+		for i := range 20 {
+			fmt.Fprintf(&src, "!define D%d D%d D%d\n", i, i+1, i+1)
+		}
+		src.WriteString("class D0")
+		p := preprocess.New()
+		p.MaxDepth = 32
+		got, _, errs := p.Source(src.String(), "a.puml")
+		require.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, "bytes after substitution")
+		assert.True(t, strings.HasSuffix(got, "\nclass D0"))
+	})
+	t.Run("MalformedDirectives", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			src  string
+			want string
+		}{
+			{"!define", "!define needs a name"},
+			{"!define F(x) x + 1", "!define F: macros with parameters are not supported"},
+			{"!define 9lives cat", `!define: "9lives" is not a valid name`},
+			{"!$ = 1", "!$ needs a variable name"},
+			{"!$x 1", "expected '=' after $x"},
+		}
+		for _, tt := range tests {
+			_, _, errs := preprocess.New().Source(tt.src, "a.puml")
+			require.Len(t, errs, 1, tt.src)
+			assert.Equal(t, tt.want, errs[0].Message, tt.src)
+		}
+	})
+	t.Run("MissingTopLevelFile", func(t *testing.T) {
+		t.Parallel()
+		_, _, _, err := preprocess.New().File(filepath.Join(t.TempDir(), "none.puml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
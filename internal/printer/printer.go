@@ -0,0 +1,604 @@
+// Package printer writes a diagram AST back out as canonical PlantUML text.
+//
+// The output is indented by two spaces inside bodies and blocks, the members
+// of classes and interfaces are sorted, relationship arrows are normalised
+// and comments are dropped. Printing the parse of the output gives the same
+// text again.
+package printer
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+)
+
+// indent is the text written per level of nesting.
+const indent = "  "
+
+// printer accumulates the canonical text of a diagram.
+type printer struct {
+	b     strings.Builder
+	depth int
+	// components holds the names of the declared components, which are
+	// written in brackets where relationships refer to them.
+	components map[string]bool
+}
+
+// Fprint writes d to w as canonical PlantUML text, from @startuml to
+// @enduml.
+func Fprint(w io.Writer, d *ast.Diagram) error {
+	p := &printer{components: map[string]bool{}}
+	ast.Inspect(d, func(n ast.Node) bool {
+		if c, ok := n.(*ast.Component); ok && !c.IsInterface {
+			p.components[c.Name] = true
+		}
+		return true
+	})
+	p.line("@startuml", optional(" ", d.Name))
+	p.statements(d.Statements)
+	p.line("@enduml")
+	_, err := io.WriteString(w, p.b.String())
+	return err
+}
+
+// line writes one line at the current depth, joining parts.
+func (p *printer) line(parts ...string) {
+	for range p.depth {
+		p.b.WriteString(indent)
+	}
+	for _, s := range parts {
+		p.b.WriteString(s)
+	}
+	p.b.WriteByte('\n')
+}
+
+// block writes the lines of text one level deeper than the current depth.
+func (p *printer) block(text string) {
+	p.depth++
+	for l := range strings.SplitSeq(text, "\n") {
+		p.line(strings.TrimSpace(l))
+	}
+	p.depth--
+}
+
+// nested writes stmts one level deeper than the current depth.
+func (p *printer) nested(stmts []ast.Statement) {
+	p.depth++
+	p.statements(stmts)
+	p.depth--
+}
+
+func (p *printer) statements(stmts []ast.Statement) {
+	for i := 0; i < len(stmts); i++ {
+		i += p.statement(stmts[i], stmts[i+1:])
+	}
+}
+
+// statement writes stmt and returns how many of the statements after it,
+// rest, it has written too. The parser adds statements implied by shorthand
+// such as "User --> (Login)" after the one written; the shorthand is kept.
+func (p *printer) statement(stmt ast.Statement, rest []ast.Statement) int {
+	switch s := stmt.(type) {
+	case *ast.Comment:
+		// A line the parser did not recognise is kept as a comment holding
+		// its first name, which declares that name.
+		if !strings.HasPrefix(s.Text, "'") && !strings.HasPrefix(s.Text, "/'") {
+			p.line(s.Text)
+		}
+	case *ast.Title:
+		p.line("title ", s.Text)
+	case *ast.Header:
+		p.line("header ", s.Text)
+	case *ast.Footer:
+		p.line("footer ", s.Text)
+	case *ast.Skinparam:
+		p.line("skinparam ", s.Name, optional(" ", s.Value))
+	case *ast.HideShow:
+		keyword := "show"
+		if s.IsHide {
+			keyword = "hide"
+		}
+		p.line(keyword, optional(" ", s.Target))
+	case *ast.LayoutDirection:
+		if s.LeftToRight {
+			p.line("left to right direction")
+		} else {
+			p.line("top to bottom direction")
+		}
+	case *ast.Note:
+		p.note(s)
+	case *ast.Legend:
+		p.line("legend ", legendAlignments[s.Alignment])
+		p.block(s.Text)
+		p.line("end legend")
+	case *ast.ClassDef:
+		keyword := "class"
+		if s.Abstract {
+			keyword = "abstract class"
+		}
+		p.line(keyword, " ", className(s.Name), spotStereotype(s.SpotLetter, s.SpotColor, s.Stereotype), stereotypes(s.ExtraStereotypes),
+			optional(" ", s.BackgroundColor), optional(" as ", s.Alias), open(s.Members))
+		p.members(sortMembers(s.Members))
+	case *ast.InterfaceDef:
+		p.line("interface ", className(s.Name), stereotype(s.Stereotype), optional(" as ", s.Alias), open(s.Members))
+		p.members(sortMembers(s.Members))
+	case *ast.AnnotationDef:
+		p.line("annotation ", className(s.Name), stereotype(s.Stereotype), optional(" as ", s.Alias), open(s.Members))
+		p.members(sortMembers(s.Members))
+	case *ast.EnumDef:
+		// Enum values keep their order, which is part of their meaning.
+		members := s.Members
+		for _, v := range s.Values {
+			members = append(members, &ast.Field{Name: v})
+		}
+		p.line("enum ", className(s.Name), stereotype(s.Stereotype), optional(" as ", s.Alias), open(members))
+		p.members(members)
+	case *ast.ObjectDef:
+		p.line("object ", className(s.Name), optional(" : ", className(s.InstanceOf)), stereotype(s.Stereotype),
+			optional(" as ", s.Alias), open(s.Members))
+		p.members(s.Members)
+	case *ast.Relationship:
+		return p.relationship(s, rest)
+	case *ast.AssociationClass:
+		p.line("(", className(s.Left), ", ", className(s.Right), ") .. ", className(s.Class))
+	case *ast.Package:
+		keyword := "package"
+		if s.IsNamespace {
+			keyword = "namespace"
+		}
+		if len(s.Statements) == 0 {
+			p.line(keyword, optional(" ", className(s.Name)), optional(" as ", s.Alias))
+			break
+		}
+		p.line(keyword, optional(" ", className(s.Name)), optional(" as ", s.Alias), " {")
+		p.nested(s.Statements)
+		p.line("}")
+	case *ast.Participant:
+		return p.participant(s, rest)
+	case *ast.Message:
+		p.message(s)
+	case *ast.Activate:
+		keyword := "activate"
+		if s.Deactivate {
+			keyword = "deactivate"
+		}
+		p.line(keyword, optional(" ", name(s.Target)))
+	case *ast.Return:
+		p.line("return", optional(" ", escapeNewlines(s.Label)))
+	case *ast.Autonumber:
+		switch {
+		case s.Stop:
+			p.line("autonumber stop")
+		case s.Resume:
+			p.line("autonumber resume")
+		default:
+			p.line("autonumber", optional(" ", s.Start))
+		}
+	case *ast.Divider:
+		p.line("== ", s.Text, " ==")
+	case *ast.Delay:
+		if s.Text == "" {
+			p.line("...")
+		} else {
+			p.line("... ", s.Text, " ...")
+		}
+	case *ast.Lifecycle:
+		keyword := "create"
+		if s.Destroy {
+			keyword = "destroy"
+		}
+		p.line(keyword, optional(" ", name(s.Target)))
+	case *ast.Fragment:
+		p.fragment(s)
+	case *ast.ActivityStart:
+		p.line("start")
+	case *ast.ActivityStop:
+		p.line("stop")
+	case *ast.Action:
+		p.line(":", strings.ReplaceAll(s.Text, "\n", "\n"+strings.Repeat(indent, p.depth)), ";")
+	case *ast.Decision:
+		p.line("if (", s.Condition, ")", parenthesized(" then ", s.ThenLabel))
+		p.nested(s.Then)
+		if s.ElseLabel != "" || len(s.Else) > 0 {
+			p.line("else", parenthesized(" ", s.ElseLabel))
+			p.nested(s.Else)
+		}
+		p.line("endif")
+	case *ast.Component:
+		if s.IsInterface {
+			p.line("() ", name(s.Name), optional(" as ", name(s.Alias)))
+		} else {
+			p.line("[", s.Name, "]", optional(" as ", name(s.Alias)))
+		}
+	case *ast.Usecase:
+		p.line("(", s.Name, ")", optional(" as ", name(s.Alias)))
+	case *ast.Entity:
+		p.entity(s)
+	}
+	return 0
+}
+
+// legendAlignments holds the keyword for each legend alignment.
+var legendAlignments = map[ast.LegendAlignment]string{
+	ast.LegendLeft:   "left",
+	ast.LegendRight:  "right",
+	ast.LegendCenter: "center",
+}
+
+func (p *printer) note(n *ast.Note) {
+	head := "note"
+	switch {
+	case n.Placement == ast.NoteLeft:
+		head += " left" + optional(" of ", noteTarget(n.Target))
+	case n.Placement == ast.NoteRight:
+		head += " right" + optional(" of ", noteTarget(n.Target))
+	case n.Placement == ast.NoteOnLink:
+		// The target is implied by the relationship printed before it.
+		head += " on link"
+	case n.Target != "":
+		head += " over " + noteTarget(n.Target)
+	}
+	if !strings.Contains(n.Text, "\n") {
+		p.line(head, " : ", escapeNewlines(n.Text))
+		return
+	}
+	p.line(head)
+	p.block(n.Text)
+	p.line("end note")
+}
+
+// noteTarget formats the target of a note, which lists participants
+// separated by commas for a note over several of them.
+func noteTarget(target string) string {
+	names := strings.Split(target, ",")
+	for i, n := range names {
+		names[i] = name(n)
+	}
+	return strings.Join(names, ", ")
+}
+
+// members writes the members of a class-like body and its closing brace, or
+// nothing if there are none.
+func (p *printer) members(members []ast.Member) {
+	if len(members) == 0 {
+		return
+	}
+	p.depth++
+	for _, m := range members {
+		p.line(member(m))
+	}
+	p.depth--
+	p.line("}")
+}
+
+// visibilities holds the marker written for each member visibility.
+var visibilities = map[ast.Visibility]string{
+	ast.VisibilityPublic:    "+",
+	ast.VisibilityPrivate:   "-",
+	ast.VisibilityProtected: "#",
+	ast.VisibilityPackage:   "~",
+}
+
+// modifiers holds the marker written for each member modifier.
+var modifiers = map[ast.Modifier]string{
+	ast.ModifierStatic:   "{static} ",
+	ast.ModifierField:    "{field} ",
+	ast.ModifierMethod:   "{method} ",
+	ast.ModifierAbstract: "{abstract} ",
+	ast.ModifierReadOnly: "{readOnly} ",
+}
+
+// separatorMarkers holds the line a separator of each style is drawn with.
+var separatorMarkers = map[ast.SeparatorStyle]string{
+	ast.SeparatorSolid:     "--",
+	ast.SeparatorDotted:    "..",
+	ast.SeparatorDouble:    "==",
+	ast.SeparatorUnderline: "__",
+}
+
+// member formats a single member of a body.
+func member(m ast.Member) string {
+	switch m := m.(type) {
+	case *ast.Field:
+		return visibilities[m.Visibility] + modifiers[m.Modifier] + m.Name + optional(" : ", m.Type) + optional(" = ", m.Value)
+	case *ast.Method:
+		return visibilities[m.Visibility] + modifiers[m.Modifier] + m.Name + "(" + m.Params + ")" + optional(" : ", m.ReturnType)
+	case *ast.Separator:
+		marker := separatorMarkers[m.Style]
+		if m.Label == "" {
+			return marker
+		}
+		return marker + " " + m.Label + " " + marker
+	}
+	return ""
+}
+
+// sortMembers returns the members with the fields before the methods, each
+// sorted by name. Separators stay where they are, and the members between
+// them are sorted on their own.
+func sortMembers(members []ast.Member) []ast.Member {
+	sorted := slices.Clone(members)
+	start := 0
+	for i := 0; i <= len(sorted); i++ {
+		if i < len(sorted) {
+			if _, ok := sorted[i].(*ast.Separator); !ok {
+				continue
+			}
+		}
+		slices.SortStableFunc(sorted[start:i], func(a, b ast.Member) int {
+			_, aMethod := a.(*ast.Method)
+			_, bMethod := b.(*ast.Method)
+			if aMethod != bMethod {
+				if aMethod {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Or(cmp.Compare(memberName(a), memberName(b)), cmp.Compare(member(a), member(b)))
+		})
+		start = i + 1
+	}
+	return sorted
+}
+
+func memberName(m ast.Member) string {
+	switch m := m.(type) {
+	case *ast.Field:
+		return m.Name
+	case *ast.Method:
+		return m.Name
+	}
+	return ""
+}
+
+// relationship writes r, along with the usecases the parser declared for
+// its ends when they were written in parentheses.
+func (p *printer) relationship(r *ast.Relationship, rest []ast.Statement) int {
+	used := 0
+	usecase := func(name string) bool {
+		if used < len(rest) {
+			if u, ok := rest[used].(*ast.Usecase); ok && u.Name == name && u.Alias == "" {
+				used++
+				return true
+			}
+		}
+		return false
+	}
+	left := p.end(r.Left, usecase(r.Left))
+	right := p.end(r.Right, usecase(r.Right))
+	p.line(left, cardinality(r.LeftCard), " ", arrow(r.Arrow, r.LineStyle), cardinality(r.RightCard), " ", right,
+		optional(" : ", escapeNewlines(r.Label)))
+	return used
+}
+
+// end formats one end of a relationship: a usecase in parentheses, a
+// declared component in brackets, or a class name.
+func (p *printer) end(n string, usecase bool) string {
+	switch {
+	case usecase:
+		return "(" + n + ")"
+	case p.components[n]:
+		return "[" + n + "]"
+	}
+	return className(n)
+}
+
+// lineStyles holds the inline style written into an arrow for each line
+// style.
+var lineStyles = map[ast.LineStyle]string{
+	ast.LineDashed: "[dashed]",
+	ast.LineDotted: "[dotted]",
+	ast.LineBold:   "[bold]",
+}
+
+// arrow normalises a relationship arrow: the line between its heads is
+// written as "--", or ".." if it is dotted, and the inline style, if any,
+// goes after its first character.
+func arrow(raw string, style ast.LineStyle) string {
+	first := strings.IndexAny(raw, "-.")
+	if first < 0 {
+		return raw
+	}
+	last := strings.LastIndexAny(raw, "-.")
+	line := "--"
+	if strings.Contains(raw[first:last+1], ".") {
+		line = ".."
+	}
+	return raw[:first] + line[:1] + lineStyles[style] + line[1:] + raw[last+1:]
+}
+
+// participantKinds holds the keyword that declares each kind of participant.
+var participantKinds = map[ast.ParticipantKind]string{
+	ast.ParticipantDefault:     "participant",
+	ast.ParticipantActor:       "actor",
+	ast.ParticipantBoundary:    "boundary",
+	ast.ParticipantControl:     "control",
+	ast.ParticipantEntity:      "entity",
+	ast.ParticipantDatabase:    "database",
+	ast.ParticipantCollections: "collections",
+	ast.ParticipantQueue:       "queue",
+}
+
+// participant writes the declaration of s, folding a create that follows it
+// into "create <kind> Name".
+func (p *printer) participant(s *ast.Participant, rest []ast.Statement) int {
+	used, prefix := 0, ""
+	if len(rest) > 0 {
+		if l, ok := rest[0].(*ast.Lifecycle); ok && !l.Destroy && l.Target == s.Name {
+			used, prefix = 1, "create "
+		}
+	}
+	order := ""
+	if s.Order != 0 {
+		order = fmt.Sprintf(" order %d", s.Order)
+	}
+	p.line(prefix, participantKinds[s.Kind], " ", name(s.Name), optional(" as ", name(s.Alias)), order, optional(" ", s.Color))
+	return used
+}
+
+func (p *printer) message(m *ast.Message) {
+	suffix := ""
+	switch {
+	case m.ActivateTarget:
+		suffix = " ++"
+	case m.DeactivateSource:
+		suffix = " --"
+	}
+	if m.DestroyTarget {
+		suffix += " !!"
+	}
+	p.line(name(m.From), " ", m.Arrow, optional(" ", name(m.To)), suffix, optional(" : ", escapeNewlines(m.Label)))
+}
+
+// fragmentKinds holds the keyword that opens each kind of fragment.
+var fragmentKinds = map[ast.FragmentKind]string{
+	ast.FragmentAlt:      "alt",
+	ast.FragmentLoop:     "loop",
+	ast.FragmentPar:      "par",
+	ast.FragmentBreak:    "break",
+	ast.FragmentRef:      "ref",
+	ast.FragmentGroup:    "group",
+	ast.FragmentOpt:      "opt",
+	ast.FragmentCritical: "critical",
+	ast.FragmentIgnore:   "ignore",
+	ast.FragmentConsider: "consider",
+}
+
+func (p *printer) fragment(f *ast.Fragment) {
+	p.line(fragmentKinds[f.Kind], optional(" ", f.Condition))
+	p.nested(f.Statements)
+	for _, e := range f.ElseParts {
+		p.line("else", optional(" ", e.Condition))
+		p.nested(e.Statements)
+	}
+	p.line("end")
+}
+
+// entity writes e with its key attributes above a "--" line. The body is
+// always written, as it is what marks the input as an ER diagram.
+func (p *printer) entity(e *ast.Entity) {
+	p.line("entity ", name(e.Name), optional(" as ", name(e.Alias)), " {")
+	p.depth++
+	keys := 0
+	for _, a := range e.Attributes {
+		if a.Key {
+			keys++
+		}
+	}
+	for i, a := range e.Attributes {
+		mandatory := ""
+		if a.Mandatory {
+			mandatory = "* "
+		}
+		p.line(mandatory, a.Name, optional(" : ", a.Type))
+		if keys > 0 && i == keys-1 {
+			p.line("--")
+		}
+	}
+	p.depth--
+	p.line("}")
+}
+
+// escapeNewlines writes the line breaks of a one-line label as "\n",
+// escaping backslashes that the parser would otherwise read as part of an
+// escape.
+func escapeNewlines(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+var labelEscaper = strings.NewReplacer(`\n`, `\\n`, `\\`, `\\\\`, "\n", `\n`)
+
+// optional returns prefix followed by s, or "" if s is empty.
+func optional(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
+
+// parenthesized returns prefix followed by s in parentheses, or "" if s is
+// empty.
+func parenthesized(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + "(" + s + ")"
+}
+
+// cardinality formats the cardinality at one end of a relationship, or
+// returns "" if there is none.
+func cardinality(s string) string {
+	if s == "" {
+		return ""
+	}
+	return ` "` + s + `"`
+}
+
+// open returns the " {" that opens a body, or "" if there are no members.
+func open(members []ast.Member) string {
+	if len(members) == 0 {
+		return ""
+	}
+	return " {"
+}
+
+// stereotype formats a stereotype, or returns "" if s is empty.
+func stereotype(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " <<" + s + ">>"
+}
+
+// stereotypes formats each of ss as a stereotype.
+func stereotypes(ss []string) string {
+	var sb strings.Builder
+	for _, s := range ss {
+		sb.WriteString(stereotype(s))
+	}
+	return sb.String()
+}
+
+// spotStereotype formats a stereotype that may start with a spot, as in
+// <<(S,#FF7700) Service>>.
+func spotStereotype(letter, color, s string) string {
+	if letter == "" {
+		return stereotype(s)
+	}
+	spot := "(" + letter + optional(",", color) + ")"
+	return stereotype(spot + optional(" ", s))
+}
+
+// name formats a name, quoting it unless it lexes as a single identifier.
+func name(s string) string {
+	if s == "" || isIdent(s) {
+		return s
+	}
+	return `"` + s + `"`
+}
+
+// className formats a class name, which may also be a dotted identifier
+// such as com.example.Foo.
+func className(s string) string {
+	if s == "" {
+		return s
+	}
+	for part := range strings.SplitSeq(s, ".") {
+		if !isIdent(part) {
+			return `"` + s + `"`
+		}
+	}
+	return s
+}
+
+// isIdent reports whether s lexes as a single identifier, and not as a
+// keyword or anything else.
+func isIdent(s string) bool {
+	tok := lexer.New(s).NextToken()
+	return tok.Type == lexer.TokenIdent && tok.Literal == s
+}
@@ -0,0 +1,314 @@
+package printer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func format(t *testing.T, source string) string {
+	t.Helper()
+	d, errs := parser.Parse(source)
+	require.Empty(t, errs)
+	var b strings.Builder
+	require.NoError(t, printer.Fprint(&b, d))
+	return b.String()
+}
+
+func TestFprint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name: "Class",
+			input: `@startuml
+' layout comment
+title   Shapes
+class   Zeta<<Entity>>   #LightBlue   as Z {
++zoo()
+-bar : int
+ ..  Section ..
++{static} alpha(a, b) : void
+  count : int
+}
+package "com.example" {
+abstract class Base
+}
+interface Shape {
++area() : double
++name : String
+}
+annotation   Audited {
++value() : String
+}
+enum Color {
+RED
+GREEN
+BLUE
+}
+@enduml`,
+			expect: `@startuml
+title Shapes
+class Zeta <<Entity>> #LightBlue as Z {
+  -bar : int
+  +zoo()
+  .. Section ..
+  count : int
+  +{static} alpha(a, b) : void
+}
+package com.example {
+  abstract class Base
+}
+interface Shape {
+  +name : String
+  +area() : double
+}
+annotation Audited {
+  +value() : String
+}
+enum Color {
+  RED
+  GREEN
+  BLUE
+}
+@enduml
+`,
+		},
+		{
+			name: "Relationships",
+			input: `@startuml
+Zeta "1" *-[dashed]--- "many" Base : owns >
+Base ...|> Shape
+Shape <|--- Zeta
+I - J
+(Zeta, Base) .. Color
+@enduml`,
+			expect: `@startuml
+Zeta "1" *-[dashed]- "many" Base : owns >
+Base ..|> Shape
+Shape <|-- Zeta
+I -- J
+(Zeta, Base) .. Color
+@enduml
+`,
+		},
+		{
+			name: "Sequence",
+			input: `@startuml
+participant "Long Name" as L order 2
+create actor Bob
+Bob -> L ++ : hello
+alt success
+Bob -> L : a
+else failure
+loop 3 times
+L --> Bob -- : ok
+end
+end
+== Phase 2 ==
+... wait ...
+note over Bob, L : shared
+@enduml`,
+			expect: `@startuml
+participant "Long Name" as L order 2
+create actor Bob
+Bob -> L ++ : hello
+alt success
+  Bob -> L : a
+else failure
+  loop 3 times
+    L --> Bob -- : ok
+  end
+end
+== Phase 2 ==
+... wait ...
+note over Bob, L : shared
+@enduml
+`,
+		},
+		{
+			name: "Activity",
+			input: `@startuml
+start
+if (ok?) then (yes)
+:a;
+else (no)
+:b;
+endif
+stop
+@enduml`,
+			expect: `@startuml
+start
+if (ok?) then (yes)
+  :a;
+else (no)
+  :b;
+endif
+stop
+@enduml
+`,
+		},
+		{
+			name: "ComponentsAndUsecases",
+			input: `@startuml
+[Web Server] as WS
+() "HTTP" as H
+[Web Server] --> [API] : calls
+H - [Web Server]
+User --> (Login)
+@enduml`,
+			expect: `@startuml
+[Web Server] as WS
+() HTTP as H
+[Web Server] --> API : calls
+H -- [Web Server]
+User --> (Login)
+@enduml
+`,
+		},
+		{
+			name: "Entities",
+			input: `@startuml
+entity User {
+* id : int
+--
+name : text
+}
+User ||--o{ Order
+@enduml`,
+			expect: `@startuml
+entity User {
+  * id : int
+  --
+  name : text
+}
+User ||--o{ Order
+@enduml
+`,
+		},
+		{
+			name: "EscapedNewlines",
+			input: `@startuml
+Alice -> Bob : one\ntwo
+Bob --> Alice : C:\temp \\n
+note over Alice : line1\nline2
+@enduml`,
+			expect: `@startuml
+Alice -> Bob : one\ntwo
+Bob --> Alice : C:\temp \\n
+note over Alice
+  line1
+  line2
+end note
+@enduml
+`,
+		},
+		{
+			name: "Notes",
+			input: `@startuml
+class A
+note left of A : short
+note right of A
+  line one
+    line two
+end note
+legend center
+text
+end legend
+@enduml`,
+			expect: `@startuml
+class A
+note left of A : short
+note right of A
+  line one
+  line two
+end note
+legend center
+  text
+end legend
+@enduml
+`,
+		},
+		{
+			name: "AbstractAndReadOnly",
+			input: `@startuml
+class S {
+{readOnly} -id : int
+{abstract} +area() : double
+}
+@enduml`,
+			expect: `@startuml
+class S {
+  -{readOnly} id : int
+  +{abstract} area() : double
+}
+@enduml
+`,
+		},
+		{
+			name: "EnumValues",
+			input: `@startuml
+enum Color {
+RED   =   0
+GREEN = 1
+}
+@enduml`,
+			expect: `@startuml
+enum Color {
+  RED = 0
+  GREEN = 1
+}
+@enduml
+`,
+		},
+		{
+			name: "StereotypeSpot",
+			input: `@startuml
+class Foo <<(S,#FF7700) Service>>
+class Bar <<(X)>>
+@enduml`,
+			expect: `@startuml
+class Foo <<(S,#FF7700) Service>>
+class Bar <<(X)>>
+@enduml
+`,
+		},
+		{
+			name: "MultipleStereotypes",
+			input: `@startuml
+class Foo   <<service>><<entity>>   #LightBlue
+@enduml`,
+			expect: `@startuml
+class Foo <<service>> <<entity>> #LightBlue
+@enduml
+`,
+		},
+		{
+			name: "NoteOnLink",
+			input: `@startuml
+A --> B : uses
+note on link : why
+@enduml`,
+			expect: `@startuml
+A --> B : uses
+note on link : why
+@enduml
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := format(t, tt.input)
+			assert.Equal(t, tt.expect, got)
+			assert.Equal(t, got, format(t, got), "formatting is not idempotent")
+		})
+	}
+}
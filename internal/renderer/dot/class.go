@@ -0,0 +1,247 @@
+// Package dot renders diagrams as Graphviz DOT source, for graph layout
+// tools and pipelines that consume DOT.
+package dot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+)
+
+// ClassRenderer writes class diagrams as a DOT digraph with one record node
+// per class.
+type ClassRenderer struct {
+	// ids maps the names and aliases of the classes of the diagram being
+	// rendered to their node IDs.
+	ids map[string]string
+	// declared holds the node IDs written so far.
+	declared map[string]bool
+	// refs holds the names relationships and notes refer to.
+	refs     []string
+	notes    int
+	clusters int
+}
+
+// NewClassRenderer creates a class diagram renderer.
+func NewClassRenderer() *ClassRenderer {
+	return &ClassRenderer{}
+}
+
+// Render writes the classes, interfaces and enums of diagram as record nodes
+// whose compartments hold the name, fields and methods, and relationships as
+// edges whose arrowheads and line styles follow the relationship type.
+// Packages become clusters.
+func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	r.ids, r.declared, r.refs, r.notes, r.clusters = map[string]string{}, map[string]bool{}, nil, 0, 0
+	ast.Inspect(diagram, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ClassDef:
+			r.declare(n.Name, n.Alias)
+		case *ast.InterfaceDef:
+			r.declare(n.Name, n.Alias)
+		case *ast.AnnotationDef:
+			r.declare(n.Name, n.Alias)
+		case *ast.EnumDef:
+			r.declare(n.Name, n.Alias)
+		}
+		return true
+	})
+	var b strings.Builder
+	b.WriteString("digraph G {\n")
+	if diagram.Title != "" {
+		fmt.Fprintf(&b, "  label=%s;\n  labelloc=t;\n", quote(diagram.Title))
+	}
+	b.WriteString("  node [shape=record, fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	var rels []*ast.Relationship
+	r.statements(&b, diagram.Statements, "  ", &rels)
+	// Classes only named by relationships and notes get a plain record of
+	// their own, as the default label would be read as record fields.
+	for _, name := range r.refs {
+		if id := r.id(name); !r.declared[id] {
+			r.declared[id] = true
+			fmt.Fprintf(&b, "  %s [label=\"{%s}\"];\n", id, escapeRecord(name))
+		}
+	}
+	for _, rel := range rels {
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", r.id(rel.Left), r.id(rel.Right), strings.Join(edgeAttributes(rel), ", "))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// statements writes the nodes declared by stmts, with each line after
+// indent, and collects their relationships into rels.
+func (r *ClassRenderer) statements(b *strings.Builder, stmts []ast.Statement, indent string, rels *[]*ast.Relationship) {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.ClassDef:
+			stereotype := s.Stereotype
+			if s.Abstract {
+				stereotype = "abstract"
+			}
+			r.node(b, indent, s.Name, stereotype, s.Members, nil)
+		case *ast.InterfaceDef:
+			r.node(b, indent, s.Name, "interface", s.Members, nil)
+		case *ast.AnnotationDef:
+			r.node(b, indent, s.Name, "annotation", s.Members, nil)
+		case *ast.EnumDef:
+			r.node(b, indent, s.Name, "enumeration", s.Members, s.Values)
+		case *ast.Relationship:
+			*rels = append(*rels, s)
+			r.refs = append(r.refs, s.Left, s.Right)
+		case *ast.LayoutDirection:
+			if s.LeftToRight {
+				fmt.Fprintf(b, "%srankdir=LR;\n", indent)
+			}
+		case *ast.Note:
+			r.notes++
+			id := fmt.Sprintf("note%d", r.notes)
+			fmt.Fprintf(b, "%s%s [shape=note, label=%s];\n", indent, id, quote(s.Text))
+			// A note on link is left unconnected, as edges cannot end at
+			// another edge.
+			if s.Target != "" && s.Placement != ast.NoteOnLink {
+				r.refs = append(r.refs, s.Target)
+				fmt.Fprintf(b, "%s%s -> %s [style=dotted, arrowhead=none];\n", indent, id, r.id(s.Target))
+			}
+		case *ast.Package:
+			r.clusters++
+			fmt.Fprintf(b, "%ssubgraph cluster_%d {\n", indent, r.clusters)
+			fmt.Fprintf(b, "%s  label=%s;\n", indent, quote(s.Name))
+			r.statements(b, s.Statements, indent+"  ", rels)
+			fmt.Fprintf(b, "%s}\n", indent)
+		}
+	}
+}
+
+// node writes the record node of a class: its name, with the stereotype
+// above it, then a compartment of fields and one of methods.
+func (r *ClassRenderer) node(b *strings.Builder, indent, name, stereotype string, members []ast.Member, values []string) {
+	id := r.id(name)
+	r.declared[id] = true
+	head := escapeRecord(name)
+	if stereotype != "" {
+		head = escapeRecord("«"+stereotype+"»") + `\n` + head
+	}
+	var fields, methods strings.Builder
+	for _, v := range values {
+		fields.WriteString(escapeRecord(v) + `\l`)
+	}
+	for _, m := range members {
+		switch m := m.(type) {
+		case *ast.Field:
+			fields.WriteString(escapeRecord(visibilities[m.Visibility]+m.Name+optional(" : ", m.Type)+optional(" = ", m.Value)) + `\l`)
+		case *ast.Method:
+			methods.WriteString(escapeRecord(visibilities[m.Visibility]+m.Name+"("+m.Params+")"+optional(" : ", m.ReturnType)) + `\l`)
+		}
+	}
+	fmt.Fprintf(b, "%s%s [label=\"{%s|%s|%s}\"];\n", indent, id, head, fields.String(), methods.String())
+}
+
+// visibilities holds the UML marker for each member visibility.
+var visibilities = map[ast.Visibility]string{
+	ast.VisibilityPublic:    "+",
+	ast.VisibilityPrivate:   "-",
+	ast.VisibilityProtected: "#",
+	ast.VisibilityPackage:   "~",
+}
+
+// declare records the node ID of the class called name, which relationships
+// may also refer to by alias, or without its generic parameters.
+func (r *ClassRenderer) declare(name, alias string) {
+	base, _, _ := strings.Cut(name, "<")
+	id := quote(base)
+	if alias != "" {
+		id = quote(alias)
+		r.ids[alias] = id
+	}
+	r.ids[name], r.ids[base] = id, id
+}
+
+// id returns the node ID of the class called name.
+func (r *ClassRenderer) id(name string) string {
+	if id, ok := r.ids[name]; ok {
+		return id
+	}
+	return quote(name)
+}
+
+// arrowheads holds the arrowhead drawn at the marked end of each type of
+// relationship.
+var arrowheads = map[ast.RelationshipType]string{
+	ast.RelAssociation: "vee",
+	ast.RelDependency:  "vee",
+	ast.RelInheritance: "empty",
+	ast.RelRealization: "empty",
+	ast.RelComposition: "diamond",
+	ast.RelAggregation: "odiamond",
+}
+
+// lineStyles holds the DOT style of each inline line style.
+var lineStyles = map[ast.LineStyle]string{
+	ast.LineDashed: "dashed",
+	ast.LineDotted: "dotted",
+	ast.LineBold:   "bold",
+}
+
+// edgeAttributes returns the attributes of the edge drawn for rel, which
+// always runs from its left to its right end.
+func edgeAttributes(rel *ast.Relationship) []string {
+	head := arrowheads[rel.Type]
+	var attrs []string
+	switch rel.Direction {
+	case ast.ArrowLeft:
+		attrs = append(attrs, "dir=back", "arrowtail="+head)
+	case ast.ArrowRight:
+		attrs = append(attrs, "arrowhead="+head)
+	case ast.ArrowBoth:
+		attrs = append(attrs, "dir=both", "arrowhead="+head, "arrowtail="+head)
+	default:
+		attrs = append(attrs, "dir=none")
+	}
+	style := lineStyles[rel.LineStyle]
+	if style == "" && (strings.Contains(rel.Arrow, "..") || rel.Type == ast.RelDependency || rel.Type == ast.RelRealization) {
+		style = "dashed"
+	}
+	if style != "" {
+		attrs = append(attrs, "style="+style)
+	}
+	if rel.Label != "" {
+		attrs = append(attrs, "label="+quote(rel.Label))
+	}
+	if rel.LeftCard != "" {
+		attrs = append(attrs, "taillabel="+quote(rel.LeftCard))
+	}
+	if rel.RightCard != "" {
+		attrs = append(attrs, "headlabel="+quote(rel.RightCard))
+	}
+	return attrs
+}
+
+// optional returns prefix followed by s, or "" if s is empty.
+func optional(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
+
+// quote returns s as a DOT quoted string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// recordEscaper escapes the characters that structure a record label, and
+// spaces, which would otherwise separate its tokens.
+var recordEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`,
+	"{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`, " ", `\ `,
+)
+
+// escapeRecord escapes s for use as text inside a quoted record label.
+func escapeRecord(s string) string {
+	return recordEscaper.Replace(s)
+}
@@ -0,0 +1,95 @@
+package dot_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/renderer/dot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, source string) string {
+	t.Helper()
+	d, errs := parser.Parse(source)
+	require.Empty(t, errs)
+	var b strings.Builder
+	require.NoError(t, dot.NewClassRenderer().Render(&b, d))
+	return b.String()
+}
+
+func TestClassRenderer(t *testing.T) {
+	t.Parallel()
+	t.Run("Fixture", func(t *testing.T) {
+		t.Parallel()
+		data, err := os.ReadFile("../../../testdata/class_basic.puml")
+		require.NoError(t, err)
+		out := render(t, string(data))
+		assert.True(t, strings.HasPrefix(out, "digraph G {\n"), out)
+		assert.True(t, strings.HasSuffix(out, "}\n"), out)
+		assert.Contains(t, out, "node [shape=record")
+		assert.Contains(t, out, `"Drawable" [label="{«interface»\nDrawable||+draw()\ :\ void\l}"];`)
+		assert.Contains(t, out, `"Color" [label="{«enumeration»\nColor|RED\lGREEN\lBLUE\l|}"];`)
+		assert.Contains(t, out, "subgraph cluster_1 {")
+		assert.Contains(t, out, `"Animal" -> "Shape" [arrowhead=empty];`)
+		assert.Contains(t, out, `"Animal" -> "Drawable" [arrowhead=empty, style=dashed];`)
+		assert.Contains(t, out, `"Animal" -> "Leg" [arrowhead=vee, label="has", taillabel="1", headlabel="*"];`)
+		assert.Contains(t, out, `"Animal" -> "Habitat" [arrowhead=odiamond];`)
+		assert.Contains(t, out, `"Animal" -> "Heart" [arrowhead=diamond];`)
+		// Classes only named by a relationship are declared as records.
+		assert.Contains(t, out, `"Dog" [label="{Dog}"];`)
+	})
+	t.Run("Relationships", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input  string
+			expect string
+		}{
+			{"A <|-- B", `"A" -> "B" [dir=back, arrowtail=empty];`},
+			{"A *-- B", `"A" -> "B" [dir=back, arrowtail=diamond];`},
+			{"A --o B", `"A" -> "B" [arrowhead=odiamond];`},
+			{"A ..> B", `"A" -> "B" [arrowhead=vee, style=dashed];`},
+			{"A <--> B", `"A" -> "B" [dir=both, arrowhead=vee, arrowtail=vee];`},
+			{"A -- B", `"A" -> "B" [dir=none];`},
+			{"A -[bold]-> B", `"A" -> "B" [arrowhead=vee, style=bold];`},
+		}
+		for _, tt := range tests {
+			out := render(t, "@startuml\n"+tt.input+"\n@enduml")
+			assert.Contains(t, out, "  "+tt.expect+"\n", tt.input)
+		}
+	})
+	t.Run("Escaping", func(t *testing.T) {
+		t.Parallel()
+		out := render(t, `@startuml
+class "Long Name" as LN
+class Box<T> {
+  +items : Map<K, V>
+  +put(k : K) : void
+}
+LN --> Box : "quoted"
+@enduml`)
+		assert.Contains(t, out, `"LN" [label="{Long\ Name||}"];`)
+		assert.Contains(t, out, `"Box" [label="{Box\<T\>|+items\ :\ Map\<K,\ V\>\l|+put(k\ :\ K)\ :\ void\l}"];`)
+		assert.Contains(t, out, `"LN" -> "Box" [arrowhead=vee, label="\"quoted\""];`)
+	})
+	t.Run("ValidForGraphviz", func(t *testing.T) {
+		t.Parallel()
+		if _, err := exec.LookPath("dot"); err != nil {
+			t.Skip("Graphviz dot is not installed")
+		}
+		data, err := os.ReadFile("../../../testdata/class_basic.puml")
+		require.NoError(t, err)
+		cmd := exec.Command("dot", "-Tsvg")
+		cmd.Stdin = strings.NewReader(render(t, string(data)))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		require.NoError(t, err, stderr.String())
+		assert.Empty(t, stderr.String())
+		assert.Contains(t, string(out), "<svg")
+	})
+}
@@ -0,0 +1,308 @@
+// Package mermaid renders diagrams as Mermaid source, for documentation tools
+// that display Mermaid but not PlantUML.
+package mermaid
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+)
+
+// ClassRenderer writes class diagrams as Mermaid classDiagram source.
+type ClassRenderer struct {
+	// ids maps the names and aliases of the classes of the diagram being
+	// rendered to the Mermaid identifiers they are written as.
+	ids map[string]string
+}
+
+// NewClassRenderer creates a class diagram renderer.
+func NewClassRenderer() *ClassRenderer {
+	return &ClassRenderer{}
+}
+
+// Render writes the classes, interfaces and enums of diagram and the
+// relationships between them as Mermaid source. Packages become namespaces
+// holding their classes; statements Mermaid has no form for are left out.
+func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	r.ids = map[string]string{}
+	var classes []class
+	var rels []*ast.Relationship
+	var notes []*ast.Note
+	direction := ""
+	var collect func(stmts []ast.Statement, pkg string)
+	collect = func(stmts []ast.Statement, pkg string) {
+		for _, stmt := range stmts {
+			switch s := stmt.(type) {
+			case *ast.ClassDef:
+				r.declare(s.Name, s.Alias)
+				classes = append(classes, class{stmt: s, pkg: pkg})
+			case *ast.InterfaceDef:
+				r.declare(s.Name, s.Alias)
+				classes = append(classes, class{stmt: s, pkg: pkg})
+			case *ast.AnnotationDef:
+				r.declare(s.Name, s.Alias)
+				classes = append(classes, class{stmt: s, pkg: pkg})
+			case *ast.EnumDef:
+				r.declare(s.Name, s.Alias)
+				classes = append(classes, class{stmt: s, pkg: pkg})
+			case *ast.Relationship:
+				rels = append(rels, s)
+			case *ast.Note:
+				notes = append(notes, s)
+			case *ast.LayoutDirection:
+				direction = "TB"
+				if s.LeftToRight {
+					direction = "LR"
+				}
+			case *ast.Package:
+				name := s.Name
+				if pkg != "" {
+					name = pkg + "." + name
+				}
+				collect(s.Statements, name)
+			}
+		}
+	}
+	collect(diagram.Statements, "")
+
+	var b strings.Builder
+	if diagram.Title != "" {
+		fmt.Fprintf(&b, "---\ntitle: %s\n---\n", diagram.Title)
+	}
+	b.WriteString("classDiagram\n")
+	if direction != "" {
+		fmt.Fprintf(&b, "  direction %s\n", direction)
+	}
+	// Classes outside any package come first, then one namespace per
+	// package. A nested package is a namespace of its own, named after the
+	// path of packages leading to it.
+	var packages []string
+	for _, c := range classes {
+		if c.pkg == "" {
+			r.writeClass(&b, c.stmt, "  ")
+		} else if !slices.Contains(packages, c.pkg) {
+			packages = append(packages, c.pkg)
+		}
+	}
+	for _, pkg := range packages {
+		fmt.Fprintf(&b, "  namespace %s {\n", identifier(pkg))
+		for _, c := range classes {
+			if c.pkg == pkg {
+				r.writeClass(&b, c.stmt, "    ")
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	for _, rel := range rels {
+		b.WriteString("  " + r.relationship(rel) + "\n")
+	}
+	for _, n := range notes {
+		// Mermaid cannot attach a note to a relationship, so a note on link
+		// floats like a note without a target.
+		if n.Target == "" || n.Placement == ast.NoteOnLink {
+			fmt.Fprintf(&b, "  note %s\n", quote(n.Text))
+		} else {
+			fmt.Fprintf(&b, "  note for %s %s\n", r.id(n.Target), quote(n.Text))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// class is a class, interface or enum and the package it is declared in,
+// or "" if it is outside any package.
+type class struct {
+	stmt ast.Statement
+	pkg  string
+}
+
+// declare records the identifier of the class called name, which
+// relationships may also refer to by alias.
+func (r *ClassRenderer) declare(name, alias string) {
+	id := identifier(name)
+	if alias != "" {
+		id = identifier(alias)
+		r.ids[alias] = id
+	}
+	r.ids[name] = id
+}
+
+// id returns the identifier of the class called name.
+func (r *ClassRenderer) id(name string) string {
+	if id, ok := r.ids[name]; ok {
+		return id
+	}
+	return identifier(name)
+}
+
+// writeClass writes the declaration of a class, interface or enum and its
+// body, with each line after indent.
+func (r *ClassRenderer) writeClass(b *strings.Builder, stmt ast.Statement, indent string) {
+	var name, annotation string
+	var members []ast.Member
+	var values []string
+	switch s := stmt.(type) {
+	case *ast.ClassDef:
+		name, annotation, members = s.Name, s.Stereotype, s.Members
+		if s.Abstract {
+			annotation = "abstract"
+		}
+	case *ast.InterfaceDef:
+		name, annotation, members = s.Name, "interface", s.Members
+	case *ast.AnnotationDef:
+		name, annotation, members = s.Name, "annotation", s.Members
+	case *ast.EnumDef:
+		name, annotation, members, values = s.Name, "enumeration", s.Members, s.Values
+	}
+	var lines []string
+	if annotation != "" {
+		lines = append(lines, "<<"+annotation+">>")
+	}
+	lines = append(lines, values...)
+	for _, m := range members {
+		if line := member(m); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	id := r.id(name)
+	if generics := genericsOf(name); generics != "" {
+		id += "~" + generics + "~"
+	} else if id != name {
+		// The name is not a valid identifier, or the class has an alias.
+		id += "[" + quote(name) + "]"
+	}
+	if len(lines) == 0 {
+		fmt.Fprintf(b, "%sclass %s\n", indent, id)
+		return
+	}
+	fmt.Fprintf(b, "%sclass %s {\n", indent, id)
+	for _, l := range lines {
+		fmt.Fprintf(b, "%s  %s\n", indent, l)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// visibilities holds the Mermaid marker for each member visibility.
+var visibilities = map[ast.Visibility]string{
+	ast.VisibilityPublic:    "+",
+	ast.VisibilityPrivate:   "-",
+	ast.VisibilityProtected: "#",
+	ast.VisibilityPackage:   "~",
+}
+
+// member formats a member in Mermaid form, such as "+String name" or
+// "+getName(id int) String". Static members end in '$' and abstract
+// methods in '*'. Separators have no Mermaid form and give "".
+func member(m ast.Member) string {
+	switch m := m.(type) {
+	case *ast.Field:
+		s := visibilities[m.Visibility]
+		if m.Type != "" {
+			s += generics(m.Type) + " "
+		}
+		s += generics(m.Name)
+		if m.Modifier == ast.ModifierStatic {
+			s += "$"
+		}
+		return s
+	case *ast.Method:
+		s := visibilities[m.Visibility] + m.Name + "(" + generics(m.Params) + ")"
+		if m.ReturnType != "" {
+			s += " " + generics(m.ReturnType)
+		}
+		switch m.Modifier {
+		case ast.ModifierStatic:
+			s += "$"
+		case ast.ModifierAbstract:
+			s += "*"
+		}
+		return s
+	}
+	return ""
+}
+
+// relationship formats a relationship as a Mermaid link such as
+// Foo "1" *-- "many" Bar : owns.
+func (r *ClassRenderer) relationship(rel *ast.Relationship) string {
+	s := r.id(rel.Left)
+	if rel.LeftCard != "" {
+		s += " " + quote(rel.LeftCard)
+	}
+	s += " " + arrow(rel)
+	if rel.RightCard != "" {
+		s += " " + quote(rel.RightCard)
+	}
+	s += " " + r.id(rel.Right)
+	if rel.Label != "" {
+		s += " : " + strings.ReplaceAll(rel.Label, "\n", "<br>")
+	}
+	return s
+}
+
+// arrow returns the Mermaid arrow for the type and direction of rel.
+func arrow(rel *ast.Relationship) string {
+	line := "--"
+	if strings.Contains(rel.Arrow, "..") || rel.Type == ast.RelDependency || rel.Type == ast.RelRealization {
+		line = ".."
+	}
+	var left, right string
+	switch rel.Type {
+	case ast.RelInheritance, ast.RelRealization:
+		left, right = "<|", "|>"
+	case ast.RelComposition:
+		left, right = "*", "*"
+	case ast.RelAggregation:
+		left, right = "o", "o"
+	default:
+		left, right = "<", ">"
+	}
+	switch rel.Direction {
+	case ast.ArrowLeft:
+		return left + line
+	case ast.ArrowRight:
+		return line + right
+	case ast.ArrowBoth:
+		return left + line + right
+	}
+	return line
+}
+
+// identifier turns name into a Mermaid class identifier: generic parameters
+// are dropped and characters other than letters, digits and '_' become '_'.
+func identifier(name string) string {
+	if i := strings.IndexByte(name, '<'); i > 0 {
+		name = name[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+}
+
+// genericsOf returns the generic parameters of a class name such as
+// Map<K, V>, in Mermaid form, or "" if it has none.
+func genericsOf(name string) string {
+	i := strings.IndexByte(name, '<')
+	if i <= 0 || !strings.HasSuffix(name, ">") {
+		return ""
+	}
+	return strings.ReplaceAll(generics(name[i+1:len(name)-1]), " ", "")
+}
+
+// generics rewrites PlantUML generics such as List<int> into the Mermaid
+// form List~int~.
+func generics(s string) string {
+	return strings.NewReplacer("<", "~", ">", "~").Replace(s)
+}
+
+// quote returns s as a Mermaid string. Mermaid has no escapes, so double
+// quotes are written as the entity #quot; and line breaks as \n.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,132 @@
+package mermaid_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	mermaidparser "github.com/bobcob7/go-uml/internal/mermaid"
+	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/renderer/mermaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, source string) string {
+	t.Helper()
+	d, errs := parser.Parse(source)
+	require.Empty(t, errs)
+	var b strings.Builder
+	require.NoError(t, mermaid.NewClassRenderer().Render(&b, d))
+	return b.String()
+}
+
+func TestClassRenderer(t *testing.T) {
+	t.Parallel()
+	t.Run("Fixture", func(t *testing.T) {
+		t.Parallel()
+		data, err := os.ReadFile("../../../testdata/class_basic.puml")
+		require.NoError(t, err)
+		out := render(t, string(data))
+		lines := strings.Split(out, "\n")
+		assert.Equal(t, "classDiagram", lines[0])
+		for _, want := range []string{
+			"  class Animal {",
+			"    +String name",
+			"    -int age",
+			"    #float weight",
+			"    ~bool internal",
+			"    +speak() void",
+			"    int count$",
+			"    move() void*",
+			"    <<abstract>>",
+			"    <<interface>>",
+			"    <<enumeration>>",
+			"  namespace com_example {",
+			"    class Foo",
+			"  Animal --|> Shape",
+			"  Animal ..|> Drawable",
+			"  Dog --|> Animal : extends",
+			`  Animal "1" --> "*" Leg : has`,
+			"  Animal --o Habitat",
+			"  Animal --* Heart",
+			`  note for Animal "This is an animal"`,
+		} {
+			assert.Contains(t, lines, want)
+		}
+	})
+	t.Run("ParsesAsMermaid", func(t *testing.T) {
+		t.Parallel()
+		out := render(t, `@startuml
+class Animal {
+  -name : String
+  +speak(loud : bool) : String
+}
+interface Pet
+class Dog
+Animal <|-- Dog
+Dog ..|> Pet
+Dog "1" *-- "many" Leg : has
+@enduml`)
+		d, errs := mermaidparser.Parse(out)
+		require.Empty(t, errs, out)
+		var rels []*ast.Relationship
+		for _, stmt := range d.Statements {
+			if rel, ok := stmt.(*ast.Relationship); ok {
+				rels = append(rels, rel)
+			}
+		}
+		require.Len(t, rels, 3)
+		assert.Equal(t, ast.RelInheritance, rels[0].Type)
+		assert.Equal(t, ast.RelRealization, rels[1].Type)
+		assert.Equal(t, ast.RelComposition, rels[2].Type)
+		assert.Equal(t, "many", rels[2].RightCard)
+		animal, ok := d.Statements[0].(*ast.ClassDef)
+		require.True(t, ok)
+		assert.Equal(t, "Animal", animal.Name)
+		require.Len(t, animal.Members, 2)
+		assert.Equal(t, &ast.Field{Pos: animal.Members[0].Position(), Name: "name", Type: "String", Visibility: ast.VisibilityPrivate}, animal.Members[0])
+		_, isInterface := d.Statements[1].(*ast.InterfaceDef)
+		assert.True(t, isInterface)
+	})
+	t.Run("Arrows", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input  string
+			expect string
+		}{
+			{"A <|-- B", "A <|-- B"},
+			{"A --|> B", "A --|> B"},
+			{"A <|.. B", "A <|.. B"},
+			{"A *-- B", "A *-- B"},
+			{"A --o B", "A --o B"},
+			{"A ..> B", "A ..> B"},
+			{"A <-- B", "A <-- B"},
+			{"A <--> B", "A <--> B"},
+			{"A -- B", "A -- B"},
+			{"A -[dashed]-> B", "A --> B"},
+			{`A --> B : has\nmany`, "A --> B : has<br>many"},
+		}
+		for _, tt := range tests {
+			out := render(t, "@startuml\n"+tt.input+"\n@enduml")
+			assert.Contains(t, out, "  "+tt.expect+"\n", tt.input)
+		}
+	})
+	t.Run("NamesAndGenerics", func(t *testing.T) {
+		t.Parallel()
+		out := render(t, `@startuml
+title Zoo
+class "Long Name" <<Entity>> as LN
+class Box<T> {
+  +items : List<T>
+}
+LN --> Box
+@enduml`)
+		assert.True(t, strings.HasPrefix(out, "---\ntitle: Zoo\n---\nclassDiagram\n"), out)
+		assert.Contains(t, out, `  class LN["Long Name"] {`)
+		assert.Contains(t, out, "    <<Entity>>")
+		assert.Contains(t, out, "  class Box~T~ {\n    +List~T~ items\n")
+		assert.Contains(t, out, "  LN --> Box\n")
+	})
+}
@@ -0,0 +1,523 @@
+package png
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/math/fixed"
+)
+
+// point is a position in user or device space.
+type point struct{ x, y float64 }
+
+func (p point) sub(q point) point { return point{p.x - q.x, p.y - q.y} }
+func (p point) angle() float64    { return math.Atan2(p.y, p.x) }
+
+// matrix is an affine transform [a b c d e f] mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f), as in the SVG transform attribute.
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+func translate(x, y float64) matrix { return matrix{1, 0, 0, 1, x, y} }
+
+func rotate(angle float64) matrix {
+	sin, cos := math.Sincos(angle)
+	return matrix{cos, sin, -sin, cos, 0, 0}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// times returns the transform applying n and then m.
+func (m matrix) times(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// scale returns the factor m scales lengths by, such as stroke widths.
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// parseTransform parses a transform attribute made of translate, scale and
+// rotate functions. Other functions are ignored.
+func parseTransform(s string) matrix {
+	m := identity
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		name, rest, ok := strings.Cut(s, "(")
+		if !ok {
+			break
+		}
+		args, after, _ := strings.Cut(rest, ")")
+		s = strings.TrimLeft(after, " ,")
+		v := numbers(args)
+		if len(v) == 0 {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "translate":
+			if len(v) == 1 {
+				v = append(v, 0)
+			}
+			m = m.times(translate(v[0], v[1]))
+		case "scale":
+			if len(v) == 1 {
+				v = append(v, v[0])
+			}
+			m = m.times(matrix{v[0], 0, 0, v[1], 0, 0})
+		case "rotate":
+			m = m.times(rotate(v[0] * math.Pi / 180))
+		}
+	}
+	return m
+}
+
+// subpath is a run of connected points, with curves already flattened.
+type subpath struct {
+	pts    []point
+	closed bool
+}
+
+// path is a shape outline made of subpaths.
+type path []subpath
+
+// transform returns p with every point mapped by m.
+func (p path) transform(m matrix) path {
+	out := make(path, len(p))
+	for i, sp := range p {
+		pts := make([]point, len(sp.pts))
+		for j, pt := range sp.pts {
+			pts[j].x, pts[j].y = m.apply(pt.x, pt.y)
+		}
+		out[i] = subpath{pts: pts, closed: sp.closed}
+	}
+	return out
+}
+
+// bounds returns the smallest rectangle of whole pixels holding p.
+func (p path) bounds() image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sp := range p {
+		for _, pt := range sp.pts {
+			minX, minY = min(minX, pt.x), min(minY, pt.y)
+			maxX, maxY = max(maxX, pt.x), max(maxY, pt.y)
+		}
+	}
+	if minX > maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// pathBuilder accumulates a path, flattening curves as they are added.
+type pathBuilder struct {
+	p   path
+	cur point
+}
+
+// curveSteps is the number of line segments a curve is flattened into.
+const curveSteps = 16
+
+func (b *pathBuilder) moveTo(x, y float64) {
+	b.p = append(b.p, subpath{pts: []point{{x, y}}})
+	b.cur = point{x, y}
+}
+
+func (b *pathBuilder) lineTo(x, y float64) {
+	if len(b.p) == 0 {
+		b.moveTo(b.cur.x, b.cur.y)
+	}
+	last := &b.p[len(b.p)-1]
+	last.pts = append(last.pts, point{x, y})
+	b.cur = point{x, y}
+}
+
+func (b *pathBuilder) cubicTo(x1, y1, x2, y2, x, y float64) {
+	p0 := b.cur
+	for i := 1; i <= curveSteps; i++ {
+		t := float64(i) / curveSteps
+		u := 1 - t
+		b.lineTo(
+			u*u*u*p0.x+3*u*u*t*x1+3*u*t*t*x2+t*t*t*x,
+			u*u*u*p0.y+3*u*u*t*y1+3*u*t*t*y2+t*t*t*y,
+		)
+	}
+}
+
+func (b *pathBuilder) quadTo(x1, y1, x, y float64) {
+	p0 := b.cur
+	for i := 1; i <= curveSteps; i++ {
+		t := float64(i) / curveSteps
+		u := 1 - t
+		b.lineTo(u*u*p0.x+2*u*t*x1+t*t*x, u*u*p0.y+2*u*t*y1+t*t*y)
+	}
+}
+
+func (b *pathBuilder) closePath() {
+	if len(b.p) == 0 {
+		return
+	}
+	last := &b.p[len(b.p)-1]
+	last.closed = true
+	b.cur = last.pts[0]
+}
+
+// kappa places the control points of a cubic Bézier approximating a quarter
+// ellipse.
+const kappa = 0.5522847498
+
+// ellipse adds a closed ellipse centred on (cx, cy).
+func (b *pathBuilder) ellipse(cx, cy, rx, ry float64) {
+	kx, ky := rx*kappa, ry*kappa
+	b.moveTo(cx+rx, cy)
+	b.cubicTo(cx+rx, cy+ky, cx+kx, cy+ry, cx, cy+ry)
+	b.cubicTo(cx-kx, cy+ry, cx-rx, cy+ky, cx-rx, cy)
+	b.cubicTo(cx-rx, cy-ky, cx-kx, cy-ry, cx, cy-ry)
+	b.cubicTo(cx+kx, cy-ry, cx+rx, cy-ky, cx+rx, cy)
+	b.closePath()
+}
+
+// roundedRect adds a closed rectangle whose corners are rounded with radii
+// rx and ry.
+func (b *pathBuilder) roundedRect(x, y, w, h, rx, ry float64) {
+	rx, ry = min(rx, w/2), min(ry, h/2)
+	if rx <= 0 || ry <= 0 {
+		b.moveTo(x, y)
+		b.lineTo(x+w, y)
+		b.lineTo(x+w, y+h)
+		b.lineTo(x, y+h)
+		b.closePath()
+		return
+	}
+	kx, ky := rx*kappa, ry*kappa
+	b.moveTo(x+rx, y)
+	b.lineTo(x+w-rx, y)
+	b.cubicTo(x+w-rx+kx, y, x+w, y+ry-ky, x+w, y+ry)
+	b.lineTo(x+w, y+h-ry)
+	b.cubicTo(x+w, y+h-ry+ky, x+w-rx+kx, y+h, x+w-rx, y+h)
+	b.lineTo(x+rx, y+h)
+	b.cubicTo(x+rx-kx, y+h, x, y+h-ry+ky, x, y+h-ry)
+	b.lineTo(x, y+ry)
+	b.cubicTo(x, y+ry-ky, x+rx-kx, y, x+rx, y)
+	b.closePath()
+}
+
+// shapePath returns the outline of a shape element in user space, and false
+// if n is not a shape.
+func shapePath(n *node) (path, bool) {
+	a := func(name string) float64 { return number(n.attrs[name], 0) }
+	var b pathBuilder
+	switch n.name {
+	case "rect":
+		rx, ry := a("rx"), a("ry")
+		if _, ok := n.attrs["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := n.attrs["rx"]; !ok {
+			rx = ry
+		}
+		b.roundedRect(a("x"), a("y"), a("width"), a("height"), rx, ry)
+	case "circle":
+		b.ellipse(a("cx"), a("cy"), a("r"), a("r"))
+	case "ellipse":
+		b.ellipse(a("cx"), a("cy"), a("rx"), a("ry"))
+	case "line":
+		b.moveTo(a("x1"), a("y1"))
+		b.lineTo(a("x2"), a("y2"))
+	case "polyline", "polygon":
+		v := numbers(n.attrs["points"])
+		for i := 0; i+1 < len(v); i += 2 {
+			if i == 0 {
+				b.moveTo(v[i], v[i+1])
+			} else {
+				b.lineTo(v[i], v[i+1])
+			}
+		}
+		if n.name == "polygon" {
+			b.closePath()
+		}
+	case "path":
+		parsePathData(&b, n.attrs["d"])
+	default:
+		return nil, false
+	}
+	return b.p, true
+}
+
+// parsePathData adds the path data d to b. It understands the M, L, H, V,
+// C, Q and Z commands in their absolute and relative forms and stops at any
+// other command.
+func parsePathData(b *pathBuilder, d string) {
+	var cmd byte
+	args := map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'Q': 4, 'Z': 0}
+	for i := 0; ; {
+		for i < len(d) && (d[i] == ' ' || d[i] == ',' || d[i] == '\n' || d[i] == '\t') {
+			i++
+		}
+		if i >= len(d) {
+			return
+		}
+		if c := d[i]; (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') {
+			cmd = c
+			i++
+			if cmd == 'Z' || cmd == 'z' {
+				b.closePath()
+				continue
+			}
+		}
+		upper := cmd &^ 0x20
+		n, ok := args[upper]
+		if !ok || n == 0 {
+			return
+		}
+		v := make([]float64, n)
+		for k := range v {
+			var read int
+			v[k], read = scanNumber(d[i:])
+			if read == 0 {
+				return
+			}
+			i += read
+		}
+		rel := cmd != upper
+		ox, oy := 0.0, 0.0
+		if rel {
+			ox, oy = b.cur.x, b.cur.y
+		}
+		switch upper {
+		case 'M':
+			b.moveTo(ox+v[0], oy+v[1])
+			// Further coordinate pairs are implicit line-tos.
+			cmd = 'L' | (cmd & 0x20)
+		case 'L':
+			b.lineTo(ox+v[0], oy+v[1])
+		case 'H':
+			b.lineTo(ox+v[0], b.cur.y)
+		case 'V':
+			b.lineTo(b.cur.x, oy+v[0])
+		case 'C':
+			b.cubicTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3], ox+v[4], oy+v[5])
+		case 'Q':
+			b.quadTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+		}
+	}
+}
+
+// scanNumber parses the number at the start of s, skipping separators
+// before it, and returns it with the count of bytes read, or zero bytes if
+// there is no number.
+func scanNumber(s string) (float64, int) {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == ',' || s[i] == '\n' || s[i] == '\t') {
+		i++
+	}
+	start := i
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		i++
+	}
+	dot := false
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '.' && !dot {
+			dot = true
+			continue
+		}
+		if c == 'e' || c == 'E' {
+			if i+1 < len(s) && (s[i+1] == '-' || s[i+1] == '+') {
+				i++
+			}
+			continue
+		}
+		if c < '0' || c > '9' {
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(s[start:i], 64)
+	if err != nil {
+		return 0, 0
+	}
+	return f, i
+}
+
+// fill paints the inside of p, placed by m, with src.
+func (c *canvas) fill(p path, m matrix, src image.Image) {
+	dp := p.transform(m)
+	r := dp.bounds().Intersect(c.img.Bounds())
+	if r.Empty() {
+		return
+	}
+	c.z.Reset(r.Dx(), r.Dy())
+	for _, sp := range dp {
+		if len(sp.pts) < 2 {
+			continue
+		}
+		c.z.MoveTo(float32(sp.pts[0].x-float64(r.Min.X)), float32(sp.pts[0].y-float64(r.Min.Y)))
+		for _, pt := range sp.pts[1:] {
+			c.z.LineTo(float32(pt.x-float64(r.Min.X)), float32(pt.y-float64(r.Min.Y)))
+		}
+		c.z.ClosePath()
+	}
+	c.z.Draw(c.img, r, src, r.Min)
+}
+
+// stroke paints the outline of p, placed by m, with a line width wide,
+// broken into dashes when dash is set. Segments meeting at a corner are
+// extended by half the width so the corner is filled.
+func (c *canvas) stroke(p path, m matrix, width float64, dash []float64, src image.Image) {
+	scale := m.scale()
+	hw := width * scale / 2
+	var quads path
+	for _, sp := range p.transform(m) {
+		pts := sp.pts
+		if sp.closed && len(pts) > 1 {
+			pts = append(pts, pts[0])
+		}
+		// An undashed closed subpath also joins its last segment to its first.
+		loop := sp.closed && len(dash) == 0
+		for _, run := range dashes(pts, dash, scale) {
+			for i := 1; i < len(run); i++ {
+				quads = append(quads, segmentQuad(run[i-1], run[i], hw, i > 1 || loop, i < len(run)-1 || loop))
+			}
+		}
+	}
+	c.fill(quads, identity, src)
+}
+
+// segmentQuad returns the rectangle covering the line from p to q with half
+// width hw, extended by hw at the ends that join another segment. Every
+// rectangle winds the same way, so overlapping ones do not cancel out.
+func segmentQuad(p, q point, hw float64, extendStart, extendEnd bool) subpath {
+	d := q.sub(p)
+	length := math.Hypot(d.x, d.y)
+	if length == 0 {
+		return subpath{}
+	}
+	ux, uy := d.x/length, d.y/length
+	if extendStart {
+		p = point{p.x - ux*hw, p.y - uy*hw}
+	}
+	if extendEnd {
+		q = point{q.x + ux*hw, q.y + uy*hw}
+	}
+	nx, ny := -uy*hw, ux*hw
+	return subpath{pts: []point{
+		{p.x + nx, p.y + ny},
+		{q.x + nx, q.y + ny},
+		{q.x - nx, q.y - ny},
+		{p.x - nx, p.y - ny},
+	}, closed: true}
+}
+
+// dashes splits the polyline pts into the runs a dash pattern, given in
+// user units and scaled by scale, draws. Without a pattern the whole
+// polyline is one run.
+func dashes(pts []point, pattern []float64, scale float64) [][]point {
+	total := 0.0
+	for _, v := range pattern {
+		total += v
+	}
+	if len(pattern) == 0 || total <= 0 || len(pts) < 2 {
+		return [][]point{pts}
+	}
+	if len(pattern)%2 == 1 {
+		pattern = append(pattern, pattern...)
+	}
+	var runs [][]point
+	run := []point{pts[0]}
+	idx, left, on := 0, pattern[0]*scale, true
+	for i := 1; i < len(pts); i++ {
+		p, q := pts[i-1], pts[i]
+		seg := math.Hypot(q.x-p.x, q.y-p.y)
+		for pos := 0.0; seg-pos > 1e-9; {
+			step := min(left, seg-pos)
+			pos += step
+			left -= step
+			at := point{p.x + (q.x-p.x)*pos/seg, p.y + (q.y-p.y)*pos/seg}
+			if on {
+				run = append(run, at)
+			}
+			if left > 1e-9 {
+				continue
+			}
+			if on {
+				runs = append(runs, run)
+				run = nil
+			} else {
+				run = []point{at}
+			}
+			on = !on
+			idx = (idx + 1) % len(pattern)
+			left = pattern[idx] * scale
+		}
+	}
+	if on && len(run) > 1 {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// gradient is a top-to-bottom linear gradient spanning device rows top to
+// bottom, used as the source image of a fill.
+type gradient struct {
+	stops       []gradientStop
+	top, bottom int
+	opacity     float64
+}
+
+func (g *gradient) ColorModel() color.Model { return color.NRGBAModel }
+
+func (g *gradient) Bounds() image.Rectangle {
+	return image.Rect(-1e9, -1e9, 1e9, 1e9)
+}
+
+func (g *gradient) At(_, y int) color.Color {
+	t := 0.0
+	if g.bottom > g.top {
+		t = (float64(y) + 0.5 - float64(g.top)) / float64(g.bottom-g.top)
+	}
+	c := g.stops[0].color
+	for i, s := range g.stops {
+		if t <= s.offset {
+			if i > 0 {
+				prev := g.stops[i-1]
+				f := 0.0
+				if s.offset > prev.offset {
+					f = (t - prev.offset) / (s.offset - prev.offset)
+				}
+				c = mix(prev.color, s.color, f)
+			} else {
+				c = s.color
+			}
+			break
+		}
+		c = s.color
+	}
+	c.A = uint8(float64(c.A) * g.opacity)
+	return c
+}
+
+// mix returns the colour f of the way from a to b.
+func mix(a, b color.NRGBA, f float64) color.NRGBA {
+	lerp := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f)) }
+	return color.NRGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), lerp(a.A, b.A)}
+}
+
+func fixedPoint(x, y float64) fixed.Point26_6 {
+	return fixed.Point26_6{X: fixed.Int26_6(math.Round(x * 64)), Y: fixed.Int26_6(math.Round(y * 64))}
+}
+
+func fixedFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
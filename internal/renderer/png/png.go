@@ -0,0 +1,493 @@
+// Package png rasterizes the SVG written by the svg renderers into PNG
+// images. It understands the subset of SVG those renderers produce: basic
+// shapes, paths, text, translated groups, linear gradients and arrowhead
+// markers. Other elements are skipped.
+package png
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	imagepng "image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/font"
+	"golang.org/x/image/colornames"
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/vector"
+)
+
+// maxSide bounds the width and height of a rasterized image in pixels, and
+// maxPixels its area, which is 128 MiB of RGBA.
+const (
+	maxSide   = 16384
+	maxPixels = 32 << 20
+)
+
+// Encode rasterizes the SVG read from r and writes it to w as a PNG image.
+// A scale of 1 gives one pixel per SVG unit; zero or less is taken as 1.
+func Encode(w io.Writer, r io.Reader, scale float64) error {
+	img, err := Rasterize(r, scale)
+	if err != nil {
+		return err
+	}
+	return imagepng.Encode(w, img)
+}
+
+// Rasterize draws the SVG read from r onto a new image, scaled by scale. The
+// image is transparent wherever the SVG draws nothing.
+func Rasterize(r io.Reader, scale float64) (*image.RGBA, error) {
+	root, err := parseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+	width, height, viewBox := documentSize(root)
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("SVG has no width and height or viewBox")
+	}
+	w, h := int(math.Ceil(width*scale)), int(math.Ceil(height*scale))
+	if w > maxSide || h > maxSide || w*h > maxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", w, h)
+	}
+	c := &canvas{
+		img:       image.NewRGBA(image.Rect(0, 0, w, h)),
+		markers:   map[string]markerDef{},
+		gradients: map[string][]gradientStop{},
+		faces:     map[faceKey]xfont.Face{},
+	}
+	defer c.close()
+	base := defaultStyle()
+	c.collectDefs(root, base)
+	m := matrix{width * scale / viewBox[2], 0, 0, height * scale / viewBox[3], 0, 0}
+	m = m.times(translate(-viewBox[0], -viewBox[1]))
+	c.drawChildren(root, m, base)
+	return c.img, nil
+}
+
+// node is an element of the parsed SVG, or character data when name is "".
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string
+}
+
+// parseDocument reads the SVG from r into a tree and returns its <svg>
+// element.
+func parseDocument(r io.Reader) (*node, error) {
+	dec := xml.NewDecoder(r)
+	doc := &node{}
+	stack := []*node{doc}
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing SVG: %w", err)
+		}
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.children = append(parent.children, &node{text: string(t)})
+		}
+	}
+	for _, n := range doc.children {
+		if n.name == "svg" {
+			return n, nil
+		}
+	}
+	return nil, errors.New("parsing SVG: no <svg> element")
+}
+
+// documentSize returns the displayed size of the <svg> element and its
+// viewBox as x, y, width and height. A missing size is taken from the
+// viewBox, and a missing viewBox from the size.
+func documentSize(root *node) (width, height float64, viewBox [4]float64) {
+	width, height = number(root.attrs["width"], 0), number(root.attrs["height"], 0)
+	vb := numbers(root.attrs["viewBox"])
+	if len(vb) == 4 && vb[2] > 0 && vb[3] > 0 {
+		copy(viewBox[:], vb)
+		if width <= 0 || height <= 0 {
+			width, height = vb[2], vb[3]
+		}
+		return width, height, viewBox
+	}
+	return width, height, [4]float64{0, 0, width, height}
+}
+
+// style holds the presentation attributes in effect for an element, which
+// it inherits from its ancestors.
+type style struct {
+	fill, stroke, color     string
+	strokeWidth             float64
+	dash                    []float64
+	opacity                 float64
+	fillOpacity, strokeOpac float64
+	fontSize                float64
+	fontFamily, fontWeight  string
+	textAnchor, decoration  string
+	markerStart, markerEnd  string
+}
+
+func defaultStyle() style {
+	return style{
+		fill: "black", stroke: "none", color: "black",
+		strokeWidth: 1, opacity: 1, fillOpacity: 1, strokeOpac: 1,
+		fontSize: 16, fontFamily: "sans-serif", textAnchor: "start",
+	}
+}
+
+// with returns s updated by the presentation attributes in attrs.
+func (s style) with(attrs map[string]string) style {
+	for name, v := range attrs {
+		switch name {
+		case "fill":
+			s.fill = v
+		case "stroke":
+			s.stroke = v
+		case "color":
+			s.color = v
+		case "stroke-width":
+			s.strokeWidth = number(v, s.strokeWidth)
+		case "stroke-dasharray":
+			s.dash = numbers(v)
+		case "opacity":
+			s.opacity *= number(v, 1)
+		case "fill-opacity":
+			s.fillOpacity = number(v, 1)
+		case "stroke-opacity":
+			s.strokeOpac = number(v, 1)
+		case "font-size":
+			s.fontSize = number(strings.TrimSuffix(v, "px"), s.fontSize)
+		case "font-family":
+			s.fontFamily = v
+		case "font-weight":
+			s.fontWeight = v
+		case "text-anchor":
+			s.textAnchor = v
+		case "text-decoration":
+			s.decoration = v
+		case "marker-start":
+			s.markerStart = v
+		case "marker-end":
+			s.markerEnd = v
+		}
+	}
+	return s
+}
+
+// markerDef is a <marker> element with the style its content inherits.
+type markerDef struct {
+	node  *node
+	style style
+}
+
+// gradientStop is one colour stop of a linear gradient.
+type gradientStop struct {
+	offset float64
+	color  color.NRGBA
+}
+
+type faceKey struct {
+	family font.Family
+	size   float64
+}
+
+// canvas is the image being drawn with the definitions it refers to.
+type canvas struct {
+	img       *image.RGBA
+	z         vector.Rasterizer
+	markers   map[string]markerDef
+	gradients map[string][]gradientStop
+	faces     map[faceKey]xfont.Face
+}
+
+func (c *canvas) close() {
+	for _, f := range c.faces {
+		_ = f.Close()
+	}
+}
+
+// collectDefs records the markers and gradients defined anywhere under n.
+func (c *canvas) collectDefs(n *node, st style) {
+	for _, child := range n.children {
+		if child.name == "" {
+			continue
+		}
+		cs := st.with(child.attrs)
+		switch child.name {
+		case "marker":
+			c.markers[child.attrs["id"]] = markerDef{node: child, style: cs}
+			continue
+		case "linearGradient":
+			c.gradients[child.attrs["id"]] = gradientStops(child, cs)
+			continue
+		}
+		c.collectDefs(child, cs)
+	}
+}
+
+// gradientStops returns the colour stops of a linear gradient.
+func gradientStops(n *node, st style) []gradientStop {
+	var stops []gradientStop
+	for _, child := range n.children {
+		if child.name != "stop" {
+			continue
+		}
+		col, ok := parseColor(child.attrs["stop-color"], st.color)
+		if !ok {
+			continue
+		}
+		col.A = uint8(float64(col.A) * number(child.attrs["stop-opacity"], 1))
+		offset := child.attrs["offset"]
+		pos := number(strings.TrimSuffix(offset, "%"), 0)
+		if strings.HasSuffix(offset, "%") {
+			pos /= 100
+		}
+		stops = append(stops, gradientStop{offset: pos, color: col})
+	}
+	return stops
+}
+
+// drawChildren draws the children of n, which inherit st and are placed by
+// m.
+func (c *canvas) drawChildren(n *node, m matrix, st style) {
+	for _, child := range n.children {
+		if child.name == "" {
+			continue
+		}
+		cs := st.with(child.attrs)
+		cm := m.times(parseTransform(child.attrs["transform"]))
+		switch child.name {
+		case "g", "a":
+			c.drawChildren(child, cm, cs)
+		case "text":
+			c.drawText(child, cm, cs)
+		default:
+			if p, ok := shapePath(child); ok {
+				c.drawShape(child.name, p, cm, cs)
+			}
+		}
+	}
+}
+
+// drawShape fills and strokes p and adds the markers of its ends.
+func (c *canvas) drawShape(kind string, p path, m matrix, st style) {
+	if kind != "line" && kind != "polyline" {
+		if src := c.paint(st.fill, st.color, st.opacity*st.fillOpacity, p, m); src != nil {
+			c.fill(p, m, src)
+		}
+	}
+	if src := c.paint(st.stroke, st.color, st.opacity*st.strokeOpac, p, m); src != nil && st.strokeWidth > 0 {
+		c.stroke(p, m, st.strokeWidth, st.dash, src)
+	}
+	if kind == "line" || kind == "polyline" || kind == "path" {
+		c.drawMarkers(p, m, st)
+	}
+}
+
+// drawMarkers draws the markers referenced by marker-start and marker-end at
+// the ends of p, turned to follow its direction there.
+func (c *canvas) drawMarkers(p path, m matrix, st style) {
+	if len(p) == 0 {
+		return
+	}
+	first, last := p[0].pts, p[len(p)-1].pts
+	if id := markerID(st.markerStart); id != "" && len(first) > 1 {
+		c.drawMarker(id, first[0], first[1].sub(first[0]).angle(), true, m)
+	}
+	if id := markerID(st.markerEnd); id != "" && len(last) > 1 {
+		n := len(last)
+		c.drawMarker(id, last[n-1], last[n-1].sub(last[n-2]).angle(), false, m)
+	}
+}
+
+func (c *canvas) drawMarker(id string, at point, angle float64, start bool, m matrix) {
+	def, ok := c.markers[id]
+	if !ok {
+		return
+	}
+	switch orient := def.node.attrs["orient"]; {
+	case orient == "auto-start-reverse" && start:
+		angle += math.Pi
+	case orient != "auto" && orient != "auto-start-reverse":
+		angle = number(orient, 0) * math.Pi / 180
+	}
+	refX, refY := number(def.node.attrs["refX"], 0), number(def.node.attrs["refY"], 0)
+	mm := m.times(translate(at.x, at.y)).times(rotate(angle)).times(translate(-refX, -refY))
+	c.drawChildren(def.node, mm, def.style)
+}
+
+// markerID returns the ID in a "url(#id)" reference, or "".
+func markerID(ref string) string {
+	if !strings.HasPrefix(ref, "url(#") || !strings.HasSuffix(ref, ")") {
+		return ""
+	}
+	return ref[len("url(#") : len(ref)-1]
+}
+
+// paint returns the image a fill or stroke of value paints with, or nil if
+// it paints nothing. Gradients span the bounding box of p.
+func (c *canvas) paint(value, current string, opacity float64, p path, m matrix) image.Image {
+	if id := markerID(value); id != "" {
+		stops := c.gradients[id]
+		if len(stops) == 0 {
+			return nil
+		}
+		box := p.transform(m).bounds()
+		return &gradient{stops: stops, top: box.Min.Y, bottom: box.Max.Y, opacity: opacity}
+	}
+	col, ok := parseColor(value, current)
+	if !ok {
+		return nil
+	}
+	col.A = uint8(float64(col.A) * opacity)
+	return image.NewUniform(col)
+}
+
+// drawText draws a <text> element and its <tspan> runs as one line starting,
+// centred or ending at its x, on the baseline at its y.
+func (c *canvas) drawText(n *node, m matrix, st style) {
+	type run struct {
+		text string
+		st   style
+	}
+	var runs []run
+	for _, child := range n.children {
+		switch child.name {
+		case "":
+			runs = append(runs, run{child.text, st})
+		case "tspan":
+			var sb strings.Builder
+			for _, t := range child.children {
+				sb.WriteString(t.text)
+			}
+			runs = append(runs, run{sb.String(), st.with(child.attrs)})
+		}
+	}
+	scale := m.scale()
+	x, y := m.apply(number(n.attrs["x"], 0), number(n.attrs["y"], 0))
+	width := 0.0
+	for _, r := range runs {
+		width += fixedFloat(xfont.MeasureString(c.face(r.st, scale), r.text))
+	}
+	switch st.textAnchor {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+	for _, r := range runs {
+		src := c.paint(r.st.fill, r.st.color, r.st.opacity*r.st.fillOpacity, nil, m)
+		face := c.face(r.st, scale)
+		advance := fixedFloat(xfont.MeasureString(face, r.text))
+		if src != nil {
+			d := xfont.Drawer{Dst: c.img, Src: src, Face: face, Dot: fixedPoint(x, y)}
+			d.DrawString(r.text)
+			if r.st.decoration == "underline" {
+				thick := math.Max(scale, 1)
+				c.fill(path{{pts: []point{{x, y + thick}, {x + advance, y + thick}, {x + advance, y + 2*thick}, {x, y + 2*thick}}, closed: true}}, identity, src)
+			}
+		}
+		x += advance
+	}
+}
+
+// face returns the font face for text in st drawn at the given scale.
+func (c *canvas) face(st style, scale float64) xfont.Face {
+	family := font.FamilySans
+	switch {
+	case strings.Contains(st.fontFamily, "mono"):
+		family = font.FamilyMono
+	case st.fontWeight == "bold" || number(st.fontWeight, 400) >= 600:
+		family = font.FamilyBold
+	}
+	key := faceKey{family, st.fontSize * scale}
+	if f, ok := c.faces[key]; ok {
+		return f
+	}
+	f, err := font.NewFace(family, key.size)
+	if err != nil {
+		// The embedded fonts always parse; fall back to a bitmap font if
+		// they somehow do not.
+		return basicfont.Face7x13
+	}
+	c.faces[key] = f
+	return f
+}
+
+// parseColor parses an SVG colour: a hex colour, a colour name or
+// currentColor, which stands for current. It reports false for "none" and
+// anything it cannot parse.
+func parseColor(value, current string) (color.NRGBA, bool) {
+	v := strings.TrimSpace(value)
+	if strings.EqualFold(v, "currentColor") {
+		if strings.EqualFold(current, "currentColor") {
+			return color.NRGBA{}, false
+		}
+		return parseColor(current, "")
+	}
+	if hex, ok := strings.CutPrefix(v, "#"); ok {
+		if len(hex) == 3 || len(hex) == 4 {
+			var long strings.Builder
+			for _, r := range hex {
+				long.WriteString(strings.Repeat(string(r), 2))
+			}
+			hex = long.String()
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		switch {
+		case err != nil:
+			return color.NRGBA{}, false
+		case len(hex) == 6:
+			return color.NRGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 0xff}, true
+		case len(hex) == 8:
+			return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+		}
+		return color.NRGBA{}, false
+	}
+	if c, ok := colornames.Map[strings.ToLower(v)]; ok {
+		return color.NRGBA{c.R, c.G, c.B, c.A}, true
+	}
+	return color.NRGBA{}, false
+}
+
+// number parses s as a float, returning def if it is not one.
+func number(s string, def float64) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+// numbers parses a list of numbers separated by commas or spaces.
+func numbers(s string) []float64 {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+	out := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		if n, err := strconv.ParseFloat(f, 64); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
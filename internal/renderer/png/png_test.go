@@ -0,0 +1,195 @@
+package png_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	imagepng "image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/renderer/png"
+	"github.com/bobcob7/go-uml/internal/renderer/svg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rasterize(t *testing.T, svg string, scale float64) *image.RGBA {
+	t.Helper()
+	img, err := png.Rasterize(strings.NewReader(svg), scale)
+	require.NoError(t, err)
+	return img
+}
+
+func TestRasterize(t *testing.T) {
+	t.Parallel()
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	t.Run("Shapes", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10" viewBox="0 0 20 10">`+
+			`<rect width="20" height="10" fill="#FF0000"/><rect x="5" y="2" width="4" height="4" fill="#0F0"/></svg>`, 1)
+		assert.Equal(t, image.Rect(0, 0, 20, 10), img.Bounds())
+		assert.Equal(t, red, img.RGBAAt(0, 0))
+		assert.Equal(t, green, img.RGBAAt(6, 3))
+		assert.Equal(t, red, img.RGBAAt(10, 3))
+	})
+	t.Run("TransparentWhereNothingIsDrawn", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="10" height="10"><rect width="5" height="10" fill="blue"/></svg>`, 1)
+		assert.Equal(t, color.RGBA{0, 0, 0xff, 0xff}, img.RGBAAt(2, 5))
+		assert.Zero(t, img.RGBAAt(7, 5).A)
+	})
+	t.Run("Scale", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="20" height="10"><rect x="5" y="2" width="4" height="4" fill="#00FF00"/></svg>`, 2)
+		assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+		assert.Equal(t, green, img.RGBAAt(12, 6))
+		assert.Zero(t, img.RGBAAt(8, 6).A)
+	})
+	t.Run("ResponsiveUsesViewBox", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg viewBox="0 0 30 15"><rect width="30" height="15" fill="red"/></svg>`, 1)
+		assert.Equal(t, image.Rect(0, 0, 30, 15), img.Bounds())
+	})
+	t.Run("CappedSizeScalesViewBox", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="10" height="5" viewBox="0 0 20 10"><rect x="10" width="10" height="10" fill="red"/></svg>`, 1)
+		assert.Equal(t, image.Rect(0, 0, 10, 5), img.Bounds())
+		assert.Zero(t, img.RGBAAt(2, 2).A)
+		assert.Equal(t, red, img.RGBAAt(7, 2))
+	})
+	t.Run("GroupTranslate", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="20" height="20"><g transform="translate(10,10)"><rect width="5" height="5" fill="red"/></g></svg>`, 1)
+		assert.Zero(t, img.RGBAAt(2, 2).A)
+		assert.Equal(t, red, img.RGBAAt(12, 12))
+	})
+	t.Run("Stroke", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="20" height="20"><rect x="4" y="4" width="12" height="12" fill="none" stroke="red" stroke-width="2"/></svg>`, 1)
+		assert.Equal(t, red, img.RGBAAt(3, 10))
+		assert.Equal(t, red, img.RGBAAt(10, 4))
+		// Corners are closed and the inside is left unfilled.
+		assert.Equal(t, red, img.RGBAAt(3, 3))
+		assert.Zero(t, img.RGBAAt(10, 10).A)
+	})
+	t.Run("Dashes", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="20" height="4"><line x1="0" y1="2" x2="20" y2="2" stroke="red" stroke-width="2" stroke-dasharray="5,5"/></svg>`, 1)
+		assert.Equal(t, red, img.RGBAAt(2, 1))
+		assert.Zero(t, img.RGBAAt(7, 1).A)
+		assert.Equal(t, red, img.RGBAAt(12, 1))
+	})
+	t.Run("MarkerUsesDefsColor", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="40" height="20"><defs color="#00FF00">`+
+			`<marker id="tip" markerWidth="10" markerHeight="10" refX="10" refY="5" orient="auto-start-reverse" markerUnits="userSpaceOnUse">`+
+			`<path d="M0,0 L10,5 L0,10 Z" fill="currentColor"/></marker></defs>`+
+			`<line x1="0" y1="10" x2="30" y2="10" stroke="red" stroke-width="1" marker-end="url(#tip)"/></svg>`, 1)
+		// The arrowhead ends at the end of the line, pointing along it.
+		assert.Equal(t, green, img.RGBAAt(22, 8))
+		assert.Zero(t, img.RGBAAt(32, 10).A)
+	})
+	t.Run("Gradient", func(t *testing.T) {
+		t.Parallel()
+		img := rasterize(t, `<svg width="10" height="100"><defs><linearGradient id="g" x1="0" y1="0" x2="0" y2="1">`+
+			`<stop offset="0" stop-color="#000000"/><stop offset="1" stop-color="#FFFFFF"/></linearGradient></defs>`+
+			`<rect width="10" height="100" fill="url(#g)"/></svg>`, 1)
+		top, bottom := img.RGBAAt(5, 0), img.RGBAAt(5, 99)
+		assert.Less(t, top.R, uint8(10))
+		assert.Greater(t, bottom.R, uint8(245))
+		assert.InDelta(t, 128, int(img.RGBAAt(5, 50).R), 5)
+	})
+	t.Run("Text", func(t *testing.T) {
+		t.Parallel()
+		const svg = `<svg width="100" height="30"><text x="50" y="20" text-anchor="%s" font-size="16" fill="red">Hello</text></svg>`
+		inked := func(img *image.RGBA, x0, x1 int) int {
+			n := 0
+			for y := range 30 {
+				for x := x0; x < x1; x++ {
+					if img.RGBAAt(x, y).A > 0 {
+						n++
+					}
+				}
+			}
+			return n
+		}
+		start := rasterize(t, strings.Replace(svg, "%s", "start", 1), 1)
+		end := rasterize(t, strings.Replace(svg, "%s", "end", 1), 1)
+		assert.Positive(t, inked(start, 50, 100))
+		assert.Zero(t, inked(start, 0, 50))
+		assert.Positive(t, inked(end, 0, 50))
+		assert.Zero(t, inked(end, 51, 100))
+	})
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		for _, svg := range []string{
+			"not xml <",
+			`<html></html>`,
+			`<svg></svg>`,
+			`<svg width="100000" height="10"></svg>`,
+			`<svg width="16000" height="16000"></svg>`,
+		} {
+			_, err := png.Rasterize(strings.NewReader(svg), 1)
+			assert.Error(t, err, svg)
+		}
+	})
+}
+
+// TestRasterizePackageOutlines checks that the curved package outlines the
+// class renderer draws come out in the PNG: the pixel halfway along each
+// curve changes when the outline is drawn.
+func TestRasterizePackageOutlines(t *testing.T) {
+	t.Parallel()
+	pathRe := regexp.MustCompile(`<path d="([^"]* C[^"]*)"[^>]*/>`)
+	commandRe := regexp.MustCompile(`[A-Z][^A-Z]*`)
+	numberRe := regexp.MustCompile(`-?[0-9.]+`)
+	for _, style := range []string{"database", "cloud"} {
+		t.Run(style, func(t *testing.T) {
+			t.Parallel()
+			diagram, errs := parser.Parse("@startuml\nskinparam packageStyle " + style + "\npackage com.example {\nclass Foo\n}\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			doc := buf.String()
+			outline := pathRe.FindStringSubmatch(doc)
+			require.NotNil(t, outline)
+			with := rasterize(t, doc, 1)
+			without := rasterize(t, strings.Replace(doc, outline[0], "", 1), 1)
+			var x, y float64 // the current point
+			curves := 0
+			for _, cmd := range commandRe.FindAllString(outline[1], -1) {
+				var v []float64
+				for _, n := range numberRe.FindAllString(cmd, -1) {
+					f, err := strconv.ParseFloat(n, 64)
+					require.NoError(t, err)
+					v = append(v, f)
+				}
+				if cmd[0] == 'C' {
+					// The point at t = 0.5 of the cubic curve.
+					mx := (x + 3*v[0] + 3*v[2] + v[4]) / 8
+					my := (y + 3*v[1] + 3*v[3] + v[5]) / 8
+					assert.NotEqual(t, without.RGBAAt(int(mx), int(my)), with.RGBAAt(int(mx), int(my)), "%s at %.1f,%.1f", cmd, mx, my)
+					curves++
+				}
+				if len(v) >= 2 {
+					x, y = v[len(v)-2], v[len(v)-1]
+				}
+			}
+			assert.Positive(t, curves)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, strings.NewReader(`<svg width="8" height="6"><rect width="8" height="6" fill="red"/></svg>`), 1))
+	img, err := imagepng.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 8, 6), img.Bounds())
+}
@@ -0,0 +1,343 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/font"
+	"github.com/bobcob7/go-uml/internal/layout"
+	"github.com/bobcob7/go-uml/internal/theme"
+)
+
+const (
+	actStartRadius   = 10.0
+	actStopRadius    = 11.0
+	actMergeSize     = 20.0
+	actCornerRadius  = 12
+	actDecisionInset = 20.0
+	actArrowSize     = 8.0
+)
+
+// ActivityRenderer renders activity diagrams to SVG.
+type ActivityRenderer struct {
+	// Responsive omits the width and height of the SVG so it scales to its
+	// container. The skinparam responsiveSVG also enables it.
+	Responsive bool
+	// Transparent omits the background rect so that the page behind the
+	// SVG shows through. A backgroundColor of "transparent" also enables it.
+	Transparent bool
+	// LayoutDebug, when set, receives a layout.Dump of the positioned
+	// graph, for debugging layouts.
+	LayoutDebug io.Writer
+	resolver    *theme.Resolver
+	drawing
+}
+
+// NewActivityRenderer creates a new activity diagram SVG renderer.
+// If resolver is nil, the default Darcula theme is used. resolver is
+// cloned, so it may be shared with other renderers.
+func NewActivityRenderer(resolver *theme.Resolver) *ActivityRenderer {
+	if resolver == nil {
+		resolver = theme.NewResolver(nil)
+	}
+	return &ActivityRenderer{resolver: resolver.Clone()}
+}
+
+// activityKind classifies activity graph nodes.
+type activityKind int
+
+const (
+	activityStart activityKind = iota
+	activityStop
+	activityAction
+	activityDecision
+	activityMerge
+)
+
+// activityNode holds the content of a node placed in the layout graph.
+type activityNode struct {
+	kind  activityKind
+	lines []string
+}
+
+// activityExit is an outgoing edge waiting for the next node in the flow.
+type activityExit struct {
+	from  string
+	label string
+}
+
+// activityBuilder converts activity statements into a layout graph.
+type activityBuilder struct {
+	graph    *layout.Graph
+	nodes    map[string]*activityNode
+	fontSize float64
+	padding  float64
+}
+
+// Render writes the activity diagram SVG to w.
+func (r *ActivityRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	for _, stmt := range diagram.Statements {
+		if sp, ok := stmt.(*ast.Skinparam); ok {
+			r.resolver.SetSkinparam(sp.Name, sp.Value)
+		}
+	}
+	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
+	b := &activityBuilder{
+		graph:    &layout.Graph{},
+		nodes:    map[string]*activityNode{},
+		fontSize: fontSize,
+		padding:  float64(r.resolver.ResolveInt("Padding", 10)),
+	}
+	b.walk(diagram.Statements, nil)
+	if len(b.graph.Nodes) == 0 {
+		return r.renderEmpty(w)
+	}
+	opts := layout.DefaultOptions()
+	layout.Layout(b.graph, opts)
+	dumpLayout(r.LayoutDebug, b.graph)
+	nodeByID := map[string]*layout.Node{}
+	layerTop := map[int]float64{}
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, n := range b.graph.Nodes {
+		if !n.Virtual {
+			nodeByID[n.ID] = n
+		}
+		if top, ok := layerTop[n.Layer]; !ok || n.Y < top {
+			layerTop[n.Layer] = n.Y
+		}
+		minX = math.Min(minX, n.X)
+		minY = math.Min(minY, n.Y)
+		maxX = math.Max(maxX, n.X+n.Width)
+		maxY = math.Max(maxY, n.Y+n.Height)
+	}
+	offsetX := -minX + diagramPadding
+	offsetY := -minY + diagramPadding
+	svgW := int(maxX - minX + 2*diagramPadding)
+	svgH := int(maxY - minY + 2*diagramPadding)
+	titles := newTitleBlock(diagram, fontSize)
+	legend := newLegendBox(diagram, fontSize)
+	if w := int(math.Ceil(math.Max(titles.minWidth(), legend.minWidth()))); w > svgW {
+		offsetX += float64(w-svgW) / 2
+		svgW = w
+	}
+	offsetY += titles.top()
+	legendY := float64(svgH) + titles.top()
+	svgH += int(math.Ceil(titles.top() + legend.bottom() + titles.bottom()))
+	var sb strings.Builder
+	r.writeSVGOpen(&sb, float64(svgW), float64(svgH), r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+	sb.WriteString("\n")
+	if writeBackground(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("BackgroundColor"), r.Transparent) {
+		sb.WriteString("\n")
+	}
+	titles.render(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("FontColor"))
+	legend.render(&sb, float64(svgW), legendY, r.resolver)
+	router := &activityRouter{
+		graph:    b.graph,
+		layerTop: layerTop,
+		spacing:  opts.LayerSpacing,
+		used:     map[*layout.Node]bool{},
+	}
+	for _, e := range b.graph.Edges {
+		from, to := nodeByID[e.From], nodeByID[e.To]
+		if from == nil || to == nil {
+			continue
+		}
+		pts := router.route(from, to)
+		for i := range pts {
+			pts[i].x += offsetX
+			pts[i].y += offsetY
+		}
+		r.renderEdge(&sb, pts, e.Label)
+	}
+	for _, n := range b.graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		r.renderNode(&sb, b.nodes[n.ID], n.X+offsetX, n.Y+offsetY, n.Width, n.Height, fontSize)
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r *ActivityRenderer) renderEmpty(w io.Writer) error {
+	return r.writeEmptySVG(w, r.resolver.ResolveColor("BackgroundColor"), r.Transparent,
+		r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+}
+
+// walk adds nodes for stmts, connecting them to the pending exits,
+// and returns the exits left dangling at the end of the sequence.
+func (b *activityBuilder) walk(stmts []ast.Statement, exits []activityExit) []activityExit {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *ast.ActivityStart:
+			id := b.add(activityStart, "")
+			b.connect(exits, id)
+			exits = []activityExit{{from: id}}
+		case *ast.ActivityStop:
+			id := b.add(activityStop, "")
+			b.connect(exits, id)
+			exits = nil
+		case *ast.Action:
+			id := b.add(activityAction, s.Text)
+			b.connect(exits, id)
+			exits = []activityExit{{from: id}}
+		case *ast.Decision:
+			id := b.add(activityDecision, s.Condition)
+			b.connect(exits, id)
+			branches := b.walk(s.Then, []activityExit{{from: id, label: s.ThenLabel}})
+			branches = append(branches, b.walk(s.Else, []activityExit{{from: id, label: s.ElseLabel}})...)
+			if len(branches) == 0 {
+				exits = nil
+				continue
+			}
+			merge := b.add(activityMerge, "")
+			b.connect(branches, merge)
+			exits = []activityExit{{from: merge}}
+		}
+	}
+	return exits
+}
+
+// add creates a sized layout node of the given kind and returns its ID.
+func (b *activityBuilder) add(kind activityKind, text string) string {
+	id := fmt.Sprintf("a%d", len(b.graph.Nodes))
+	node := &activityNode{kind: kind}
+	var w, h float64
+	switch kind {
+	case activityStart:
+		w, h = 2*actStartRadius, 2*actStartRadius
+	case activityStop:
+		w, h = 2*actStopRadius, 2*actStopRadius
+	case activityMerge:
+		w, h = actMergeSize, actMergeSize
+	case activityAction, activityDecision:
+		node.lines = strings.Split(text, "\n")
+		size, _ := font.MeasureText(text, b.fontSize, font.FamilySans)
+		w = size.Width + 2*b.padding
+		h = size.Height + 2*b.padding
+		if kind == activityDecision {
+			w += 2 * actDecisionInset
+			h = math.Max(h+actDecisionInset, 2*actDecisionInset)
+		}
+	}
+	b.nodes[id] = node
+	b.graph.Nodes = append(b.graph.Nodes, &layout.Node{ID: id, Width: w, Height: h})
+	return id
+}
+
+func (b *activityBuilder) connect(exits []activityExit, to string) {
+	for _, e := range exits {
+		b.graph.Edges = append(b.graph.Edges, &layout.Edge{From: e.from, To: to, Label: e.label})
+	}
+}
+
+// activityRouter computes orthogonal edge paths, threading edges that span
+// several layers through the virtual nodes inserted by the layout.
+type activityRouter struct {
+	graph    *layout.Graph
+	layerTop map[int]float64
+	spacing  float64
+	used     map[*layout.Node]bool
+}
+
+func (ar *activityRouter) route(from, to *layout.Node) []point {
+	cur := point{from.X + from.Width/2, from.Y + from.Height}
+	pts := []point{cur}
+	for layer := from.Layer + 1; layer <= to.Layer; layer++ {
+		targetX := to.X + to.Width/2
+		if layer < to.Layer {
+			if vn := ar.nearestVirtual(layer, cur.x); vn != nil {
+				targetX = vn.X
+			}
+		}
+		jogY := ar.layerTop[layer] - ar.spacing/2
+		if targetX != cur.x {
+			pts = append(pts, point{cur.x, jogY}, point{targetX, jogY})
+		}
+		cur = point{targetX, jogY}
+	}
+	return append(pts, point{to.X + to.Width/2, to.Y})
+}
+
+// nearestVirtual returns the unused virtual node in layer closest to x.
+func (ar *activityRouter) nearestVirtual(layer int, x float64) *layout.Node {
+	var best *layout.Node
+	for _, n := range ar.graph.Nodes {
+		if !n.Virtual || n.Layer != layer || ar.used[n] {
+			continue
+		}
+		if best == nil || math.Abs(n.X-x) < math.Abs(best.X-x) {
+			best = n
+		}
+	}
+	if best != nil {
+		ar.used[best] = true
+	}
+	return best
+}
+
+func (r *ActivityRenderer) renderEdge(sb *strings.Builder, pts []point, label string) {
+	arrowColor := escapeXML(r.resolver.ResolveColor("ArrowColor"))
+	thickness := r.resolver.ResolveInt("ArrowThickness", 1)
+	fmt.Fprintf(sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%d"/>`,
+		formatPoints(pts), arrowColor, thickness)
+	sb.WriteString("\n")
+	tip := pts[len(pts)-1]
+	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/>`,
+		tip.x-actArrowSize/2, tip.y-actArrowSize, tip.x+actArrowSize/2, tip.y-actArrowSize, tip.x, tip.y, arrowColor)
+	sb.WriteString("\n")
+	if label != "" && len(pts) > 1 {
+		arrowFontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
+		anchor := pts[len(pts)-2]
+		if len(pts) > 2 {
+			anchor = pts[2]
+		}
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			anchor.x+4, anchor.y+float64(arrowFontSize)+2, arrowFontSize, arrowColor, escapeXML(label))
+		sb.WriteString("\n")
+	}
+}
+
+func (r *ActivityRenderer) renderNode(sb *strings.Builder, node *activityNode, x, y, w, h, fontSize float64) {
+	bgColor := escapeXML(r.resolver.ResolveColor("ActivityBackgroundColor"))
+	borderColor := escapeXML(r.resolver.ResolveColor("ActivityBorderColor"))
+	fontColor := escapeXML(r.resolver.ResolveColor("ActivityFontColor"))
+	arrowColor := escapeXML(r.resolver.ResolveColor("ArrowColor"))
+	borderW := r.resolver.ResolveInt("BorderWidth", 1)
+	cx, cy := x+w/2, y+h/2
+	switch node.kind {
+	case activityStart:
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`, cx, cy, actStartRadius, arrowColor)
+	case activityStop:
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="%d"/>`,
+			cx, cy, actStopRadius, arrowColor, borderW)
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`, cx, cy, actStopRadius-4, arrowColor)
+	case activityAction:
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%d" ry="%d" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			x, y, w, h, actCornerRadius, actCornerRadius, bgColor, borderColor, borderW)
+		r.renderLines(sb, node.lines, cx, cy, fontSize, fontColor)
+	case activityDecision, activityMerge:
+		fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			cx, y, x+w, cy, cx, y+h, x, cy, bgColor, borderColor, borderW)
+		r.renderLines(sb, node.lines, cx, cy, fontSize, fontColor)
+	}
+	sb.WriteString("\n")
+}
+
+// renderLines draws text lines centered vertically and horizontally on (cx, cy).
+func (r *ActivityRenderer) renderLines(sb *strings.Builder, lines []string, cx, cy, fontSize float64, color string) {
+	lineH := fontSize + 4
+	startY := cy - lineH*float64(len(lines))/2 + fontSize
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			cx, startY+float64(i)*lineH, fontSize, color, escapeXML(line))
+	}
+}
@@ -0,0 +1,103 @@
+package svg_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/renderer/svg"
+	"github.com/bobcob7/go-uml/internal/theme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityRenderer(t *testing.T) {
+	t.Parallel()
+	t.Run("EmptyDiagram", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewActivityRenderer(nil), "@startuml\n@enduml")
+		assert.Contains(t, out, "<svg")
+		assert.Contains(t, out, "</svg>")
+	})
+	t.Run("StartAndStop", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewActivityRenderer(nil), "@startuml\nstart\nstop\n@enduml")
+		// One filled start circle plus the stop node's ring and dot.
+		assert.Equal(t, 3, strings.Count(out, "<circle"))
+		assert.Equal(t, 1, strings.Count(out, "<polyline"))
+	})
+	t.Run("ActionRoundedRect", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewActivityRenderer(nil), "@startuml\nstart\n:do something;\nstop\n@enduml")
+		assert.Contains(t, out, "do something")
+		assert.Contains(t, out, `rx="12"`)
+		assert.Equal(t, 2, strings.Count(out, "<polyline"))
+	})
+	t.Run("DecisionDiamondAndLabels", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nstart\nif (ok?) then (yes)\n:a;\nelse (no)\n:b;\nendif\nstop\n@enduml"
+		out := renderSVG(t, svg.NewActivityRenderer(nil), input)
+		assert.Contains(t, out, "ok?")
+		assert.Contains(t, out, ">yes<")
+		assert.Contains(t, out, ">no<")
+		diamonds := 0
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "<polygon") && strings.Contains(line, "stroke=") {
+				diamonds++
+			}
+		}
+		assert.Equal(t, 2, diamonds, "expected a decision and a merge diamond")
+	})
+	t.Run("BranchesMergeToSingleExit", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nif (x) then\n:a;\nelse\n:b;\nendif\n:after;\n@enduml"
+		out := renderSVG(t, svg.NewActivityRenderer(nil), input)
+		var ends []string
+		for _, line := range strings.Split(out, "\n") {
+			if !strings.HasPrefix(line, "<polyline") {
+				continue
+			}
+			pts := strings.Fields(strings.Split(line, `"`)[1])
+			ends = append(ends, pts[len(pts)-1])
+		}
+		// decision->a, decision->b, a->merge, b->merge, merge->after.
+		require.Len(t, ends, 5)
+		assert.Equal(t, ends[2], ends[3], "both branches should end at the merge node")
+	})
+	t.Run("TitleAndLegend", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\ntitle Flow\nheader Draft\nfooter Page one\nstart\n:step;\nstop\nlegend\nA legend wider than the whole diagram body\nend legend\n@enduml"
+		out := renderSVG(t, svg.NewActivityRenderer(nil), input)
+		assert.Contains(t, out, ">Flow</text>")
+		assert.Contains(t, out, ">Draft</text>")
+		assert.Contains(t, out, ">Page one</text>")
+		var w, h float64
+		_, err := fmt.Sscanf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%f" height="%f"`, &w, &h)
+		require.NoError(t, err)
+		x, y, lw, lh := legendRect(t, out, "A legend wider than the whole diagram body")
+		assert.GreaterOrEqual(t, x, 0.0)
+		assert.LessOrEqual(t, x+lw, w)
+		assert.LessOrEqual(t, y+lh, h)
+		var stepY float64
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasSuffix(line, ">step</text>") {
+				_, err = fmt.Sscanf(line[strings.Index(line, ` y="`):], ` y="%f"`, &stepY)
+				require.NoError(t, err)
+			}
+		}
+		assert.Greater(t, y, stepY, "the legend sits below the flow")
+	})
+	t.Run("CustomTheme", func(t *testing.T) {
+		t.Parallel()
+		th := theme.Darcula()
+		th.ActivityBackgroundColor = "#123456"
+		diagram, errs := parser.Parse("@startuml\n:step;\n@enduml")
+		require.Empty(t, errs)
+		r := svg.NewActivityRenderer(theme.NewResolver(th))
+		var buf bytes.Buffer
+		require.NoError(t, r.Render(&buf, diagram))
+		assert.Contains(t, buf.String(), "#123456")
+	})
+}
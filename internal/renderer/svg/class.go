@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
 	"strings"
 
 	"github.com/bobcob7/go-uml/internal/ast"
@@ -19,42 +20,162 @@ const (
 	stereotypeFontPx = 11
 	visibilityWidth  = 14
 	diagramPadding   = 20
+	spotRadius       = 9 // circled letter of a stereotype spot
 )
 
 // ClassRenderer renders class diagrams to SVG.
 type ClassRenderer struct {
-	resolver *theme.Resolver
+	// Responsive omits the width and height of the SVG so it scales to its
+	// container. The skinparam responsiveSVG also enables it.
+	Responsive bool
+	// Transparent omits the background rect so that the page behind the
+	// SVG shows through. A backgroundColor of "transparent" also enables it.
+	Transparent bool
+	// Accessible wraps each class box in a <g> whose <title> names it, for
+	// screen readers and hover tooltips.
+	Accessible bool
+	// LayoutDebug, when set, receives a layout.Dump of the positioned
+	// graph, for debugging layouts.
+	LayoutDebug io.Writer
+	resolver    *theme.Resolver
+	// hidden collects the hide/show directives of the diagram being rendered.
+	hidden hideSet
+	// face is the font of class boxes in the diagram being rendered.
+	face fontFace
+	// routing is how relationship lines of the diagram being rendered are
+	// drawn, from skinparam linetype.
+	routing layout.Routing
+	drawing
 }
 
+// hideSet records which parts of a class diagram hide/show directives suppress.
+// Directives apply in source order, so a later "show" undoes an earlier "hide".
+type hideSet struct {
+	emptyMembers bool
+	fields       bool
+	methods      bool
+	// stereotypeLabels hides the <<stereotype>> line of class headers, and
+	// circle the spots drawn beside class names.
+	stereotypeLabels bool
+	circle           bool
+	stereotypes      map[string]bool
+}
+
+// apply folds a single hide/show directive into the set.
+// Targets that do not affect class rendering, such as "footbox", are ignored.
+func (h *hideSet) apply(hs *ast.HideShow) {
+	switch strings.Join(strings.Fields(strings.ToLower(hs.Target)), " ") {
+	case "stereotype", "stereotypes":
+		h.stereotypeLabels = hs.IsHide
+	case "circle":
+		h.circle = hs.IsHide
+	case "empty members":
+		h.emptyMembers = hs.IsHide
+	case "members":
+		h.fields = hs.IsHide
+		h.methods = hs.IsHide
+	case "fields", "attributes":
+		h.fields = hs.IsHide
+	case "methods":
+		h.methods = hs.IsHide
+	default:
+		compact := strings.Join(strings.Fields(hs.Target), "")
+		if len(compact) > 4 && strings.HasPrefix(compact, "<<") && strings.HasSuffix(compact, ">>") {
+			if h.stereotypes == nil {
+				h.stereotypes = map[string]bool{}
+			}
+			h.stereotypes[compact[2:len(compact)-2]] = hs.IsHide
+		}
+	}
+}
+
+// hidesStereotype reports whether classes carrying any of the stereotypes
+// are hidden.
+func (h *hideSet) hidesStereotype(stereotypes []string) bool {
+	return slices.ContainsFunc(stereotypes, func(s string) bool { return h.stereotypes[s] })
+}
+
+// shownStereotypes returns the stereotypes b shows in its header, or none
+// under "hide stereotypes". The kindLabels, which stand in for the kind
+// circles of PlantUML, are not affected.
+func (h *hideSet) shownStereotypes(b *classBox) []string {
+	if h.stereotypeLabels {
+		return nil
+	}
+	return b.stereotypes
+}
+
+// stereotypeList returns the non-empty stereotypes of an element.
+func stereotypeList(first string, extra ...string) []string {
+	if first == "" {
+		return nil
+	}
+	return append([]string{first}, extra...)
+}
+
+// kindLabels holds the kinds of box whose header names the kind, as in
+// <<interface>>, in place of a stereotype.
+var kindLabels = map[string]bool{"interface": true, "annotation": true, "enum": true}
+
 // NewClassRenderer creates a renderer with the given theme resolver.
-// If resolver is nil, Darcula defaults are used.
+// If resolver is nil, Darcula defaults are used. The renderer keeps a clone
+// of resolver, so skinparams in rendered diagrams never reach it and one
+// resolver can be shared by renderers on different goroutines.
 func NewClassRenderer(resolver *theme.Resolver) *ClassRenderer {
 	if resolver == nil {
 		resolver = theme.NewResolver(nil)
 	}
-	return &ClassRenderer{resolver: resolver}
+	return &ClassRenderer{resolver: resolver.Clone()}
 }
 
 // classBox holds measured dimensions and content for a class-like element.
 type classBox struct {
-	id         string
-	name       string
-	stereotype string
-	abstract   bool
-	kind       string // "class", "interface", "enum"
-	fields     []memberLine
-	methods    []memberLine
-	width      float64
-	height     float64
-	nameH      float64
-	fieldsH    float64
-	methodsH   float64
+	id          string
+	name        string
+	stereotypes []string
+	spotLetter  string // letter in the stereotype's spot; "" for none
+	spotColor   string
+	abstract    bool
+	kind        string // "class", "interface", "annotation", "enum", "object"
+	instanceOf  string // class name shown after an object's name
+	bgColor     string // per-element colour overriding the theme
+	fields      []memberLine
+	methods     []memberLine
+	showFields  bool
+	showMethods bool
+	width       float64
+	height      float64
+	nameH       float64
+	fieldsH     float64
+	methodsH    float64
+	// columns is 2 when the members are laid out in two side-by-side
+	// columns to keep the box within ClassMaxHeight, and 1 otherwise.
+	columns int
+}
+
+// memberRows returns how many rows n members take up in the box.
+func (b *classBox) memberRows(n int) int {
+	return (n + b.columns - 1) / b.columns
 }
 
 type memberLine struct {
 	visibility ast.Visibility
 	modifier   ast.Modifier
 	text       string
+	// separator is set for a divider line, whose text is its label.
+	separator *ast.Separator
+}
+
+// readOnlyPrefix marks a {readOnly} member.
+const readOnlyPrefix = "\U0001F512 "
+
+// label returns the text drawn for the member, which for a {readOnly}
+// member starts with a lock.
+func (ml memberLine) label() string {
+	if ml.modifier == ast.ModifierReadOnly {
+		return readOnlyPrefix + ml.text
+	}
+	return ml.text
 }
 
 // noteBox holds a positioned note.
@@ -79,66 +200,125 @@ func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 	fontSizeF := float64(fontSize)
 	padding := r.resolver.ResolveInt("ClassPadding", 10)
 	paddingF := float64(padding)
+	r.hidden = hideSet{}
+	opts := layout.DefaultOptions()
+	opts.Routing = layout.ParseRouting(r.resolver.ResolveString("linetype"))
 	for _, stmt := range diagram.Statements {
-		if sp, ok := stmt.(*ast.Skinparam); ok {
-			r.resolver.SetSkinparam(sp.Name, sp.Value)
+		switch s := stmt.(type) {
+		case *ast.LayoutDirection:
+			if s.LeftToRight {
+				opts.Direction = layout.LeftRight
+			} else {
+				opts.Direction = layout.TopDown
+			}
+		case *ast.Skinparam:
+			r.resolver.SetSkinparam(s.Name, s.Value)
+			if strings.EqualFold(s.Name, "linetype") {
+				opts.Routing = layout.ParseRouting(s.Value)
+			}
+		case *ast.HideShow:
+			r.hidden.apply(s)
 		}
 	}
+	r.routing = opts.Routing
+	fontName := r.resolver.ResolveString("ClassFontName")
+	if fontName == "" {
+		fontName = r.resolver.ResolveString("FontName")
+	}
+	r.face = resolveFontFace(fontName)
 	var boxes []*classBox
 	var rels []*ast.Relationship
+	var assocs []*ast.AssociationClass
 	var notes []*noteBox
+	// linkNotes holds the notes on each relationship's line, and relByID the
+	// latest relationship with each LinkID, which a note on link annotates.
+	linkNotes := map[*ast.Relationship][]*noteBox{}
+	relByID := map[string]*ast.Relationship{}
 	var pkgs []*packageBox
+	var bands []*dividerBand
 	boxByName := map[string]*classBox{}
+	hiddenIDs := map[string]bool{}
+	// sectionOf holds the number of dividers that precede each class.
+	sectionOf := map[string]int{}
+	addBox := func(b *classBox) bool {
+		if r.hidden.hidesStereotype(b.stereotypes) {
+			hiddenIDs[b.id] = true
+			return false
+		}
+		boxes = append(boxes, b)
+		boxByName[b.id] = b
+		sectionOf[b.id] = len(bands)
+		return true
+	}
 	for _, stmt := range diagram.Statements {
 		switch s := stmt.(type) {
 		case *ast.ClassDef:
-			b := r.measureClass(s, fontSizeF, paddingF)
-			boxes = append(boxes, b)
-			boxByName[b.id] = b
+			addBox(r.measureClass(s, fontSizeF, paddingF))
 		case *ast.InterfaceDef:
-			b := r.measureInterface(s, fontSizeF, paddingF)
-			boxes = append(boxes, b)
-			boxByName[b.id] = b
+			addBox(r.measureInterface(s, fontSizeF, paddingF))
+		case *ast.AnnotationDef:
+			addBox(r.measureAnnotation(s, fontSizeF, paddingF))
 		case *ast.EnumDef:
-			b := r.measureEnum(s, fontSizeF, paddingF)
-			boxes = append(boxes, b)
-			boxByName[b.id] = b
+			addBox(r.measureEnum(s, fontSizeF, paddingF))
+		case *ast.ObjectDef:
+			addBox(r.measureObject(s, fontSizeF, paddingF))
 		case *ast.Relationship:
 			rels = append(rels, s)
+			relByID[s.LinkID()] = s
 			for _, name := range []string{s.Left, s.Right} {
-				if _, exists := boxByName[name]; !exists && name != "" {
-					b := r.measureImplicitClass(name, fontSizeF, paddingF)
-					boxes = append(boxes, b)
-					boxByName[b.id] = b
+				if _, exists := boxByName[name]; !exists && !hiddenIDs[name] && name != "" {
+					addBox(r.measureImplicitClass(name, fontSizeF, paddingF))
 				}
 			}
+		case *ast.AssociationClass:
+			assocs = append(assocs, s)
 		case *ast.Note:
 			nb := r.measureNote(s, fontSizeF, paddingF)
-			notes = append(notes, nb)
+			if s.Placement != ast.NoteOnLink {
+				notes = append(notes, nb)
+			} else if rel := relByID[s.Target]; rel != nil {
+				linkNotes[rel] = append(linkNotes[rel], nb)
+			}
+		case *ast.Divider:
+			bands = append(bands, &dividerBand{text: s.Text})
 		case *ast.Package:
 			pb := &packageBox{name: s.Name}
 			for _, child := range s.Statements {
 				switch c := child.(type) {
 				case *ast.ClassDef:
-					b := r.measureClass(c, fontSizeF, paddingF)
-					boxes = append(boxes, b)
-					boxByName[b.id] = b
-					pb.children = append(pb.children, b.id)
+					if b := r.measureClass(c, fontSizeF, paddingF); addBox(b) {
+						pb.children = append(pb.children, b.id)
+					}
 				case *ast.InterfaceDef:
-					b := r.measureInterface(c, fontSizeF, paddingF)
-					boxes = append(boxes, b)
-					boxByName[b.id] = b
-					pb.children = append(pb.children, b.id)
+					if b := r.measureInterface(c, fontSizeF, paddingF); addBox(b) {
+						pb.children = append(pb.children, b.id)
+					}
+				case *ast.AnnotationDef:
+					if b := r.measureAnnotation(c, fontSizeF, paddingF); addBox(b) {
+						pb.children = append(pb.children, b.id)
+					}
 				case *ast.EnumDef:
-					b := r.measureEnum(c, fontSizeF, paddingF)
-					boxes = append(boxes, b)
-					boxByName[b.id] = b
-					pb.children = append(pb.children, b.id)
+					if b := r.measureEnum(c, fontSizeF, paddingF); addBox(b) {
+						pb.children = append(pb.children, b.id)
+					}
+				case *ast.ObjectDef:
+					if b := r.measureObject(c, fontSizeF, paddingF); addBox(b) {
+						pb.children = append(pb.children, b.id)
+					}
 				}
 			}
 			pkgs = append(pkgs, pb)
 		}
 	}
+	// Association classes are resolved after every definition has been seen,
+	// so that "(A, B) .. C" may come before "class C".
+	for _, a := range assocs {
+		for _, name := range []string{a.Left, a.Right, a.Class} {
+			if _, exists := boxByName[name]; !exists && !hiddenIDs[name] {
+				addBox(r.measureImplicitClass(name, fontSizeF, paddingF))
+			}
+		}
+	}
 	if len(boxes) == 0 {
 		return r.writeEmptyDiagram(w)
 	}
@@ -149,12 +329,34 @@ func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 		g.Nodes = append(g.Nodes, n)
 		nodeByID[b.id] = n
 	}
+	edgeByRel := map[*ast.Relationship]*layout.Edge{}
 	for _, rel := range rels {
 		if rel.Left != "" && rel.Right != "" {
-			g.Edges = append(g.Edges, &layout.Edge{From: rel.Left, To: rel.Right, Label: rel.Label})
+			e := &layout.Edge{From: rel.Left, To: rel.Right, Label: rel.Label}
+			g.Edges = append(g.Edges, e)
+			edgeByRel[rel] = e
+		}
+	}
+	for _, a := range assocs {
+		// Routing the pair through the association class puts the class on
+		// the layer between them, level with the middle of their edge.
+		from, to := associationEnds(a, rels)
+		g.Edges = append(g.Edges,
+			&layout.Edge{From: from, To: a.Class},
+			&layout.Edge{From: a.Class, To: to})
+	}
+	if len(bands) == 0 {
+		layout.Layout(g, opts)
+	} else {
+		layoutSections(g, opts, sectionOf, bands)
+	}
+	dumpLayout(r.LayoutDebug, g)
+	for _, a := range assocs {
+		from, to := associationEnds(a, rels)
+		if fromNode, toNode, classNode := nodeByID[from], nodeByID[to], nodeByID[a.Class]; fromNode != nil && toNode != nil && classNode != nil {
+			clearAssociationLine(fromNode, toNode, classNode, opts)
 		}
 	}
-	layout.Layout(g, layout.DefaultOptions())
 	minX, minY := math.MaxFloat64, math.MaxFloat64
 	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
 	for _, n := range g.Nodes {
@@ -174,6 +376,10 @@ func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 			maxY = n.Y + n.Height
 		}
 	}
+	for _, band := range bands {
+		minY = math.Min(minY, band.y)
+		maxY = math.Max(maxY, band.y+classDividerHeight)
+	}
 	noteOffset := 160.0
 	for _, nb := range notes {
 		if nb.target == "" {
@@ -222,29 +428,65 @@ func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 	offsetY := -minY + diagramPadding
 	svgW := int(maxX - minX + 2*diagramPadding)
 	svgH := int(maxY - minY + 2*diagramPadding)
+	titles := newTitleBlock(diagram, fontSizeF)
+	legend := newLegendBox(diagram, fontSizeF)
+	minWidth := math.Max(titles.minWidth(), legend.minWidth())
+	for _, band := range bands {
+		size, _ := font.MeasureText(band.text, fontSizeF, font.FamilyBold)
+		minWidth = math.Max(minWidth, size.Width+4*diagramPadding)
+	}
+	if w := int(math.Ceil(minWidth)); w > svgW {
+		offsetX += float64(w-svgW) / 2
+		svgW = w
+	}
+	offsetY += titles.top()
+	legendY := float64(svgH) + titles.top()
+	svgH += int(math.Ceil(titles.top() + legend.bottom() + titles.bottom()))
 	var sb strings.Builder
-	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, svgW, svgH, svgW, svgH)
-	sb.WriteString("\n")
-	bgColor := r.resolver.ResolveColor("BackgroundColor")
-	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="%s"/>`, svgW, svgH, bgColor)
+	r.writeSVGOpen(&sb, float64(svgW), float64(svgH), r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
 	sb.WriteString("\n")
+	if writeBackground(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("BackgroundColor"), r.Transparent) {
+		sb.WriteString("\n")
+	}
+	// The body is drawn first so that the gradients it uses can be defined
+	// in the <defs> section that precedes it.
+	var body strings.Builder
+	var defs defsBuilder
+	titles.render(&body, float64(svgW), float64(svgH), r.resolver.ResolveColor("FontColor"))
+	legend.render(&body, float64(svgW), legendY, r.resolver)
+	for _, band := range bands {
+		r.renderDivider(&body, band, float64(svgW), band.y+offsetY, fontSizeF)
+	}
 	for _, pb := range pkgs {
-		r.renderPackage(&sb, pb, offsetX, offsetY, fontSizeF)
+		r.renderPackage(&body, pb, offsetX, offsetY, fontSizeF)
 	}
+	midpoints := map[*ast.Relationship]point{}
 	for _, rel := range rels {
 		fromNode := nodeByID[rel.Left]
 		toNode := nodeByID[rel.Right]
 		if fromNode == nil || toNode == nil {
 			continue
 		}
-		r.renderRelationship(&sb, rel, fromNode, toNode, offsetX, offsetY, fontSizeF)
+		var bends []layout.Point
+		if e := edgeByRel[rel]; e != nil {
+			bends = e.Bends
+		}
+		midpoints[rel] = r.renderRelationship(&body, rel, fromNode, toNode, bends, offsetX, offsetY, fontSizeF)
+	}
+	for _, a := range assocs {
+		from, to := associationEnds(a, rels)
+		fromNode, toNode, classNode := nodeByID[from], nodeByID[to], nodeByID[a.Class]
+		if fromNode == nil || toNode == nil || classNode == nil {
+			continue
+		}
+		r.renderAssociationClass(&body, fromNode, toNode, classNode, offsetX, offsetY)
 	}
 	for _, b := range boxes {
 		n := nodeByID[b.id]
 		if n == nil || n.Virtual {
 			continue
 		}
-		r.renderClassBox(&sb, b, n.X+offsetX, n.Y+offsetY, fontSizeF, paddingF)
+		r.renderClassBox(&body, &defs, b, n.X+offsetX, n.Y+offsetY, fontSizeF, paddingF)
 	}
 	for _, nb := range notes {
 		targetNode := nodeByID[nb.target]
@@ -258,7 +500,7 @@ func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 			noteX = targetNode.X + targetNode.Width + 20 + offsetX
 		}
 		noteY := targetNode.Y + offsetY
-		r.renderNote(&sb, nb, noteX, noteY, fontSizeF)
+		r.renderNote(&body, &defs, nb, noteX, noteY, fontSizeF)
 		var lineFromX, lineToX float64
 		if nb.left {
 			lineFromX = noteX + nb.width
@@ -269,32 +511,127 @@ func (r *ClassRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 		}
 		lineY := noteY + nb.height/2
 		arrowColor := r.resolver.ResolveColor("ArrowColor")
-		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-dasharray="5,5"/>`,
+		fmt.Fprintf(&body, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-dasharray="5,5"/>`,
 			lineFromX, lineY, lineToX, lineY, arrowColor)
-		sb.WriteString("\n")
+		body.WriteString("\n")
 	}
+	for _, rel := range rels {
+		mid, ok := midpoints[rel]
+		if !ok {
+			continue
+		}
+		r.renderLinkNotes(&body, rel, linkNotes[rel], mid)
+	}
+	writeDefs(&sb, r.resolver.ResolveColor("ArrowColor"), len(rels) > 0, &defs)
+	sb.WriteString(body.String())
 	sb.WriteString("</svg>\n")
 	_, err := io.WriteString(w, sb.String())
 	return err
 }
 
+// classDividerHeight is the height of the band drawn for a divider, and
+// classSectionGap the space between it and the classes above and below.
+const (
+	classDividerHeight = 28.0
+	classSectionGap    = 30.0
+)
+
+// dividerBand is a "== text ==" divider of a class diagram and the layout
+// position of the top of its band.
+type dividerBand struct {
+	text string
+	y    float64
+}
+
+// layoutSections lays out the classes between consecutive dividers as
+// separate graphs and stacks them top to bottom, centred on the widest,
+// with the band of each divider between them. Relationships that cross a
+// divider are left without bends.
+func layoutSections(g *layout.Graph, opts layout.Options, sectionOf map[string]int, bands []*dividerBand) {
+	sections := make([]layout.Graph, len(bands)+1)
+	for _, n := range g.Nodes {
+		i := sectionOf[n.ID]
+		sections[i].Nodes = append(sections[i].Nodes, n)
+	}
+	for _, e := range g.Edges {
+		if i := sectionOf[e.From]; i == sectionOf[e.To] {
+			sections[i].Edges = append(sections[i].Edges, e)
+		}
+	}
+	type extent struct{ minX, minY, maxX, maxY float64 }
+	extents := make([]extent, len(sections))
+	width := 0.0
+	for i := range sections {
+		if len(sections[i].Nodes) == 0 {
+			continue
+		}
+		layout.Layout(&sections[i], opts)
+		e := extent{math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
+		for _, n := range sections[i].Nodes {
+			e.minX, e.minY = math.Min(e.minX, n.X), math.Min(e.minY, n.Y)
+			e.maxX, e.maxY = math.Max(e.maxX, n.X+n.Width), math.Max(e.maxY, n.Y+n.Height)
+		}
+		extents[i] = e
+		width = math.Max(width, e.maxX-e.minX)
+	}
+	g.Nodes = g.Nodes[:0]
+	y := 0.0
+	for i := range sections {
+		if nodes := sections[i].Nodes; len(nodes) > 0 {
+			e := extents[i]
+			dx, dy := (width-(e.maxX-e.minX))/2-e.minX, y-e.minY
+			for _, n := range nodes {
+				n.X += dx
+				n.Y += dy
+			}
+			for _, edge := range sections[i].Edges {
+				for j := range edge.Bends {
+					edge.Bends[j].X += dx
+					edge.Bends[j].Y += dy
+				}
+			}
+			g.Nodes = append(g.Nodes, nodes...)
+			y += e.maxY - e.minY + classSectionGap
+		}
+		if i < len(bands) {
+			bands[i].y = y
+			y += classDividerHeight + classSectionGap
+		}
+	}
+}
+
+// renderDivider draws the band of a divider across the full width of the
+// diagram, with its text centred in it.
+func (r *ClassRenderer) renderDivider(sb *strings.Builder, band *dividerBand, width, y, fontSize float64) {
+	borderColor := r.resolver.ResolveColor("ClassBorderColor")
+	fmt.Fprintf(sb, `<rect x="0" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`,
+		y, width, classDividerHeight, escapeXML(r.resolver.ResolveColor("ClassBackgroundColor")))
+	for _, lineY := range []float64{y, y + classDividerHeight} {
+		fmt.Fprintf(sb, `<line x1="0" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
+			lineY, width, lineY, escapeXML(borderColor))
+	}
+	if band.text != "" {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%.0f" fill="%s" text-anchor="middle" font-weight="bold">%s</text>`,
+			width/2, y+classDividerHeight/2+fontSize/3, fontSize, escapeXML(r.resolver.ResolveColor("FontColor")), escapeXML(band.text))
+	}
+	sb.WriteString("\n")
+}
+
 func (r *ClassRenderer) writeEmptyDiagram(w io.Writer) error {
-	bgColor := r.resolver.ResolveColor("BackgroundColor")
-	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100" viewBox="0 0 100 100">
-<rect width="100" height="100" fill="%s"/>
-</svg>
-`, bgColor)
-	_, err := io.WriteString(w, svg)
-	return err
+	return r.writeEmptySVG(w, r.resolver.ResolveColor("BackgroundColor"), r.Transparent,
+		r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
 }
 
 func (r *ClassRenderer) measureClass(cd *ast.ClassDef, fontSize, padding float64) *classBox {
 	b := &classBox{
-		id:         cd.Name,
-		name:       cd.Name,
-		stereotype: cd.Stereotype,
-		abstract:   cd.Abstract,
-		kind:       "class",
+		id:          cd.Name,
+		name:        cd.Name,
+		stereotypes: stereotypeList(cd.Stereotype, cd.ExtraStereotypes...),
+		spotLetter:  cd.SpotLetter,
+		spotColor:   cd.SpotColor,
+		abstract:    cd.Abstract,
+		kind:        "class",
+		bgColor:     cd.BackgroundColor,
 	}
 	r.measureMembers(b, cd.Members, fontSize, padding)
 	return b
@@ -302,15 +639,28 @@ func (r *ClassRenderer) measureClass(cd *ast.ClassDef, fontSize, padding float64
 
 func (r *ClassRenderer) measureInterface(id *ast.InterfaceDef, fontSize, padding float64) *classBox {
 	b := &classBox{
-		id:         id.Name,
-		name:       id.Name,
-		stereotype: id.Stereotype,
-		kind:       "interface",
+		id:          id.Name,
+		name:        id.Name,
+		stereotypes: stereotypeList(id.Stereotype),
+		kind:        "interface",
 	}
 	r.measureMembers(b, id.Members, fontSize, padding)
 	return b
 }
 
+// measureAnnotation measures an annotation type, whose name is shown with
+// an "@" before it.
+func (r *ClassRenderer) measureAnnotation(ad *ast.AnnotationDef, fontSize, padding float64) *classBox {
+	b := &classBox{
+		id:          ad.Name,
+		name:        "@" + ad.Name,
+		stereotypes: stereotypeList(ad.Stereotype),
+		kind:        "annotation",
+	}
+	r.measureMembers(b, ad.Members, fontSize, padding)
+	return b
+}
+
 func (r *ClassRenderer) measureEnum(ed *ast.EnumDef, fontSize, padding float64) *classBox {
 	b := &classBox{
 		id:   ed.Name,
@@ -321,6 +671,22 @@ func (r *ClassRenderer) measureEnum(ed *ast.EnumDef, fontSize, padding float64)
 	return b
 }
 
+func (r *ClassRenderer) measureObject(od *ast.ObjectDef, fontSize, padding float64) *classBox {
+	id := od.Name
+	if od.Alias != "" {
+		id = od.Alias
+	}
+	b := &classBox{
+		id:          id,
+		name:        od.Name,
+		stereotypes: stereotypeList(od.Stereotype),
+		kind:        "object",
+		instanceOf:  od.InstanceOf,
+	}
+	r.measureMembers(b, od.Members, fontSize, padding)
+	return b
+}
+
 func (r *ClassRenderer) measureImplicitClass(name string, fontSize, padding float64) *classBox {
 	b := &classBox{
 		id:   name,
@@ -333,69 +699,124 @@ func (r *ClassRenderer) measureImplicitClass(name string, fontSize, padding floa
 
 func (r *ClassRenderer) measureMembers(b *classBox, members []ast.Member, fontSize, padding float64) {
 	lineH := fontSize + 4
-	nameSize, _ := font.MeasureText(b.name, fontSize, font.FamilyBold)
+	nameSize, _ := font.MeasureText(b.name, fontSize, r.face.bold)
 	maxW := nameSize.Width + 2*padding
+	if b.instanceOf != "" {
+		suffix, _ := font.MeasureText(" : "+b.instanceOf, float64(stereotypeFontPx), r.face.regular)
+		maxW += suffix.Width
+	}
+	if r.hidden.circle {
+		b.spotLetter = ""
+	}
+	stereotypes := r.hidden.shownStereotypes(b)
+	if b.spotLetter != "" {
+		// Room for the spot on both sides keeps the name and stereotypes,
+		// which are centred, clear of it.
+		header := maxW
+		for _, stereotype := range stereotypes {
+			st, _ := font.MeasureText("<<"+stereotype+">>", float64(stereotypeFontPx), r.face.regular)
+			header = math.Max(header, st.Width+2*padding)
+		}
+		maxW = header + 2*(2*spotRadius+4)
+	}
+	b.columns = 1
 	b.nameH = lineH + 2*padding
-	if b.stereotype != "" || b.kind == "interface" || b.kind == "enum" {
+	if kindLabels[b.kind] {
 		b.nameH += float64(stereotypeFontPx) + 4
+	} else {
+		// Each stereotype takes a line of its own above the name.
+		b.nameH += float64(len(stereotypes)) * (float64(stereotypeFontPx) + 4)
 	}
-	for _, m := range members {
-		switch mem := m.(type) {
-		case *ast.Field:
-			text := formatField(mem)
-			b.fields = append(b.fields, memberLine{
-				visibility: mem.Visibility,
-				modifier:   mem.Modifier,
-				text:       text,
-			})
-		case *ast.Method:
-			text := formatMethod(mem)
-			b.methods = append(b.methods, memberLine{
-				visibility: mem.Visibility,
-				modifier:   mem.Modifier,
-				text:       text,
-			})
-		}
-	}
-	if len(b.fields) > 0 {
-		b.fieldsH = float64(len(b.fields))*lineH + padding
-		for _, f := range b.fields {
-			sz, _ := font.MeasureText(f.text, fontSize, font.FamilySans)
-			w := sz.Width + visibilityWidth + 2*padding
-			if w > maxW {
-				maxW = w
+	if slices.ContainsFunc(members, isSeparator) {
+		// Separators divide the body into sections of their own, so the
+		// members keep their source order in a single compartment.
+		for _, m := range members {
+			switch mem := m.(type) {
+			case *ast.Field:
+				if !r.hidden.fields {
+					b.fields = append(b.fields, memberLine{visibility: mem.Visibility, modifier: mem.Modifier, text: formatField(mem)})
+				}
+			case *ast.Method:
+				if !r.hidden.methods {
+					b.fields = append(b.fields, memberLine{visibility: mem.Visibility, modifier: mem.Modifier, text: formatMethod(mem)})
+				}
+			case *ast.Separator:
+				b.fields = append(b.fields, memberLine{text: mem.Label, separator: mem})
 			}
 		}
+		b.showFields = true
+	} else {
+		for _, m := range members {
+			switch mem := m.(type) {
+			case *ast.Field:
+				b.fields = append(b.fields, memberLine{visibility: mem.Visibility, modifier: mem.Modifier, text: formatField(mem)})
+			case *ast.Method:
+				b.methods = append(b.methods, memberLine{visibility: mem.Visibility, modifier: mem.Modifier, text: formatMethod(mem)})
+			}
+		}
+		b.showFields = !r.hidden.fields && (len(b.fields) > 0 || !r.hidden.emptyMembers)
+		// Objects have no operations, so they never get a methods compartment.
+		b.showMethods = b.kind != "object" && !r.hidden.methods && (len(b.methods) > 0 || !r.hidden.emptyMembers)
+	}
+	memberW := 0.0
+	if b.showFields {
+		for _, f := range b.fields {
+			sz, _ := font.MeasureText(f.label(), fontSize, r.face.regular)
+			memberW = math.Max(memberW, sz.Width+visibilityWidth+2*padding)
+		}
 	}
-	if len(b.methods) > 0 {
-		b.methodsH = float64(len(b.methods))*lineH + padding
+	if b.showMethods {
 		for _, m := range b.methods {
-			sz, _ := font.MeasureText(m.text, fontSize, font.FamilySans)
-			w := sz.Width + visibilityWidth + 2*padding
-			if w > maxW {
-				maxW = w
-			}
+			sz, _ := font.MeasureText(m.label(), fontSize, r.face.regular)
+			memberW = math.Max(memberW, sz.Width+visibilityWidth+2*padding)
 		}
 	}
-	b.width = math.Max(maxW, 100)
+	b.sizeMembers(maxW, memberW, lineH, padding)
+	// A box taller than ClassMaxHeight splits its members into two columns,
+	// each as wide as the widest member.
+	maxHeight := r.resolver.ResolveInt("ClassMaxHeight", 0)
+	if maxHeight > 0 && b.height > float64(maxHeight) && max(len(b.fields), len(b.methods)) > 1 {
+		b.columns = 2
+		b.sizeMembers(maxW, 2*memberW, lineH, padding)
+	}
+}
+
+// isSeparator reports whether m is a separator line in a class body.
+func isSeparator(m ast.Member) bool {
+	_, ok := m.(*ast.Separator)
+	return ok
+}
+
+// sizeMembers sets the compartment heights and the size of the box for its
+// columns, given the widths the name and the members need.
+func (b *classBox) sizeMembers(nameW, membersW, lineH, padding float64) {
+	b.width = math.Max(math.Max(nameW, membersW), 100)
 	b.height = b.nameH
-	if len(b.fields) > 0 {
+	if b.showFields {
+		b.fieldsH = float64(b.memberRows(len(b.fields)))*lineH + padding
 		b.height += b.fieldsH + compartmentGap
 	}
-	if len(b.methods) > 0 {
+	if b.showMethods {
+		b.methodsH = float64(b.memberRows(len(b.methods)))*lineH + padding
 		b.height += b.methodsH + compartmentGap
 	}
 }
 
 func (r *ClassRenderer) measureNote(note *ast.Note, fontSize, padding float64) *noteBox {
 	isLeft := note.Placement == ast.NoteLeft
-	sz, _ := font.MeasureText(note.Text, fontSize, font.FamilySans)
+	maxWidth := float64(r.resolver.ResolveInt("NoteMaxWidth", 200))
+	lines := wrapText(note.Text, maxWidth, fontSize)
+	maxW := 0.0
+	for _, line := range lines {
+		sz, _ := font.MeasureText(line, fontSize, font.FamilySans)
+		maxW = math.Max(maxW, sz.Width)
+	}
 	nb := &noteBox{
 		target: note.Target,
-		text:   note.Text,
+		text:   strings.Join(lines, "\n"),
 		left:   isLeft,
-		width:  sz.Width + 2*padding + 10,
-		height: sz.Height + 2*padding,
+		width:  maxW + 2*padding + 10,
+		height: float64(len(lines))*(fontSize+4) + 2*padding,
 	}
 	if nb.width < 80 {
 		nb.width = 80
@@ -439,94 +860,205 @@ func (r *ClassRenderer) computePackageBounds(pb *packageBox, nodeByID map[string
 	pb.h = (maxY - minY) + 2*padding + tabH
 }
 
-func (r *ClassRenderer) renderClassBox(sb *strings.Builder, b *classBox, x, y, fontSize, padding float64) {
-	bgColor := r.resolver.ResolveColor("ClassBackgroundColor")
+// renderClassBox draws a class, interface or enum box. A background given
+// as a gradient, such as "#White|#LightBlue", is added to defs and filled
+// from top to bottom.
+func (r *ClassRenderer) renderClassBox(sb *strings.Builder, defs *defsBuilder, b *classBox, x, y, fontSize, padding float64) {
+	bgProperty := "ClassBackgroundColor"
 	borderColor := r.resolver.ResolveColor("ClassBorderColor")
 	fontColor := r.resolver.ResolveColor("ClassFontColor")
 	borderW := r.resolver.ResolveInt("BorderWidth", 1)
 	switch b.kind {
-	case "interface":
-		bgColor = r.resolver.ResolveColor("InterfaceBackgroundColor")
+	case "interface", "annotation":
+		bgProperty = "InterfaceBackgroundColor"
 		borderColor = r.resolver.ResolveColor("InterfaceBorderColor")
 		fontColor = r.resolver.ResolveColor("InterfaceFontColor")
 	case "enum":
-		bgColor = r.resolver.ResolveColor("EnumBackgroundColor")
+		bgProperty = "EnumBackgroundColor"
 		borderColor = r.resolver.ResolveColor("EnumBorderColor")
 		fontColor = r.resolver.ResolveColor("EnumFontColor")
 	}
-	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%d" ry="%d" fill="%s" stroke="%s" stroke-width="%d"/>`,
-		x, y, b.width, b.height, cornerRadius, cornerRadius, bgColor, borderColor, borderW)
+	bgColor := r.resolver.ResolveColor(bgProperty)
+	if top, bottom, ok := r.resolver.ResolveGradient(bgProperty); ok {
+		bgColor = fmt.Sprintf("url(#%s)", defs.gradient(top, bottom))
+	}
+	if b.bgColor != "" {
+		bgColor = svgColor(b.bgColor)
+	}
+	if end := openTitledGroup(sb, b.name, r.Accessible); end != "" {
+		sb.WriteString("\n")
+		defer sb.WriteString(end + "\n")
+	}
+	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%d" ry="%d" fill="%s" stroke="%s" stroke-width="%d"%s/>`,
+		x, y, b.width, b.height, cornerRadius, cornerRadius, bgColor, borderColor, borderW,
+		defs.shadowAttr(r.resolver.ResolveBool("Shadowing", false)))
 	sb.WriteString("\n")
 	lineH := fontSize + 4
 	nameY := y + padding
+	if b.spotLetter != "" {
+		r.renderSpot(sb, b, x+padding+spotRadius, y+b.nameH/2, borderColor)
+	}
 	stereotypeColor := r.resolver.ResolveColor("ClassStereotypeFontColor")
 	switch {
-	case b.kind == "interface":
-		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s" font-style="italic">&lt;&lt;interface&gt;&gt;</text>`,
-			x+b.width/2, nameY+float64(stereotypeFontPx), stereotypeFontPx, stereotypeColor)
-		sb.WriteString("\n")
-		nameY += float64(stereotypeFontPx) + 4
-	case b.kind == "enum":
-		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s" font-style="italic">&lt;&lt;enum&gt;&gt;</text>`,
-			x+b.width/2, nameY+float64(stereotypeFontPx), stereotypeFontPx, stereotypeColor)
-		sb.WriteString("\n")
-		nameY += float64(stereotypeFontPx) + 4
-	case b.stereotype != "":
-		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s" font-style="italic">&lt;&lt;%s&gt;&gt;</text>`,
-			x+b.width/2, nameY+float64(stereotypeFontPx), stereotypeFontPx, stereotypeColor, escapeXML(b.stereotype))
+	case kindLabels[b.kind]:
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="%s" font-size="%d" fill="%s" font-style="italic">&lt;&lt;%s&gt;&gt;</text>`,
+			x+b.width/2, nameY+float64(stereotypeFontPx), r.face.css, stereotypeFontPx, stereotypeColor, b.kind)
 		sb.WriteString("\n")
 		nameY += float64(stereotypeFontPx) + 4
+	default:
+		for _, stereotype := range r.hidden.shownStereotypes(b) {
+			fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="%s" font-size="%d" fill="%s" font-style="italic">&lt;&lt;%s&gt;&gt;</text>`,
+				x+b.width/2, nameY+float64(stereotypeFontPx), r.face.css, stereotypeFontPx, stereotypeColor, escapeXML(stereotype))
+			sb.WriteString("\n")
+			nameY += float64(stereotypeFontPx) + 4
+		}
 	}
 	fontStyle := ""
 	if b.abstract {
 		fontStyle = ` font-style="italic"`
 	}
-	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" font-weight="bold" fill="%s"%s>%s</text>`,
-		x+b.width/2, nameY+fontSize, fontSize, fontColor, fontStyle, escapeXML(b.name))
+	instanceOf := ""
+	if b.instanceOf != "" {
+		instanceOf = fmt.Sprintf(`<tspan font-size="%d" font-weight="normal" font-style="italic" fill="%s"> : %s</tspan>`,
+			stereotypeFontPx, stereotypeColor, escapeXML(b.instanceOf))
+	}
+	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="%s" font-size="%.0f" font-weight="bold" fill="%s"%s>%s%s</text>`,
+		x+b.width/2, nameY+fontSize, r.face.css, fontSize, fontColor, fontStyle, escapeXML(b.name), instanceOf)
 	sb.WriteString("\n")
 	curY := y + b.nameH
-	if len(b.fields) > 0 {
+	if b.showFields {
 		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"/>`,
 			x, curY, x+b.width, curY, borderColor, borderW)
 		sb.WriteString("\n")
-		memberY := curY + padding/2
-		for _, f := range b.fields {
-			r.renderMemberLine(sb, f, x+padding, memberY+lineH-2, fontSize, fontColor)
-			memberY += lineH
-		}
+		r.renderMembers(sb, b, b.fields, x, curY+padding/2+lineH-2, fontSize, padding, fontColor, borderColor)
 		curY += b.fieldsH + compartmentGap
 	}
-	if len(b.methods) > 0 {
+	if b.showMethods {
 		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"/>`,
 			x, curY, x+b.width, curY, borderColor, borderW)
 		sb.WriteString("\n")
-		memberY := curY + padding/2
-		for _, m := range b.methods {
-			r.renderMemberLine(sb, m, x+padding, memberY+lineH-2, fontSize, fontColor)
-			memberY += lineH
+		r.renderMembers(sb, b, b.methods, x, curY+padding/2+lineH-2, fontSize, padding, fontColor, borderColor)
+	}
+}
+
+// renderMembers draws the lines of a compartment of the box at x from the
+// baseline y of its first line down. In a two-column box the second column
+// starts half the box width to the right.
+func (r *ClassRenderer) renderMembers(sb *strings.Builder, b *classBox, lines []memberLine, x, y, fontSize, padding float64, fontColor, lineColor string) {
+	lineH := fontSize + 4
+	rows := b.memberRows(len(lines))
+	colW := b.width / float64(b.columns)
+	for i, ml := range lines {
+		col, row := i/rows, i%rows
+		colX, lineY := x+float64(col)*colW, y+float64(row)*lineH
+		if ml.separator != nil {
+			r.renderSeparator(sb, ml.separator, colX, colX+colW, lineY-lineH/2+4, fontSize, fontColor, lineColor)
+			continue
+		}
+		r.renderMemberLine(sb, ml, colX+padding, lineY, fontSize, fontColor)
+	}
+}
+
+// renderSeparator draws a divider from x1 to x2 at height y, leaving a gap
+// for its label in the middle. A double separator is two lines and a dotted
+// one is dashed finely.
+func (r *ClassRenderer) renderSeparator(sb *strings.Builder, sep *ast.Separator, x1, x2, y, fontSize float64, fontColor, lineColor string) {
+	segments := [][2]float64{{x1, x2}}
+	if sep.Label != "" {
+		sz, _ := font.MeasureText(sep.Label, fontSize, r.face.regular)
+		mid := (x1 + x2) / 2
+		segments = [][2]float64{{x1, mid - sz.Width/2 - 4}, {mid + sz.Width/2 + 4, x2}}
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="%s" font-size="%.0f" fill="%s">%s</text>`,
+			mid, y+fontSize/2-2, r.face.css, fontSize, fontColor, escapeXML(sep.Label))
+		sb.WriteString("\n")
+	}
+	offsets := []float64{0}
+	if sep.Style == ast.SeparatorDouble {
+		offsets = []float64{-1.5, 1.5}
+	}
+	dash := ""
+	if sep.Style == ast.SeparatorDotted {
+		dash = ` stroke-dasharray="1,2"`
+	}
+	for _, seg := range segments {
+		for _, dy := range offsets {
+			fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"%s/>`,
+				seg[0], y+dy, seg[1], y+dy, lineColor, dash)
+			sb.WriteString("\n")
 		}
 	}
 }
 
 func (r *ClassRenderer) renderMemberLine(sb *strings.Builder, ml memberLine, x, y, fontSize float64, fontColor string) {
-	annotationColor := r.resolver.ResolveColor("AnnotationColor")
 	visIcon := visibilityIcon(ml.visibility)
-	visColor := visibilityColor(ml.visibility, annotationColor)
+	visColor := r.visibilityColor(ml.visibility)
 	if visIcon != "" {
-		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
-			x, y, fontSize, visColor, visIcon)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="%s" font-size="%.0f" fill="%s">%s</text>`,
+			x, y, r.face.css, fontSize, visColor, visIcon)
 	}
 	textX := x + visibilityWidth
 	decoration := ""
-	if ml.modifier == ast.ModifierStatic {
+	switch ml.modifier {
+	case ast.ModifierStatic:
 		decoration = ` text-decoration="underline"`
+	case ast.ModifierAbstract:
+		decoration = ` font-style="italic"`
 	}
-	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%.0f" fill="%s"%s>%s</text>`,
-		textX, y, fontSize, fontColor, decoration, escapeXML(ml.text))
+	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="%s" font-size="%.0f" fill="%s"%s>%s</text>`,
+		textX, y, r.face.css, fontSize, fontColor, decoration, escapeXML(ml.label()))
 	sb.WriteString("\n")
 }
 
-func (r *ClassRenderer) renderRelationship(sb *strings.Builder, rel *ast.Relationship, from, to *layout.Node, offsetX, offsetY, fontSize float64) {
+// associationEnds returns the pair of an association class in the direction
+// of the relationship between them, or as written when there is none.
+func associationEnds(a *ast.AssociationClass, rels []*ast.Relationship) (string, string) {
+	for _, rel := range rels {
+		if rel.Left == a.Right && rel.Right == a.Left {
+			return a.Right, a.Left
+		}
+	}
+	return a.Left, a.Right
+}
+
+// clearAssociationLine moves an association class sideways within its layer
+// when the straight edge between from and to would pass through it, since
+// edges are drawn straight rather than through their virtual nodes.
+func clearAssociationLine(from, to, class *layout.Node, opts layout.Options) {
+	gap := opts.NodePadding / 2
+	fromCX, fromCY := from.X+from.Width/2, from.Y+from.Height/2
+	toCX, toCY := to.X+to.Width/2, to.Y+to.Height/2
+	if opts.Direction == layout.LeftRight {
+		if toCX == fromCX {
+			return
+		}
+		lineY := fromCY + (toCY-fromCY)*(class.X+class.Width/2-fromCX)/(toCX-fromCX)
+		if lineY < class.Y-gap || lineY > class.Y+class.Height+gap {
+			return
+		}
+		if class.Y+class.Height/2 >= lineY {
+			class.Y = lineY + gap
+		} else {
+			class.Y = lineY - gap - class.Height
+		}
+		return
+	}
+	if toCY == fromCY {
+		return
+	}
+	lineX := fromCX + (toCX-fromCX)*(class.Y+class.Height/2-fromCY)/(toCY-fromCY)
+	if lineX < class.X-gap || lineX > class.X+class.Width+gap {
+		return
+	}
+	if class.X+class.Width/2 >= lineX {
+		class.X = lineX + gap
+	} else {
+		class.X = lineX - gap - class.Width
+	}
+}
+
+// renderAssociationClass draws a dashed line from the middle of the edge
+// between from and to to the association class box.
+func (r *ClassRenderer) renderAssociationClass(sb *strings.Builder, from, to, class *layout.Node, offsetX, offsetY float64) {
 	arrowColor := r.resolver.ResolveColor("ArrowColor")
 	thickness := r.resolver.ResolveInt("ArrowThickness", 1)
 	fromCX := from.X + from.Width/2 + offsetX
@@ -535,28 +1067,126 @@ func (r *ClassRenderer) renderRelationship(sb *strings.Builder, rel *ast.Relatio
 	toCY := to.Y + to.Height/2 + offsetY
 	fromPt := edgePoint(from.X+offsetX, from.Y+offsetY, from.Width, from.Height, toCX, toCY)
 	toPt := edgePoint(to.X+offsetX, to.Y+offsetY, to.Width, to.Height, fromCX, fromCY)
+	mid := point{(fromPt.x + toPt.x) / 2, (fromPt.y + toPt.y) / 2}
+	classPt := edgePoint(class.X+offsetX, class.Y+offsetY, class.Width, class.Height, mid.x, mid.y)
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d" stroke-dasharray="7,4"/>`,
+		mid.x, mid.y, classPt.x, classPt.y, arrowColor, thickness)
+	sb.WriteString("\n")
+}
+
+// renderRelationship draws the line of rel with its label and cardinalities,
+// and returns the midpoint of the line's middle segment, where the label sits.
+func (r *ClassRenderer) renderRelationship(sb *strings.Builder, rel *ast.Relationship, from, to *layout.Node, bends []layout.Point, offsetX, offsetY, fontSize float64) point {
+	arrowColor := r.resolver.ResolveColor("ArrowColor")
+	thickness := r.resolver.ResolveInt("ArrowThickness", 1)
+	fromRect := rect{from.X + offsetX, from.Y + offsetY, from.Width, from.Height}
+	toRect := rect{to.X + offsetX, to.Y + offsetY, to.Width, to.Height}
+	var via []point
+	for _, b := range bends {
+		via = append(via, point{b.X + offsetX, b.Y + offsetY})
+	}
+	pts := routeEdge(r.routing, fromRect, toRect, via)
 	dashAttr := ""
 	if rel.Type == ast.RelDependency || rel.Type == ast.RelRealization {
 		dashAttr = ` stroke-dasharray="7,4"`
 	}
-	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"%s/>`,
-		fromPt.x, fromPt.y, toPt.x, toPt.y, arrowColor, thickness, dashAttr)
+	switch rel.LineStyle {
+	case ast.LineDashed:
+		dashAttr = ` stroke-dasharray="7,4"`
+	case ast.LineDotted:
+		dashAttr = ` stroke-dasharray="2,3"`
+	case ast.LineBold:
+		thickness *= 2
+	}
+	if len(pts) == 2 {
+		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"%s%s/>`,
+			pts[0].x, pts[0].y, pts[1].x, pts[1].y, arrowColor, thickness, dashAttr, markerAttrs(relationshipMarkers(rel)))
+	} else {
+		fmt.Fprintf(sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%d"%s%s/>`,
+			formatPoints(pts), arrowColor, thickness, dashAttr, markerAttrs(relationshipMarkers(rel)))
+	}
 	sb.WriteString("\n")
-	r.renderArrowHead(sb, rel, fromPt, toPt, arrowColor)
+	// The label sits on the middle segment of the line.
+	seg := (len(pts) - 2) / 2
+	mid := point{(pts[seg].x + pts[seg+1].x) / 2, (pts[seg].y + pts[seg+1].y) / 2}
 	if rel.Label != "" {
+		// A label of several lines grows upwards from the line.
 		arrowFontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
-		labelX := (fromPt.x + toPt.x) / 2
-		labelY := (fromPt.y+toPt.y)/2 - 5
-		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
-			labelX, labelY, arrowFontSize, arrowColor, escapeXML(rel.Label))
-		sb.WriteString("\n")
+		lines := strings.Split(rel.Label, "\n")
+		lineH := float64(arrowFontSize) + 2
+		y := mid.y - 5 - float64(len(lines)-1)*lineH
+		for _, line := range lines {
+			fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+				mid.x, y, arrowFontSize, arrowColor, escapeXML(line))
+			sb.WriteString("\n")
+			y += lineH
+		}
 	}
 	if rel.LeftCard != "" {
-		r.renderCardinality(sb, rel.LeftCard, fromPt, toPt, true, arrowColor)
+		r.renderCardinality(sb, rel.LeftCard, pts[0], pts[1], true, arrowColor)
 	}
 	if rel.RightCard != "" {
-		r.renderCardinality(sb, rel.RightCard, fromPt, toPt, false, arrowColor)
+		r.renderCardinality(sb, rel.RightCard, pts[len(pts)-2], pts[len(pts)-1], false, arrowColor)
+	}
+	return mid
+}
+
+// rect is an axis-aligned box in SVG coordinates.
+type rect struct {
+	x, y, w, h float64
+}
+
+func (b rect) center() point {
+	return point{b.x + b.w/2, b.y + b.h/2}
+}
+
+// routeEdge returns the points of a line from box from to box to. Straight
+// routing joins the box edges directly. Polyline routing passes through via,
+// the bends of a long edge. Ortho routing uses vertical and horizontal
+// segments, leaving and entering the boxes through the sides that face each
+// other and turning halfway between them.
+func routeEdge(routing layout.Routing, from, to rect, via []point) []point {
+	fc, tc := from.center(), to.center()
+	straight := []point{
+		edgePoint(from.x, from.y, from.w, from.h, tc.x, tc.y),
+		edgePoint(to.x, to.y, to.w, to.h, fc.x, fc.y),
 	}
+	switch routing {
+	case layout.RoutingPolyline:
+		if len(via) == 0 {
+			return straight
+		}
+		first, last := via[0], via[len(via)-1]
+		pts := []point{edgePoint(from.x, from.y, from.w, from.h, first.x, first.y)}
+		pts = append(pts, via...)
+		return append(pts, edgePoint(to.x, to.y, to.w, to.h, last.x, last.y))
+	case layout.RoutingOrtho:
+		gapY := max(to.y-(from.y+from.h), from.y-(to.y+to.h))
+		gapX := max(to.x-(from.x+from.w), from.x-(to.x+to.w))
+		switch {
+		case gapY > 0 && gapY >= gapX:
+			start, end := point{fc.x, from.y + from.h}, point{tc.x, to.y}
+			if tc.y < fc.y {
+				start.y, end.y = from.y, to.y+to.h
+			}
+			if start.x == end.x {
+				return []point{start, end}
+			}
+			midY := (start.y + end.y) / 2
+			return []point{start, {start.x, midY}, {end.x, midY}, end}
+		case gapX > 0:
+			start, end := point{from.x + from.w, fc.y}, point{to.x, tc.y}
+			if tc.x < fc.x {
+				start.x, end.x = from.x, to.x+to.w
+			}
+			if start.y == end.y {
+				return []point{start, end}
+			}
+			midX := (start.x + end.x) / 2
+			return []point{start, {midX, start.y}, {midX, end.y}, end}
+		}
+	}
+	return straight
 }
 
 func (r *ClassRenderer) renderCardinality(sb *strings.Builder, card string, from, to point, nearFrom bool, color string) {
@@ -571,74 +1201,196 @@ func (r *ClassRenderer) renderCardinality(sb *strings.Builder, card string, from
 	sb.WriteString("\n")
 }
 
-func (r *ClassRenderer) renderArrowHead(sb *strings.Builder, rel *ast.Relationship, from, to point, color string) {
-	dir := rel.Direction
+// relationshipMarkers returns the marker IDs for the start and end of the
+// line drawn from rel.Left to rel.Right.
+func relationshipMarkers(rel *ast.Relationship) (start, end string) {
+	var head string
 	switch rel.Type {
-	case ast.RelInheritance:
-		if dir == ast.ArrowLeft {
-			drawTriangle(sb, from, to, color, true)
-		} else {
-			drawTriangle(sb, to, from, color, true)
-		}
-	case ast.RelRealization:
-		if dir == ast.ArrowLeft {
-			drawTriangle(sb, from, to, color, true)
-		} else {
-			drawTriangle(sb, to, from, color, true)
-		}
+	case ast.RelInheritance, ast.RelRealization:
+		head = markerTriangleOpen
 	case ast.RelComposition:
-		if dir == ast.ArrowLeft {
-			drawDiamond(sb, from, to, color, true)
-		} else {
-			drawDiamond(sb, to, from, color, true)
-		}
+		head = markerDiamondFilled
 	case ast.RelAggregation:
-		if dir == ast.ArrowLeft {
-			drawDiamond(sb, from, to, color, false)
-		} else {
-			drawDiamond(sb, to, from, color, false)
-		}
+		head = markerDiamondOpen
 	case ast.RelDependency, ast.RelAssociation:
-		if dir == ast.ArrowLeft || dir == ast.ArrowBoth {
-			drawOpenArrow(sb, from, to, color)
+		if rel.Direction == ast.ArrowLeft || rel.Direction == ast.ArrowBoth {
+			start = markerArrowOpen
 		}
-		if dir == ast.ArrowRight || dir == ast.ArrowBoth {
-			drawOpenArrow(sb, to, from, color)
+		if rel.Direction == ast.ArrowRight || rel.Direction == ast.ArrowBoth {
+			end = markerArrowOpen
 		}
+		return start, end
 	}
+	if rel.Direction == ast.ArrowLeft {
+		return head, ""
+	}
+	return "", head
 }
 
+// renderPackage draws a package in the shape "skinparam packageStyle"
+// names, with its name at the top left. Any style it does not know is
+// drawn as a folder.
 func (r *ClassRenderer) renderPackage(sb *strings.Builder, pb *packageBox, offsetX, offsetY, fontSize float64) {
 	x := pb.x + offsetX
 	y := pb.y + offsetY
+	w, h := pb.w, pb.h
 	bgColor := r.resolver.ResolveColor("PackageBackgroundColor")
 	borderColor := r.resolver.ResolveColor("PackageBorderColor")
 	fontColor := r.resolver.ResolveColor("PackageFontColor")
-	tabW := 80.0
-	tabH := 20.0
-	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s"/>`,
-		x, y, tabW, tabH, bgColor, borderColor)
-	sb.WriteString("\n")
-	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" fill-opacity="0.3"/>`,
-		x, y+tabH, pb.w, pb.h-tabH, bgColor, borderColor)
+	const depth = 8.0 // of a node's faces and a database's ellipse
+	labelX, labelY := x+5, y+15
+	switch strings.ToLower(r.resolver.ResolveString("PackageStyle")) {
+	case "frame":
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" fill-opacity="0.3"/>`,
+			x, y, w, h, bgColor, borderColor)
+		sb.WriteString("\n")
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="%s"/>`,
+			x+3, y+3, w-6, h-6, borderColor)
+		labelX = x + 8
+	case "node":
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" fill-opacity="0.3"/>`,
+			x, y+depth, w-depth, h-depth, bgColor, borderColor)
+		sb.WriteString("\n")
+		fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s"/>`,
+			x, y+depth, x+depth, y, x+w, y, x+w-depth, y+depth, bgColor, borderColor)
+		sb.WriteString("\n")
+		fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s"/>`,
+			x+w-depth, y+depth, x+w, y, x+w, y+h-depth, x+w-depth, y+h, bgColor, borderColor)
+		labelY += depth
+	case "database":
+		// The bottom half of an ellipse, drawn as two quarter curves.
+		cx, cy, k := x+w/2, y+h-depth, ellipseKappa
+		fmt.Fprintf(sb, `<path d="M%.1f,%.1f L%.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f C%.1f,%.1f %.1f,%.1f %.1f,%.1f L%.1f,%.1f" fill="%s" stroke="%s" fill-opacity="0.3"/>`,
+			x, y+depth, x, cy,
+			x, cy+k*depth, cx-k*w/2, cy+depth, cx, cy+depth,
+			cx+k*w/2, cy+depth, x+w, cy+k*depth, x+w, cy,
+			x+w, y+depth, bgColor, borderColor)
+		sb.WriteString("\n")
+		fmt.Fprintf(sb, `<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" fill="%s" stroke="%s"/>`,
+			x+w/2, y+depth, w/2, depth, bgColor, borderColor)
+		labelY += 2 * depth
+	case "cloud":
+		fmt.Fprintf(sb, `<path d="%s" fill="%s" stroke="%s" fill-opacity="0.3"/>`,
+			cloudPath(x+depth, y+depth, w-2*depth, h-2*depth), bgColor, borderColor)
+		labelX += depth
+		labelY += depth
+	default:
+		tabW := 80.0
+		tabH := 20.0
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s"/>`,
+			x, y, tabW, tabH, bgColor, borderColor)
+		sb.WriteString("\n")
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" fill-opacity="0.3"/>`,
+			x, y+tabH, w, h-tabH, bgColor, borderColor)
+	}
 	sb.WriteString("\n")
 	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
-		x+5, y+tabH-5, fontSize, fontColor, escapeXML(pb.name))
+		labelX, labelY, fontSize, fontColor, escapeXML(pb.name))
+	sb.WriteString("\n")
+}
+
+// ellipseKappa places the control points of a cubic curve that approximates
+// a quarter of an ellipse.
+const ellipseKappa = 0.5522847498
+
+// cloudPath returns the outline of a cloud around the rectangle at (x, y):
+// its edges are replaced by shallow curves that bulge outwards. Curves are
+// used instead of arcs so that the PNG renderer can draw them.
+func cloudPath(x, y, w, h float64) string {
+	const bump = 40.0 // rough width of one bump
+	corners := []point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}, {x, y}}
+	var d strings.Builder
+	fmt.Fprintf(&d, "M%.1f,%.1f", x, y)
+	for i := range 4 {
+		from, to := corners[i], corners[i+1]
+		length := math.Hypot(to.x-from.x, to.y-from.y)
+		n := max(1, int(math.Round(length/bump)))
+		// Going clockwise, (dy, -dx) points outwards. Pulling both control
+		// points out by a third of the chord gives a shallow bump.
+		lift := length / float64(n) / 3
+		nx, ny := (to.y-from.y)/length*lift, -(to.x-from.x)/length*lift
+		prev := from
+		for j := 1; j <= n; j++ {
+			t := float64(j) / float64(n)
+			next := point{from.x + (to.x-from.x)*t, from.y + (to.y-from.y)*t}
+			fmt.Fprintf(&d, " C%.1f,%.1f %.1f,%.1f %.1f,%.1f", prev.x+nx, prev.y+ny, next.x+nx, next.y+ny, next.x, next.y)
+			prev = next
+		}
+	}
+	d.WriteString(" Z")
+	return d.String()
+}
+
+// renderSpot draws the circled letter of a stereotype spot centred at
+// (cx, cy). A spot without a colour of its own uses the default for its
+// letter.
+func (r *ClassRenderer) renderSpot(sb *strings.Builder, b *classBox, cx, cy float64, borderColor string) {
+	fill := svgColor(b.spotColor)
+	if b.spotColor == "" {
+		fill = defaultSpotColor(b.spotLetter)
+	}
+	fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%d" fill="%s" stroke="%s" stroke-width="1"/>`,
+		cx, cy, spotRadius, fill, borderColor)
+	sb.WriteString("\n")
+	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="%s" font-size="%d" font-weight="bold" fill="#000000">%s</text>`,
+		cx, cy+float64(stereotypeFontPx)*0.35, r.face.css, stereotypeFontPx, escapeXML(b.spotLetter))
 	sb.WriteString("\n")
 }
 
-func (r *ClassRenderer) renderNote(sb *strings.Builder, nb *noteBox, x, y, fontSize float64) {
+// defaultSpotColor returns the colour PlantUML gives the spot of a class,
+// abstract class, interface or enum letter, and a neutral one otherwise.
+func defaultSpotColor(letter string) string {
+	switch letter {
+	case "C":
+		return "#ADD1B2"
+	case "A":
+		return "#A9DCDF"
+	case "I":
+		return "#B4A7E5"
+	case "E":
+		return "#EB937F"
+	}
+	return "#DDDDDD"
+}
+
+// renderLinkNotes draws the text of the notes on rel as a floating label
+// centred above its line at mid, and above its own label if it has one.
+// The lines of the last note end nearest the line.
+func (r *ClassRenderer) renderLinkNotes(sb *strings.Builder, rel *ast.Relationship, notes []*noteBox, mid point) {
+	var lines []string
+	for _, nb := range notes {
+		lines = append(lines, strings.Split(nb.text, "\n")...)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fontColor := r.resolver.ResolveColor("NoteFontColor")
+	fontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
+	lineH := float64(fontSize) + 2
+	y := mid.y - 5 - float64(len(lines)-1)*lineH
+	if rel.Label != "" {
+		y -= float64(strings.Count(rel.Label, "\n")+1) * lineH
+	}
+	for _, line := range lines {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s" font-style="italic">%s</text>`,
+			mid.x, y, fontSize, fontColor, escapeXML(line))
+		sb.WriteString("\n")
+		y += lineH
+	}
+}
+
+func (r *ClassRenderer) renderNote(sb *strings.Builder, defs *defsBuilder, nb *noteBox, x, y, fontSize float64) {
 	bgColor := r.resolver.ResolveColor("NoteBackgroundColor")
 	borderColor := r.resolver.ResolveColor("NoteBorderColor")
 	fontColor := r.resolver.ResolveColor("NoteFontColor")
 	fold := 10.0
-	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s"/>`,
+	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s"%s/>`,
 		x, y,
 		x+nb.width-fold, y,
 		x+nb.width, y+fold,
 		x+nb.width, y+nb.height,
 		x, y+nb.height,
-		bgColor, borderColor)
+		bgColor, borderColor, defs.shadowAttr(r.resolver.ResolveBool("Shadowing", false)))
 	sb.WriteString("\n")
 	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s"/>`,
 		x+nb.width-fold, y,
@@ -689,60 +1441,14 @@ func edgePoint(rx, ry, rw, rh, targetX, targetY float64) point {
 	return point{cx + dx*scale, cy + dy*scale}
 }
 
-func drawTriangle(sb *strings.Builder, tip, from point, color string, filled bool) {
-	size := 12.0
-	angle := math.Atan2(from.y-tip.y, from.x-tip.x)
-	spread := math.Pi / 7
-	x1 := tip.x + size*math.Cos(angle+spread)
-	y1 := tip.y + size*math.Sin(angle+spread)
-	x2 := tip.x + size*math.Cos(angle-spread)
-	y2 := tip.y + size*math.Sin(angle-spread)
-	fill := color
-	if filled {
-		fill = "white"
-	}
-	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s" stroke-width="1"/>`,
-		tip.x, tip.y, x1, y1, x2, y2, fill, color)
-	sb.WriteString("\n")
-}
-
-func drawDiamond(sb *strings.Builder, tip, from point, color string, filled bool) {
-	size := 10.0
-	angle := math.Atan2(from.y-tip.y, from.x-tip.x)
-	spread := math.Pi / 5
-	mx := tip.x + size*math.Cos(angle)
-	my := tip.y + size*math.Sin(angle)
-	x1 := tip.x + size*0.6*math.Cos(angle+spread)
-	y1 := tip.y + size*0.6*math.Sin(angle+spread)
-	x2 := tip.x + size*0.6*math.Cos(angle-spread)
-	y2 := tip.y + size*0.6*math.Sin(angle-spread)
-	fill := color
-	if !filled {
-		fill = "white"
-	}
-	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s" stroke-width="1"/>`,
-		tip.x, tip.y, x1, y1, mx, my, x2, y2, fill, color)
-	sb.WriteString("\n")
-}
-
-func drawOpenArrow(sb *strings.Builder, tip, from point, color string) {
-	size := 10.0
-	angle := math.Atan2(from.y-tip.y, from.x-tip.x)
-	spread := math.Pi / 7
-	x1 := tip.x + size*math.Cos(angle+spread)
-	y1 := tip.y + size*math.Sin(angle+spread)
-	x2 := tip.x + size*math.Cos(angle-spread)
-	y2 := tip.y + size*math.Sin(angle-spread)
-	fmt.Fprintf(sb, `<polyline points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="none" stroke="%s" stroke-width="1"/>`,
-		x1, y1, tip.x, tip.y, x2, y2, color)
-	sb.WriteString("\n")
-}
-
 func formatField(f *ast.Field) string {
 	s := f.Name
 	if f.Type != "" {
 		s += " : " + f.Type
 	}
+	if f.Value != "" {
+		s += " = " + f.Value
+	}
 	return s
 }
 
@@ -769,18 +1475,19 @@ func visibilityIcon(v ast.Visibility) string {
 	}
 }
 
-func visibilityColor(v ast.Visibility, defaultColor string) string {
+// visibilityColor returns the colour of the icon for a member visibility.
+func (r *ClassRenderer) visibilityColor(v ast.Visibility) string {
 	switch v {
 	case ast.VisibilityPublic:
-		return "#6A8759"
+		return r.resolver.ResolveColor("IconPublicColor")
 	case ast.VisibilityPrivate:
-		return "#CC7832"
+		return r.resolver.ResolveColor("IconPrivateColor")
 	case ast.VisibilityProtected:
-		return "#FFC66D"
+		return r.resolver.ResolveColor("IconProtectedColor")
 	case ast.VisibilityPackage:
-		return "#6897BB"
+		return r.resolver.ResolveColor("IconPackageColor")
 	default:
-		return defaultColor
+		return r.resolver.ResolveColor("AnnotationColor")
 	}
 }
 
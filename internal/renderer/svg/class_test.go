@@ -2,11 +2,17 @@ package svg_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/bobcob7/go-uml/internal/ast"
 	"github.com/bobcob7/go-uml/internal/parser"
 	"github.com/bobcob7/go-uml/internal/renderer/svg"
 	"github.com/bobcob7/go-uml/internal/theme"
@@ -16,6 +22,22 @@ import (
 
 const updateGolden = false // set to true to regenerate golden files
 
+// diagramRenderer is implemented by each of the svg renderers.
+type diagramRenderer interface {
+	Render(w io.Writer, diagram *ast.Diagram) error
+}
+
+// renderSVG parses input and returns the SVG r renders for it, failing the
+// test on any parse or render error.
+func renderSVG(t *testing.T, r diagramRenderer, input string) string {
+	t.Helper()
+	diagram, errs := parser.Parse(input)
+	require.Empty(t, errs)
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf, diagram))
+	return buf.String()
+}
+
 func TestClassRenderer(t *testing.T) {
 	t.Parallel()
 	t.Run("EmptyDiagram", func(t *testing.T) {
@@ -61,6 +83,41 @@ func TestClassRenderer(t *testing.T) {
 		assert.Contains(t, out, "Drawable")
 		assert.Contains(t, out, "draw()")
 	})
+	t.Run("Annotation", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nannotation MyAnno {\n+value() : String\n}\nclass Foo\nFoo ..> MyAnno\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, "&lt;&lt;annotation&gt;&gt;")
+		assert.Contains(t, out, ">@MyAnno</text>")
+		assert.Contains(t, out, "value() : String")
+		// The relationship finds the box by its name, without the "@".
+		assert.Equal(t, 1, strings.Count(out, "MyAnno"))
+		// Annotations share the interface colours.
+		diagram, errs = parser.Parse("@startuml\nskinparam InterfaceBackgroundColor #ABCDEF\n" + input[len("@startuml\n"):])
+		require.Empty(t, errs)
+		buf.Reset()
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		assert.Contains(t, buf.String(), `fill="#ABCDEF"`)
+	})
+	t.Run("StereotypeSpot", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Foo <<(S,#FF7700) Service>>\nclass Bar <<plain>>\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Equal(t, 1, strings.Count(out, "<circle"), "only Foo has a spot")
+		assert.Contains(t, out, `fill="#FF7700"`)
+		assert.Contains(t, out, `fill="#000000">S</text>`)
+		assert.Contains(t, out, "&lt;&lt;Service&gt;&gt;")
+		assert.Contains(t, out, "&lt;&lt;plain&gt;&gt;")
+		assert.NotContains(t, out, "(S")
+	})
 	t.Run("EnumWithValues", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nenum Color {\nRED\nGREEN\nBLUE\n}\n@enduml"
@@ -74,6 +131,16 @@ func TestClassRenderer(t *testing.T) {
 		assert.Contains(t, out, "&lt;&lt;enum&gt;&gt;")
 		assert.Contains(t, out, "Color")
 	})
+	t.Run("EnumWithExplicitValues", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nenum Color {\nRED = 0\nGREEN = 1\n}\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">RED = 0</text>")
+		assert.Contains(t, out, ">GREEN = 1</text>")
+	})
 	t.Run("AbstractClass", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nabstract class Shape {\n+area() : double\n}\n@enduml"
@@ -99,8 +166,10 @@ func TestClassRenderer(t *testing.T) {
 		out := buf.String()
 		assert.Contains(t, out, "Animal")
 		assert.Contains(t, out, "Dog")
-		assert.Contains(t, out, "<line")
-		assert.Contains(t, out, "<polygon")
+		assert.Contains(t, out, "<defs")
+		assert.Contains(t, out, `<marker id="marker-triangle-open"`)
+		assert.Contains(t, out, `marker-end="url(#marker-triangle-open)"`)
+		assert.NotContains(t, out, "<polygon")
 	})
 	t.Run("RelationshipWithLabel", func(t *testing.T) {
 		t.Parallel()
@@ -128,6 +197,26 @@ func TestClassRenderer(t *testing.T) {
 		assert.Contains(t, out, ">*<")
 		assert.Contains(t, out, "has")
 	})
+	t.Run("LeftArrowUsesMarkerStart", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass A\nclass B\nA <-- B\nA <|-- B\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, `marker-start="url(#marker-arrow-open)"`)
+		assert.Contains(t, out, `marker-start="url(#marker-triangle-open)"`)
+		assert.NotContains(t, out, "marker-end")
+	})
+	t.Run("NoDefsWithoutRelationships", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nclass A\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		assert.NotContains(t, buf.String(), "<defs")
+	})
 	t.Run("DashedRelationships", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nclass A\nclass B\nA ..> B\n@enduml"
@@ -154,6 +243,80 @@ func TestClassRenderer(t *testing.T) {
 		assert.Contains(t, out, "<polygon")
 		assert.Contains(t, out, `stroke-dasharray="5,5"`)
 	})
+	t.Run("MultiLineRelationshipLabel", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nclass A\nclass B\nA --> B : has\\nmany\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">has</text>")
+		assert.Contains(t, out, ">many</text>")
+	})
+	t.Run("NoteOnLink", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass A\nclass B\nclass C\nA --> B : uses\nnote on link : to B\nA --> C\nnote on link : to C\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		position := func(text string) (x, y float64) {
+			m := regexp.MustCompile(`<text x="([0-9.]+)" y="([0-9.]+)"[^>]*>` + text + `</text>`).FindStringSubmatch(out)
+			require.NotNil(t, m, text)
+			x, _ = strconv.ParseFloat(m[1], 64)
+			y, _ = strconv.ParseFloat(m[2], 64)
+			return x, y
+		}
+		labelX, labelY := position("uses")
+		toBX, toBY := position("to B")
+		toCX, _ := position("to C")
+		// Each note is centred on its own line, above the line's label.
+		assert.InDelta(t, labelX, toBX, 0.01)
+		assert.Less(t, toBY, labelY)
+		assert.NotEqual(t, toBX, toCX)
+		assert.NotContains(t, out, `stroke-dasharray="5,5"`, "no connector is drawn to a class")
+	})
+	t.Run("MultiLineNote", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Foo\nnote left of Foo\nFirst line\nSecond line\nend note\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, ">First line</text>")
+		assert.Contains(t, out, ">Second line</text>")
+	})
+	t.Run("LongNoteWraps", func(t *testing.T) {
+		t.Parallel()
+		long := "this note keeps going well past the maximum note width so it has to wrap"
+		input := "@startuml\nclass Foo\nnote left of Foo : " + long + "\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.NotContains(t, out, long)
+		assert.Contains(t, out, ">this note")
+		assert.Contains(t, out, "to wrap</text>")
+	})
+	t.Run("WrapWidthSkinparam", func(t *testing.T) {
+		t.Parallel()
+		long := "this note keeps going well past the maximum note width so it has to wrap"
+		input := "@startuml\nskinparam wrapWidth 1000\nclass Foo\nnote left of Foo : " + long + "\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), ">"+long+"</text>")
+	})
 	t.Run("Package", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\npackage com.example {\nclass Foo\nclass Bar\n}\n@enduml"
@@ -168,6 +331,38 @@ func TestClassRenderer(t *testing.T) {
 		assert.Contains(t, out, "Foo")
 		assert.Contains(t, out, "Bar")
 	})
+	t.Run("PackageStyles", func(t *testing.T) {
+		t.Parallel()
+		render := func(style string) string {
+			input := "@startuml\nskinparam packageStyle " + style + "\npackage com.example {\nclass Foo\n}\n@enduml"
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		folderTab := regexp.MustCompile(`<rect [^>]*width="80.0" height="20.0"`)
+		tests := []struct {
+			style string
+			shape *regexp.Regexp
+		}{
+			{"folder", folderTab},
+			{"Folder", folderTab},
+			{"unknown", folderTab},
+			{"frame", regexp.MustCompile(`<rect [^>]*fill="none"`)},
+			{"node", regexp.MustCompile(`<polygon `)},
+			{"database", regexp.MustCompile(`<ellipse `)},
+			{"cloud", regexp.MustCompile(`<path d="M[0-9.,]+( C[0-9.,]+ [0-9.,]+ [0-9.,]+)+ Z"`)},
+		}
+		for _, tt := range tests {
+			out := render(tt.style)
+			assert.Contains(t, out, ">com.example</text>", tt.style)
+			assert.Regexp(t, tt.shape, out, tt.style)
+			if tt.shape != folderTab {
+				assert.NotRegexp(t, folderTab, out, tt.style)
+			}
+		}
+	})
 	t.Run("AllVisibilityIcons", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nclass V {\n+pub : int\n-priv : int\n#prot : int\n~pkg : int\n}\n@enduml"
@@ -195,6 +390,18 @@ func TestClassRenderer(t *testing.T) {
 		out := buf.String()
 		assert.Contains(t, out, `text-decoration="underline"`)
 	})
+	t.Run("AbstractAndReadOnlyModifiers", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass S {\n{readOnly} id : int\n{abstract} area() : double\nname : String\n}\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, `font-style="italic">area() : double</text>`)
+		assert.Contains(t, out, ">\U0001F512 id : int</text>")
+		assert.Contains(t, out, ">name : String</text>", "plain members are unchanged")
+	})
 	t.Run("DarculaThemeColors", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nclass Foo\n@enduml"
@@ -211,8 +418,6 @@ func TestClassRenderer(t *testing.T) {
 	})
 	t.Run("SkinparamOverride", func(t *testing.T) {
 		t.Parallel()
-		// Note: parser tokenizes "#FF0000" as "# FF0000", so the skinparam
-		// value includes the space. We verify the override takes effect.
 		input := "@startuml\nskinparam backgroundColor #FF0000\nclass Foo\n@enduml"
 		diagram, errs := parser.Parse(input)
 		require.Empty(t, errs)
@@ -223,6 +428,24 @@ func TestClassRenderer(t *testing.T) {
 		out := buf.String()
 		// The skinparam value overrides the default Darcula background.
 		assert.NotContains(t, out, `fill="#2B2B2B"`)
+		assert.Contains(t, out, `fill="#FF0000"`)
+	})
+	t.Run("Shadowing", func(t *testing.T) {
+		t.Parallel()
+		render := func(skinparam string) string {
+			diagram, errs := parser.Parse("@startuml\n" + skinparam + "\nclass Foo\nnote right of Foo : hi\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		on := render("skinparam shadowing true")
+		assert.Equal(t, 1, strings.Count(on, `<filter id="shadow"`), "the filter is defined once")
+		assert.Equal(t, 2, strings.Count(on, `filter="url(#shadow)"`), "class box and note")
+		for _, skinparam := range []string{"", "skinparam shadowing false"} {
+			off := render(skinparam)
+			assert.NotContains(t, off, "filter", skinparam)
+		}
 	})
 	t.Run("NilResolverUsesDarcula", func(t *testing.T) {
 		t.Parallel()
@@ -249,6 +472,33 @@ func TestClassRenderer(t *testing.T) {
 		require.NoError(t, err)
 		assert.Contains(t, buf.String(), "#AABBCC")
 	})
+	t.Run("SharedResolver", func(t *testing.T) {
+		t.Parallel()
+		base := theme.NewResolver(nil)
+		classDiagram, errs := parser.Parse("@startuml\nskinparam classBackgroundColor #ABCDEF\nclass Foo\n@enduml")
+		require.Empty(t, errs)
+		seqDiagram, errs := parser.Parse("@startuml\nskinparam backgroundColor #FEDCBA\nAlice -> Bob\n@enduml")
+		require.Empty(t, errs)
+		var wg sync.WaitGroup
+		outs := make([]string, 100)
+		for i := range outs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var buf bytes.Buffer
+				if i%2 == 0 {
+					assert.NoError(t, svg.NewClassRenderer(base).Render(&buf, classDiagram))
+				} else {
+					assert.NoError(t, svg.NewSequenceRenderer(base).Render(&buf, seqDiagram))
+				}
+				outs[i] = buf.String()
+			}()
+		}
+		wg.Wait()
+		assert.Contains(t, outs[0], "#ABCDEF")
+		assert.Contains(t, outs[1], "#FEDCBA")
+		assert.Equal(t, theme.Darcula().BackgroundColor, base.ResolveColor("BackgroundColor"), "diagram skinparams must not reach the shared resolver")
+	})
 	t.Run("XMLEscaping", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nclass Foo\nnote left of Foo : Use <T> & \"quotes\"\n@enduml"
@@ -285,7 +535,7 @@ func TestClassRenderer(t *testing.T) {
 		err := r.Render(&buf, diagram)
 		require.NoError(t, err)
 		out := buf.String()
-		assert.Contains(t, out, "<polygon")
+		assert.Contains(t, out, `marker-end="url(#marker-diamond-filled)"`)
 	})
 	t.Run("AggregationDiamond", func(t *testing.T) {
 		t.Parallel()
@@ -297,8 +547,610 @@ func TestClassRenderer(t *testing.T) {
 		err := r.Render(&buf, diagram)
 		require.NoError(t, err)
 		out := buf.String()
-		assert.Contains(t, out, "<polygon")
-		assert.Contains(t, out, `fill="white"`)
+		assert.Contains(t, out, `marker-end="url(#marker-diamond-open)"`)
+	})
+	t.Run("DiamondAtOwner", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow, want string
+		}{
+			{"*--", `marker-start="url(#marker-diamond-filled)"`},
+			{"--*", `marker-end="url(#marker-diamond-filled)"`},
+			{"o--", `marker-start="url(#marker-diamond-open)"`},
+			{"--o", `marker-end="url(#marker-diamond-open)"`},
+		}
+		for _, tt := range tests {
+			diagram, errs := parser.Parse("@startuml\nclass A\nclass B\nA " + tt.arrow + " B\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			assert.Contains(t, buf.String(), tt.want, tt.arrow)
+		}
+	})
+	t.Run("InlineLineStyle", func(t *testing.T) {
+		t.Parallel()
+		line := func(arrow string) string {
+			diagram, errs := parser.Parse("@startuml\nclass A\nclass B\nA " + arrow + " B\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return regexp.MustCompile(`<line [^>]*marker-end[^>]*>`).FindString(buf.String())
+		}
+		assert.NotContains(t, line("-->"), "stroke-dasharray")
+		assert.Contains(t, line("..>"), `stroke-dasharray="7,4"`)
+		assert.Contains(t, line("-[dashed]->"), `stroke-dasharray="7,4"`)
+		assert.Contains(t, line("-[dotted]->"), `stroke-dasharray="2,3"`)
+		assert.Contains(t, line("..[dotted]..>"), `stroke-dasharray="2,3"`)
+		bold := line("-[bold]->")
+		assert.Contains(t, bold, `stroke-width="2"`)
+		assert.NotContains(t, bold, "stroke-dasharray")
+	})
+	t.Run("LeftToRightDirection", func(t *testing.T) {
+		t.Parallel()
+		dims := func(input string) (int, int) {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			var w, h int
+			_, err := fmt.Sscanf(buf.String(), `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d"`, &w, &h)
+			require.NoError(t, err)
+			return w, h
+		}
+		tbW, tbH := dims("@startuml\nA --> B\nB --> C\n@enduml")
+		lrW, lrH := dims("@startuml\nleft to right direction\nA --> B\nB --> C\n@enduml")
+		assert.Less(t, tbW, tbH)
+		assert.Greater(t, lrW, lrH)
+	})
+	t.Run("TitleHeaderFooter", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string) (string, int, int) {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			var w, h int
+			_, err := fmt.Sscanf(buf.String(), `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d"`, &w, &h)
+			require.NoError(t, err)
+			return buf.String(), w, h
+		}
+		_, plainW, plainH := render("@startuml\nclass Foo\n@enduml")
+		out, w, h := render("@startuml\ntitle My Big System Overview\nheader Draft\nfooter Page one\nclass Foo\n@enduml")
+		assert.Contains(t, out, `font-weight="bold" fill="#A9B7C6">My Big System Overview</text>`)
+		assert.Contains(t, out, `text-anchor="end"`)
+		assert.Contains(t, out, ">Draft</text>")
+		assert.Contains(t, out, ">Page one</text>")
+		assert.Greater(t, h, plainH, "title, header and footer reserve vertical space")
+		assert.Greater(t, w, plainW, "a long title widens the diagram")
+	})
+	t.Run("Responsive", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string, responsive bool) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			r := svg.NewClassRenderer(nil)
+			r.Responsive = responsive
+			var buf bytes.Buffer
+			require.NoError(t, r.Render(&buf, diagram))
+			out := buf.String()
+			return out[:strings.Index(out, ">")]
+		}
+		fixed := render("@startuml\nclass Foo\n@enduml", false)
+		assert.Contains(t, fixed, ` width="`)
+		assert.Contains(t, fixed, ` height="`)
+		assert.Contains(t, render("@startuml\n@enduml", false), ` width="100"`)
+		for _, tag := range []string{
+			render("@startuml\nclass Foo\n@enduml", true),
+			render("@startuml\nskinparam responsiveSVG true\nclass Foo\n@enduml", false),
+			render("@startuml\n@enduml", true),
+			render("@startuml\nskinparam responsiveSVG true\n@enduml", false),
+		} {
+			assert.NotContains(t, tag, ` width="`)
+			assert.NotContains(t, tag, ` height="`)
+			assert.Contains(t, tag, `viewBox="0 0 `)
+		}
+	})
+	t.Run("ClassMaxHeight", func(t *testing.T) {
+		t.Parallel()
+		var members strings.Builder
+		for i := range 10 {
+			fmt.Fprintf(&members, "  field%d : int\n", i)
+		}
+		members.WriteString("  run()\n")
+		body := "class Big {\n" + members.String() + "}\n@enduml"
+		render := func(input string) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		number := func(s string) float64 {
+			f, err := strconv.ParseFloat(s, 64)
+			require.NoError(t, err)
+			return f
+		}
+		boxRect := regexp.MustCompile(`<rect x="[0-9.]+" y="[0-9.]+" width="([0-9.]+)" height="([0-9.]+)" rx="8"`)
+		boxSize := func(out string) (w, h float64) {
+			m := boxRect.FindStringSubmatch(out)
+			require.NotNil(t, m)
+			return number(m[1]), number(m[2])
+		}
+		textPos := func(out, text string) (x, y float64) {
+			m := regexp.MustCompile(`<text x="([0-9.]+)" y="([0-9.]+)"[^>]*>` + regexp.QuoteMeta(text) + `<`).FindStringSubmatch(out)
+			require.NotNil(t, m, text)
+			return number(m[1]), number(m[2])
+		}
+		single := render("@startuml\n" + body)
+		split := render("@startuml\nskinparam ClassMaxHeight 150\n" + body)
+		singleW, singleH := boxSize(single)
+		splitW, splitH := boxSize(split)
+		assert.Less(t, splitH, singleH)
+		assert.Greater(t, splitW, singleW)
+		// field0 to field4 fill the first column and field5 to field9 the
+		// second, level with them.
+		x0, y0 := textPos(split, "field0 : int")
+		x5, y5 := textPos(split, "field5 : int")
+		assert.InDelta(t, splitW/2, x5-x0, 0.1)
+		assert.Equal(t, y0, y5)
+		// The lone method stays in the first column.
+		xm, _ := textPos(split, "run()")
+		assert.Equal(t, x0, xm)
+		// A box within the limit keeps one column.
+		assert.Equal(t, single, render("@startuml\nskinparam ClassMaxHeight 1000\n"+body))
+	})
+	t.Run("Separators", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nclass Foo {\n  id : int\n  .. Getters ..\n  getId() : int\n  ==\n  run()\n}\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		// The label sits between the two halves of the dotted line.
+		assert.Contains(t, out, `text-anchor="middle" font-family="sans-serif" font-size="13" fill="#A9B7C6">Getters<`)
+		assert.NotContains(t, out, ">.. Getters ..<")
+		assert.Equal(t, 2, strings.Count(out, `stroke-dasharray="1,2"`))
+		// One compartment line, since the members share a compartment, two
+		// halves of the dotted line and the two lines of the double separator.
+		assert.Equal(t, 5, strings.Count(out, "<line"))
+		// The separators go above the methods they precede.
+		getters := strings.Index(out, ">Getters<")
+		assert.Less(t, strings.Index(out, ">id : int<"), getters)
+		assert.Less(t, getters, strings.Index(out, ">getId() : int<"))
+	})
+	t.Run("SolidAndDoubleSeparators", func(t *testing.T) {
+		t.Parallel()
+		render := func(sep string) string {
+			diagram, errs := parser.Parse("@startuml\nclass Foo {\n  id : int\n  " + sep + "\n  name : string\n}\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		// One compartment line plus one line for "--" and two for "==".
+		assert.Equal(t, 2, strings.Count(render("--"), "<line"))
+		assert.Equal(t, 3, strings.Count(render("=="), "<line"))
+	})
+	t.Run("SeparatorsKeepSourceOrder", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewClassRenderer(nil), "@startuml\nclass Shape {\n  +area() : double\n  -- Data --\n  -id : int\n}\n@enduml")
+		textY := func(text string) float64 {
+			t.Helper()
+			for _, line := range strings.Split(out, "\n") {
+				if strings.Contains(line, ">"+text+"<") {
+					i := strings.Index(line, ` y="`)
+					require.GreaterOrEqual(t, i, 0, line)
+					var y float64
+					_, err := fmt.Sscanf(line[i:], ` y="%f"`, &y)
+					require.NoError(t, err)
+					return y
+				}
+			}
+			t.Fatalf("text %q not found", text)
+			return 0
+		}
+		// The method stays above the field, with the separator between them.
+		assert.Less(t, textY("area() : double"), textY("Data"))
+		assert.Less(t, textY("Data"), textY("id : int"))
+	})
+	t.Run("LineType", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string, r *svg.ClassRenderer) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, r.Render(&buf, diagram))
+			return buf.String()
+		}
+		const body = "class A\nclass B\nclass C\nclass D\nA --> B\nB --> C\nA --> C\nA --> D\n@enduml"
+		out := render("@startuml\n"+body, svg.NewClassRenderer(nil))
+		assert.NotContains(t, out, "<polyline")
+		// Ortho routing bends the lines from A to the boxes beside its axis
+		// and keeps every segment horizontal or vertical.
+		out = render("@startuml\nskinparam linetype ortho\n"+body, svg.NewClassRenderer(nil))
+		assert.Equal(t, 3, strings.Count(out, "<polyline"))
+		for _, line := range strings.Split(out, "\n") {
+			if !strings.HasPrefix(line, "<polyline") {
+				continue
+			}
+			_, rest, _ := strings.Cut(line, `points="`)
+			coords, _, _ := strings.Cut(rest, `"`)
+			pts := strings.Fields(coords)
+			require.Len(t, pts, 4)
+			for i := 1; i < len(pts); i++ {
+				x0, y0, _ := strings.Cut(pts[i-1], ",")
+				x1, y1, _ := strings.Cut(pts[i], ",")
+				assert.True(t, x0 == x1 || y0 == y1, line)
+			}
+			assert.Contains(t, line, `marker-end="url(#marker-arrow-open)"`)
+		}
+		// Polyline routing runs the long edge from A to C through its bend.
+		out = render("@startuml\nskinparam linetype polyline\n"+body, svg.NewClassRenderer(nil))
+		assert.Equal(t, 1, strings.Count(out, "<polyline"))
+		// The skinparam may also come from the resolver.
+		resolver := theme.NewResolver(nil)
+		resolver.SetSkinparam("linetype", "ortho")
+		out = render("@startuml\n"+body, svg.NewClassRenderer(resolver))
+		assert.Equal(t, 3, strings.Count(out, "<polyline"))
+	})
+	t.Run("Accessible", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nclass Foo\ninterface Bar\nFoo ..|> Bar\n@enduml")
+		require.Empty(t, errs)
+		for _, accessible := range []bool{false, true} {
+			r := svg.NewClassRenderer(nil)
+			r.Accessible = accessible
+			var buf bytes.Buffer
+			require.NoError(t, r.Render(&buf, diagram))
+			out := buf.String()
+			if !accessible {
+				assert.NotContains(t, out, "<title>")
+				continue
+			}
+			assert.Contains(t, out, "<g><title>Foo</title>\n<rect")
+			assert.Contains(t, out, "<g><title>Bar</title>\n<rect")
+			assert.Equal(t, strings.Count(out, "<g>"), strings.Count(out, "</g>"))
+		}
+	})
+	t.Run("Transparent", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string, transparent bool) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			r := svg.NewClassRenderer(nil)
+			r.Transparent = transparent
+			var buf bytes.Buffer
+			require.NoError(t, r.Render(&buf, diagram))
+			return buf.String()
+		}
+		for _, input := range []string{"@startuml\nclass Foo\n@enduml", "@startuml\n@enduml"} {
+			assert.Contains(t, render(input, false), `<rect width="`, input)
+			out := render(input, true)
+			assert.NotContains(t, out, `<rect width="`, input)
+			assert.True(t, strings.HasSuffix(out, "</svg>\n"), input)
+		}
+		out := render("@startuml\nskinparam backgroundColor transparent\nclass Foo\n@enduml", false)
+		assert.NotContains(t, out, `<rect width="`)
+		assert.Contains(t, out, ">Foo<", "only the background is dropped")
+	})
+	t.Run("TitlePushesBodyDown", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\ntitle Overview\nclass Foo\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		var titleY, boxY float64
+		for _, line := range strings.Split(out, "\n") {
+			switch {
+			case strings.HasSuffix(line, ">Overview</text>"):
+				_, err := fmt.Sscanf(line[strings.Index(line, ` y="`):], ` y="%f"`, &titleY)
+				require.NoError(t, err)
+			case strings.Contains(line, `rx="8"`) && boxY == 0:
+				_, err := fmt.Sscanf(line[strings.Index(line, ` y="`):], ` y="%f"`, &boxY)
+				require.NoError(t, err)
+			}
+		}
+		require.NotZero(t, titleY)
+		assert.Greater(t, boxY, titleY, "the class box starts below the title baseline")
+	})
+	t.Run("Legend", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string) (string, int, int) {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			var w, h int
+			_, err := fmt.Sscanf(buf.String(), `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d"`, &w, &h)
+			require.NoError(t, err)
+			return buf.String(), w, h
+		}
+		_, _, plainH := render("@startuml\nclass Foo\n@enduml")
+		out, w, h := render("@startuml\nclass Foo\nlegend\nkey\nend legend\n@enduml")
+		assert.Contains(t, out, ">key</text>")
+		assert.Greater(t, h, plainH, "the legend reserves vertical space")
+		x, y, lw, lh := legendRect(t, out, "key")
+		assert.LessOrEqual(t, x+lw, float64(w))
+		assert.LessOrEqual(t, y+lh, float64(h))
+		assert.Greater(t, x, float64(w)/2, "legends default to the right")
+	})
+	t.Run("ClassColor", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{"class Foo #LightBlue", `fill="#ADD8E6"`},
+			{"class Foo #123456", `fill="#123456"`},
+			{"class Foo #red/green", `fill="#FF0000"`},
+			{"class Foo #PapayaWhip", `fill="#FFEFD5"`},
+		}
+		for _, tt := range tests {
+			diagram, errs := parser.Parse("@startuml\n" + tt.input + "\nclass Bar\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			out := buf.String()
+			assert.Contains(t, out, tt.want, tt.input)
+			// Bar keeps the themed background.
+			assert.Contains(t, out, `fill="#3C3F41"`, tt.input)
+		}
+	})
+	t.Run("GradientBackground", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nskinparam classBackgroundColor #White|#LightBlue\nclass Foo\nclass Bar #123456\ninterface Baz\nFoo --> Bar\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		// One <defs> holds the markers and the gradient, ahead of the boxes.
+		assert.Equal(t, 1, strings.Count(out, "<defs"))
+		assert.Equal(t, 1, strings.Count(out, "<linearGradient"))
+		assert.Contains(t, out, `<stop offset="0" stop-color="#FFFFFF"/><stop offset="1" stop-color="#ADD8E6"/>`)
+		assert.Less(t, strings.Index(out, "<linearGradient"), strings.Index(out, "</defs>"))
+		assert.Less(t, strings.Index(out, "</defs>"), strings.Index(out, `fill="url(#gradient-1)"`))
+		assert.Contains(t, out, "marker-arrow-open")
+		// Foo uses the gradient; Bar's own colour and the interface's theme
+		// colour stay flat.
+		assert.Equal(t, 1, strings.Count(out, `fill="url(#gradient-1)"`))
+		assert.Contains(t, out, `fill="#123456"`)
+		assert.Contains(t, out, `fill="#3C3F41"`)
+	})
+	t.Run("GradientWithoutRelationships", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nskinparam classBackgroundColor #FFFFFF|#000000\nclass A\nclass B\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Equal(t, 1, strings.Count(out, "<defs"))
+		assert.NotContains(t, out, "<marker")
+		// Both boxes share the one gradient.
+		assert.Equal(t, 1, strings.Count(out, "<linearGradient"))
+		assert.Equal(t, 2, strings.Count(out, `fill="url(#gradient-1)"`))
+	})
+	t.Run("Generics", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass List<T> {\n+items : Array<T>\n}\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">List&lt;T&gt;</text>")
+		assert.Contains(t, out, "items : Array&lt;T&gt;")
+	})
+	t.Run("AssociationClass", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Student\nclass Course\nStudent -- Course\n(Student, Course) .. Enrollment\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		for _, name := range []string{"Student", "Course", "Enrollment"} {
+			assert.Contains(t, out, ">"+name+"</text>")
+		}
+		assert.Equal(t, 1, strings.Count(out, `stroke-dasharray="7,4"`))
+	})
+	t.Run("Object", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nobject \"o1 : Foo\" {\nid = 1\n}\nobject o2\no1 --> o2\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">o1<tspan")
+		assert.Contains(t, out, "> : Foo</tspan>")
+		assert.Contains(t, out, ">id = 1<")
+		assert.Contains(t, out, ">o2<")
+		assert.Contains(t, out, "<line")
+	})
+	t.Run("HideEmptyMembers", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Empty\nhide empty members\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, "Empty")
+		assert.NotContains(t, out, "<line", "empty class should render as a single compartment")
+	})
+	t.Run("HideMethods", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nhide methods\nclass Foo {\n+name : String\n+run()\n}\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, "name : String")
+		assert.NotContains(t, out, "run()")
+		assert.Equal(t, 1, strings.Count(out, "<line"), "only the fields divider should remain")
+	})
+	t.Run("ShowUndoesHide", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nhide methods\nshow methods\nclass Foo {\n+run()\n}\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "run()")
+	})
+	t.Run("HideStereotype", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nhide <<Internal>>\nclass Foo\nclass Secret <<Internal>>\nFoo --> Secret\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, ">Foo</text>")
+		assert.NotContains(t, out, "Secret")
+		assert.NotContains(t, out, "Internal")
+	})
+	t.Run("HideStereotypes", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		const body = "class Foo <<Service>>\ninterface Bar\n@enduml"
+		out := render("@startuml\nhide stereotypes\n" + body)
+		assert.Contains(t, out, ">Foo</text>")
+		assert.NotContains(t, out, "Service")
+		// The kind label of an interface is not a stereotype.
+		assert.Contains(t, out, "&lt;&lt;interface&gt;&gt;")
+		assert.Contains(t, render("@startuml\nhide stereotype\nshow stereotypes\n"+body), "&lt;&lt;Service&gt;&gt;")
+		// Without the stereotype line the box is shorter.
+		boxHeight := func(out string) float64 {
+			m := regexp.MustCompile(`<rect x="[0-9.]+" y="[0-9.]+" width="[0-9.]+" height="([0-9.]+)" rx="8"`).FindStringSubmatch(out)
+			require.NotNil(t, m)
+			h, err := strconv.ParseFloat(m[1], 64)
+			require.NoError(t, err)
+			return h
+		}
+		plain := render("@startuml\nclass Foo <<Service>>\n@enduml")
+		hidden := render("@startuml\nhide stereotypes\nclass Foo <<Service>>\n@enduml")
+		assert.Less(t, boxHeight(hidden), boxHeight(plain))
+	})
+	t.Run("MultipleStereotypes", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		out := render("@startuml\nclass Foo <<service>> <<entity>>\n@enduml")
+		service := regexp.MustCompile(`y="([0-9.]+)"[^>]*>&lt;&lt;service&gt;&gt;</text>`).FindStringSubmatch(out)
+		entity := regexp.MustCompile(`y="([0-9.]+)"[^>]*>&lt;&lt;entity&gt;&gt;</text>`).FindStringSubmatch(out)
+		require.NotNil(t, service)
+		require.NotNil(t, entity)
+		// Each stereotype has its own line, in the order given.
+		serviceY, _ := strconv.ParseFloat(service[1], 64)
+		entityY, _ := strconv.ParseFloat(entity[1], 64)
+		assert.Less(t, serviceY, entityY)
+		// "hide <<entity>>" matches any of a class's stereotypes.
+		assert.NotContains(t, render("@startuml\nhide <<entity>>\nclass Foo <<service>> <<entity>>\nclass Bar\n@enduml"), ">Foo</text>")
+	})
+	t.Run("HideCircle", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nhide circle\nclass Foo <<(S,#FF7700) Service>>\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.NotContains(t, out, "<circle")
+		assert.NotContains(t, out, ">S</text>")
+		assert.Contains(t, out, "&lt;&lt;Service&gt;&gt;")
+	})
+	t.Run("EmptyCompartmentsShownByDefault", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nclass Empty\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewClassRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Equal(t, 2, strings.Count(out, "<line"))
+	})
+	t.Run("Dividers", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\n== Web ==\nclass Controller\nclass View\nController --> View\n" +
+			"== Domain & Data ==\nclass Order\nController --> Order\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		width := regexp.MustCompile(`<svg [^>]*width="([0-9.]+)"`).FindStringSubmatch(out)
+		require.NotNil(t, width)
+		textY := func(text string) float64 {
+			m := regexp.MustCompile(`<text x="[0-9.]+" y="([0-9.]+)"[^>]*>` + regexp.QuoteMeta(text) + `<`).FindStringSubmatch(out)
+			require.NotNil(t, m, text)
+			y, err := strconv.ParseFloat(m[1], 64)
+			require.NoError(t, err)
+			return y
+		}
+		// Each band spans the diagram, with the classes declared after its
+		// divider below it.
+		bands := regexp.MustCompile(`<rect x="0" y="[0-9.]+" width="([0-9.]+)" height="28.0"`).FindAllStringSubmatch(out, -1)
+		require.Len(t, bands, 2)
+		for _, band := range bands {
+			assert.Equal(t, width[1], strings.TrimSuffix(band[1], ".0"))
+		}
+		assert.Less(t, textY("Web"), textY("Controller"))
+		assert.Less(t, textY("Controller"), textY("View"))
+		assert.Less(t, textY("View"), textY("Domain &amp; Data"))
+		assert.Less(t, textY("Domain &amp; Data"), textY("Order"))
+		assert.Equal(t, 2, strings.Count(out, "marker-end"))
+	})
+	t.Run("MonospacedFont", func(t *testing.T) {
+		t.Parallel()
+		render := func(skinparam string) string {
+			input := "@startuml\n" + skinparam + "\nclass Foo {\n+illicit_filling_list : int\n}\n@enduml"
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewClassRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		rectWidth := regexp.MustCompile(`<rect x="[0-9.]+" y="[0-9.]+" width="([0-9.]+)" height="[0-9.]+" rx="8"`)
+		width := func(out string) float64 {
+			m := rectWidth.FindStringSubmatch(out)
+			require.NotNil(t, m)
+			w, err := strconv.ParseFloat(m[1], 64)
+			require.NoError(t, err)
+			return w
+		}
+		sans := render("")
+		mono := render("skinparam defaultFontName Monospaced")
+		assert.NotContains(t, sans, `font-family="monospace"`)
+		assert.Contains(t, mono, `font-family="monospace" font-size="13" font-weight="bold"`)
+		assert.Contains(t, mono, `font-family="monospace" font-size="13" fill="#A9B7C6">illicit_filling_list : int`)
+		// The narrow letters take a full cell each in a monospaced font.
+		assert.Greater(t, width(mono), width(sans))
+		classOnly := render("skinparam classFontName Courier")
+		assert.Contains(t, classOnly, `font-family="monospace"`)
 	})
 }
 
@@ -388,3 +1240,15 @@ package com.example {
 		assert.Contains(t, out, "This is an animal")
 	})
 }
+
+// legendRect returns the bounds of the last rect drawn before the legend line text.
+func legendRect(t *testing.T, out, text string) (x, y, w, h float64) {
+	t.Helper()
+	i := strings.Index(out, ">"+text+"<")
+	require.GreaterOrEqual(t, i, 0, "legend %q not found", text)
+	start := strings.LastIndex(out[:i], `<rect x="`)
+	require.GreaterOrEqual(t, start, 0)
+	_, err := fmt.Sscanf(out[start:], `<rect x="%f" y="%f" width="%f" height="%f"`, &x, &y, &w, &h)
+	require.NoError(t, err)
+	return x, y, w, h
+}
@@ -0,0 +1,257 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/font"
+	"github.com/bobcob7/go-uml/internal/layout"
+	"github.com/bobcob7/go-uml/internal/theme"
+)
+
+const (
+	compTabWidth        = 10.0
+	compTabHeight       = 6.0
+	compInterfaceRadius = 8.0
+)
+
+// ComponentRenderer renders component diagrams to SVG.
+type ComponentRenderer struct {
+	// Responsive omits the width and height of the SVG so it scales to its
+	// container. The skinparam responsiveSVG also enables it.
+	Responsive bool
+	// Transparent omits the background rect so that the page behind the
+	// SVG shows through. A backgroundColor of "transparent" also enables it.
+	Transparent bool
+	// LayoutDebug, when set, receives a layout.Dump of the positioned
+	// graph, for debugging layouts.
+	LayoutDebug io.Writer
+	resolver    *theme.Resolver
+	drawing
+}
+
+// NewComponentRenderer creates a new component diagram SVG renderer.
+// If resolver is nil, the default Darcula theme is used. resolver is
+// cloned, so it may be shared with other renderers.
+func NewComponentRenderer(resolver *theme.Resolver) *ComponentRenderer {
+	if resolver == nil {
+		resolver = theme.NewResolver(nil)
+	}
+	return &ComponentRenderer{resolver: resolver.Clone()}
+}
+
+// componentBox holds a component or interface placed in the layout graph.
+type componentBox struct {
+	label       string
+	isInterface bool
+}
+
+// Render writes the component diagram SVG to w.
+func (r *ComponentRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	opts := layout.DefaultOptions()
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.LayoutDirection:
+			if s.LeftToRight {
+				opts.Direction = layout.LeftRight
+			} else {
+				opts.Direction = layout.TopDown
+			}
+		case *ast.Skinparam:
+			r.resolver.SetSkinparam(s.Name, s.Value)
+		}
+	}
+	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
+	padding := float64(r.resolver.ResolveInt("Padding", 10))
+	graph := &layout.Graph{}
+	boxes := map[string]*componentBox{}
+	aliases := map[string]string{}
+	add := func(id, label string, isInterface bool) {
+		if _, ok := boxes[id]; ok {
+			return
+		}
+		boxes[id] = &componentBox{label: label, isInterface: isInterface}
+		size, _ := font.MeasureText(label, fontSize, font.FamilySans)
+		var nw, nh float64
+		if isInterface {
+			nw = math.Max(2*compInterfaceRadius, size.Width)
+			nh = 2*compInterfaceRadius + size.Height + 4
+		} else {
+			nw = size.Width + 2*padding + compTabWidth
+			nh = math.Max(size.Height+2*padding, 3*compTabHeight+2*padding)
+		}
+		graph.Nodes = append(graph.Nodes, &layout.Node{ID: id, Width: nw, Height: nh})
+	}
+	var rels []*ast.Relationship
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.Component:
+			id := s.Name
+			if s.Alias != "" {
+				id = s.Alias
+				aliases[s.Alias] = s.Name
+			}
+			add(id, s.Name, s.IsInterface)
+		case *ast.Relationship:
+			rels = append(rels, s)
+		}
+	}
+	resolve := func(name string) string {
+		if _, ok := boxes[name]; ok {
+			return name
+		}
+		for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+			if aliases[alias] == name {
+				return alias
+			}
+		}
+		add(name, name, false)
+		return name
+	}
+	ends := make([][2]string, len(rels))
+	for i, rel := range rels {
+		ends[i] = [2]string{resolve(rel.Left), resolve(rel.Right)}
+		graph.Edges = append(graph.Edges, &layout.Edge{From: ends[i][0], To: ends[i][1], Label: rel.Label})
+	}
+	if len(graph.Nodes) == 0 {
+		return r.renderEmpty(w)
+	}
+	layout.Layout(graph, opts)
+	dumpLayout(r.LayoutDebug, graph)
+	nodeByID := map[string]*layout.Node{}
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, n := range graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		nodeByID[n.ID] = n
+		minX = math.Min(minX, n.X)
+		minY = math.Min(minY, n.Y)
+		maxX = math.Max(maxX, n.X+n.Width)
+		maxY = math.Max(maxY, n.Y+n.Height)
+	}
+	offsetX := -minX + diagramPadding
+	offsetY := -minY + diagramPadding
+	svgW := int(maxX - minX + 2*diagramPadding)
+	svgH := int(maxY - minY + 2*diagramPadding)
+	titles := newTitleBlock(diagram, fontSize)
+	if w := int(math.Ceil(titles.minWidth())); w > svgW {
+		offsetX += float64(w-svgW) / 2
+		svgW = w
+	}
+	offsetY += titles.top()
+	svgH += int(titles.top() + titles.bottom())
+	var sb strings.Builder
+	r.writeSVGOpen(&sb, float64(svgW), float64(svgH), r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+	sb.WriteString("\n")
+	if writeBackground(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("BackgroundColor"), r.Transparent) {
+		sb.WriteString("\n")
+	}
+	if len(rels) > 0 {
+		writeMarkerDefs(&sb, r.resolver.ResolveColor("ArrowColor"))
+	}
+	titles.render(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("FontColor"))
+	for i, rel := range rels {
+		from, to := nodeByID[ends[i][0]], nodeByID[ends[i][1]]
+		if from == nil || to == nil {
+			continue
+		}
+		r.renderConnection(&sb, rel, boxes[ends[i][0]], from, boxes[ends[i][1]], to, offsetX, offsetY)
+	}
+	for _, n := range graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		r.renderBox(&sb, boxes[n.ID], n.X+offsetX, n.Y+offsetY, n.Width, n.Height, fontSize)
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r *ComponentRenderer) renderEmpty(w io.Writer) error {
+	return r.writeEmptySVG(w, r.resolver.ResolveColor("BackgroundColor"), r.Transparent,
+		r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+}
+
+// anchor returns the point where an edge towards (tx, ty) leaves the box.
+// Interfaces attach to the edge of their circle rather than their label.
+func (b *componentBox) anchor(x, y, w, h, tx, ty float64) point {
+	if !b.isInterface {
+		return edgePoint(x, y, w, h, tx, ty)
+	}
+	cx, cy := x+w/2, y+compInterfaceRadius
+	angle := math.Atan2(ty-cy, tx-cx)
+	return point{cx + compInterfaceRadius*math.Cos(angle), cy + compInterfaceRadius*math.Sin(angle)}
+}
+
+func (r *ComponentRenderer) renderConnection(sb *strings.Builder, rel *ast.Relationship, fromBox *componentBox, from *layout.Node, toBox *componentBox, to *layout.Node, offsetX, offsetY float64) {
+	arrowColor := escapeXML(r.resolver.ResolveColor("ArrowColor"))
+	thickness := r.resolver.ResolveInt("ArrowThickness", 1)
+	fx, fy := from.X+offsetX, from.Y+offsetY
+	tx, ty := to.X+offsetX, to.Y+offsetY
+	fromPt := fromBox.anchor(fx, fy, from.Width, from.Height, tx+to.Width/2, ty+to.Height/2)
+	toPt := toBox.anchor(tx, ty, to.Width, to.Height, fx+from.Width/2, fy+from.Height/2)
+	dashAttr := ""
+	if strings.Contains(rel.Arrow, "..") {
+		dashAttr = ` stroke-dasharray="7,4"`
+	}
+	var startMarker, endMarker string
+	if rel.Direction == ast.ArrowLeft || rel.Direction == ast.ArrowBoth {
+		startMarker = markerArrowOpen
+	}
+	if rel.Direction == ast.ArrowRight || rel.Direction == ast.ArrowBoth {
+		endMarker = markerArrowOpen
+	}
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"%s%s/>`,
+		fromPt.x, fromPt.y, toPt.x, toPt.y, arrowColor, thickness, dashAttr, markerAttrs(startMarker, endMarker))
+	sb.WriteString("\n")
+	if rel.Label != "" {
+		arrowFontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			(fromPt.x+toPt.x)/2, (fromPt.y+toPt.y)/2-5, arrowFontSize, arrowColor, escapeXML(rel.Label))
+		sb.WriteString("\n")
+	}
+}
+
+func (r *ComponentRenderer) renderBox(sb *strings.Builder, b *componentBox, x, y, w, h, fontSize float64) {
+	bgColor := escapeXML(r.resolver.ResolveColor("ComponentBackgroundColor"))
+	borderColor := escapeXML(r.resolver.ResolveColor("ComponentBorderColor"))
+	fontColor := escapeXML(r.resolver.ResolveColor("ComponentFontColor"))
+	borderW := r.resolver.ResolveInt("BorderWidth", 1)
+	cx := x + w/2
+	if b.isInterface {
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			cx, y+compInterfaceRadius, compInterfaceRadius, bgColor, borderColor, borderW)
+		sb.WriteString("\n")
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			cx, y+2*compInterfaceRadius+fontSize+2, fontSize, fontColor, escapeXML(b.label))
+		sb.WriteString("\n")
+		return
+	}
+	// The body starts half a tab in so the two tabs straddle its left edge.
+	bodyX := x + compTabWidth/2
+	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+		bodyX, y, w-compTabWidth/2, h, bgColor, borderColor, borderW)
+	sb.WriteString("\n")
+	for _, tabY := range []float64{y + h/2 - 2*compTabHeight, y + h/2 + compTabHeight} {
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			x, tabY, compTabWidth, compTabHeight, bgColor, borderColor, borderW)
+		sb.WriteString("\n")
+	}
+	lines := strings.Split(b.label, "\n")
+	lineH := fontSize + 4
+	textX := bodyX + (w-compTabWidth/2)/2
+	startY := y + h/2 - lineH*float64(len(lines))/2 + fontSize
+	for i, line := range lines {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			textX, startY+float64(i)*lineH, fontSize, fontColor, escapeXML(line))
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,59 @@
+package svg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/renderer/svg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentRenderer(t *testing.T) {
+	t.Parallel()
+	t.Run("EmptyDiagram", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\n@enduml")
+		assert.Contains(t, out, "<svg")
+		assert.Contains(t, out, "</svg>")
+	})
+	t.Run("ComponentWithTabs", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\n[Web]\n@enduml")
+		assert.Contains(t, out, ">Web<")
+		// Background, body and the two tabs on the left edge.
+		assert.Equal(t, 4, strings.Count(out, "<rect"))
+		assert.Contains(t, out, "#3C3F41")
+	})
+	t.Run("Interface", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\n() \"HTTP\"\n@enduml")
+		assert.Equal(t, 1, strings.Count(out, "<circle"))
+		assert.Contains(t, out, ">HTTP<")
+	})
+	t.Run("ConnectionWithLabel", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\n[Web] --> [API] : calls\n@enduml")
+		assert.Contains(t, out, ">API<")
+		assert.Contains(t, out, ">calls<")
+		assert.Equal(t, 1, strings.Count(out, "<line"))
+		assert.Contains(t, out, "<defs")
+		assert.Equal(t, 1, strings.Count(out, `marker-end="url(#marker-arrow-open)"`))
+		assert.NotContains(t, out, "stroke-dasharray")
+	})
+	t.Run("DottedConnection", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\n[A] ..> [B]\n@enduml")
+		assert.Contains(t, out, "stroke-dasharray")
+	})
+	t.Run("AliasResolvesToOneNode", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\n[Web Server] as WS\nWS --> [DB]\n@enduml")
+		assert.Equal(t, 1, strings.Count(out, ">Web Server<"))
+		assert.NotContains(t, out, ">WS<")
+	})
+	t.Run("ComponentSkinparam", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewComponentRenderer(nil), "@startuml\nskinparam componentBackgroundColor lightblue\n[Web]\n@enduml")
+		assert.Contains(t, out, `fill="#ADD8E6"`)
+	})
+}
@@ -0,0 +1,323 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/font"
+	"github.com/bobcob7/go-uml/internal/layout"
+	"github.com/bobcob7/go-uml/internal/theme"
+)
+
+const (
+	erFootSpread    = 6.0  // half the width of a crow's foot or cardinality bar
+	erFootLength    = 10.0 // distance from the entity to the toe of a crow's foot
+	erFootRadius    = 4.0  // radius of the "zero" circle
+	erSeparatorGap  = 4.0  // space around the line below key attributes
+	erHeaderPadding = 6.0  // space above and below the entity name
+)
+
+// ERRenderer renders entity-relationship diagrams to SVG. Entities are drawn
+// as tables of attributes and relationships carry crow's-foot cardinalities.
+type ERRenderer struct {
+	// Responsive omits the width and height of the SVG so it scales to its
+	// container. The skinparam responsiveSVG also enables it.
+	Responsive bool
+	// Transparent omits the background rect so that the page behind the
+	// SVG shows through. A backgroundColor of "transparent" also enables it.
+	Transparent bool
+	// LayoutDebug, when set, receives a layout.Dump of the positioned
+	// graph, for debugging layouts.
+	LayoutDebug io.Writer
+	resolver    *theme.Resolver
+	drawing
+}
+
+// NewERRenderer creates a new entity-relationship diagram SVG renderer.
+// If resolver is nil, the default Darcula theme is used. resolver is
+// cloned, so it may be shared with other renderers.
+func NewERRenderer(resolver *theme.Resolver) *ERRenderer {
+	if resolver == nil {
+		resolver = theme.NewResolver(nil)
+	}
+	return &ERRenderer{resolver: resolver.Clone()}
+}
+
+// entityBox holds an entity placed in the layout graph.
+type entityBox struct {
+	name  string
+	rows  []string // attribute text, one per row
+	keys  int      // number of leading rows above the key separator
+	lineH float64
+}
+
+// headerHeight returns the height of the box's name header.
+func (b *entityBox) headerHeight(fontSize float64) float64 {
+	return fontSize + 2*erHeaderPadding
+}
+
+// Render writes the entity-relationship diagram SVG to w.
+func (r *ERRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	opts := layout.DefaultOptions()
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.LayoutDirection:
+			if s.LeftToRight {
+				opts.Direction = layout.LeftRight
+			} else {
+				opts.Direction = layout.TopDown
+			}
+		case *ast.Skinparam:
+			r.resolver.SetSkinparam(s.Name, s.Value)
+		}
+	}
+	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
+	padding := float64(r.resolver.ResolveInt("Padding", 10))
+	graph := &layout.Graph{}
+	boxes := map[string]*entityBox{}
+	aliases := map[string]string{}
+	add := func(id string, e *ast.Entity) {
+		if _, ok := boxes[id]; ok {
+			return
+		}
+		b := &entityBox{name: e.Name, lineH: fontSize + 4}
+		for _, attr := range e.Attributes {
+			text := attr.Name
+			if attr.Type != "" {
+				text += " : " + attr.Type
+			}
+			if attr.Mandatory {
+				text = "* " + text
+			}
+			b.rows = append(b.rows, text)
+			if attr.Key {
+				b.keys++
+			}
+		}
+		boxes[id] = b
+		size, _ := font.MeasureText(b.name, fontSize, font.FamilySans)
+		nw := size.Width
+		for _, row := range b.rows {
+			size, _ := font.MeasureText(row, fontSize, font.FamilySans)
+			nw = math.Max(nw, size.Width)
+		}
+		nh := b.headerHeight(fontSize)
+		if len(b.rows) > 0 {
+			nh += float64(len(b.rows))*b.lineH + padding
+		}
+		if b.keys > 0 && b.keys < len(b.rows) {
+			nh += 2 * erSeparatorGap
+		}
+		graph.Nodes = append(graph.Nodes, &layout.Node{ID: id, Width: nw + 2*padding, Height: nh})
+	}
+	var rels []*ast.Relationship
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.Entity:
+			id := s.Name
+			if s.Alias != "" {
+				id = s.Alias
+				aliases[s.Alias] = s.Name
+			}
+			add(id, s)
+		case *ast.Relationship:
+			rels = append(rels, s)
+		}
+	}
+	resolve := func(name string) string {
+		if _, ok := boxes[name]; ok {
+			return name
+		}
+		for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+			if aliases[alias] == name {
+				return alias
+			}
+		}
+		add(name, &ast.Entity{Name: name})
+		return name
+	}
+	ends := make([][2]string, len(rels))
+	for i, rel := range rels {
+		ends[i] = [2]string{resolve(rel.Left), resolve(rel.Right)}
+		graph.Edges = append(graph.Edges, &layout.Edge{From: ends[i][0], To: ends[i][1], Label: rel.Label})
+	}
+	if len(graph.Nodes) == 0 {
+		return r.writeEmptySVG(w, r.resolver.ResolveColor("BackgroundColor"), r.Transparent,
+			r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+	}
+	layout.Layout(graph, opts)
+	dumpLayout(r.LayoutDebug, graph)
+	nodeByID := map[string]*layout.Node{}
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, n := range graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		nodeByID[n.ID] = n
+		minX = math.Min(minX, n.X)
+		minY = math.Min(minY, n.Y)
+		maxX = math.Max(maxX, n.X+n.Width)
+		maxY = math.Max(maxY, n.Y+n.Height)
+	}
+	offsetX := -minX + diagramPadding
+	offsetY := -minY + diagramPadding
+	svgW := int(maxX - minX + 2*diagramPadding)
+	svgH := int(maxY - minY + 2*diagramPadding)
+	titles := newTitleBlock(diagram, fontSize)
+	if w := int(math.Ceil(titles.minWidth())); w > svgW {
+		offsetX += float64(w-svgW) / 2
+		svgW = w
+	}
+	offsetY += titles.top()
+	svgH += int(titles.top() + titles.bottom())
+	var sb strings.Builder
+	r.writeSVGOpen(&sb, float64(svgW), float64(svgH), r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+	sb.WriteString("\n")
+	if writeBackground(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("BackgroundColor"), r.Transparent) {
+		sb.WriteString("\n")
+	}
+	if len(rels) > 0 {
+		writeMarkerDefs(&sb, r.resolver.ResolveColor("ArrowColor"))
+	}
+	titles.render(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("FontColor"))
+	for i, rel := range rels {
+		from, to := nodeByID[ends[i][0]], nodeByID[ends[i][1]]
+		if from == nil || to == nil {
+			continue
+		}
+		r.renderRelationship(&sb, rel, from, to, offsetX, offsetY)
+	}
+	for _, n := range graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		r.renderEntity(&sb, boxes[n.ID], n.X+offsetX, n.Y+offsetY, n.Width, n.Height, fontSize, padding)
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r *ERRenderer) renderRelationship(sb *strings.Builder, rel *ast.Relationship, from, to *layout.Node, offsetX, offsetY float64) {
+	arrowColor := escapeXML(r.resolver.ResolveColor("ArrowColor"))
+	thickness := r.resolver.ResolveInt("ArrowThickness", 1)
+	fx, fy := from.X+offsetX, from.Y+offsetY
+	tx, ty := to.X+offsetX, to.Y+offsetY
+	fromPt := edgePoint(fx, fy, from.Width, from.Height, tx+to.Width/2, ty+to.Height/2)
+	toPt := edgePoint(tx, ty, to.Width, to.Height, fx+from.Width/2, fy+from.Height/2)
+	dashAttr := ""
+	if strings.Contains(rel.Arrow, "..") {
+		dashAttr = ` stroke-dasharray="7,4"`
+	}
+	var startMarker, endMarker string
+	if rel.LeftCrowFoot == ast.CrowFootNone {
+		if rel.Direction == ast.ArrowLeft || rel.Direction == ast.ArrowBoth {
+			startMarker = markerArrowOpen
+		}
+		if rel.Direction == ast.ArrowRight || rel.Direction == ast.ArrowBoth {
+			endMarker = markerArrowOpen
+		}
+	}
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"%s%s/>`,
+		fromPt.x, fromPt.y, toPt.x, toPt.y, arrowColor, thickness, dashAttr, markerAttrs(startMarker, endMarker))
+	sb.WriteString("\n")
+	r.renderCrowFoot(sb, rel.LeftCrowFoot, fromPt, toPt, arrowColor, thickness)
+	r.renderCrowFoot(sb, rel.RightCrowFoot, toPt, fromPt, arrowColor, thickness)
+	if rel.Label != "" {
+		arrowFontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			(fromPt.x+toPt.x)/2, (fromPt.y+toPt.y)/2-5, arrowFontSize, arrowColor, escapeXML(rel.Label))
+		sb.WriteString("\n")
+	}
+}
+
+// renderCrowFoot draws cardinality c at end, the point where a relationship
+// line coming from other meets an entity. A bar means one, a circle zero and
+// a crow's foot many.
+func (r *ERRenderer) renderCrowFoot(sb *strings.Builder, c ast.CrowFoot, end, other point, color string, thickness int) {
+	if c == ast.CrowFootNone {
+		return
+	}
+	length := math.Hypot(other.x-end.x, other.y-end.y)
+	if length == 0 {
+		return
+	}
+	ux, uy := (other.x-end.x)/length, (other.y-end.y)/length
+	// at returns the point d along the line from end and s across it.
+	at := func(d, s float64) point {
+		return point{end.x + ux*d - uy*s, end.y + uy*d + ux*s}
+	}
+	line := func(a, b point) {
+		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"/>`,
+			a.x, a.y, b.x, b.y, color, thickness)
+		sb.WriteString("\n")
+	}
+	bar := func(d float64) {
+		line(at(d, -erFootSpread), at(d, erFootSpread))
+	}
+	circle := func(d float64) {
+		c := at(d, 0)
+		fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			c.x, c.y, erFootRadius, escapeXML(r.resolver.ResolveColor("BackgroundColor")), color, thickness)
+		sb.WriteString("\n")
+	}
+	foot := func() {
+		toe := at(erFootLength, 0)
+		line(toe, at(0, -erFootSpread))
+		line(toe, at(0, erFootSpread))
+	}
+	switch c {
+	case ast.CrowFootExactlyOne:
+		bar(erFootLength - erFootRadius)
+		bar(erFootLength)
+	case ast.CrowFootZeroOrOne:
+		bar(erFootLength - erFootRadius)
+		circle(erFootLength + erFootRadius)
+	case ast.CrowFootOneOrMany:
+		foot()
+		bar(erFootLength + erFootRadius)
+	case ast.CrowFootZeroOrMany:
+		foot()
+		circle(erFootLength + 2*erFootRadius)
+	}
+}
+
+func (r *ERRenderer) renderEntity(sb *strings.Builder, b *entityBox, x, y, w, h, fontSize, padding float64) {
+	bgColor := escapeXML(r.resolver.ResolveColor("ClassBackgroundColor"))
+	borderColor := escapeXML(r.resolver.ResolveColor("ClassBorderColor"))
+	fontColor := escapeXML(r.resolver.ResolveColor("ClassFontColor"))
+	borderW := r.resolver.ResolveInt("BorderWidth", 1)
+	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+		x, y, w, h, bgColor, borderColor, borderW)
+	sb.WriteString("\n")
+	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" font-weight="bold" fill="%s">%s</text>`,
+		x+w/2, y+erHeaderPadding+fontSize-2, fontSize, fontColor, escapeXML(b.name))
+	sb.WriteString("\n")
+	if len(b.rows) == 0 {
+		return
+	}
+	rowY := y + b.headerHeight(fontSize)
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"/>`,
+		x, rowY, x+w, rowY, borderColor, borderW)
+	sb.WriteString("\n")
+	rowY += padding / 2
+	for i, row := range b.rows {
+		if i == b.keys && i > 0 {
+			sepY := rowY + erSeparatorGap
+			fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d" stroke-dasharray="4,3"/>`,
+				x, sepY, x+w, sepY, borderColor, borderW)
+			sb.WriteString("\n")
+			rowY += 2 * erSeparatorGap
+		}
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			x+padding, rowY+fontSize, fontSize, fontColor, escapeXML(row))
+		sb.WriteString("\n")
+		rowY += b.lineH
+	}
+}
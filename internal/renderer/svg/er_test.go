@@ -0,0 +1,81 @@
+package svg_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/renderer/svg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestERRenderer(t *testing.T) {
+	t.Parallel()
+	t.Run("EmptyDiagram", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewERRenderer(nil), "@startuml\n@enduml")
+		assert.Contains(t, out, "<svg")
+		assert.Contains(t, out, "</svg>")
+	})
+	t.Run("EntityTable", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewERRenderer(nil), "@startuml\nentity User {\n  * id : int\n  --\n  email : text\n}\n@enduml")
+		assert.Contains(t, out, `font-weight="bold" fill="#A9B7C6">User<`)
+		assert.Contains(t, out, ">* id : int<")
+		assert.Contains(t, out, ">email : text<")
+		// Background and the entity box.
+		assert.Equal(t, 2, strings.Count(out, "<rect"))
+		// The header line and the dashed line below the key.
+		assert.Equal(t, 2, strings.Count(out, "<line"))
+		assert.Equal(t, 1, strings.Count(out, `stroke-dasharray="4,3"`))
+	})
+	t.Run("EntityWithoutAttributes", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewERRenderer(nil), "@startuml\nentity User {\n}\n@enduml")
+		assert.Contains(t, out, ">User<")
+		assert.NotContains(t, out, "<line")
+	})
+	t.Run("CrowFootMarkers", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			arrow   string
+			lines   int
+			circles int
+		}{
+			// The relationship line plus two bars for each end.
+			{"||--||", 5, 0},
+			// One bar and a circle for each end.
+			{"|o--o|", 3, 2},
+			// A two-line foot and a bar for each end.
+			{"}|--|{", 7, 0},
+			// A two-line foot and a circle for each end.
+			{"}o--o{", 5, 2},
+		}
+		for _, tt := range tests {
+			out := renderSVG(t, svg.NewERRenderer(nil), "@startuml\nentity A {\n}\nentity B {\n}\nA "+tt.arrow+" B\n@enduml")
+			assert.Equal(t, tt.lines, strings.Count(out, "<line"), tt.arrow)
+			assert.Equal(t, tt.circles, strings.Count(out, "<circle"), tt.arrow)
+			assert.NotContains(t, out, "marker-end", tt.arrow)
+		}
+	})
+	t.Run("RelationshipLabel", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewERRenderer(nil), "@startuml\nUser ||..o{ Order : places\n@enduml")
+		assert.Contains(t, out, ">places<")
+		assert.Contains(t, out, ">User<")
+		assert.Contains(t, out, ">Order<")
+		assert.Contains(t, out, `stroke-dasharray="7,4"`)
+	})
+	t.Run("Transparent", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nentity User {\n}\n@enduml")
+		require.Empty(t, errs)
+		r := svg.NewERRenderer(nil)
+		r.Transparent = true
+		var buf bytes.Buffer
+		require.NoError(t, r.Render(&buf, diagram))
+		assert.Equal(t, 1, strings.Count(buf.String(), "<rect"))
+	})
+}
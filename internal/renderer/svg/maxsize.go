@@ -0,0 +1,91 @@
+package svg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// svgSize matches the width and height attributes of the opening <svg> tag.
+var svgSize = regexp.MustCompile(` width="([0-9.]+)" height="([0-9.]+)"`)
+
+// maxSizeWriter caps the width and height of the opening <svg> tag, which it
+// holds back until the tag is complete. Everything after it passes through.
+type maxSizeWriter struct {
+	w                   io.Writer
+	maxWidth, maxHeight float64
+	head                []byte // the output up to the end of the <svg> tag
+	done                bool   // the <svg> tag has been written
+}
+
+// NewMaxSizeWriter returns a writer that passes SVG through to w, scaling
+// the drawing down to fit within maxWidth x maxHeight when it is larger. The
+// width and height of the <svg> tag are scaled by the same factor and the
+// viewBox is kept, so the whole drawing shows at the smaller size. A limit
+// of zero or less leaves that dimension unbounded. A responsive SVG, which
+// has no width or height, is left as it is.
+func NewMaxSizeWriter(w io.Writer, maxWidth, maxHeight int) io.Writer {
+	return &maxSizeWriter{w: w, maxWidth: float64(maxWidth), maxHeight: float64(maxHeight)}
+}
+
+// Write implements io.Writer.
+func (m *maxSizeWriter) Write(p []byte) (int, error) {
+	if m.done {
+		return m.w.Write(p)
+	}
+	m.head = append(m.head, p...)
+	start := bytes.Index(m.head, []byte("<svg"))
+	if start < 0 {
+		return len(p), nil
+	}
+	end := bytes.IndexByte(m.head[start:], '>')
+	if end < 0 {
+		return len(p), nil
+	}
+	end += start
+	m.done = true
+	out := append(append([]byte{}, m.head[:start]...), m.capSize(m.head[start:end])...)
+	out = append(out, m.head[end:]...)
+	m.head = nil
+	if _, err := m.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// capSize returns the opening <svg> tag with its width and height scaled
+// down as by FitSize.
+func (m *maxSizeWriter) capSize(tag []byte) []byte {
+	match := svgSize.FindSubmatchIndex(tag)
+	if match == nil {
+		return tag
+	}
+	width, _ := strconv.ParseFloat(string(tag[match[2]:match[3]]), 64)
+	height, _ := strconv.ParseFloat(string(tag[match[4]:match[5]]), 64)
+	fitW, fitH := FitSize(width, height, m.maxWidth, m.maxHeight)
+	if fitW == width && fitH == height {
+		return tag
+	}
+	size := fmt.Sprintf(` width="%.0f" height="%.0f"`, fitW, fitH)
+	return append(append(append([]byte{}, tag[:match[0]]...), size...), tag[match[1]:]...)
+}
+
+// FitSize returns width and height scaled down by
+// min(maxWidth/width, maxHeight/height) when that is below one, which is the
+// size a writer from NewMaxSizeWriter gives the drawing. A limit of zero or
+// less leaves that dimension unbounded.
+func FitSize(width, height, maxWidth, maxHeight float64) (float64, float64) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = maxWidth / width
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, maxHeight/height)
+	}
+	if scale >= 1 {
+		return width, height
+	}
+	return width * scale, height * scale
+}
@@ -0,0 +1,45 @@
+package svg
+
+import "io"
+
+// minifyWriter drops whitespace that sits between two tags, such as the
+// newline written after every element, so the SVG ends up on one line.
+// Whitespace inside text content is kept.
+type minifyWriter struct {
+	w        io.Writer
+	afterTag bool   // the last byte written was '>'
+	pending  []byte // whitespace seen since the last '>'
+}
+
+// NewMinifyWriter returns a writer that passes SVG through to w with the
+// whitespace between elements removed. Trailing whitespace after the final
+// element is never written.
+func NewMinifyWriter(w io.Writer) io.Writer {
+	return &minifyWriter{w: w}
+}
+
+// Write implements io.Writer. It reports len(p) on success, since the bytes
+// it drops are consumed rather than lost.
+func (m *minifyWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, c := range p {
+		if m.afterTag && isSpace(c) {
+			m.pending = append(m.pending, c)
+			continue
+		}
+		if c != '<' {
+			out = append(out, m.pending...)
+		}
+		m.pending = m.pending[:0]
+		m.afterTag = c == '>'
+		out = append(out, c)
+	}
+	if _, err := m.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t'
+}
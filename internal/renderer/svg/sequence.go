@@ -1,9 +1,12 @@
 package svg
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"maps"
 	"math"
+	"slices"
 	"strings"
 
 	"github.com/bobcob7/go-uml/internal/ast"
@@ -13,16 +16,27 @@ import (
 
 // SequenceRenderer renders sequence diagrams to SVG.
 type SequenceRenderer struct {
-	resolver *theme.Resolver
+	// Responsive omits the width and height of the SVG so it scales to its
+	// container. The skinparam responsiveSVG also enables it.
+	Responsive bool
+	// Transparent omits the background rect so that the page behind the
+	// SVG shows through. A backgroundColor of "transparent" also enables it.
+	Transparent bool
+	// Accessible wraps each participant and message in a <g> whose <title>
+	// names it, for screen readers and hover tooltips.
+	Accessible bool
+	resolver   *theme.Resolver
+	drawing
 }
 
 // NewSequenceRenderer creates a new sequence diagram SVG renderer.
-// If resolver is nil, the default Darcula theme is used.
+// If resolver is nil, the default Darcula theme is used. resolver is
+// cloned, so it may be shared with other renderers.
 func NewSequenceRenderer(resolver *theme.Resolver) *SequenceRenderer {
 	if resolver == nil {
 		resolver = theme.NewResolver(nil)
 	}
-	return &SequenceRenderer{resolver: resolver}
+	return &SequenceRenderer{resolver: resolver.Clone()}
 }
 
 // participantBox holds layout info for a participant.
@@ -30,10 +44,13 @@ type participantBox struct {
 	name   string
 	alias  string
 	kind   ast.ParticipantKind
+	color  string  // per-participant colour override, or ""
 	x      float64 // center x
 	y      float64 // top of box
 	width  float64
 	height float64
+	// destroyedY is the lifeline end for a destroyed participant, or 0.
+	destroyedY float64
 }
 
 // displayName returns the name to show for a participant.
@@ -59,6 +76,15 @@ type seqEvent struct {
 	y      float64
 	height float64
 	stmt   ast.Statement
+	depth  int       // number of fragments enclosing the event
+	elseYs []float64 // y of each else divider, for fragments
+}
+
+// seqCall is an open activation on the call stack, remembering which
+// participant's message started it so a return can be drawn back.
+type seqCall struct {
+	caller string // empty when activated without an incoming message
+	callee string
 }
 
 // activationRange tracks when a lifeline is active.
@@ -77,23 +103,24 @@ const (
 	seqActivationWidth = 10.0
 	seqFragmentPadding = 10.0
 	seqNotePadding     = 8.0
-	seqNoteMaxWidth    = 150.0
 	seqTopMargin       = 20.0
 	seqLeftMargin      = 20.0
 	seqBottomMargin    = 20.0
 	seqDividerHeight   = 30.0
 	seqDelayHeight     = 30.0
 	seqFragmentLabelH  = 20.0
+	seqFragmentInset   = 6.0 // per nesting level, on each side
 	seqLifelineDash    = "5,5"
+	seqDestroySize     = 8.0
 )
 
 // Render writes the sequence diagram SVG to w.
 func (r *SequenceRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	r.applySkinparams(diagram)
 	participants := r.collectParticipants(diagram)
 	if len(participants) == 0 {
 		return r.renderEmpty(w)
 	}
-	r.applySkinparams(diagram)
 	pboxes := r.layoutParticipants(participants)
 	pmap := make(map[string]*participantBox)
 	for i := range pboxes {
@@ -104,58 +131,92 @@ func (r *SequenceRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
 	}
 	events, activations := r.layoutEvents(diagram, pboxes, pmap)
 	totalWidth, totalHeight := r.computeBounds(pboxes, events, activations)
+	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
+	titles := newTitleBlock(diagram, fontSize)
+	legend := newLegendBox(diagram, fontSize)
+	svgW := math.Max(totalWidth, math.Max(titles.minWidth(), legend.minWidth()))
+	svgH := totalHeight + titles.top() + legend.bottom() + titles.bottom()
 	var sb strings.Builder
-	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f"`, totalWidth, totalHeight)
-	fmt.Fprintf(&sb, ` viewBox="0 0 %.0f %.0f">`, totalWidth, totalHeight)
-	bgColor := r.resolver.ResolveColor("BackgroundColor")
-	fmt.Fprintf(&sb, `<rect width="%.0f" height="%.0f" fill="%s"/>`, totalWidth, totalHeight, escSeq(bgColor))
+	r.writeSVGOpen(&sb, svgW, svgH, r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+	writeBackground(&sb, svgW, svgH, r.resolver.ResolveColor("BackgroundColor"), r.Transparent)
+	// The body is drawn first so that the filters it uses can be defined
+	// in the <defs> section that precedes it.
+	var body strings.Builder
+	var defs defsBuilder
+	legend.render(&body, svgW, totalHeight+titles.top(), r.resolver)
+	shifted := !titles.empty() || svgW > totalWidth
+	if shifted {
+		titles.render(&body, svgW, svgH, r.resolver.ResolveColor("FontColor"))
+		// The body is laid out from the origin; shift it below the title.
+		fmt.Fprintf(&body, `<g transform="translate(%.1f,%.1f)">`, (svgW-totalWidth)/2, titles.top())
+	}
 	for i := range pboxes {
-		r.renderParticipantBox(&sb, &pboxes[i])
+		r.renderParticipantBox(&body, &defs, &pboxes[i])
 	}
 	lifelineEndY := r.lifelineEndY(events, pboxes)
 	for i := range pboxes {
-		r.renderLifeline(&sb, &pboxes[i], lifelineEndY)
+		r.renderLifeline(&body, &pboxes[i], lifelineEndY)
 	}
 	for i := range activations {
-		r.renderActivation(&sb, &activations[i], pmap)
+		r.renderActivation(&body, &activations[i], pmap)
 	}
 	msgNum := 0
-	autonumber := false
+	autonumber, stopped := false, false
 	for _, ev := range events {
 		switch s := ev.stmt.(type) {
 		case *ast.Message:
-			if autonumber {
+			numbered := autonumber && !stopped
+			if numbered {
 				msgNum++
 			}
-			r.renderMessage(&sb, s, ev.y, pmap, autonumber, msgNum)
+			r.renderMessage(&body, s, ev.y, pmap, numbered, msgNum)
+			if pb := pmap[s.To]; pb != nil && s.DestroyTarget {
+				r.renderDestroyMark(&body, pb)
+			}
 		case *ast.Note:
-			r.renderSeqNote(&sb, s, ev.y, pmap)
+			r.renderSeqNote(&body, &defs, s, ev.y, pmap)
 		case *ast.Fragment:
-			r.renderFragment(&sb, s, ev.y, ev.height, pmap, pboxes)
+			r.renderFragment(&body, s, ev, pmap, pboxes)
 		case *ast.Divider:
-			r.renderDivider(&sb, s, ev.y, totalWidth)
+			r.renderDivider(&body, s, ev.y, totalWidth)
 		case *ast.Delay:
-			r.renderDelay(&sb, s, ev.y, totalWidth)
+			r.renderDelay(&body, s, ev.y, totalWidth)
 		case *ast.Autonumber:
-			autonumber = true
+			if s.Stop {
+				stopped = true
+				break
+			}
+			// A plain autonumber or a resume restarts numbering; only an
+			// explicit start value resets the counter.
+			autonumber, stopped = true, false
 			if s.Start != "" {
 				msgNum = atoiSimple(s.Start) - 1
 			}
+		case *ast.Lifecycle:
+			if pb := pmap[s.Target]; pb != nil && s.Destroy {
+				r.renderDestroyMark(&body, pb)
+			}
 		}
 	}
 	for i := range pboxes {
-		r.renderParticipantBoxBottom(&sb, &pboxes[i], lifelineEndY)
+		if pboxes[i].destroyedY > 0 {
+			continue
+		}
+		r.renderParticipantBoxBottom(&body, &defs, &pboxes[i], lifelineEndY)
 	}
+	if shifted {
+		body.WriteString("</g>")
+	}
+	writeDefs(&sb, r.resolver.ResolveColor("ArrowColor"), false, &defs)
+	sb.WriteString(body.String())
 	sb.WriteString("</svg>")
 	_, err := io.WriteString(w, sb.String())
 	return err
 }
 
 func (r *SequenceRenderer) renderEmpty(w io.Writer) error {
-	bgColor := r.resolver.ResolveColor("BackgroundColor")
-	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100"><rect width="100" height="100" fill="%s"/></svg>`, escSeq(bgColor))
-	_, err := io.WriteString(w, svg)
-	return err
+	return r.writeEmptySVG(w, r.resolver.ResolveColor("BackgroundColor"), r.Transparent,
+		r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
 }
 
 func (r *SequenceRenderer) applySkinparams(diagram *ast.Diagram) {
@@ -167,7 +228,8 @@ func (r *SequenceRenderer) applySkinparams(diagram *ast.Diagram) {
 }
 
 // collectParticipants extracts ordered participants from the diagram.
-// Explicit participant declarations come first, then implicit ones from messages.
+// Explicit participant declarations come first, then implicit ones from
+// messages, including those nested in fragments.
 func (r *SequenceRenderer) collectParticipants(diagram *ast.Diagram) []*ast.Participant {
 	seen := make(map[string]bool)
 	var result []*ast.Participant
@@ -180,16 +242,27 @@ func (r *SequenceRenderer) collectParticipants(diagram *ast.Diagram) []*ast.Part
 			}
 		}
 	}
-	for _, stmt := range diagram.Statements {
-		if m, ok := stmt.(*ast.Message); ok {
-			for _, name := range []string{m.From, m.To} {
-				if name != "" && !seen[name] {
-					seen[name] = true
-					result = append(result, &ast.Participant{Name: name, Kind: ast.ParticipantDefault})
-				}
+	// Messages inside fragments introduce participants too.
+	ast.Walk(diagram, func(n ast.Node) bool {
+		var names []string
+		switch s := n.(type) {
+		case *ast.Message:
+			names = []string{s.From, s.To}
+		case *ast.Lifecycle:
+			names = []string{s.Target}
+		}
+		for _, name := range names {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				result = append(result, &ast.Participant{Name: name, Kind: ast.ParticipantDefault})
 			}
 		}
-	}
+		return true
+	})
+	// Explicit orders move participants; ties keep their declaration order.
+	slices.SortStableFunc(result, func(a, b *ast.Participant) int {
+		return cmp.Compare(a.Order, b.Order)
+	})
 	return result
 }
 
@@ -207,6 +280,7 @@ func (r *SequenceRenderer) layoutParticipants(participants []*ast.Participant) [
 			name:   p.Name,
 			alias:  p.Alias,
 			kind:   p.Kind,
+			color:  p.Color,
 			width:  size.Width + seqParticipantPadX*2,
 			height: size.Height + seqParticipantPadY*2,
 		}
@@ -231,72 +305,198 @@ func (r *SequenceRenderer) layoutEvents(diagram *ast.Diagram, pboxes []participa
 			maxBottom = pb.bottomY()
 		}
 	}
+	// endActivation closes the open activation of name, if any, at endY.
+	endActivation := func(name string, endY float64) {
+		if startY, ok := activeStarts[name]; ok {
+			activations = append(activations, activationRange{
+				participant: name,
+				startY:      startY,
+				endY:        endY,
+			})
+			delete(activeStarts, name)
+		}
+	}
+	var calls []seqCall
+	var lastMsg *ast.Message
+	// popCall removes the most recent call into callee from the stack.
+	popCall := func(callee string) {
+		for i := len(calls) - 1; i >= 0; i-- {
+			if calls[i].callee == callee {
+				calls = append(calls[:i], calls[i+1:]...)
+				return
+			}
+		}
+	}
 	curY := maxBottom + seqMessageSpacing
-	for _, stmt := range diagram.Statements {
-		switch s := stmt.(type) {
-		case *ast.Message:
-			events = append(events, seqEvent{y: curY, height: seqMessageSpacing, stmt: s})
-			curY += seqMessageSpacing
-		case *ast.Note:
-			h := r.noteHeight(s)
-			events = append(events, seqEvent{y: curY, height: h, stmt: s})
-			curY += h
-		case *ast.Fragment:
-			h := r.fragmentHeight(s)
-			events = append(events, seqEvent{y: curY, height: h, stmt: s})
-			curY += h
-		case *ast.Divider:
-			events = append(events, seqEvent{y: curY, height: seqDividerHeight, stmt: s})
-			curY += seqDividerHeight
-		case *ast.Delay:
-			events = append(events, seqEvent{y: curY, height: seqDelayHeight, stmt: s})
-			curY += seqDelayHeight
-		case *ast.Autonumber:
-			events = append(events, seqEvent{y: curY, height: 0, stmt: s})
-		case *ast.Activate:
-			if s.Deactivate {
-				if startY, ok := activeStarts[s.Target]; ok {
-					activations = append(activations, activationRange{
-						participant: s.Target,
-						startY:     startY,
-						endY:       curY,
-					})
-					delete(activeStarts, s.Target)
+	// place reserves h pixels for a boxed event such as a note and returns
+	// its top. Inside fragments the box starts at the top of the slot.
+	place := func(h float64, depth int) float64 {
+		top := curY
+		if depth > 0 {
+			top -= seqMessageSpacing / 2
+		}
+		curY = top + h
+		if depth > 0 {
+			curY += seqMessageSpacing / 2
+		}
+		return top
+	}
+	// creating is a participant whose box was just drawn by a create. The
+	// message that creates it points at the middle of the box, so only half
+	// the box is reserved until it is known whether that message follows.
+	var creating *participantBox
+	finishCreate := func(next ast.Statement) {
+		if creating == nil {
+			return
+		}
+		if m, ok := next.(*ast.Message); !ok || pmap[m.To] != creating {
+			curY += creating.height/2 + seqParticipantPadY
+		}
+		creating = nil
+	}
+	// layout places stmts from curY on. Inside fragments, curY is the middle
+	// of a message slot rather than its top, so the first message clears the
+	// fragment's label.
+	var layout func(stmts []ast.Statement, depth int)
+	layout = func(stmts []ast.Statement, depth int) {
+		for _, stmt := range stmts {
+			finishCreate(stmt)
+			switch s := stmt.(type) {
+			case *ast.Message:
+				curY += r.labelLift(s.Label)
+				events = append(events, seqEvent{y: curY, height: seqMessageSpacing, stmt: s})
+				if s.DeactivateSource {
+					endActivation(s.From, curY)
+					popCall(s.From)
+				}
+				if s.ActivateTarget {
+					activeStarts[s.To] = curY
+					calls = append(calls, seqCall{caller: s.From, callee: s.To})
+				}
+				lastMsg = s
+				if pb := pmap[s.To]; pb != nil && s.DestroyTarget {
+					pb.destroyedY = curY
+					endActivation(s.To, curY)
+					popCall(s.To)
+				}
+				curY += seqMessageSpacing
+			case *ast.Return:
+				if len(calls) == 0 {
+					continue
+				}
+				call := calls[len(calls)-1]
+				calls = calls[:len(calls)-1]
+				if call.caller != "" {
+					// Draw the return as a dashed reply from the active participant.
+					reply := &ast.Message{
+						Pos:    s.Pos,
+						From:   call.callee,
+						To:     call.caller,
+						Label:  strings.TrimSpace(s.Label),
+						Arrow:  "-->",
+						Dashed: true,
+					}
+					curY += r.labelLift(reply.Label)
+					events = append(events, seqEvent{y: curY, height: seqMessageSpacing, stmt: reply})
+					lastMsg = reply
+				}
+				endActivation(call.callee, curY)
+				if call.caller != "" {
+					curY += seqMessageSpacing
+				}
+			case *ast.Note:
+				h := r.noteHeight(s)
+				events = append(events, seqEvent{y: place(h, depth), height: h, stmt: s})
+			case *ast.Fragment:
+				top := place(0, depth)
+				idx := len(events)
+				events = append(events, seqEvent{y: top, stmt: s, depth: depth})
+				var elseYs []float64
+				curY = top + seqFragmentLabelH + seqFragmentPadding + seqMessageSpacing/2
+				layoutBranch := func(stmts []ast.Statement) {
+					if len(stmts) == 0 {
+						curY += seqMessageSpacing
+					}
+					layout(stmts, depth+1)
+				}
+				layoutBranch(s.Statements)
+				for _, ep := range s.ElseParts {
+					elseYs = append(elseYs, curY-seqMessageSpacing/2)
+					curY += seqFragmentLabelH
+					layoutBranch(ep.Statements)
+				}
+				bottom := curY - seqMessageSpacing/2 + seqFragmentPadding
+				events[idx].height = bottom - top
+				events[idx].elseYs = elseYs
+				curY = bottom
+				if depth > 0 {
+					curY += seqFragmentPadding + seqMessageSpacing/2
+				}
+			case *ast.Divider:
+				events = append(events, seqEvent{y: place(seqDividerHeight, depth), height: seqDividerHeight, stmt: s})
+			case *ast.Delay:
+				events = append(events, seqEvent{y: place(seqDelayHeight, depth), height: seqDelayHeight, stmt: s})
+			case *ast.Autonumber:
+				events = append(events, seqEvent{y: curY, height: 0, stmt: s})
+			case *ast.Lifecycle:
+				pb := pmap[s.Target]
+				if pb == nil {
+					continue
+				}
+				if s.Destroy {
+					// A destroyed participant's activations end with it.
+					endActivation(s.Target, curY)
+					popCall(s.Target)
+					pb.destroyedY = curY
+					events = append(events, seqEvent{y: curY, height: seqDestroySize * 2, stmt: s})
+					curY += seqDestroySize * 2
+				} else {
+					pb.y = curY
+					events = append(events, seqEvent{y: curY, height: pb.height + seqParticipantPadY, stmt: s})
+					curY += pb.height / 2
+					creating = pb
+				}
+			case *ast.Activate:
+				if s.Deactivate {
+					endActivation(s.Target, curY)
+					popCall(s.Target)
+				} else {
+					activeStarts[s.Target] = curY
+					caller := ""
+					if lastMsg != nil && lastMsg.To == s.Target {
+						caller = lastMsg.From
+					}
+					calls = append(calls, seqCall{caller: caller, callee: s.Target})
 				}
-			} else {
-				activeStarts[s.Target] = curY
 			}
 		}
+		finishCreate(nil)
 	}
-	for name, startY := range activeStarts {
+	layout(diagram.Statements, 0)
+	// Close activations still open at the end in name order, so the output
+	// does not depend on map iteration order.
+	for _, name := range slices.Sorted(maps.Keys(activeStarts)) {
 		activations = append(activations, activationRange{
 			participant: name,
-			startY:     startY,
-			endY:       curY,
+			startY:      activeStarts[name],
+			endY:        curY,
 		})
 	}
 	return events, activations
 }
 
 func (r *SequenceRenderer) noteHeight(n *ast.Note) float64 {
-	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
-	size, _ := font.MeasureText(n.Text, fontSize, font.FamilySans)
+	_, size := r.measureSeqNote(n)
 	return size.Height + seqNotePadding*2 + 10
 }
 
-func (r *SequenceRenderer) fragmentHeight(f *ast.Fragment) float64 {
-	h := seqFragmentLabelH + seqFragmentPadding
-	count := len(f.Statements)
-	for _, ep := range f.ElseParts {
-		count += len(ep.Statements)
-		h += seqFragmentLabelH // else divider
-	}
-	if count == 0 {
-		count = 1
-	}
-	h += float64(count) * seqMessageSpacing
-	h += seqFragmentPadding
-	return h
+// measureSeqNote wraps the note text and returns the lines with their text extent.
+func (r *SequenceRenderer) measureSeqNote(n *ast.Note) ([]string, font.Size) {
+	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
+	maxWidth := float64(r.resolver.ResolveInt("NoteMaxWidth", 200))
+	lines := wrapText(n.Text, maxWidth, fontSize)
+	size, _ := font.MeasureText(strings.Join(lines, "\n"), fontSize, font.FamilySans)
+	return lines, size
 }
 
 func (r *SequenceRenderer) computeBounds(pboxes []participantBox, events []seqEvent, _ []activationRange) (float64, float64) {
@@ -344,18 +544,20 @@ func (r *SequenceRenderer) lifelineEndY(events []seqEvent, pboxes []participantB
 	return maxY + seqMessageSpacing/2
 }
 
-func (r *SequenceRenderer) renderParticipantBox(sb *strings.Builder, pb *participantBox) {
-	bgColor := r.resolver.ResolveColor("ParticipantBackgroundColor")
+func (r *SequenceRenderer) renderParticipantBox(sb *strings.Builder, defs *defsBuilder, pb *participantBox) {
+	bgColor := r.participantColor(pb, "ParticipantBackgroundColor")
 	borderColor := r.resolver.ResolveColor("ParticipantBorderColor")
 	fontColor := r.resolver.ResolveColor("ParticipantFontColor")
 	fontSize := r.resolver.ResolveInt("FontSize", 13)
 	borderWidth := r.resolver.ResolveInt("BorderWidth", 1)
+	defer sb.WriteString(openTitledGroup(sb, pb.displayName(), r.Accessible))
 	switch pb.kind {
 	case ast.ParticipantActor:
 		r.renderActorIcon(sb, pb, borderColor, fontColor, fontSize)
 	default:
-		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d" rx="4"/>`,
-			pb.x, pb.y, pb.width, pb.height, escSeq(bgColor), escSeq(borderColor), borderWidth)
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d" rx="4"%s/>`,
+			pb.x, pb.y, pb.width, pb.height, escSeq(bgColor), escSeq(borderColor), borderWidth,
+			defs.shadowAttr(r.resolver.ResolveBool("Shadowing", false)))
 		textX := pb.centerX()
 		textY := pb.y + pb.height/2 + float64(fontSize)/3
 		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" text-anchor="middle">%s</text>`,
@@ -365,40 +567,29 @@ func (r *SequenceRenderer) renderParticipantBox(sb *strings.Builder, pb *partici
 
 func (r *SequenceRenderer) renderActorIcon(sb *strings.Builder, pb *participantBox, borderColor, fontColor string, fontSize int) {
 	cx := pb.centerX()
-	topY := pb.y + 4
-	headR := 8.0
-	fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="1"/>`,
-		cx, topY+headR, headR, escSeq(borderColor))
-	bodyTop := topY + headR*2
-	bodyBot := bodyTop + 12
-	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
-		cx, bodyTop, cx, bodyBot, escSeq(borderColor))
-	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
-		cx-10, bodyTop+4, cx+10, bodyTop+4, escSeq(borderColor))
-	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
-		cx, bodyBot, cx-8, bodyBot+10, escSeq(borderColor))
-	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
-		cx, bodyBot, cx+8, bodyBot+10, escSeq(borderColor))
+	writeStickFigure(sb, cx, pb.y+4, escSeq(borderColor))
 	textY := pb.y + pb.height - 2
 	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" text-anchor="middle">%s</text>`,
 		cx, textY, fontSize, escSeq(fontColor), escSeq(pb.displayName()))
 }
 
-func (r *SequenceRenderer) renderParticipantBoxBottom(sb *strings.Builder, pb *participantBox, lifelineEndY float64) {
-	bgColor := r.resolver.ResolveColor("ParticipantBackgroundColor")
+func (r *SequenceRenderer) renderParticipantBoxBottom(sb *strings.Builder, defs *defsBuilder, pb *participantBox, lifelineEndY float64) {
+	bgColor := r.participantColor(pb, "ParticipantBackgroundColor")
 	borderColor := r.resolver.ResolveColor("ParticipantBorderColor")
 	fontColor := r.resolver.ResolveColor("ParticipantFontColor")
 	fontSize := r.resolver.ResolveInt("FontSize", 13)
 	borderWidth := r.resolver.ResolveInt("BorderWidth", 1)
 	y := lifelineEndY
+	defer sb.WriteString(openTitledGroup(sb, pb.displayName(), r.Accessible))
 	switch pb.kind {
 	case ast.ParticipantActor:
 		botPb := *pb
 		botPb.y = y
 		r.renderActorIcon(sb, &botPb, borderColor, fontColor, fontSize)
 	default:
-		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d" rx="4"/>`,
-			pb.x, y, pb.width, pb.height, escSeq(bgColor), escSeq(borderColor), borderWidth)
+		fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%d" rx="4"%s/>`,
+			pb.x, y, pb.width, pb.height, escSeq(bgColor), escSeq(borderColor), borderWidth,
+			defs.shadowAttr(r.resolver.ResolveBool("Shadowing", false)))
 		textX := pb.centerX()
 		textY := y + pb.height/2 + float64(fontSize)/3
 		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" text-anchor="middle">%s</text>`,
@@ -406,14 +597,37 @@ func (r *SequenceRenderer) renderParticipantBoxBottom(sb *strings.Builder, pb *p
 	}
 }
 
+// participantColor returns the participant's own colour if it has one,
+// otherwise the themed value of property.
+func (r *SequenceRenderer) participantColor(pb *participantBox, property string) string {
+	if pb.color != "" {
+		return svgColor(pb.color)
+	}
+	return r.resolver.ResolveColor(property)
+}
+
 func (r *SequenceRenderer) renderLifeline(sb *strings.Builder, pb *participantBox, endY float64) {
-	lineColor := r.resolver.ResolveColor("SequenceLifeLineBorderColor")
+	lineColor := r.participantColor(pb, "SequenceLifeLineBorderColor")
 	cx := pb.centerX()
 	startY := pb.bottomY()
+	if pb.destroyedY > 0 && pb.destroyedY < endY {
+		endY = pb.destroyedY
+	}
 	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1" stroke-dasharray="%s"/>`,
 		cx, startY, cx, endY, escSeq(lineColor), seqLifelineDash)
 }
 
+// renderDestroyMark draws an X at the end of a destroyed participant's lifeline.
+func (r *SequenceRenderer) renderDestroyMark(sb *strings.Builder, pb *participantBox) {
+	color := r.resolver.ResolveColor("ArrowColor")
+	cx := pb.centerX()
+	y := pb.destroyedY
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="2"/>`,
+		cx-seqDestroySize, y-seqDestroySize, cx+seqDestroySize, y+seqDestroySize, escSeq(color))
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="2"/>`,
+		cx-seqDestroySize, y+seqDestroySize, cx+seqDestroySize, y-seqDestroySize, escSeq(color))
+}
+
 func (r *SequenceRenderer) renderActivation(sb *strings.Builder, a *activationRange, pmap map[string]*participantBox) {
 	pb, ok := pmap[a.participant]
 	if !ok {
@@ -442,6 +656,19 @@ func (r *SequenceRenderer) renderMessage(sb *strings.Builder, m *ast.Message, y
 	fontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
 	x1 := fromPb.centerX()
 	x2 := toPb.centerX()
+	if fromPb != toPb && y > toPb.y && y < toPb.bottomY() {
+		// A creating message ends at the side of the new participant's box.
+		if x2 > x1 {
+			x2 = toPb.x
+		} else {
+			x2 = toPb.x + toPb.width
+		}
+	}
+	title := fromPb.displayName() + " " + m.Arrow + " " + toPb.displayName()
+	if m.Label != "" {
+		title += ": " + m.Label
+	}
+	defer sb.WriteString(openTitledGroup(sb, title, r.Accessible))
 	dashAttr := ""
 	if m.Dashed {
 		dashAttr = ` stroke-dasharray="6,4"`
@@ -449,18 +676,31 @@ func (r *SequenceRenderer) renderMessage(sb *strings.Builder, m *ast.Message, y
 	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"%s/>`,
 		x1, y, x2, y, escSeq(arrowColor), dashAttr)
 	r.drawSeqArrowHead(sb, x1, x2, y, arrowColor)
+	if m.Bidirectional {
+		r.drawSeqArrowHead(sb, x2, x1, y, arrowColor)
+	}
 	label := m.Label
 	if autonumber && msgNum > 0 {
 		label = fmt.Sprintf("%d. %s", msgNum, label)
 	}
 	if label != "" {
 		midX := (x1 + x2) / 2
-		labelY := y - 5
-		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" text-anchor="middle">%s</text>`,
-			midX, labelY, fontSize, escSeq(fontColor), escSeq(label))
+		labelY := y - 5 - r.labelLift(label)
+		for _, line := range strings.Split(label, "\n") {
+			fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" text-anchor="middle">%s</text>`,
+				midX, labelY, fontSize, escSeq(fontColor), escSeq(line))
+			labelY += float64(fontSize) + 2
+		}
 	}
 }
 
+// labelLift returns how far above a one-line label the first line of a
+// message label sits, which is the extra room the message needs above it.
+func (r *SequenceRenderer) labelLift(label string) float64 {
+	fontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
+	return float64(strings.Count(label, "\n")) * float64(fontSize+2)
+}
+
 func (r *SequenceRenderer) drawSeqArrowHead(sb *strings.Builder, x1, x2, y float64, color string) {
 	if x2 > x1 {
 		fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/>`,
@@ -471,17 +711,15 @@ func (r *SequenceRenderer) drawSeqArrowHead(sb *strings.Builder, x1, x2, y float
 	}
 }
 
-func (r *SequenceRenderer) renderSeqNote(sb *strings.Builder, n *ast.Note, y float64, pmap map[string]*participantBox) {
+func (r *SequenceRenderer) renderSeqNote(sb *strings.Builder, defs *defsBuilder, n *ast.Note, y float64, pmap map[string]*participantBox) {
 	bgColor := r.resolver.ResolveColor("NoteBackgroundColor")
 	borderColor := r.resolver.ResolveColor("NoteBorderColor")
 	fontColor := r.resolver.ResolveColor("NoteFontColor")
 	fontSize := r.resolver.ResolveInt("FontSize", 13)
-	size, _ := font.MeasureText(n.Text, float64(fontSize), font.FamilySans)
+	lines, size := r.measureSeqNote(n)
 	noteW := size.Width + seqNotePadding*2
-	if noteW > seqNoteMaxWidth {
-		noteW = seqNoteMaxWidth
-	}
 	noteH := size.Height + seqNotePadding*2
+	lineH := size.Height / float64(len(lines))
 	pb := pmap[n.Target]
 	if pb == nil {
 		return
@@ -497,13 +735,13 @@ func (r *SequenceRenderer) renderSeqNote(sb *strings.Builder, n *ast.Note, y flo
 		noteX = cx - noteW/2
 	}
 	fold := 8.0
-	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s" stroke-width="1"/>`,
+	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s" stroke-width="1"%s/>`,
 		noteX, y,
 		noteX+noteW-fold, y,
 		noteX+noteW, y+fold,
 		noteX+noteW, y+noteH,
 		noteX, y+noteH,
-		escSeq(bgColor), escSeq(borderColor))
+		escSeq(bgColor), escSeq(borderColor), defs.shadowAttr(r.resolver.ResolveBool("Shadowing", false)))
 	fmt.Fprintf(sb, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="none" stroke="%s" stroke-width="1"/>`,
 		noteX+noteW-fold, y,
 		noteX+noteW-fold, y+fold,
@@ -513,20 +751,29 @@ func (r *SequenceRenderer) renderSeqNote(sb *strings.Builder, n *ast.Note, y flo
 		cx, y+noteH/2, noteX+noteW, y+noteH/2, escSeq(borderColor))
 	textX := noteX + seqNotePadding
 	textY := y + seqNotePadding + float64(fontSize)
-	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
-		textX, textY, fontSize, escSeq(fontColor), escSeq(n.Text))
+	for _, line := range lines {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			textX, textY, fontSize, escSeq(fontColor), escSeq(line))
+		textY += lineH
+	}
 }
 
-func (r *SequenceRenderer) renderFragment(sb *strings.Builder, f *ast.Fragment, y, height float64, pmap map[string]*participantBox, pboxes []participantBox) {
+// renderFragment draws the fragment of ev. Nested fragments are inset from
+// their parents so the borders do not overlap.
+func (r *SequenceRenderer) renderFragment(sb *strings.Builder, f *ast.Fragment, ev seqEvent, pmap map[string]*participantBox, pboxes []participantBox) {
 	borderColor := r.resolver.ResolveColor("ParticipantBorderColor")
 	fontColor := r.resolver.ResolveColor("FontColor")
 	fontSize := r.resolver.ResolveInt("FontSize", 13)
+	y, height := ev.y, ev.height
 	minX, maxX := r.fragmentSpan(f, pmap, pboxes)
 	fragX := minX - seqFragmentPadding
 	fragW := (maxX - minX) + seqFragmentPadding*2
 	if fragW < 100 {
 		fragW = 100
 	}
+	inset := float64(ev.depth) * seqFragmentInset
+	fragX += inset
+	fragW -= inset * 2
 	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="%s" stroke-width="1"/>`,
 		fragX, y, fragW, height, escSeq(borderColor))
 	label := fragmentLabel(f.Kind)
@@ -545,27 +792,16 @@ func (r *SequenceRenderer) renderFragment(sb *strings.Builder, f *ast.Fragment,
 		escSeq(borderColor))
 	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" font-weight="bold">%s</text>`,
 		fragX+8, y+tagH-5, fontSize, escSeq(fontColor), escSeq(label))
-	if len(f.ElseParts) > 0 {
-		stmtCount := len(f.Statements)
-		if stmtCount == 0 {
-			stmtCount = 1
-		}
-		elseY := y + seqFragmentLabelH + seqFragmentPadding + float64(stmtCount)*seqMessageSpacing
-		for _, ep := range f.ElseParts {
-			fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1" stroke-dasharray="5,5"/>`,
-				fragX, elseY, fragX+fragW, elseY, escSeq(borderColor))
-			elseLabel := "else"
-			if ep.Condition != "" {
-				elseLabel += " [" + ep.Condition + "]"
-			}
-			fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
-				fragX+8, elseY+float64(fontSize)+2, fontSize, escSeq(fontColor), escSeq(elseLabel))
-			epCount := len(ep.Statements)
-			if epCount == 0 {
-				epCount = 1
-			}
-			elseY += seqFragmentLabelH + float64(epCount)*seqMessageSpacing
+	for i, ep := range f.ElseParts {
+		elseY := ev.elseYs[i]
+		fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1" stroke-dasharray="5,5"/>`,
+			fragX, elseY, fragX+fragW, elseY, escSeq(borderColor))
+		elseLabel := "else"
+		if ep.Condition != "" {
+			elseLabel += " [" + ep.Condition + "]"
 		}
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			fragX+8, elseY+float64(fontSize)+2, fontSize, escSeq(fontColor), escSeq(elseLabel))
 	}
 }
 
@@ -596,21 +832,28 @@ func (r *SequenceRenderer) fragmentSpan(f *ast.Fragment, pmap map[string]*partic
 	return minX, maxX
 }
 
+// collectFragmentParticipants returns the participants messaged within f,
+// including those of nested fragments so that parents enclose them.
 func (r *SequenceRenderer) collectFragmentParticipants(f *ast.Fragment) map[string]bool {
 	names := make(map[string]bool)
-	for _, stmt := range f.Statements {
-		if m, ok := stmt.(*ast.Message); ok {
-			names[m.From] = true
-			names[m.To] = true
+	var collect func(stmts []ast.Statement)
+	collect = func(stmts []ast.Statement) {
+		for _, stmt := range stmts {
+			switch s := stmt.(type) {
+			case *ast.Message:
+				names[s.From] = true
+				names[s.To] = true
+			case *ast.Fragment:
+				collect(s.Statements)
+				for _, ep := range s.ElseParts {
+					collect(ep.Statements)
+				}
+			}
 		}
 	}
+	collect(f.Statements)
 	for _, ep := range f.ElseParts {
-		for _, stmt := range ep.Statements {
-			if m, ok := stmt.(*ast.Message); ok {
-				names[m.From] = true
-				names[m.To] = true
-			}
-		}
+		collect(ep.Statements)
 	}
 	return names
 }
@@ -664,6 +907,14 @@ func fragmentLabel(kind ast.FragmentKind) string {
 		return "ref"
 	case ast.FragmentGroup:
 		return "group"
+	case ast.FragmentOpt:
+		return "opt"
+	case ast.FragmentCritical:
+		return "critical"
+	case ast.FragmentIgnore:
+		return "ignore"
+	case ast.FragmentConsider:
+		return "consider"
 	default:
 		return "fragment"
 	}
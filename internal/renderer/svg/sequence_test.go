@@ -2,8 +2,11 @@ package svg_test
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -45,6 +48,49 @@ func TestSequenceRenderer(t *testing.T) {
 		assert.Contains(t, out, "Bob")
 		assert.Contains(t, out, "<rect")
 	})
+	t.Run("ParticipantColor", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice #FF0000\nparticipant Bob\nAlice -> Bob : hello\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Equal(t, 2, strings.Count(out, `fill="#FF0000"`), "top and bottom boxes use the participant colour")
+		assert.Contains(t, out, `stroke="#FF0000" stroke-width="1" stroke-dasharray`)
+		assert.Contains(t, out, `fill="#3C3F41"`, "other participants keep the theme colour")
+	})
+	t.Run("NamedParticipantColor", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice #lightblue\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), `fill="#ADD8E6"`)
+	})
+	t.Run("BidirectionalMessage", func(t *testing.T) {
+		t.Parallel()
+		render := func(arrow string) string {
+			diagram, errs := parser.Parse("@startuml\nparticipant Alice\nparticipant Bob\nAlice " + arrow + " Bob : sync\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		assert.Equal(t, 1, strings.Count(render("->"), "<polygon"))
+		assert.Equal(t, 1, strings.Count(render("<-"), "<polygon"))
+		both := render("<->")
+		require.Equal(t, 2, strings.Count(both, "<polygon"))
+		// The second head points back at the sender.
+		heads := regexp.MustCompile(`<polygon points="([\d.]+),`).FindAllStringSubmatch(both, -1)
+		assert.NotEqual(t, heads[0][1], heads[1][1])
+		assert.Equal(t, 2, strings.Count(render("<-->"), "<polygon"))
+	})
 	t.Run("ActorParticipant", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nactor Bob\nparticipant Alice\nAlice -> Bob : hello\n@enduml"
@@ -112,6 +158,44 @@ func TestSequenceRenderer(t *testing.T) {
 		// At least: bg rect + 2 top participant boxes + 2 bottom participant boxes + 1 activation
 		assert.GreaterOrEqual(t, rectCount, 6)
 	})
+	t.Run("ActivationShorthand", func(t *testing.T) {
+		t.Parallel()
+		count := func(input string) int {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+			return strings.Count(buf.String(), `width="10.0"`)
+		}
+		assert.Equal(t, 0, count("@startuml\nAlice -> Bob : work\nBob --> Alice : done\n@enduml"))
+		assert.Equal(t, 1, count("@startuml\nAlice -> Bob ++ : work\nBob --> Alice -- : done\n@enduml"))
+		// Shorthand and explicit directives share the same activation state.
+		assert.Equal(t, 1, count("@startuml\nAlice -> Bob ++ : work\ndeactivate Bob\n@enduml"))
+		assert.Equal(t, 1, count("@startuml\nactivate Bob\nAlice -> Bob : work\nBob --> Alice -- : done\n@enduml"))
+	})
+	t.Run("Return", func(t *testing.T) {
+		t.Parallel()
+		render := func(input string) string {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+			return buf.String()
+		}
+		out := render("@startuml\nAlice -> Bob ++ : compute\nreturn result\n@enduml")
+		assert.Contains(t, out, ">result</text>")
+		assert.Equal(t, 4, strings.Count(out, "<line"), "two lifelines, the call and the return")
+		assert.Contains(t, out, "stroke-dasharray")
+		// An explicit activate after the call works the same way.
+		out = render("@startuml\nAlice -> Bob : compute\nactivate Bob\nreturn done\n@enduml")
+		assert.Contains(t, out, ">done</text>")
+		// Nested calls return to their own callers.
+		out = render("@startuml\nAlice -> Bob ++ : a\nBob -> Carol ++ : b\nreturn fromCarol\nreturn fromBob\n@enduml")
+		assert.Less(t, strings.Index(out, "fromCarol"), strings.Index(out, "fromBob"))
+		// With nothing active the return is dropped.
+		out = render("@startuml\nAlice -> Bob : hi\nreturn lost\n@enduml")
+		assert.NotContains(t, out, "lost")
+	})
 	t.Run("NoteLeft", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nparticipant Alice\nnote left of Alice : Client side\n@enduml"
@@ -149,6 +233,78 @@ func TestSequenceRenderer(t *testing.T) {
 		out := buf.String()
 		assert.Contains(t, out, "Centered note")
 	})
+	t.Run("EscapedNewlines", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nAlice -> Bob : first\\nsecond\nnote over Alice : line1\\nline2\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		for _, text := range []string{">first</text>", ">second</text>", ">line1</text>", ">line2</text>"} {
+			assert.Contains(t, out, text)
+		}
+		assert.NotContains(t, out, `\n`)
+		// The label's lines stack above the arrow.
+		textY := func(text string) float64 {
+			m := regexp.MustCompile(`<text x="[0-9.]+" y="([0-9.]+)"[^>]*>` + text + `</text>`).FindStringSubmatch(out)
+			require.NotNil(t, m, text)
+			y, err := strconv.ParseFloat(m[1], 64)
+			require.NoError(t, err)
+			return y
+		}
+		assert.Less(t, textY("first"), textY("second"))
+	})
+	t.Run("LongNoteWraps", func(t *testing.T) {
+		t.Parallel()
+		long := "this note keeps going well past the maximum note width so it has to wrap"
+		input := "@startuml\nparticipant Alice\nnote right of Alice : " + long + "\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.NotContains(t, out, long)
+		assert.Contains(t, out, ">this note")
+		assert.Contains(t, out, "to wrap</text>")
+	})
+	t.Run("TitleHeaderFooter", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\ntitle My System\nheader Draft\nfooter Page one\nAlice -> Bob : hi\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, ">My System</text>")
+		assert.Contains(t, out, ">Draft</text>")
+		assert.Contains(t, out, ">Page one</text>")
+		assert.Contains(t, out, `<g transform="translate(`)
+		assert.True(t, strings.HasSuffix(out, "</g></svg>"))
+	})
+	t.Run("Legend", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nAlice -> Bob : hi\nlegend left\nA legend wider than the whole diagram body\nend legend\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, ">A legend wider than the whole diagram body</text>")
+		var w, h float64
+		_, err = fmt.Sscanf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%f" height="%f"`, &w, &h)
+		require.NoError(t, err)
+		x, y, lw, lh := legendRect(t, out, "A legend wider than the whole diagram body")
+		assert.InDelta(t, 20.0, x, 0.01)
+		assert.LessOrEqual(t, x+lw, w)
+		assert.LessOrEqual(t, y+lh, h)
+	})
 	t.Run("AltElseFragment", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nparticipant Alice\nparticipant Bob\nalt success\nAlice -> Bob : ok\nelse failure\nAlice -> Bob : retry\nend\n@enduml"
@@ -177,6 +333,79 @@ func TestSequenceRenderer(t *testing.T) {
 		assert.Contains(t, out, "loop")
 		assert.Contains(t, out, "3 times")
 	})
+	t.Run("OptFragment", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nopt cache miss\nAlice -> Bob : fetch\nend\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">opt [cache miss]</text>")
+		assert.Contains(t, out, `fill="none"`)
+	})
+	t.Run("OptBracketedCondition", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nopt [x > 0]\nAlice -> Bob : ping\nend\n@enduml")
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">opt [x &gt; 0]</text>")
+		assert.Contains(t, out, ">Alice</text>", "participants of nested messages are drawn")
+		assert.Contains(t, out, ">ping</text>")
+	})
+	t.Run("NestedFragmentsInset", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nloop retry\nalt ok\nAlice -> Bob : inner\nend\nend\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">inner</text>", "messages inside fragments are drawn")
+		rects := regexp.MustCompile(`<rect x="([0-9.]+)" y="([0-9.]+)" width="([0-9.]+)" height="([0-9.]+)" fill="none"`).FindAllStringSubmatch(out, -1)
+		require.Len(t, rects, 2)
+		num := func(s string) float64 {
+			v, err := strconv.ParseFloat(s, 64)
+			require.NoError(t, err)
+			return v
+		}
+		outerX, outerY, outerW, outerH := num(rects[0][1]), num(rects[0][2]), num(rects[0][3]), num(rects[0][4])
+		innerX, innerY, innerW, innerH := num(rects[1][1]), num(rects[1][2]), num(rects[1][3]), num(rects[1][4])
+		assert.Greater(t, innerX, outerX)
+		assert.Less(t, innerX+innerW, outerX+outerW)
+		assert.Greater(t, innerY, outerY)
+		assert.Less(t, innerY+innerH, outerY+outerH)
+	})
+	t.Run("FragmentEnclosesNotes", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nloop poll\nAlice -> Bob : ping\nnote over Bob : first\\nsecond\\nthird\nend\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		frag := regexp.MustCompile(`<rect x="[0-9.]+" y="([0-9.]+)" width="[0-9.]+" height="([0-9.]+)" fill="none"`).FindStringSubmatch(out)
+		require.NotNil(t, frag)
+		fragY, err := strconv.ParseFloat(frag[1], 64)
+		require.NoError(t, err)
+		fragH, err := strconv.ParseFloat(frag[2], 64)
+		require.NoError(t, err)
+		note := regexp.MustCompile(`<polygon points="[0-9.-]+,([0-9.]+) [^"]* [0-9.-]+,([0-9.]+)" fill="` + theme.Darcula().NoteBackgroundColor).FindStringSubmatch(out)
+		require.NotNil(t, note)
+		noteTop, err := strconv.ParseFloat(note[1], 64)
+		require.NoError(t, err)
+		noteBottom, err := strconv.ParseFloat(note[2], 64)
+		require.NoError(t, err)
+		ping := regexp.MustCompile(`<line x1="[0-9.]+" y1="([0-9.]+)"[^>]*stroke="#A9B7C6"`).FindStringSubmatch(out)
+		require.NotNil(t, ping)
+		pingY, err := strconv.ParseFloat(ping[1], 64)
+		require.NoError(t, err)
+		assert.Greater(t, pingY, fragY+20, "message starts below the fragment label")
+		assert.Greater(t, noteTop, pingY, "note follows the message")
+		assert.Less(t, noteBottom, fragY+fragH, "fragment border encloses the note")
+	})
 	t.Run("Divider", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nparticipant Alice\nparticipant Bob\nAlice -> Bob : first\n== Phase 2 ==\nAlice -> Bob : second\n@enduml"
@@ -215,6 +444,73 @@ func TestSequenceRenderer(t *testing.T) {
 		assert.Contains(t, out, "1.")
 		assert.Contains(t, out, "2.")
 	})
+	t.Run("Responsive", func(t *testing.T) {
+		t.Parallel()
+		// The empty diagram takes a separate path.
+		for _, input := range []string{"@startuml\nAlice -> Bob : hi\n@enduml", "@startuml\n@enduml"} {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			for _, responsive := range []bool{false, true} {
+				r := svg.NewSequenceRenderer(nil)
+				r.Responsive = responsive
+				var buf bytes.Buffer
+				require.NoError(t, r.Render(&buf, diagram))
+				out := buf.String()
+				tag := out[:strings.Index(out, ">")]
+				assert.Equal(t, !responsive, strings.Contains(tag, ` width="`), input)
+				assert.Equal(t, !responsive, strings.Contains(tag, ` height="`), input)
+				assert.Contains(t, tag, `viewBox="0 0 `, input)
+			}
+		}
+	})
+	t.Run("Accessible", func(t *testing.T) {
+		t.Parallel()
+		diagram, errs := parser.Parse("@startuml\nactor Alice\nAlice -> Bob : hi\n@enduml")
+		require.Empty(t, errs)
+		for _, accessible := range []bool{false, true} {
+			r := svg.NewSequenceRenderer(nil)
+			r.Accessible = accessible
+			var buf bytes.Buffer
+			require.NoError(t, r.Render(&buf, diagram))
+			out := buf.String()
+			if !accessible {
+				assert.NotContains(t, out, "<title>")
+				continue
+			}
+			// Each participant is drawn at the top and bottom of its lifeline.
+			assert.Equal(t, 2, strings.Count(out, "<g><title>Alice</title>"))
+			assert.Equal(t, 2, strings.Count(out, "<g><title>Bob</title>"))
+			assert.Contains(t, out, "<g><title>Alice -&gt; Bob: hi</title><line")
+			assert.Equal(t, strings.Count(out, "<g>"), strings.Count(out, "</g>"))
+		}
+	})
+	t.Run("Transparent", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"@startuml\nAlice -> Bob : hi\n@enduml", "@startuml\n@enduml"} {
+			diagram, errs := parser.Parse(input)
+			require.Empty(t, errs)
+			for _, transparent := range []bool{false, true} {
+				r := svg.NewSequenceRenderer(nil)
+				r.Transparent = transparent
+				var buf bytes.Buffer
+				require.NoError(t, r.Render(&buf, diagram))
+				assert.Equal(t, !transparent, strings.Contains(buf.String(), `<rect width="`), input)
+			}
+		}
+	})
+	t.Run("AutonumberStopResume", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nautonumber 5\nAlice -> Bob : first\n" +
+			"autonumber stop\nAlice -> Bob : paused\nautonumber resume\nAlice -> Bob : second\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		out := buf.String()
+		assert.Contains(t, out, ">5. first<")
+		assert.Contains(t, out, ">paused<")
+		assert.Contains(t, out, ">6. second<")
+	})
 	t.Run("DarculaThemeColors", func(t *testing.T) {
 		t.Parallel()
 		input := "@startuml\nparticipant Alice\nAlice -> Alice : self\n@enduml"
@@ -283,38 +579,165 @@ func TestSequenceRenderer(t *testing.T) {
 		assert.Contains(t, out, "DB")
 		assert.Contains(t, out, "query")
 	})
-}
-
-func TestSequenceRendererGolden(t *testing.T) {
-	t.Parallel()
-	t.Run("SequenceBasicFixture", func(t *testing.T) {
+	t.Run("CreatedParticipantStartsLower", func(t *testing.T) {
 		t.Parallel()
-		data, err := os.ReadFile("../../../testdata/sequence_basic.puml")
+		input := "@startuml\nparticipant Alice\nAlice -> Alice : init\ncreate Bob\nAlice -> Bob : new\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
 		require.NoError(t, err)
-		diagram, errs := parser.Parse(string(data))
+		out := buf.String()
+		// Only Alice's header box sits on the top row; Bob's is drawn at its creation point.
+		assert.Equal(t, 1, strings.Count(out, `y="20.0" width=`))
+		assert.Contains(t, out, "Bob")
+	})
+	t.Run("CreatingMessagePointsAtBox", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nAlice -> Alice : one\nAlice -> Alice : two\n" +
+			"create participant Bob\nAlice -> Bob : new\n@enduml"
+		diagram, errs := parser.Parse(input)
 		require.Empty(t, errs)
 		r := svg.NewSequenceRenderer(nil)
 		var buf bytes.Buffer
-		err = r.Render(&buf, diagram)
+		require.NoError(t, r.Render(&buf, diagram))
+		out := buf.String()
+		// The self messages sit at y=92 and y=132; Bob's box follows them and
+		// the creating message meets the middle of its left side.
+		assert.Contains(t, out, `<rect x="129.0" y="172.0" width="63.0" height="32.0"`)
+		assert.Contains(t, out, `<line x1="54.5" y1="188.0" x2="129.0" y2="188.0"`)
+		// Bob's lifeline starts below the box.
+		assert.Contains(t, out, `<line x1="160.5" y1="204.0"`)
+	})
+	t.Run("DestroyedParticipant", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nAlice -> Bob : bye\ndestroy Bob\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
 		require.NoError(t, err)
-		got := buf.String()
-		goldenPath := filepath.Join("../../../testdata", "sequence_basic.golden.svg")
-		if updateSeqGolden {
-			err = os.WriteFile(goldenPath, []byte(got), 0o644)
-			require.NoError(t, err)
-			return
-		}
-		golden, err := os.ReadFile(goldenPath)
-		if err != nil {
-			err = os.WriteFile(goldenPath, []byte(got), 0o644)
-			require.NoError(t, err)
-			t.Log("Created golden file, rerun to verify")
-			return
+		out := buf.String()
+		// Two crossing strokes form the X, and Bob gets no bottom box.
+		assert.Equal(t, 2, strings.Count(out, `stroke-width="2"/>`))
+		assert.Equal(t, 3, strings.Count(out, `rx="4"`))
+	})
+	t.Run("DestroyShorthand", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice\nparticipant Bob\nAlice -> Bob ++ : work\nAlice -> Bob !! : bye\nAlice -> Alice : done\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		require.NoError(t, r.Render(&buf, diagram))
+		out := buf.String()
+		// The X sits where the second message meets Bob's lifeline, at
+		// y=132, and the lifeline and Bob's activation stop there.
+		assert.Equal(t, 2, strings.Count(out, `stroke-width="2"/>`))
+		assert.Contains(t, out, `x2="168.5" y2="140.0" stroke=`)
+		assert.Contains(t, out, `y2="132.0" stroke="#555555" stroke-width="1" stroke-dasharray="5,5"/>`)
+		assert.Contains(t, out, `y="92.0" width="10.0" height="40.0"`)
+		// Bob has no bottom box.
+		assert.Equal(t, 3, strings.Count(out, `rx="4"`))
+	})
+	t.Run("Shadowing", func(t *testing.T) {
+		t.Parallel()
+		render := func(skinparam string) string {
+			diagram, errs := parser.Parse("@startuml\n" + skinparam + "\nAlice -> Bob : hi\nnote over Alice : n\n@enduml")
+			require.Empty(t, errs)
+			var buf bytes.Buffer
+			require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+			return buf.String()
 		}
-		assert.Equal(t, string(golden), got, "SVG output differs from golden file")
+		on := render("skinparam shadowing true")
+		assert.Equal(t, 1, strings.Count(on, `<filter id="shadow"`), "the filter is defined once")
+		// Two participants drawn at the top and bottom, and the note.
+		assert.Equal(t, 5, strings.Count(on, `filter="url(#shadow)"`))
+		assert.Less(t, strings.Index(on, "<defs"), strings.Index(on, "<rect x=\"20.0\""), "defs precede the boxes")
+		off := render("skinparam shadowing false")
+		assert.NotContains(t, off, "filter")
+		assert.NotContains(t, off, "<defs")
+	})
+	t.Run("ParticipantOrder", func(t *testing.T) {
+		t.Parallel()
+		input := "@startuml\nparticipant Alice order 20\nparticipant Bob\nparticipant Carol order -1\nBob -> Dave : hi\n@enduml"
+		diagram, errs := parser.Parse(input)
+		require.Empty(t, errs)
+		r := svg.NewSequenceRenderer(nil)
+		var buf bytes.Buffer
+		err := r.Render(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		// Carol moves first, Alice last, and Bob and Dave keep their order.
+		carol, bob := strings.Index(out, ">Carol<"), strings.Index(out, ">Bob<")
+		dave, alice := strings.Index(out, ">Dave<"), strings.Index(out, ">Alice<")
+		assert.Less(t, carol, bob)
+		assert.Less(t, bob, dave)
+		assert.Less(t, dave, alice)
 	})
 }
 
+func TestSequenceRendererGolden(t *testing.T) {
+	t.Parallel()
+	fixtures := []struct {
+		name    string
+		fixture string
+	}{
+		{"SequenceBasicFixture", "sequence_basic"},
+		{"SequenceNestedFixture", "sequence_nested"},
+	}
+	for _, tt := range fixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			data, err := os.ReadFile(filepath.Join("../../../testdata", tt.fixture+".puml"))
+			require.NoError(t, err)
+			diagram, errs := parser.Parse(string(data))
+			require.Empty(t, errs)
+			r := svg.NewSequenceRenderer(nil)
+			var buf bytes.Buffer
+			err = r.Render(&buf, diagram)
+			require.NoError(t, err)
+			got := buf.String()
+			goldenPath := filepath.Join("../../../testdata", tt.fixture+".golden.svg")
+			if updateSeqGolden {
+				err = os.WriteFile(goldenPath, []byte(got), 0o644)
+				require.NoError(t, err)
+				return
+			}
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				err = os.WriteFile(goldenPath, []byte(got), 0o644)
+				require.NoError(t, err)
+				t.Log("Created golden file, rerun to verify")
+				return
+			}
+			assert.Equal(t, string(golden), got, "SVG output differs from golden file")
+		})
+	}
+}
+
+func TestSequenceRendererDeterministic(t *testing.T) {
+	t.Parallel()
+	// Several activations are left open so they are closed together at the
+	// end of the diagram, which used to happen in map iteration order.
+	input := "@startuml\nparticipant Alice\nparticipant Bob\nparticipant Carol\nparticipant Dave\n" +
+		"Alice -> Bob : a\nactivate Bob\nBob -> Carol : b\nactivate Carol\nCarol -> Dave : c\nactivate Dave\n" +
+		"activate Alice\ngroup batch\nDave -> Alice : d\nBob -> Dave : e\nend\n@enduml"
+	diagram, errs := parser.Parse(input)
+	require.Empty(t, errs)
+	render := func() string {
+		var buf bytes.Buffer
+		require.NoError(t, svg.NewSequenceRenderer(nil).Render(&buf, diagram))
+		return buf.String()
+	}
+	want := render()
+	for range 100 {
+		require.Equal(t, want, render())
+	}
+}
+
 func TestSequenceRendererValidSVG(t *testing.T) {
 	t.Parallel()
 	t.Run("ValidSVG11", func(t *testing.T) {
@@ -1,2 +1,444 @@
 // Package svg implements SVG output rendering using ajstarks/svgo.
 package svg
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/font"
+	"github.com/bobcob7/go-uml/internal/layout"
+	"github.com/bobcob7/go-uml/internal/theme"
+)
+
+// wrapText greedily packs the words of text into lines no wider than maxWidth
+// when measured at fontSize. Explicit line breaks are preserved, and a single
+// word wider than maxWidth is placed on a line of its own.
+func wrapText(text string, maxWidth, fontSize float64) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		cur := words[0]
+		for _, w := range words[1:] {
+			candidate := cur + " " + w
+			if sz, _ := font.MeasureText(candidate, fontSize, font.FamilySans); sz.Width > maxWidth {
+				lines = append(lines, cur)
+				cur = w
+				continue
+			}
+			cur = candidate
+		}
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// dumpLayout writes a layout.Dump of g to w when w is set. Write errors are
+// ignored, as the dump is only a debugging aid.
+func dumpLayout(w io.Writer, g *layout.Graph) {
+	if w != nil {
+		_ = layout.Dump(g, w)
+	}
+}
+
+// fontFace is the font chosen for a diagram's text: the families used to
+// measure regular and bold text, and the generic CSS family emitted in
+// font-family attributes.
+type fontFace struct {
+	regular, bold font.Family
+	css           string
+}
+
+// resolveFontFace maps a PlantUML font name to a font face. Names such as
+// "Monospaced", "DejaVu Sans Mono" or "Courier" select monospace; any other
+// name, including "", selects sans-serif.
+func resolveFontFace(name string) fontFace {
+	n := strings.ToLower(name)
+	for _, mono := range []string{"mono", "courier", "consol"} {
+		if strings.Contains(n, mono) {
+			return fontFace{regular: font.FamilyMono, bold: font.FamilyMono, css: "monospace"}
+		}
+	}
+	return fontFace{regular: font.FamilySans, bold: font.FamilyBold, css: "sans-serif"}
+}
+
+// drawing records the size of the last SVG a renderer wrote. Each renderer
+// embeds one, which gives it the Bounds method.
+type drawing struct {
+	width, height float64
+	responsive    bool
+}
+
+// Bounds returns the width and height of the last diagram rendered, as
+// written in its <svg> tag, and whether the tag left them out to make the
+// SVG responsive, keeping them only in the viewBox.
+func (d *drawing) Bounds() (width, height float64, responsive bool) {
+	return d.width, d.height, d.responsive
+}
+
+// writeSVGOpen writes the opening <svg> tag for a width x height drawing and
+// records its size for Bounds. A responsive drawing omits width and height
+// so that it scales to its container, keeping only the viewBox.
+func (d *drawing) writeSVGOpen(sb *strings.Builder, width, height float64, responsive bool) {
+	d.width, d.height, d.responsive = math.RoundToEven(width), math.RoundToEven(height), responsive
+	if responsive {
+		fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f">`, width, height)
+		return
+	}
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`,
+		width, height, width, height)
+}
+
+// writeBackground writes a rect filling a width x height drawing with color
+// and reports whether it did. Nothing is written when transparent is set or
+// color is "transparent", so the page behind the SVG shows through.
+func writeBackground(sb *strings.Builder, width, height float64, color string, transparent bool) bool {
+	if transparent || strings.EqualFold(color, "transparent") {
+		return false
+	}
+	fmt.Fprintf(sb, `<rect width="%.0f" height="%.0f" fill="%s"/>`, width, height, escapeXML(color))
+	return true
+}
+
+// writeEmptySVG writes the blank 100x100 drawing rendered for a diagram with
+// nothing in it. A responsive drawing has no fixed size, as with
+// writeSVGOpen.
+func (d *drawing) writeEmptySVG(w io.Writer, color string, transparent, responsive bool) error {
+	var sb strings.Builder
+	d.writeSVGOpen(&sb, 100, 100, responsive)
+	sb.WriteString("\n")
+	if writeBackground(&sb, 100, 100, color, transparent) {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// stickFigureHeight is the height of the figure drawn by writeStickFigure.
+const stickFigureHeight = 38.0
+
+// writeStickFigure draws an actor as a stick figure centred on cx, with the
+// top of its head at y. The color must already be XML-escaped.
+func writeStickFigure(sb *strings.Builder, cx, y float64, color string) {
+	headR := 8.0
+	fmt.Fprintf(sb, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="1"/>`,
+		cx, y+headR, headR, color)
+	bodyTop := y + headR*2
+	bodyBot := bodyTop + 12
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
+		cx, bodyTop, cx, bodyBot, color)
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
+		cx-10, bodyTop+4, cx+10, bodyTop+4, color)
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
+		cx, bodyBot, cx-8, bodyBot+10, color)
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1"/>`,
+		cx, bodyBot, cx+8, bodyBot+10, color)
+}
+
+// titleMargin is the gap around title, header and footer text.
+const titleMargin = 10.0
+
+// titleBlock holds a diagram's title, header and footer and measures the
+// space they reserve around the diagram body.
+type titleBlock struct {
+	title, header, footer string
+	titleSize, noteSize   float64 // font sizes for the title and for header/footer
+}
+
+// newTitleBlock collects the title, header and footer statements of d.
+// When a directive is repeated, the last one wins.
+func newTitleBlock(d *ast.Diagram, fontSize float64) titleBlock {
+	tb := titleBlock{titleSize: fontSize + 4, noteSize: math.Max(fontSize-2, 8)}
+	for _, stmt := range d.Statements {
+		switch s := stmt.(type) {
+		case *ast.Title:
+			tb.title = s.Text
+		case *ast.Header:
+			tb.header = s.Text
+		case *ast.Footer:
+			tb.footer = s.Text
+		}
+	}
+	return tb
+}
+
+// empty reports whether the block has nothing to draw.
+func (tb titleBlock) empty() bool {
+	return tb.title == "" && tb.header == "" && tb.footer == ""
+}
+
+// top returns the vertical space reserved above the diagram body.
+func (tb titleBlock) top() float64 {
+	h := 0.0
+	if tb.header != "" {
+		h += tb.noteSize + titleMargin
+	}
+	if tb.title != "" {
+		h += tb.titleSize + titleMargin
+	}
+	return h
+}
+
+// bottom returns the vertical space reserved below the diagram body.
+func (tb titleBlock) bottom() float64 {
+	if tb.footer == "" {
+		return 0
+	}
+	return tb.noteSize + titleMargin
+}
+
+// minWidth returns the narrowest diagram width that fits every line of text.
+func (tb titleBlock) minWidth() float64 {
+	w := 0.0
+	if tb.title != "" {
+		sz, _ := font.MeasureText(tb.title, tb.titleSize, font.FamilyBold)
+		w = math.Max(w, sz.Width)
+	}
+	for _, text := range []string{tb.header, tb.footer} {
+		if text != "" {
+			sz, _ := font.MeasureText(text, tb.noteSize, font.FamilySans)
+			w = math.Max(w, sz.Width)
+		}
+	}
+	if w == 0 {
+		return 0
+	}
+	return w + 2*diagramPadding
+}
+
+// render draws the header in the top-right corner, the title centered below
+// it, and the footer centered at the bottom of a width x height diagram.
+func (tb titleBlock) render(sb *strings.Builder, width, height float64, color string) {
+	y := 0.0
+	if tb.header != "" {
+		y += tb.noteSize + titleMargin
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="end" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			width-diagramPadding, y, tb.noteSize, color, escapeXML(tb.header))
+		sb.WriteString("\n")
+	}
+	if tb.title != "" {
+		y += tb.titleSize + titleMargin
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" font-weight="bold" fill="%s">%s</text>`,
+			width/2, y, tb.titleSize, color, escapeXML(tb.title))
+		sb.WriteString("\n")
+	}
+	if tb.footer != "" {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			width/2, height-titleMargin, tb.noteSize, color, escapeXML(tb.footer))
+		sb.WriteString("\n")
+	}
+}
+
+// legendBox holds a diagram's legend, measured for placement below the body.
+type legendBox struct {
+	lines          []string
+	align          ast.LegendAlignment
+	width, height  float64
+	fontSize, padX float64
+}
+
+// newLegendBox measures the legend statement of d. When several legends are
+// given, the last one wins.
+func newLegendBox(d *ast.Diagram, fontSize float64) legendBox {
+	lb := legendBox{fontSize: fontSize, padX: 5}
+	for _, stmt := range d.Statements {
+		if l, ok := stmt.(*ast.Legend); ok {
+			lb.lines = strings.Split(l.Text, "\n")
+			lb.align = l.Alignment
+		}
+	}
+	if lb.lines == nil {
+		return lb
+	}
+	sz, _ := font.MeasureText(strings.Join(lb.lines, "\n"), fontSize, font.FamilySans)
+	lb.width = sz.Width + 2*lb.padX
+	lb.height = float64(len(lb.lines))*(fontSize+4) + 6
+	return lb
+}
+
+// bottom returns the vertical space reserved below the diagram body.
+func (lb legendBox) bottom() float64 {
+	if lb.lines == nil {
+		return 0
+	}
+	return lb.height + diagramPadding
+}
+
+// minWidth returns the narrowest diagram width that fits the legend.
+func (lb legendBox) minWidth() float64 {
+	if lb.lines == nil {
+		return 0
+	}
+	return lb.width + 2*diagramPadding
+}
+
+// render draws the legend with its top edge at y, aligned within a diagram
+// of the given width, using the note colours of resolver.
+func (lb legendBox) render(sb *strings.Builder, width, y float64, resolver *theme.Resolver) {
+	if lb.lines == nil {
+		return
+	}
+	x := width - diagramPadding - lb.width
+	switch lb.align {
+	case ast.LegendLeft:
+		x = diagramPadding
+	case ast.LegendCenter:
+		x = (width - lb.width) / 2
+	}
+	fmt.Fprintf(sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s"/>`,
+		x, y, lb.width, lb.height,
+		escapeXML(svgColor(resolver.ResolveColor("NoteBackgroundColor"))),
+		escapeXML(svgColor(resolver.ResolveColor("NoteBorderColor"))))
+	sb.WriteString("\n")
+	fontColor := escapeXML(svgColor(resolver.ResolveColor("NoteFontColor")))
+	textY := y + lb.fontSize + 3
+	for _, line := range lb.lines {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			x+lb.padX, textY, lb.fontSize, fontColor, escapeXML(line))
+		sb.WriteString("\n")
+		textY += lb.fontSize + 4
+	}
+}
+
+// Arrowhead marker IDs defined by writeMarkerDefs. Each marker points along
+// the path direction and is mirrored when used as a marker-start.
+const (
+	markerArrowOpen      = "marker-arrow-open"
+	markerTriangleFilled = "marker-triangle-filled"
+	markerTriangleOpen   = "marker-triangle-open"
+	markerDiamondFilled  = "marker-diamond-filled"
+	markerDiamondOpen    = "marker-diamond-open"
+)
+
+// shadowFilter is the ID of the drop-shadow filter that boxes reference
+// when skinparam shadowing is on.
+const shadowFilter = "shadow"
+
+// defsBuilder collects definitions, such as gradients, while a diagram is
+// drawn, so that they can be written in one <defs> section near the top.
+type defsBuilder struct {
+	sb        strings.Builder
+	gradients map[[2]string]string // top and bottom colour → gradient ID
+	shadowed  bool                 // the shadow filter has been defined
+}
+
+// shadowAttr returns the filter attribute that gives a box a drop shadow,
+// defining the shared filter the first time it is used. It returns "" when
+// on is false.
+func (d *defsBuilder) shadowAttr(on bool) string {
+	if !on {
+		return ""
+	}
+	if !d.shadowed {
+		d.shadowed = true
+		fmt.Fprintf(&d.sb, `<filter id="%s" x="-10%%" y="-10%%" width="130%%" height="130%%"><feDropShadow dx="3" dy="3" stdDeviation="2" flood-color="#000000" flood-opacity="0.3"/></filter>`,
+			shadowFilter)
+		d.sb.WriteString("\n")
+	}
+	return fmt.Sprintf(` filter="url(#%s)"`, shadowFilter)
+}
+
+// gradient returns the ID of a top-to-bottom linear gradient between two
+// colours, defining it the first time the pair is used.
+func (d *defsBuilder) gradient(top, bottom string) string {
+	key := [2]string{top, bottom}
+	if id, ok := d.gradients[key]; ok {
+		return id
+	}
+	if d.gradients == nil {
+		d.gradients = map[[2]string]string{}
+	}
+	id := fmt.Sprintf("gradient-%d", len(d.gradients)+1)
+	d.gradients[key] = id
+	fmt.Fprintf(&d.sb, `<linearGradient id="%s" x1="0" y1="0" x2="0" y2="1"><stop offset="0" stop-color="%s"/><stop offset="1" stop-color="%s"/></linearGradient>`,
+		id, escapeXML(top), escapeXML(bottom))
+	d.sb.WriteString("\n")
+	return id
+}
+
+// writeMarkerDefs writes a <defs> section with the arrowhead markers that
+// relationship lines reference. The markers are drawn in currentColor, which
+// the <defs> element sets to color. Open shapes are filled white so the line
+// does not show through them.
+func writeMarkerDefs(sb *strings.Builder, color string) {
+	writeDefs(sb, color, true, nil)
+}
+
+// writeDefs writes a <defs> section like writeMarkerDefs, leaving out the
+// markers unless markers is set and adding the definitions collected in
+// extra, which may be nil. Nothing is written if the section would be empty.
+func writeDefs(sb *strings.Builder, color string, markers bool, extra *defsBuilder) {
+	if !markers && (extra == nil || extra.sb.Len() == 0) {
+		return
+	}
+	fmt.Fprintf(sb, `<defs color="%s">`, escapeXML(color))
+	sb.WriteString("\n")
+	if markers {
+		marker := func(id string, w, h float64, shape string) {
+			fmt.Fprintf(sb, `<marker id="%s" markerWidth="%.0f" markerHeight="%.0f" refX="%.0f" refY="%.0f" orient="auto-start-reverse" markerUnits="userSpaceOnUse">%s</marker>`,
+				id, w, h, w, h/2, shape)
+			sb.WriteString("\n")
+		}
+		marker(markerArrowOpen, 10, 10, `<path d="M1,1 L10,5 L1,9" fill="none" stroke="currentColor" stroke-width="1"/>`)
+		marker(markerTriangleFilled, 12, 12, `<path d="M0.5,0.5 L12,6 L0.5,11.5 Z" fill="currentColor" stroke="currentColor" stroke-width="1"/>`)
+		marker(markerTriangleOpen, 12, 12, `<path d="M0.5,0.5 L12,6 L0.5,11.5 Z" fill="white" stroke="currentColor" stroke-width="1"/>`)
+		marker(markerDiamondFilled, 12, 8, `<path d="M0.5,4 L6,0.5 L12,4 L6,7.5 Z" fill="currentColor" stroke="currentColor" stroke-width="1"/>`)
+		marker(markerDiamondOpen, 12, 8, `<path d="M0.5,4 L6,0.5 L12,4 L6,7.5 Z" fill="white" stroke="currentColor" stroke-width="1"/>`)
+	}
+	if extra != nil {
+		sb.WriteString(extra.sb.String())
+	}
+	sb.WriteString("</defs>\n")
+}
+
+// formatPoints formats pts as the value of a points attribute.
+func formatPoints(pts []point) string {
+	coords := make([]string, len(pts))
+	for i, p := range pts {
+		coords[i] = fmt.Sprintf("%.1f,%.1f", p.x, p.y)
+	}
+	return strings.Join(coords, " ")
+}
+
+// openTitledGroup starts a <g> element whose <title> is title, which screen
+// readers announce and browsers show as a tooltip, and returns the tag that
+// closes it. It writes nothing and returns "" when on is false.
+func openTitledGroup(sb *strings.Builder, title string, on bool) string {
+	if !on {
+		return ""
+	}
+	fmt.Fprintf(sb, "<g><title>%s</title>", escapeXML(title))
+	return "</g>"
+}
+
+// markerAttrs returns marker-start and marker-end attributes referencing the
+// given marker IDs; an empty ID leaves that end bare.
+func markerAttrs(start, end string) string {
+	var attrs string
+	if start != "" {
+		attrs += fmt.Sprintf(` marker-start="url(#%s)"`, start)
+	}
+	if end != "" {
+		attrs += fmt.Sprintf(` marker-end="url(#%s)"`, end)
+	}
+	return attrs
+}
+
+// svgColor converts a PlantUML colour to SVG syntax. Hex colours keep their
+// '#', known names such as "#LightBlue" become hex, and other names lose the
+// '#', since SVG spells them without it. Of a gradient such as "#red/green"
+// only the first colour is used.
+func svgColor(c string) string {
+	c, _, _ = strings.Cut(c, "/")
+	if hex, ok := theme.ResolveColorName(c); ok {
+		return hex
+	}
+	return strings.TrimPrefix(c, "#")
+}
@@ -0,0 +1,268 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/font"
+	"github.com/bobcob7/go-uml/internal/layout"
+	"github.com/bobcob7/go-uml/internal/theme"
+)
+
+// UsecaseRenderer renders usecase diagrams to SVG.
+type UsecaseRenderer struct {
+	// Responsive omits the width and height of the SVG so it scales to its
+	// container. The skinparam responsiveSVG also enables it.
+	Responsive bool
+	// Transparent omits the background rect so that the page behind the
+	// SVG shows through. A backgroundColor of "transparent" also enables it.
+	Transparent bool
+	// LayoutDebug, when set, receives a layout.Dump of the positioned
+	// graph, for debugging layouts.
+	LayoutDebug io.Writer
+	resolver    *theme.Resolver
+	drawing
+}
+
+// NewUsecaseRenderer creates a new usecase diagram SVG renderer.
+// If resolver is nil, the default Darcula theme is used. resolver is
+// cloned, so it may be shared with other renderers.
+func NewUsecaseRenderer(resolver *theme.Resolver) *UsecaseRenderer {
+	if resolver == nil {
+		resolver = theme.NewResolver(nil)
+	}
+	return &UsecaseRenderer{resolver: resolver.Clone()}
+}
+
+// usecaseNode holds an actor or usecase placed in the layout graph.
+type usecaseNode struct {
+	label   string
+	isActor bool
+}
+
+// Render writes the usecase diagram SVG to w. Actors are drawn as stick
+// figures and usecases as ellipses; a name used in a relationship without
+// being declared is taken to be an actor.
+func (r *UsecaseRenderer) Render(w io.Writer, diagram *ast.Diagram) error {
+	opts := layout.DefaultOptions()
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.LayoutDirection:
+			if s.LeftToRight {
+				opts.Direction = layout.LeftRight
+			} else {
+				opts.Direction = layout.TopDown
+			}
+		case *ast.Skinparam:
+			r.resolver.SetSkinparam(s.Name, s.Value)
+		}
+	}
+	fontSize := float64(r.resolver.ResolveInt("FontSize", 13))
+	padding := float64(r.resolver.ResolveInt("Padding", 10))
+	graph := &layout.Graph{}
+	nodes := map[string]*usecaseNode{}
+	aliases := map[string]string{}
+	add := func(id, label string, isActor bool) {
+		if _, ok := nodes[id]; ok {
+			return
+		}
+		nodes[id] = &usecaseNode{label: label, isActor: isActor}
+		size, _ := font.MeasureText(label, fontSize, font.FamilySans)
+		var nw, nh float64
+		if isActor {
+			nw = math.Max(size.Width, 2*padding)
+			nh = stickFigureHeight + 4 + size.Height
+		} else {
+			// An ellipse through the corners of the padded label scales
+			// each side of the label box by √2.
+			nw = math.Sqrt2 * (size.Width + padding)
+			nh = math.Sqrt2 * (size.Height + padding)
+		}
+		graph.Nodes = append(graph.Nodes, &layout.Node{ID: id, Width: nw, Height: nh})
+	}
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.Participant:
+			if s.Alias != "" {
+				aliases[s.Alias] = s.Name
+			}
+		case *ast.Usecase:
+			if s.Alias != "" {
+				aliases[s.Alias] = s.Name
+			}
+		}
+	}
+	// aliasOf returns the alias declared for name, if any.
+	aliasOf := func(name string) (string, bool) {
+		for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+			if aliases[alias] == name {
+				return alias, true
+			}
+		}
+		return "", false
+	}
+	var rels []*ast.Relationship
+	for _, stmt := range diagram.Statements {
+		switch s := stmt.(type) {
+		case *ast.Participant:
+			if s.Alias != "" {
+				add(s.Alias, s.Name, true)
+			} else if _, ok := aliasOf(s.Name); !ok {
+				add(s.Name, s.Name, true)
+			}
+		case *ast.Usecase:
+			if s.Alias != "" {
+				add(s.Alias, s.Name, false)
+			} else if _, ok := aliasOf(s.Name); !ok {
+				add(s.Name, s.Name, false)
+			}
+		case *ast.Relationship:
+			rels = append(rels, s)
+		}
+	}
+	resolve := func(name string) string {
+		if _, ok := nodes[name]; ok {
+			return name
+		}
+		if alias, ok := aliasOf(name); ok {
+			return alias
+		}
+		add(name, name, true)
+		return name
+	}
+	ends := make([][2]string, len(rels))
+	for i, rel := range rels {
+		ends[i] = [2]string{resolve(rel.Left), resolve(rel.Right)}
+		graph.Edges = append(graph.Edges, &layout.Edge{From: ends[i][0], To: ends[i][1], Label: rel.Label})
+	}
+	if len(graph.Nodes) == 0 {
+		return r.renderEmpty(w)
+	}
+	layout.Layout(graph, opts)
+	dumpLayout(r.LayoutDebug, graph)
+	nodeByID := map[string]*layout.Node{}
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for _, n := range graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		nodeByID[n.ID] = n
+		minX = math.Min(minX, n.X)
+		minY = math.Min(minY, n.Y)
+		maxX = math.Max(maxX, n.X+n.Width)
+		maxY = math.Max(maxY, n.Y+n.Height)
+	}
+	offsetX := -minX + diagramPadding
+	offsetY := -minY + diagramPadding
+	svgW := int(maxX - minX + 2*diagramPadding)
+	svgH := int(maxY - minY + 2*diagramPadding)
+	titles := newTitleBlock(diagram, fontSize)
+	if w := int(math.Ceil(titles.minWidth())); w > svgW {
+		offsetX += float64(w-svgW) / 2
+		svgW = w
+	}
+	offsetY += titles.top()
+	svgH += int(titles.top() + titles.bottom())
+	var sb strings.Builder
+	r.writeSVGOpen(&sb, float64(svgW), float64(svgH), r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+	sb.WriteString("\n")
+	if writeBackground(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("BackgroundColor"), r.Transparent) {
+		sb.WriteString("\n")
+	}
+	if len(rels) > 0 {
+		writeMarkerDefs(&sb, r.resolver.ResolveColor("ArrowColor"))
+	}
+	titles.render(&sb, float64(svgW), float64(svgH), r.resolver.ResolveColor("FontColor"))
+	for i, rel := range rels {
+		from, to := nodeByID[ends[i][0]], nodeByID[ends[i][1]]
+		if from == nil || to == nil {
+			continue
+		}
+		r.renderConnection(&sb, rel, nodes[ends[i][0]], from, nodes[ends[i][1]], to, offsetX, offsetY)
+	}
+	for _, n := range graph.Nodes {
+		if n.Virtual {
+			continue
+		}
+		r.renderNode(&sb, nodes[n.ID], n.X+offsetX, n.Y+offsetY, n.Width, n.Height, fontSize)
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r *UsecaseRenderer) renderEmpty(w io.Writer) error {
+	return r.writeEmptySVG(w, r.resolver.ResolveColor("BackgroundColor"), r.Transparent,
+		r.Responsive || r.resolver.ResolveBool("ResponsiveSVG", false))
+}
+
+// anchor returns the point where an edge towards (tx, ty) leaves the node.
+// Usecases attach to their ellipse rather than its bounding box.
+func (n *usecaseNode) anchor(x, y, w, h, tx, ty float64) point {
+	if n.isActor {
+		return edgePoint(x, y, w, h, tx, ty)
+	}
+	cx, cy := x+w/2, y+h/2
+	dx, dy := tx-cx, ty-cy
+	if dx == 0 && dy == 0 {
+		return point{cx, cy}
+	}
+	rx, ry := w/2, h/2
+	t := 1 / math.Sqrt(dx*dx/(rx*rx)+dy*dy/(ry*ry))
+	return point{cx + t*dx, cy + t*dy}
+}
+
+func (r *UsecaseRenderer) renderConnection(sb *strings.Builder, rel *ast.Relationship, fromNode *usecaseNode, from *layout.Node, toNode *usecaseNode, to *layout.Node, offsetX, offsetY float64) {
+	arrowColor := escapeXML(r.resolver.ResolveColor("ArrowColor"))
+	thickness := r.resolver.ResolveInt("ArrowThickness", 1)
+	fx, fy := from.X+offsetX, from.Y+offsetY
+	tx, ty := to.X+offsetX, to.Y+offsetY
+	fromPt := fromNode.anchor(fx, fy, from.Width, from.Height, tx+to.Width/2, ty+to.Height/2)
+	toPt := toNode.anchor(tx, ty, to.Width, to.Height, fx+from.Width/2, fy+from.Height/2)
+	dashAttr := ""
+	if strings.Contains(rel.Arrow, "..") {
+		dashAttr = ` stroke-dasharray="7,4"`
+	}
+	startMarker, endMarker := relationshipMarkers(rel)
+	fmt.Fprintf(sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%d"%s%s/>`,
+		fromPt.x, fromPt.y, toPt.x, toPt.y, arrowColor, thickness, dashAttr, markerAttrs(startMarker, endMarker))
+	sb.WriteString("\n")
+	if rel.Label != "" {
+		arrowFontSize := r.resolver.ResolveInt("ArrowFontSize", 11)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			(fromPt.x+toPt.x)/2, (fromPt.y+toPt.y)/2-5, arrowFontSize, arrowColor, escapeXML(rel.Label))
+		sb.WriteString("\n")
+	}
+}
+
+func (r *UsecaseRenderer) renderNode(sb *strings.Builder, n *usecaseNode, x, y, w, h, fontSize float64) {
+	cx := x + w/2
+	if n.isActor {
+		writeStickFigure(sb, cx, y, escapeXML(r.resolver.ResolveColor("ParticipantBorderColor")))
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			cx, y+stickFigureHeight+4+fontSize, fontSize, escapeXML(r.resolver.ResolveColor("ParticipantFontColor")), escapeXML(n.label))
+		sb.WriteString("\n")
+		return
+	}
+	fmt.Fprintf(sb, `<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" fill="%s" stroke="%s" stroke-width="%d"/>`,
+		cx, y+h/2, w/2, h/2,
+		escapeXML(r.resolver.ResolveColor("UsecaseBackgroundColor")),
+		escapeXML(r.resolver.ResolveColor("UsecaseBorderColor")),
+		r.resolver.ResolveInt("BorderWidth", 1))
+	sb.WriteString("\n")
+	fontColor := escapeXML(r.resolver.ResolveColor("UsecaseFontColor"))
+	lines := strings.Split(n.label, "\n")
+	lineH := fontSize + 4
+	startY := y + h/2 - lineH*float64(len(lines))/2 + fontSize
+	for i, line := range lines {
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-family="sans-serif" font-size="%.0f" fill="%s">%s</text>`,
+			cx, startY+float64(i)*lineH, fontSize, fontColor, escapeXML(line))
+		sb.WriteString("\n")
+	}
+}
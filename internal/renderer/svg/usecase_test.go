@@ -0,0 +1,59 @@
+package svg_test
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bobcob7/go-uml/internal/renderer/svg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsecaseRenderer(t *testing.T) {
+	t.Parallel()
+	t.Run("EmptyDiagram", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewUsecaseRenderer(nil), "@startuml\n@enduml")
+		assert.Contains(t, out, "<svg")
+		assert.Contains(t, out, "</svg>")
+	})
+	t.Run("ActorAsStickFigure", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewUsecaseRenderer(nil), "@startuml\nactor User\n(Log In)\n@enduml")
+		assert.Equal(t, 1, strings.Count(out, "<circle"))
+		assert.Equal(t, 4, strings.Count(out, "<line"))
+		assert.Contains(t, out, ">User<")
+	})
+	t.Run("UsecaseEllipseFitsLabel", func(t *testing.T) {
+		t.Parallel()
+		short := renderSVG(t, svg.NewUsecaseRenderer(nil), "@startuml\n(Pay)\n@enduml")
+		long := renderSVG(t, svg.NewUsecaseRenderer(nil), "@startuml\n(Pay the outstanding bill)\n@enduml")
+		assert.Contains(t, long, ">Pay the outstanding bill<")
+		rx := regexp.MustCompile(`<ellipse [^>]*rx="([0-9.]+)"`)
+		width := func(out string) float64 {
+			m := rx.FindStringSubmatch(out)
+			require.NotNil(t, m)
+			v, err := strconv.ParseFloat(m[1], 64)
+			require.NoError(t, err)
+			return v
+		}
+		assert.Greater(t, width(long), width(short))
+	})
+	t.Run("Association", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewUsecaseRenderer(nil), "@startuml\nactor User\nUser --> (Log In) : starts\n@enduml")
+		assert.Equal(t, 1, strings.Count(out, "<ellipse"))
+		assert.Contains(t, out, ">starts<")
+		assert.Equal(t, 1, strings.Count(out, `marker-end="url(#marker-arrow-open)"`))
+	})
+	t.Run("AliasedUsecase", func(t *testing.T) {
+		t.Parallel()
+		out := renderSVG(t, svg.NewUsecaseRenderer(nil), "@startuml\nusecase (Check Out) as CO\nGuest --> CO\n@enduml")
+		assert.Equal(t, 1, strings.Count(out, "<ellipse"))
+		assert.Contains(t, out, ">Check Out<")
+		assert.NotContains(t, out, ">CO<")
+		assert.Contains(t, out, ">Guest<")
+	})
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the render duration
+// histogram buckets.
+var durationBuckets = [...]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// metrics counts renders for the /metrics endpoint. It is safe for
+// concurrent use.
+type metrics struct {
+	renders      atomic.Uint64
+	renderErrors atomic.Uint64
+	// buckets[i] counts renders that took at most durationBuckets[i].
+	buckets     [len(durationBuckets)]atomic.Uint64
+	durationSum atomic.Int64 // nanoseconds
+}
+
+// observe records a render that took d and failed if failed is set.
+func (m *metrics) observe(d time.Duration, failed bool) {
+	m.renders.Add(1)
+	if failed {
+		m.renderErrors.Add(1)
+	}
+	for i, le := range durationBuckets {
+		if d.Seconds() <= le {
+			m.buckets[i].Add(1)
+		}
+	}
+	m.durationSum.Add(int64(d))
+}
+
+// writeTo writes the metrics to w in the Prometheus text exposition format.
+func (m *metrics) writeTo(w io.Writer) {
+	renders := m.renders.Load()
+	fmt.Fprintln(w, "# HELP godot_uml_renders_total Total number of diagrams rendered.")
+	fmt.Fprintln(w, "# TYPE godot_uml_renders_total counter")
+	fmt.Fprintf(w, "godot_uml_renders_total %d\n", renders)
+	fmt.Fprintln(w, "# HELP godot_uml_render_errors_total Total number of renders that failed.")
+	fmt.Fprintln(w, "# TYPE godot_uml_render_errors_total counter")
+	fmt.Fprintf(w, "godot_uml_render_errors_total %d\n", m.renderErrors.Load())
+	fmt.Fprintln(w, "# HELP godot_uml_render_duration_seconds Time taken to render a diagram.")
+	fmt.Fprintln(w, "# TYPE godot_uml_render_duration_seconds histogram")
+	for i, le := range durationBuckets {
+		fmt.Fprintf(w, "godot_uml_render_duration_seconds_bucket{le=\"%g\"} %d\n", le, m.buckets[i].Load())
+	}
+	fmt.Fprintf(w, "godot_uml_render_duration_seconds_bucket{le=\"+Inf\"} %d\n", renders)
+	fmt.Fprintf(w, "godot_uml_render_duration_seconds_sum %g\n", time.Duration(m.durationSum.Load()).Seconds())
+	fmt.Fprintf(w, "godot_uml_render_duration_seconds_count %d\n", renders)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitMiddleware returns middleware that limits each client, keyed by
+// the host of r.RemoteAddr, to rps requests per second with bursts of up to
+// burst requests. Requests over the limit get 429 Too Many Requests with a
+// Retry-After header giving the seconds until the next one is allowed.
+func RateLimitMiddleware(rps, burst int) func(http.Handler) http.Handler {
+	limiter := newRateLimiter(rps, burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			if wait := limiter.bucket(clientAddr(r), now).take(now); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimiter holds a token bucket for each client. A bucket left idle long
+// enough to refill behaves like a new one, so such buckets are dropped by a
+// sweep that runs at most once per refill time.
+type rateLimiter struct {
+	rps, burst int
+	refill     time.Duration // time for an empty bucket to fill up
+
+	mu        sync.Mutex
+	clients   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(rps, burst int) *rateLimiter {
+	refill := time.Duration(float64(max(burst, 1)) / float64(max(rps, 1)) * float64(time.Second))
+	return &rateLimiter{rps: rps, burst: burst, refill: refill, clients: map[string]*tokenBucket{}}
+}
+
+// bucket returns the bucket of the client key at time now, creating it if
+// needed.
+func (l *rateLimiter) bucket(key string, now time.Time) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= l.refill {
+		l.sweep(now)
+	}
+	b, ok := l.clients[key]
+	if !ok {
+		b = newTokenBucket(l.rps, l.burst)
+		l.clients[key] = b
+	}
+	return b
+}
+
+// sweep drops the buckets that are full again at time now.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.clients {
+		if b.idleSince(now) >= l.refill {
+			delete(l.clients, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientAddr returns the host part of r.RemoteAddr, so that connections
+// from one client on different ports share a limit.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tokenBucket is a token bucket that refills at rate tokens per second up
+// to burst tokens. It starts full.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps, burst int) *tokenBucket {
+	return &tokenBucket{rate: float64(max(rps, 1)), burst: float64(max(burst, 1)), tokens: float64(max(burst, 1))}
+}
+
+// idleSince returns how long before now the bucket was last used.
+func (b *tokenBucket) idleSince(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Sub(b.last)
+}
+
+// take removes a token at time now and returns 0, or, when the bucket is
+// empty, leaves it unchanged and returns how long until a token is free.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterSweep(t *testing.T) {
+	t.Parallel()
+	l := newRateLimiter(10, 10) // refills in one second
+	start := time.Now()
+	l.bucket("192.0.2.1", start).take(start)
+	l.bucket("192.0.2.2", start.Add(500*time.Millisecond)).take(start.Add(500 * time.Millisecond))
+	assert.Len(t, l.clients, 2)
+
+	// The first client has been idle for a full refill, the second has not.
+	now := start.Add(1200 * time.Millisecond)
+	l.bucket("192.0.2.3", now)
+	assert.NotContains(t, l.clients, "192.0.2.1")
+	assert.Contains(t, l.clients, "192.0.2.2")
+	assert.Contains(t, l.clients, "192.0.2.3")
+
+	// Sweeps run at most once per refill time.
+	l.bucket("192.0.2.4", now.Add(900*time.Millisecond))
+	assert.Contains(t, l.clients, "192.0.2.2")
+	l.bucket("192.0.2.4", now.Add(time.Second))
+	assert.NotContains(t, l.clients, "192.0.2.2")
+	assert.Contains(t, l.clients, "192.0.2.4")
+}
@@ -2,12 +2,15 @@
 package server
 
 import (
+	"bytes"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bobcob7/go-uml/internal/encoding"
@@ -23,39 +26,122 @@ type Config struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// MaxConcurrent limits how many diagrams of a batch render at once.
+	MaxConcurrent int
+	// CORSOrigins lists the origins allowed to call the server from a
+	// browser; {"*"} allows any origin. Empty disables CORS headers.
+	CORSOrigins []string
+	// RateLimit is the number of render requests per second allowed from
+	// each client, and RateBurst how many may arrive at once. A RateLimit
+	// of zero disables rate limiting.
+	RateLimit int
+	RateBurst int
+	// EnableMetrics serves render counters and durations at /metrics in
+	// the Prometheus text format.
+	EnableMetrics bool
+	// EnableCompression gzips SVG, JSON and HTML responses for clients
+	// that accept it, leaving out small responses.
+	EnableCompression bool
+	// Debounce is how long the live-render WebSocket at /ws waits after a
+	// message before rendering it; a message arriving within that time
+	// replaces the pending one. Zero renders every message.
+	Debounce time.Duration
+	// Version is reported by /health.
+	Version string
 }
 
 // DefaultConfig returns sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		Host:         "localhost",
-		Port:         8080,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		Host:              "localhost",
+		Port:              8080,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		MaxConcurrent:     4,
+		RateLimit:         10,
+		RateBurst:         20,
+		Debounce:          200 * time.Millisecond,
+		EnableCompression: true,
+		Version:           "dev",
 	}
 }
 
 // Server is the HTTP server for go-uml.
 type Server struct {
-	config Config
-	mux    *http.ServeMux
+	config  Config
+	mux     *http.ServeMux
+	handler http.Handler // mux wrapped in any middleware
+	metrics metrics
 }
 
-// New creates a new Server with the given config.
+// New creates a new Server with the given config. When cfg.CORSOrigins is
+// set, every response passes through CORSMiddleware. When cfg.RateLimit is
+// set, the rendering endpoints pass through RateLimitMiddleware. When
+// cfg.EnableCompression is set, responses pass through CompressionMiddleware.
 func New(cfg Config) *Server {
 	s := &Server{config: cfg, mux: http.NewServeMux()}
-	s.mux.HandleFunc("POST /render", s.handleRender)
-	s.mux.HandleFunc("GET /svg/{encoded...}", s.handleSVG)
+	limit := func(h http.Handler) http.Handler { return h }
+	if cfg.RateLimit > 0 {
+		limit = RateLimitMiddleware(cfg.RateLimit, cfg.RateBurst)
+	}
+	s.mux.Handle("POST /render", limit(http.HandlerFunc(s.handleRender)))
+	s.mux.Handle("POST /render/batch", limit(http.HandlerFunc(s.handleRenderBatch)))
+	s.mux.HandleFunc("POST /validate", s.handleValidate)
+	s.mux.Handle("GET /svg/{encoded...}", limit(http.HandlerFunc(s.handleSVG)))
+	s.mux.Handle("GET /png/{encoded...}", limit(http.HandlerFunc(s.handlePNG)))
+	s.mux.Handle("GET /size/{encoded...}", limit(http.HandlerFunc(s.handleSize)))
+	s.mux.Handle("GET /ws", limit(http.HandlerFunc(s.handleWebSocket)))
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	if cfg.EnableMetrics {
+		s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	}
 	s.mux.HandleFunc("GET /", s.handleEditor)
+	s.handler = s.mux
+	if cfg.EnableCompression {
+		s.handler = CompressionMiddleware(compressMinSize)(s.handler)
+	}
+	if len(cfg.CORSOrigins) > 0 {
+		s.handler = CORSMiddleware(cfg.CORSOrigins)(s.handler)
+	}
 	return s
 }
 
+// CORSMiddleware returns middleware that adds Cross-Origin Resource Sharing
+// headers to responses for requests from allowedOrigins, and answers their
+// preflight OPTIONS requests with 204 No Content. An allowedOrigins of
+// {"*"} allows every origin.
+func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowAll := slices.Contains(allowedOrigins, "*")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			switch {
+			case allowAll:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin != "" && slices.Contains(allowedOrigins, origin):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // ListenAndServe starts the server.
 func (s *Server) ListenAndServe() error {
 	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      s.mux,
+		Handler:      s.handler,
 		ReadTimeout:  s.config.ReadTimeout,
 		WriteTimeout: s.config.WriteTimeout,
 	}
@@ -64,34 +150,162 @@ func (s *Server) ListenAndServe() error {
 
 // Handler returns the HTTP handler for testing.
 func (s *Server) Handler() http.Handler {
-	return s.mux
+	return s.handler
 }
 
-func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+// readSource reads the request body as diagram source. On failure it writes
+// a 400 response and returns false.
+func readSource(w http.ResponseWriter, r *http.Request) (string, bool) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, "failed to read body", http.StatusBadRequest)
-		return
+		return "", false
 	}
-	errs := gouml.Validate(strings.NewReader(string(body)))
-	if len(errs) > 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		resp := errorResponse{Errors: make([]errorDetail, len(errs))}
-		for i, e := range errs {
-			resp.Errors[i] = errorDetail{Line: e.Line, Column: e.Column, Message: e.Message}
+	return string(body), true
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeDiagram renders source to w as SVG, or as PNG when asPNG is set,
+// with the matching Content-Type. A PNG is rendered in full before anything
+// is written, so a failure can still be reported as an error response.
+func writeDiagram(w http.ResponseWriter, source string, asPNG bool) error {
+	if !asPNG {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		return gouml.Render(strings.NewReader(source), w)
+	}
+	var buf bytes.Buffer
+	if err := gouml.RenderPNG(strings.NewReader(source), &buf); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// blockingErrors returns the problems gouml.Validate finds in source that
+// prevent it from rendering, leaving out warnings.
+func blockingErrors(source string) []errorDetail {
+	var errs []errorDetail
+	for _, e := range gouml.Validate(strings.NewReader(source)) {
+		if e.Severity == gouml.SeverityError {
+			errs = append(errs, errorDetail{Line: e.Line, Column: e.Column, Message: e.Message})
 		}
-		_ = json.NewEncoder(w).Encode(resp)
+	}
+	return errs
+}
+
+// handleRender renders the posted source as SVG, or as PNG when the format
+// query parameter is "png".
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+	var asPNG bool
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "svg":
+	case "png":
+		asPNG = true
+	default:
+		http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		return
+	}
+	source, ok := readSource(w, r)
+	if !ok {
+		return
+	}
+	start, failed := time.Now(), true
+	defer func() { s.metrics.observe(time.Since(start), failed) }()
+	if errs := blockingErrors(source); len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Errors: errs})
 		return
 	}
-	w.Header().Set("Content-Type", "image/svg+xml")
-	if err := gouml.Render(strings.NewReader(string(body)), w); err != nil {
+	if err := writeDiagram(w, source, asPNG); err != nil {
 		http.Error(w, fmt.Sprintf("render error: %s", err), http.StatusInternalServerError)
 		return
 	}
+	failed = false
+}
+
+// handleValidate reports the problems gouml.Validate finds in the posted
+// source without rendering it. It always responds 200 OK; "valid" is false
+// when any problem is an error rather than a warning.
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	source, ok := readSource(w, r)
+	if !ok {
+		return
+	}
+	resp := validateResponse{Valid: true}
+	for _, e := range gouml.Validate(strings.NewReader(source)) {
+		if e.Severity == gouml.SeverityError {
+			resp.Valid = false
+		}
+		resp.Errors = append(resp.Errors, errorDetail{Line: e.Line, Column: e.Column, Message: e.Message, Severity: e.Severity.String()})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleRenderBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid batch request: %s", err), http.StatusBadRequest)
+		return
+	}
+	limit := s.config.MaxConcurrent
+	if limit < 1 {
+		limit = 1
+	}
+	results := make([]batchResult, len(req.Diagrams))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, d := range req.Diagrams {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			results[i] = renderBatchItem(d)
+			s.metrics.observe(time.Since(start), results[i].SVG == "")
+		}()
+	}
+	wg.Wait()
+	writeJSON(w, http.StatusOK, batchResponse{Results: results})
+}
+
+// renderBatchItem renders one diagram of a batch, reporting failures in the
+// result rather than aborting the batch.
+func renderBatchItem(d batchDiagram) batchResult {
+	res := batchResult{Name: d.Name, Errors: []errorDetail{}}
+	diagram, errs := gouml.Parse(strings.NewReader(d.Source))
+	if len(errs) > 0 {
+		for _, e := range errs {
+			res.Errors = append(res.Errors, errorDetail{Line: e.Line, Column: e.Column, Message: e.Message})
+		}
+		return res
+	}
+	var sb strings.Builder
+	if err := gouml.RenderDiagram(&sb, diagram); err != nil {
+		res.Errors = append(res.Errors, errorDetail{Message: fmt.Sprintf("render error: %s", err)})
+		return res
+	}
+	res.SVG = sb.String()
+	return res
 }
 
 func (s *Server) handleSVG(w http.ResponseWriter, r *http.Request) {
+	s.handleEncoded(w, r, false)
+}
+
+func (s *Server) handlePNG(w http.ResponseWriter, r *http.Request) {
+	s.handleEncoded(w, r, true)
+}
+
+// handleEncoded renders the diagram encoded in the request path as SVG, or
+// as PNG when asPNG is set.
+func (s *Server) handleEncoded(w http.ResponseWriter, r *http.Request, asPNG bool) {
 	encoded := r.PathValue("encoded")
 	if encoded == "" {
 		http.Error(w, "missing encoded diagram", http.StatusBadRequest)
@@ -102,13 +316,121 @@ func (s *Server) handleSVG(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("decode error: %s", err), http.StatusBadRequest)
 		return
 	}
-	w.Header().Set("Content-Type", "image/svg+xml")
-	if err := gouml.Render(strings.NewReader(text), w); err != nil {
+	start := time.Now()
+	err = writeDiagram(w, text, asPNG)
+	s.metrics.observe(time.Since(start), err != nil)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("render error: %s", err), http.StatusInternalServerError)
 		return
 	}
 }
 
+// handleSize reports the rendered size and the inferred kind of the diagram
+// encoded in the request path, so that a client can size a preview before
+// fetching the image.
+func (s *Server) handleSize(w http.ResponseWriter, r *http.Request) {
+	text, err := encoding.Decode(r.PathValue("encoded"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decode error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if errs := blockingErrors(text); len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Errors: errs})
+		return
+	}
+	diagram, _ := gouml.ParseString(text)
+	start := time.Now()
+	size, err := gouml.Measure(diagram)
+	s.metrics.observe(time.Since(start), err != nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("render error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, sizeResponse{Size: size, Type: diagram.DiagramType().String()})
+}
+
+// handleWebSocket serves the live-render WebSocket. Each text message from
+// the client is diagram source; once no newer message has arrived for
+// Config.Debounce, the server replies with the rendered SVG, or with the
+// JSON error response /render would give.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	sources := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(sources)
+		for {
+			source, err := conn.readMessage()
+			if err != nil {
+				return
+			}
+			select {
+			case sources <- source:
+			case <-done:
+				return
+			}
+		}
+	}()
+	timer := time.NewTimer(s.config.Debounce)
+	timer.Stop()
+	var pending string
+	for {
+		select {
+		case source, ok := <-sources:
+			if !ok {
+				return
+			}
+			if s.config.Debounce <= 0 {
+				if conn.writeText(s.renderLive(source)) != nil {
+					return
+				}
+				continue
+			}
+			pending = source
+			timer.Reset(s.config.Debounce)
+		case <-timer.C:
+			if conn.writeText(s.renderLive(pending)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// renderLive renders source for the live-render WebSocket, returning the
+// SVG or, on failure, an error response as JSON.
+func (s *Server) renderLive(source string) string {
+	start, failed := time.Now(), true
+	defer func() { s.metrics.observe(time.Since(start), failed) }()
+	errs := blockingErrors(source)
+	if len(errs) == 0 {
+		var sb strings.Builder
+		err := gouml.Render(strings.NewReader(source), &sb)
+		if err == nil {
+			failed = false
+			return sb.String()
+		}
+		errs = []errorDetail{{Message: fmt.Sprintf("render error: %s", err)}}
+	}
+	data, _ := json.Marshal(errorResponse{Errors: errs})
+	return string(data)
+}
+
+// handleHealth answers liveness and readiness probes.
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok", Version: s.config.Version})
+}
+
+// handleMetrics writes the render metrics in the Prometheus text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.writeTo(w)
+}
+
 func (s *Server) handleEditor(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
@@ -123,12 +445,47 @@ func (s *Server) handleEditor(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(data)
 }
 
+type sizeResponse struct {
+	gouml.Size
+	Type string `json:"type"`
+}
+
 type errorResponse struct {
 	Errors []errorDetail `json:"errors"`
 }
 
 type errorDetail struct {
-	Line    int    `json:"line"`
-	Column  int    `json:"column"`
-	Message string `json:"message"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"`
+}
+
+type healthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+type validateResponse struct {
+	Valid  bool          `json:"valid"`
+	Errors []errorDetail `json:"errors,omitempty"`
+}
+
+type batchRequest struct {
+	Diagrams []batchDiagram `json:"diagrams"`
+}
+
+type batchDiagram struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+type batchResult struct {
+	Name   string        `json:"name"`
+	SVG    string        `json:"svg"`
+	Errors []errorDetail `json:"errors"`
 }
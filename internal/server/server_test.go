@@ -1,11 +1,18 @@
 package server_test
 
 import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"image/png"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bobcob7/go-uml/internal/encoding"
 	"github.com/bobcob7/go-uml/internal/server"
@@ -65,6 +72,85 @@ func TestServer(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rec.Code)
 		assert.Contains(t, rec.Body.String(), "<svg")
 	})
+	t.Run("PostRenderBatch", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		body := `{"diagrams":[` +
+			`{"name":"good","source":"@startuml\nclass Foo\n@enduml"},` +
+			`{"name":"bad","source":"not a diagram"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/render/batch", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var resp struct {
+			Results []struct {
+				Name   string            `json:"name"`
+				SVG    string            `json:"svg"`
+				Errors []json.RawMessage `json:"errors"`
+			} `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, "good", resp.Results[0].Name)
+		assert.Contains(t, resp.Results[0].SVG, "Foo")
+		assert.Empty(t, resp.Results[0].Errors)
+		assert.Equal(t, "bad", resp.Results[1].Name)
+		assert.Empty(t, resp.Results[1].SVG)
+		assert.NotEmpty(t, resp.Results[1].Errors)
+	})
+	t.Run("PostRenderBatchInvalidJSON", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		req := httptest.NewRequest(http.MethodPost, "/render/batch", strings.NewReader("{"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("PostValidateValid", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		req := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader("@startuml\nclass Foo\n@enduml"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"valid":true}`, rec.Body.String())
+	})
+	t.Run("PostValidateInvalid", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		req := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader("@startuml\nclass Foo\n$bad\n@enduml"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp struct {
+			Valid  bool `json:"valid"`
+			Errors []struct {
+				Line     int    `json:"line"`
+				Column   int    `json:"column"`
+				Message  string `json:"message"`
+				Severity string `json:"severity"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.False(t, resp.Valid)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, 3, resp.Errors[0].Line)
+		assert.Equal(t, 1, resp.Errors[0].Column)
+		assert.NotEmpty(t, resp.Errors[0].Message)
+		assert.Equal(t, "error", resp.Errors[0].Severity)
+	})
+	t.Run("PostValidateWarningsOnly", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		req := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader("@startuml\nskinparam noSuchParam red\nclass Foo\n@enduml"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"valid":true`)
+		assert.Contains(t, rec.Body.String(), `"severity":"warning"`)
+	})
 	t.Run("GetSVGEncoded", func(t *testing.T) {
 		t.Parallel()
 		handler := newTestServer()
@@ -87,6 +173,104 @@ func TestServer(t *testing.T) {
 		handler.ServeHTTP(rec, req)
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
+	t.Run("GetPNGEncoded", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		encoded, err := encoding.Encode("@startuml\nclass Foo\n@enduml")
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/png/"+encoded, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+		img, err := png.Decode(rec.Body)
+		require.NoError(t, err)
+		assert.Positive(t, img.Bounds().Dx())
+	})
+	t.Run("GetPNGInvalidEncoding", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		req := httptest.NewRequest(http.MethodGet, "/png/!!!!invalid", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("GetSizeEncoded", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		tests := []struct {
+			source string
+			kind   string
+		}{
+			{"@startuml\nclass Foo\nclass Bar\nFoo --> Bar\n@enduml", "class"},
+			{"@startuml\nAlice -> Bob : hello\n@enduml", "sequence"},
+		}
+		for _, tt := range tests {
+			encoded, err := encoding.Encode(tt.source)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/size/"+encoded, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, tt.kind)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			var size struct {
+				Width  int    `json:"width"`
+				Height int    `json:"height"`
+				Type   string `json:"type"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &size))
+			assert.Positive(t, size.Width, tt.kind)
+			assert.Positive(t, size.Height, tt.kind)
+			assert.Equal(t, tt.kind, size.Type)
+		}
+	})
+	t.Run("GetSizeParseError", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		encoded, err := encoding.Encode("@startuml\nclass Foo {\n")
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/size/"+encoded, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), `"errors"`)
+		assert.Contains(t, rec.Body.String(), `"line"`)
+	})
+	t.Run("GetSizeInvalidEncoding", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		req := httptest.NewRequest(http.MethodGet, "/size/!!!!invalid", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("PostRenderFormat", func(t *testing.T) {
+		t.Parallel()
+		handler := newTestServer()
+		body := "@startuml\nAlice -> Bob : hello\n@enduml"
+		tests := []struct {
+			format      string
+			status      int
+			contentType string
+		}{
+			{"svg", http.StatusOK, "image/svg+xml"},
+			{"png", http.StatusOK, "image/png"},
+			{"gif", http.StatusBadRequest, "text/plain; charset=utf-8"},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodPost, "/render?format="+tt.format, strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, tt.format)
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"), tt.format)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/render?format=png", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		_, err := png.Decode(rec.Body)
+		require.NoError(t, err)
+	})
 	t.Run("GetEditor", func(t *testing.T) {
 		t.Parallel()
 		handler := newTestServer()
@@ -110,6 +294,210 @@ func TestServer(t *testing.T) {
 	})
 }
 
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	newCORSServer := func(origins ...string) http.Handler {
+		cfg := server.DefaultConfig()
+		cfg.CORSOrigins = origins
+		return server.New(cfg).Handler()
+	}
+	t.Run("Preflight", func(t *testing.T) {
+		t.Parallel()
+		handler := newCORSServer("https://editor.example")
+		req := httptest.NewRequest(http.MethodOptions, "/render", nil)
+		req.Header.Set("Origin", "https://editor.example")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://editor.example", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "POST")
+		assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Content-Type")
+		assert.Empty(t, rec.Body.String())
+	})
+	t.Run("PostRender", func(t *testing.T) {
+		t.Parallel()
+		handler := newCORSServer("https://editor.example")
+		req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\nclass Foo\n@enduml"))
+		req.Header.Set("Origin", "https://editor.example")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "https://editor.example", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+		assert.Contains(t, rec.Body.String(), "<svg")
+	})
+	t.Run("DisallowedOrigin", func(t *testing.T) {
+		t.Parallel()
+		handler := newCORSServer("https://editor.example")
+		req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\nclass Foo\n@enduml"))
+		req.Header.Set("Origin", "https://other.example")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+	t.Run("Wildcard", func(t *testing.T) {
+		t.Parallel()
+		handler := newCORSServer("*")
+		req := httptest.NewRequest(http.MethodOptions, "/validate", nil)
+		req.Header.Set("Origin", "https://any.example")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\nclass Foo\n@enduml"))
+		req.Header.Set("Origin", "https://editor.example")
+		rec := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rec, req)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+	t.Run("Middleware", func(t *testing.T) {
+		t.Parallel()
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		handler := server.CORSMiddleware([]string{"*"})(next)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+	t.Run("Middleware", func(t *testing.T) {
+		t.Parallel()
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		handler := server.RateLimitMiddleware(10, 10)(next)
+		request := func(addr string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = addr
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+		for i := range 10 {
+			assert.Equal(t, http.StatusNoContent, request("192.0.2.1:1000").Code, i)
+		}
+		rec := request("192.0.2.1:2000")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		// Another client has its own limit.
+		assert.Equal(t, http.StatusNoContent, request("192.0.2.2:1000").Code)
+	})
+	t.Run("RenderEndpoints", func(t *testing.T) {
+		t.Parallel()
+		cfg := server.DefaultConfig()
+		cfg.RateLimit = 1
+		cfg.RateBurst = 1
+		handler := server.New(cfg).Handler()
+		encoded, err := encoding.Encode("@startuml\nclass Foo\n@enduml")
+		require.NoError(t, err)
+		tests := []struct {
+			method, target string
+			want           int
+		}{
+			{http.MethodPost, "/render", http.StatusOK},
+			{http.MethodPost, "/render", http.StatusTooManyRequests},
+			{http.MethodGet, "/svg/" + encoded, http.StatusTooManyRequests},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader("@startuml\nclass Foo\n@enduml"))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code, tt.target)
+		}
+		// Validation is cheap and not limited.
+		req := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader("@startuml\nclass Foo\n@enduml"))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		cfg := server.DefaultConfig()
+		cfg.RateLimit = 0
+		handler := server.New(cfg).Handler()
+		for range 30 {
+			req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\n@enduml"))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+}
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+	cfg := server.DefaultConfig()
+	cfg.Version = "1.2.3"
+	handler := server.New(cfg).Handler()
+	health := func() {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"status":"ok","version":"1.2.3"}`, rec.Body.String())
+	}
+	health()
+	req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\nclass {\n@enduml"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	health()
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	t.Run("CountsRenders", func(t *testing.T) {
+		t.Parallel()
+		cfg := server.DefaultConfig()
+		cfg.EnableMetrics = true
+		handler := server.New(cfg).Handler()
+		for range 3 {
+			req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\nclass Foo\n@enduml"))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+		body := rec.Body.String()
+		assert.Contains(t, body, "\ngodot_uml_renders_total 3\n")
+		assert.Contains(t, body, "\ngodot_uml_render_errors_total 0\n")
+		assert.Contains(t, body, "# TYPE godot_uml_render_duration_seconds histogram\n")
+		assert.Contains(t, body, "\ngodot_uml_render_duration_seconds_bucket{le=\"+Inf\"} 3\n")
+		assert.Contains(t, body, "\ngodot_uml_render_duration_seconds_count 3\n")
+	})
+	t.Run("CountsErrors", func(t *testing.T) {
+		t.Parallel()
+		cfg := server.DefaultConfig()
+		cfg.EnableMetrics = true
+		handler := server.New(cfg).Handler()
+		req := httptest.NewRequest(http.MethodPost, "/render", strings.NewReader("@startuml\nclass {\n@enduml"))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Contains(t, rec.Body.String(), "\ngodot_uml_renders_total 1\n")
+		assert.Contains(t, rec.Body.String(), "\ngodot_uml_render_errors_total 1\n")
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		rec := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.NotEqual(t, http.StatusOK, rec.Code)
+	})
+}
+
 func TestDefaultConfig(t *testing.T) {
 	t.Parallel()
 	cfg := server.DefaultConfig()
@@ -117,4 +505,245 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, 8080, cfg.Port)
 	assert.Greater(t, cfg.ReadTimeout.Seconds(), 0.0)
 	assert.Greater(t, cfg.WriteTimeout.Seconds(), 0.0)
+	assert.Positive(t, cfg.MaxConcurrent)
+	assert.Positive(t, cfg.RateLimit)
+	assert.GreaterOrEqual(t, cfg.RateBurst, cfg.RateLimit)
+	assert.Positive(t, cfg.Debounce)
+	assert.True(t, cfg.EnableCompression)
+}
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+	get := func(t *testing.T, handler http.Handler, method, target, body, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	gunzip := func(t *testing.T, rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		zr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		return string(data)
+	}
+	diagram := "@startuml\nclass Foo {\n+name : String\n+age : int\n+speak() : void\n}\nclass Bar\nFoo --> Bar\n@enduml"
+	t.Run("SVG", func(t *testing.T) {
+		t.Parallel()
+		rec := get(t, newTestServer(), http.MethodPost, "/render", diagram, "gzip, deflate")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Values("Vary"), "Accept-Encoding")
+		svg := gunzip(t, rec)
+		assert.Contains(t, svg, "<svg")
+		assert.Contains(t, svg, "Foo")
+	})
+	t.Run("Editor", func(t *testing.T) {
+		t.Parallel()
+		rec := get(t, newTestServer(), http.MethodGet, "/", "", "gzip")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, gunzip(t, rec), "<html")
+	})
+	t.Run("ErrorJSON", func(t *testing.T) {
+		t.Parallel()
+		source := "@startuml\n" + strings.Repeat("class {\n", 40) + "@enduml"
+		rec := get(t, newTestServer(), http.MethodPost, "/render", source, "gzip")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Contains(t, gunzip(t, rec), `"errors"`)
+	})
+	t.Run("SmallResponse", func(t *testing.T) {
+		t.Parallel()
+		rec := get(t, newTestServer(), http.MethodGet, "/health", "", "gzip")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.JSONEq(t, `{"status":"ok","version":"dev"}`, rec.Body.String())
+	})
+	t.Run("PNG", func(t *testing.T) {
+		t.Parallel()
+		rec := get(t, newTestServer(), http.MethodPost, "/render?format=png", diagram, "gzip")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		_, err := png.Decode(rec.Body)
+		assert.NoError(t, err)
+	})
+	t.Run("NotAccepted", func(t *testing.T) {
+		t.Parallel()
+		for _, accept := range []string{"", "deflate", "gzip;q=0"} {
+			rec := get(t, newTestServer(), http.MethodPost, "/render", diagram, accept)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("Content-Encoding"), accept)
+			assert.Contains(t, rec.Body.String(), "<svg", accept)
+		}
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		cfg := server.DefaultConfig()
+		cfg.EnableCompression = false
+		rec := get(t, server.New(cfg).Handler(), http.MethodPost, "/render", diagram, "gzip")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Contains(t, rec.Body.String(), "<svg")
+	})
+}
+
+// wsClient is a minimal WebSocket client for exercising /ws.
+type wsClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dialWebSocket opens a WebSocket connection to the /ws endpoint of srv.
+func dialWebSocket(t *testing.T, srv *httptest.Server) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_, err = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: localhost\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	require.NoError(t, err)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	// The accept key for the sample nonce given in RFC 6455.
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return &wsClient{conn: conn, br: br}
+}
+
+// send writes s as a masked text frame.
+func (c *wsClient) send(t *testing.T, s string) {
+	t.Helper()
+	frame := []byte{0x81}
+	if len(s) < 126 {
+		frame = append(frame, 0x80|byte(len(s)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(s)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i := range len(s) {
+		frame = append(frame, s[i]^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+// receive reads the next frame, which must arrive within timeout, and
+// returns its opcode and payload.
+func (c *wsClient) receive(t *testing.T, timeout time.Duration) (byte, string, error) {
+	t.Helper()
+	require.NoError(t, c.conn.SetReadDeadline(time.Now().Add(timeout)))
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return 0, "", err
+	}
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		_, err := io.ReadFull(c.br, ext[:])
+		require.NoError(t, err)
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, err := io.ReadFull(c.br, ext[:])
+		require.NoError(t, err)
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, size)
+	_, err := io.ReadFull(c.br, payload)
+	require.NoError(t, err)
+	return head[0] & 0x0F, string(payload), nil
+}
+
+func TestWebSocket(t *testing.T) {
+	t.Parallel()
+	newServer := func(debounce time.Duration) *httptest.Server {
+		cfg := server.DefaultConfig()
+		cfg.Debounce = debounce
+		srv := httptest.NewServer(server.New(cfg).Handler())
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	t.Run("RendersSVG", func(t *testing.T) {
+		t.Parallel()
+		c := dialWebSocket(t, newServer(0))
+		c.send(t, "@startuml\nclass Foo\n@enduml")
+		op, msg, err := c.receive(t, 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, byte(0x1), op)
+		assert.Contains(t, msg, "<svg")
+		assert.Contains(t, msg, "Foo")
+	})
+	t.Run("ReportsErrors", func(t *testing.T) {
+		t.Parallel()
+		c := dialWebSocket(t, newServer(0))
+		c.send(t, "@startuml\nclass {\n@enduml")
+		_, msg, err := c.receive(t, 5*time.Second)
+		require.NoError(t, err)
+		var resp struct {
+			Errors []struct {
+				Line    int    `json:"line"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(msg), &resp), msg)
+		require.NotEmpty(t, resp.Errors)
+		assert.Equal(t, 2, resp.Errors[0].Line)
+	})
+	t.Run("Debounces", func(t *testing.T) {
+		t.Parallel()
+		c := dialWebSocket(t, newServer(100*time.Millisecond))
+		c.send(t, "@startuml\nclass First\n@enduml")
+		c.send(t, "@startuml\nclass Second\n@enduml")
+		c.send(t, "@startuml\nclass Third\n@enduml")
+		_, msg, err := c.receive(t, 5*time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, msg, "Third")
+		assert.NotContains(t, msg, "First")
+		_, _, err = c.receive(t, 300*time.Millisecond)
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr, "expected only one render")
+		assert.True(t, netErr.Timeout())
+	})
+	t.Run("LargeMessage", func(t *testing.T) {
+		t.Parallel()
+		c := dialWebSocket(t, newServer(0))
+		var b strings.Builder
+		b.WriteString("@startuml\n")
+		for b.Len() < 1000 {
+			b.WriteString("class LongClassName" + strings.Repeat("X", b.Len()%7) + "\n")
+		}
+		b.WriteString("@enduml")
+		c.send(t, b.String())
+		_, msg, err := c.receive(t, 5*time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, msg, "<svg")
+	})
+	t.Run("Close", func(t *testing.T) {
+		t.Parallel()
+		c := dialWebSocket(t, newServer(0))
+		_, err := c.conn.Write([]byte{0x88, 0x82, 0, 0, 0, 0, 0x03, 0xE8})
+		require.NoError(t, err)
+		op, msg, err := c.receive(t, 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, byte(0x8), op)
+		assert.Equal(t, "\x03\xe8", msg)
+	})
+	t.Run("RejectsPlainRequest", func(t *testing.T) {
+		t.Parallel()
+		rec := httptest.NewRecorder()
+		newTestServer().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
 }
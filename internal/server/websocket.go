@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is appended to the client's key to derive the accept key of
+// the opening handshake (RFC 6455, section 1.3).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessageSize bounds the size of one message from a client, so that a
+// client cannot make the server buffer without limit.
+const maxMessageSize = 1 << 20
+
+// WebSocket frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// WebSocket close status codes.
+const (
+	closeNormal        = 1000
+	closeProtocolError = 1002
+	closeTooLarge      = 1009
+)
+
+// errClosed is returned by readMessage once the connection is closed.
+var errClosed = errors.New("websocket: connection closed")
+
+// wsConn is the server end of a WebSocket connection. It supports what the
+// live-render endpoint needs: text and binary messages, fragmentation, ping
+// and close; extensions and subprotocols are not negotiated.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	mu   sync.Mutex // serialises writes
+}
+
+// upgradeWebSocket performs the server side of the WebSocket opening
+// handshake. If r is not a valid upgrade request it writes a 400 response
+// and returns an error.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		http.Error(w, "expected a WebSocket upgrade request", http.StatusBadRequest)
+		return nil, errors.New("websocket: not an upgrade request")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// The server's read and write timeouts are meant for single requests,
+	// not for a connection that stays open while the user edits.
+	_ = conn.SetDeadline(time.Time{})
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// headerContains reports whether the comma-separated header name of h
+// contains token, ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage returns the payload of the next text or binary message,
+// answering pings and reassembling fragments on the way. It returns
+// errClosed when the client closes the connection.
+func (c *wsConn) readMessage() (string, error) {
+	var msg []byte
+	started := false
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return "", err
+		}
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return "", err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			_ = c.writeClose(closeNormal)
+			return "", errClosed
+		case opText, opBinary:
+			if started {
+				_ = c.writeClose(closeProtocolError)
+				return "", errors.New("websocket: new message inside a fragmented one")
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				_ = c.writeClose(closeProtocolError)
+				return "", errors.New("websocket: continuation without a message")
+			}
+		default:
+			_ = c.writeClose(closeProtocolError)
+			return "", fmt.Errorf("websocket: unknown opcode %#x", opcode)
+		}
+		if len(msg)+len(payload) > maxMessageSize {
+			_ = c.writeClose(closeTooLarge)
+			return "", errors.New("websocket: message too large")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return string(msg), nil
+		}
+	}
+}
+
+// readFrame reads one frame and returns its unmasked payload. Frames from
+// a client must be masked.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = head[0]&0x80 != 0, head[0]&0x0F
+	if head[1]&0x80 == 0 {
+		_ = c.writeClose(closeProtocolError)
+		return false, 0, nil, errors.New("websocket: unmasked client frame")
+	}
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > maxMessageSize {
+		_ = c.writeClose(closeTooLarge)
+		return false, 0, nil, errors.New("websocket: frame too large")
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeText sends s as a single text message.
+func (c *wsConn) writeText(s string) error {
+	return c.writeFrame(opText, []byte(s))
+}
+
+// writeClose sends a close frame with the given status code.
+func (c *wsConn) writeClose(code uint16) error {
+	return c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, code))
+}
+
+// writeFrame sends payload as one unfragmented, unmasked frame.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(append(frame, payload...))
+	return err
+}
+
+// Close closes the underlying connection.
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
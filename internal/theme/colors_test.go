@@ -0,0 +1,35 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveColorName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"HTMLName", "red", "#FF0000", true},
+		{"MixedCase", "LightBlue", "#ADD8E6", true},
+		{"HashPrefixedName", "#DarkSlateGray", "#2F4F4F", true},
+		{"ArchiMateName", "MOTIVATION", "#CCCCFF", true},
+		{"HexUntouched", "#2b2B2b", "#2b2B2b", true},
+		{"ShortHex", "#FFF", "#FFF", true},
+		{"HexWithAlpha", "#11223344", "#11223344", true},
+		{"Unknown", "notacolour", "", false},
+		{"Empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := ResolveColorName(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
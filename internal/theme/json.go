@@ -0,0 +1,44 @@
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Load reads a theme stored as JSON, keyed by skinparam names such as
+// "backgroundColor". The theme starts from Darcula and the file overlays
+// it, so properties left out keep their Darcula values. Unknown keys are
+// ignored, so that a theme written for a newer version still loads.
+func Load(r io.Reader) (*Theme, error) {
+	t := Darcula()
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, fmt.Errorf("decoding theme: %w", err)
+	}
+	return t, nil
+}
+
+// LoadFile reads a JSON theme from the file at path, as Load does.
+func LoadFile(path string) (*Theme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	t, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Save writes t to w as indented JSON that Load can read back.
+func Save(w io.Writer, t *Theme) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t); err != nil {
+		return fmt.Errorf("encoding theme: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package theme
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSave(t *testing.T) {
+	t.Parallel()
+	t.Run("RoundTripDarcula", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, Save(&buf, Darcula()))
+		loaded, err := Load(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, Darcula(), loaded)
+	})
+	t.Run("SkinparamKeys", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, Save(&buf, Darcula()))
+		out := buf.String()
+		assert.Contains(t, out, `"backgroundColor": "#2B2B2B"`)
+		assert.Contains(t, out, `"defaultFontSize": 13`)
+		assert.Contains(t, out, `"wrapWidth": 200`)
+		for property, key := range skinparamKeys {
+			if property == "ResponsiveSVG" || property == "Shadowing" || property == "ClassFontName" {
+				continue
+			}
+			assert.Contains(t, out, `"`+key+`"`, property)
+		}
+	})
+	t.Run("PartialThemeOverlaysDarcula", func(t *testing.T) {
+		t.Parallel()
+		loaded, err := Load(strings.NewReader(`{"classBackgroundColor": "#123456", "defaultFontSize": 16}`))
+		require.NoError(t, err)
+		want := Darcula()
+		want.ClassBackgroundColor = "#123456"
+		want.FontSize = 16
+		assert.Equal(t, want, loaded)
+		r := NewResolver(loaded)
+		assert.Equal(t, "#123456", r.ResolveColor("ClassBackgroundColor"))
+		assert.Equal(t, Darcula().BackgroundColor, r.ResolveColor("BackgroundColor"))
+	})
+	t.Run("UnknownKeyIgnored", func(t *testing.T) {
+		t.Parallel()
+		loaded, err := Load(strings.NewReader(`{"clasBackgroundColor": "#123456", "arrowColor": "#654321"}`))
+		require.NoError(t, err)
+		want := Darcula()
+		want.ArrowColor = "#654321"
+		assert.Equal(t, want, loaded)
+	})
+	t.Run("WrongType", func(t *testing.T) {
+		t.Parallel()
+		_, err := Load(strings.NewReader(`{"defaultFontSize": "big"}`))
+		assert.Error(t, err)
+	})
+	t.Run("InvalidJSON", func(t *testing.T) {
+		t.Parallel()
+		_, err := Load(strings.NewReader(`{`))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "theme.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"backgroundColor": "#FFFFFF"}`), 0o644))
+		loaded, err := LoadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "#FFFFFF", loaded.BackgroundColor)
+		assert.Equal(t, Darcula().ClassBackgroundColor, loaded.ClassBackgroundColor)
+	})
+	t.Run("Missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "theme.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{`), 0o644))
+		_, err := LoadFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path)
+	})
+}
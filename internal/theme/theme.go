@@ -1,52 +1,80 @@
 // Package theme provides theme definitions and skinparam resolution for diagram styling.
 package theme
 
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
 // Theme defines the complete visual styling for diagram rendering.
 // Property resolution order: skinparam overrides → theme values → hardcoded fallbacks.
+// In JSON, each field is keyed by the skinparam that overrides it.
 type Theme struct {
 	// Global
-	BackgroundColor string
-	FontName        string
-	FontSize        int
-	FontColor       string
+	BackgroundColor string `json:"backgroundColor,omitempty"`
+	FontName        string `json:"defaultFontName,omitempty"`
+	FontSize        int    `json:"defaultFontSize,omitempty"`
+	FontColor       string `json:"defaultFontColor,omitempty"`
 	// Class elements
-	ClassBackgroundColor     string
-	ClassBorderColor         string
-	ClassFontColor           string
-	ClassFontSize            int
-	ClassStereotypeFontColor string
+	ClassBackgroundColor     string `json:"classBackgroundColor,omitempty"`
+	ClassBorderColor         string `json:"classBorderColor,omitempty"`
+	ClassFontColor           string `json:"classFontColor,omitempty"`
+	ClassFontSize            int    `json:"classFontSize,omitempty"`
+	ClassStereotypeFontColor string `json:"classStereotypeFontColor,omitempty"`
+	// Member visibility icons
+	IconPublicColor    string `json:"iconPublicColor,omitempty"`
+	IconPrivateColor   string `json:"iconPrivateColor,omitempty"`
+	IconProtectedColor string `json:"iconProtectedColor,omitempty"`
+	IconPackageColor   string `json:"iconPackageColor,omitempty"`
 	// Interface elements
-	InterfaceBackgroundColor string
-	InterfaceBorderColor     string
-	InterfaceFontColor       string
+	InterfaceBackgroundColor string `json:"interfaceBackgroundColor,omitempty"`
+	InterfaceBorderColor     string `json:"interfaceBorderColor,omitempty"`
+	InterfaceFontColor       string `json:"interfaceFontColor,omitempty"`
 	// Enum elements
-	EnumBackgroundColor string
-	EnumBorderColor     string
-	EnumFontColor       string
+	EnumBackgroundColor string `json:"enumBackgroundColor,omitempty"`
+	EnumBorderColor     string `json:"enumBorderColor,omitempty"`
+	EnumFontColor       string `json:"enumFontColor,omitempty"`
 	// Arrows and lines
-	ArrowColor    string
-	ArrowFontSize int
+	ArrowColor    string `json:"arrowColor,omitempty"`
+	ArrowFontSize int    `json:"arrowFontSize,omitempty"`
 	// Notes
-	NoteBackgroundColor string
-	NoteBorderColor     string
-	NoteFontColor       string
+	NoteBackgroundColor string `json:"noteBackgroundColor,omitempty"`
+	NoteBorderColor     string `json:"noteBorderColor,omitempty"`
+	NoteFontColor       string `json:"noteFontColor,omitempty"`
 	// Sequence diagram
-	ParticipantBackgroundColor  string
-	ParticipantBorderColor      string
-	ParticipantFontColor        string
-	SequenceLifeLineBorderColor string
+	ParticipantBackgroundColor  string `json:"participantBackgroundColor,omitempty"`
+	ParticipantBorderColor      string `json:"participantBorderColor,omitempty"`
+	ParticipantFontColor        string `json:"participantFontColor,omitempty"`
+	SequenceLifeLineBorderColor string `json:"sequenceLifeLineBorderColor,omitempty"`
+	// Activity diagram
+	ActivityBackgroundColor string `json:"activityBackgroundColor,omitempty"`
+	ActivityBorderColor     string `json:"activityBorderColor,omitempty"`
+	ActivityFontColor       string `json:"activityFontColor,omitempty"`
+	// Component diagram
+	ComponentBackgroundColor string `json:"componentBackgroundColor,omitempty"`
+	ComponentBorderColor     string `json:"componentBorderColor,omitempty"`
+	ComponentFontColor       string `json:"componentFontColor,omitempty"`
+	// Usecase diagram
+	UsecaseBackgroundColor string `json:"usecaseBackgroundColor,omitempty"`
+	UsecaseBorderColor     string `json:"usecaseBorderColor,omitempty"`
+	UsecaseFontColor       string `json:"usecaseFontColor,omitempty"`
 	// Package/Namespace
-	PackageBackgroundColor string
-	PackageBorderColor     string
-	PackageFontColor       string
+	PackageBackgroundColor string `json:"packageBackgroundColor,omitempty"`
+	PackageBorderColor     string `json:"packageBorderColor,omitempty"`
+	PackageFontColor       string `json:"packageFontColor,omitempty"`
+	// PackageStyle is the shape packages are drawn as: folder, frame, node,
+	// database or cloud.
+	PackageStyle string `json:"packageStyle,omitempty"`
 	// Spacing
-	Padding        int
-	ClassPadding   int
-	NotePadding    int
-	BorderWidth    int
-	ArrowThickness int
+	Padding        int `json:"padding,omitempty"`
+	ClassPadding   int `json:"classPadding,omitempty"`
+	NotePadding    int `json:"notePadding,omitempty"`
+	NoteMaxWidth   int `json:"wrapWidth,omitempty"` // notes wrap to this text width
+	BorderWidth    int `json:"borderWidth,omitempty"`
+	ArrowThickness int `json:"arrowThickness,omitempty"`
 	// Annotation/string colors
-	AnnotationColor string
+	AnnotationColor string `json:"annotationColor,omitempty"`
 }
 
 // Darcula returns the default Darcula theme matching JetBrains color palette.
@@ -61,6 +89,10 @@ func Darcula() *Theme {
 		ClassFontColor:              "#A9B7C6",
 		ClassFontSize:               13,
 		ClassStereotypeFontColor:    "#CC7832",
+		IconPublicColor:             "#6A8759",
+		IconPrivateColor:            "#CC7832",
+		IconProtectedColor:          "#FFC66D",
+		IconPackageColor:            "#6897BB",
 		InterfaceBackgroundColor:    "#3C3F41",
 		InterfaceBorderColor:        "#555555",
 		InterfaceFontColor:          "#6897BB",
@@ -76,18 +108,267 @@ func Darcula() *Theme {
 		ParticipantBorderColor:      "#555555",
 		ParticipantFontColor:        "#A9B7C6",
 		SequenceLifeLineBorderColor: "#555555",
+		ActivityBackgroundColor:     "#3C3F41",
+		ActivityBorderColor:         "#555555",
+		ActivityFontColor:           "#A9B7C6",
+		ComponentBackgroundColor:    "#3C3F41",
+		ComponentBorderColor:        "#555555",
+		ComponentFontColor:          "#A9B7C6",
+		UsecaseBackgroundColor:      "#3C3F41",
+		UsecaseBorderColor:          "#555555",
+		UsecaseFontColor:            "#A9B7C6",
 		PackageBackgroundColor:      "#2B2B2B",
 		PackageBorderColor:          "#555555",
 		PackageFontColor:            "#A9B7C6",
+		PackageStyle:                "folder",
 		Padding:                     10,
 		ClassPadding:                8,
 		NotePadding:                 8,
+		NoteMaxWidth:                200,
 		BorderWidth:                 1,
 		ArrowThickness:              1,
 		AnnotationColor:             "#6A8759",
 	}
 }
 
+// Monokai returns a dark theme based on the Monokai colour palette.
+func Monokai() *Theme {
+	return &Theme{
+		BackgroundColor:             "#272822",
+		FontName:                    "DejaVu Sans",
+		FontSize:                    13,
+		FontColor:                   "#F8F8F2",
+		ClassBackgroundColor:        "#3E3D32",
+		ClassBorderColor:            "#75715E",
+		ClassFontColor:              "#F8F8F2",
+		ClassFontSize:               13,
+		ClassStereotypeFontColor:    "#F92672",
+		IconPublicColor:             "#A6E22E",
+		IconPrivateColor:            "#FD971F",
+		IconProtectedColor:          "#AE81FF",
+		IconPackageColor:            "#66D9EF",
+		InterfaceBackgroundColor:    "#3E3D32",
+		InterfaceBorderColor:        "#75715E",
+		InterfaceFontColor:          "#66D9EF",
+		EnumBackgroundColor:         "#3E3D32",
+		EnumBorderColor:             "#75715E",
+		EnumFontColor:               "#66D9EF",
+		ArrowColor:                  "#F8F8F2",
+		ArrowFontSize:               11,
+		NoteBackgroundColor:         "#49483E",
+		NoteBorderColor:             "#75715E",
+		NoteFontColor:               "#F8F8F2",
+		ParticipantBackgroundColor:  "#3E3D32",
+		ParticipantBorderColor:      "#75715E",
+		ParticipantFontColor:        "#F8F8F2",
+		SequenceLifeLineBorderColor: "#75715E",
+		ActivityBackgroundColor:     "#3E3D32",
+		ActivityBorderColor:         "#75715E",
+		ActivityFontColor:           "#F8F8F2",
+		ComponentBackgroundColor:    "#3E3D32",
+		ComponentBorderColor:        "#75715E",
+		ComponentFontColor:          "#F8F8F2",
+		UsecaseBackgroundColor:      "#3E3D32",
+		UsecaseBorderColor:          "#75715E",
+		UsecaseFontColor:            "#F8F8F2",
+		PackageBackgroundColor:      "#272822",
+		PackageBorderColor:          "#75715E",
+		PackageFontColor:            "#F8F8F2",
+		PackageStyle:                "folder",
+		Padding:                     10,
+		ClassPadding:                8,
+		NotePadding:                 8,
+		NoteMaxWidth:                200,
+		BorderWidth:                 1,
+		ArrowThickness:              1,
+		AnnotationColor:             "#E6DB74",
+	}
+}
+
+// Solarized returns a dark theme based on the Solarized colour palette.
+func Solarized() *Theme {
+	return &Theme{
+		BackgroundColor:             "#002B36",
+		FontName:                    "DejaVu Sans",
+		FontSize:                    13,
+		FontColor:                   "#839496",
+		ClassBackgroundColor:        "#073642",
+		ClassBorderColor:            "#586E75",
+		ClassFontColor:              "#839496",
+		ClassFontSize:               13,
+		ClassStereotypeFontColor:    "#CB4B16",
+		IconPublicColor:             "#859900",
+		IconPrivateColor:            "#DC322F",
+		IconProtectedColor:          "#B58900",
+		IconPackageColor:            "#268BD2",
+		InterfaceBackgroundColor:    "#073642",
+		InterfaceBorderColor:        "#586E75",
+		InterfaceFontColor:          "#268BD2",
+		EnumBackgroundColor:         "#073642",
+		EnumBorderColor:             "#586E75",
+		EnumFontColor:               "#2AA198",
+		ArrowColor:                  "#839496",
+		ArrowFontSize:               11,
+		NoteBackgroundColor:         "#073642",
+		NoteBorderColor:             "#B58900",
+		NoteFontColor:               "#93A1A1",
+		ParticipantBackgroundColor:  "#073642",
+		ParticipantBorderColor:      "#586E75",
+		ParticipantFontColor:        "#839496",
+		SequenceLifeLineBorderColor: "#586E75",
+		ActivityBackgroundColor:     "#073642",
+		ActivityBorderColor:         "#586E75",
+		ActivityFontColor:           "#839496",
+		ComponentBackgroundColor:    "#073642",
+		ComponentBorderColor:        "#586E75",
+		ComponentFontColor:          "#839496",
+		UsecaseBackgroundColor:      "#073642",
+		UsecaseBorderColor:          "#586E75",
+		UsecaseFontColor:            "#839496",
+		PackageBackgroundColor:      "#002B36",
+		PackageBorderColor:          "#586E75",
+		PackageFontColor:            "#839496",
+		PackageStyle:                "folder",
+		Padding:                     10,
+		ClassPadding:                8,
+		NotePadding:                 8,
+		NoteMaxWidth:                200,
+		BorderWidth:                 1,
+		ArrowThickness:              1,
+		AnnotationColor:             "#2AA198",
+	}
+}
+
+// Light returns a light theme with dark text on white, for pages and printouts
+// with a light background.
+func Light() *Theme {
+	return &Theme{
+		BackgroundColor:             "#FFFFFF",
+		FontName:                    "DejaVu Sans",
+		FontSize:                    13,
+		FontColor:                   "#24292F",
+		ClassBackgroundColor:        "#F6F8FA",
+		ClassBorderColor:            "#57606A",
+		ClassFontColor:              "#24292F",
+		ClassFontSize:               13,
+		ClassStereotypeFontColor:    "#8250DF",
+		IconPublicColor:             "#1A7F37",
+		IconPrivateColor:            "#CF222E",
+		IconProtectedColor:          "#9A6700",
+		IconPackageColor:            "#0969DA",
+		InterfaceBackgroundColor:    "#F6F8FA",
+		InterfaceBorderColor:        "#57606A",
+		InterfaceFontColor:          "#0969DA",
+		EnumBackgroundColor:         "#F6F8FA",
+		EnumBorderColor:             "#57606A",
+		EnumFontColor:               "#24292F",
+		ArrowColor:                  "#24292F",
+		ArrowFontSize:               11,
+		NoteBackgroundColor:         "#FFF8C5",
+		NoteBorderColor:             "#D4A72C",
+		NoteFontColor:               "#24292F",
+		ParticipantBackgroundColor:  "#F6F8FA",
+		ParticipantBorderColor:      "#57606A",
+		ParticipantFontColor:        "#24292F",
+		SequenceLifeLineBorderColor: "#57606A",
+		ActivityBackgroundColor:     "#F6F8FA",
+		ActivityBorderColor:         "#57606A",
+		ActivityFontColor:           "#24292F",
+		ComponentBackgroundColor:    "#F6F8FA",
+		ComponentBorderColor:        "#57606A",
+		ComponentFontColor:          "#24292F",
+		UsecaseBackgroundColor:      "#F6F8FA",
+		UsecaseBorderColor:          "#57606A",
+		UsecaseFontColor:            "#24292F",
+		PackageBackgroundColor:      "#FFFFFF",
+		PackageBorderColor:          "#57606A",
+		PackageFontColor:            "#24292F",
+		PackageStyle:                "folder",
+		Padding:                     10,
+		ClassPadding:                8,
+		NotePadding:                 8,
+		NoteMaxWidth:                200,
+		BorderWidth:                 1,
+		ArrowThickness:              1,
+		AnnotationColor:             "#1A7F37",
+	}
+}
+
+// Mono returns a black and white theme, for printing and for documents that
+// avoid colour.
+func Mono() *Theme {
+	return &Theme{
+		BackgroundColor:             "#FFFFFF",
+		FontName:                    "DejaVu Sans",
+		FontSize:                    13,
+		FontColor:                   "#000000",
+		ClassBackgroundColor:        "#FFFFFF",
+		ClassBorderColor:            "#000000",
+		ClassFontColor:              "#000000",
+		ClassFontSize:               13,
+		ClassStereotypeFontColor:    "#444444",
+		IconPublicColor:             "#444444",
+		IconPrivateColor:            "#444444",
+		IconProtectedColor:          "#444444",
+		IconPackageColor:            "#000000",
+		InterfaceBackgroundColor:    "#FFFFFF",
+		InterfaceBorderColor:        "#000000",
+		InterfaceFontColor:          "#000000",
+		EnumBackgroundColor:         "#FFFFFF",
+		EnumBorderColor:             "#000000",
+		EnumFontColor:               "#000000",
+		ArrowColor:                  "#000000",
+		ArrowFontSize:               11,
+		NoteBackgroundColor:         "#EEEEEE",
+		NoteBorderColor:             "#000000",
+		NoteFontColor:               "#000000",
+		ParticipantBackgroundColor:  "#FFFFFF",
+		ParticipantBorderColor:      "#000000",
+		ParticipantFontColor:        "#000000",
+		SequenceLifeLineBorderColor: "#000000",
+		ActivityBackgroundColor:     "#FFFFFF",
+		ActivityBorderColor:         "#000000",
+		ActivityFontColor:           "#000000",
+		ComponentBackgroundColor:    "#FFFFFF",
+		ComponentBorderColor:        "#000000",
+		ComponentFontColor:          "#000000",
+		UsecaseBackgroundColor:      "#FFFFFF",
+		UsecaseBorderColor:          "#000000",
+		UsecaseFontColor:            "#000000",
+		PackageBackgroundColor:      "#FFFFFF",
+		PackageBorderColor:          "#000000",
+		PackageFontColor:            "#000000",
+		PackageStyle:                "folder",
+		Padding:                     10,
+		ClassPadding:                8,
+		NotePadding:                 8,
+		NoteMaxWidth:                200,
+		BorderWidth:                 1,
+		ArrowThickness:              1,
+		AnnotationColor:             "#444444",
+	}
+}
+
+// ByName returns the built-in theme with the given case-insensitive name:
+// "darcula", "monokai", "solarized", "light" or "mono". Any other name is an
+// error.
+func ByName(name string) (*Theme, error) {
+	switch strings.ToLower(name) {
+	case "darcula":
+		return Darcula(), nil
+	case "monokai":
+		return Monokai(), nil
+	case "solarized":
+		return Solarized(), nil
+	case "light":
+		return Light(), nil
+	case "mono":
+		return Mono(), nil
+	default:
+		return nil, fmt.Errorf("unknown theme %q", name)
+	}
+}
+
 // hardcodedFallback returns the minimal fallback theme used when no theme is set.
 func hardcodedFallback() *Theme {
 	return &Theme{
@@ -100,6 +381,10 @@ func hardcodedFallback() *Theme {
 		ClassFontColor:              "#000000",
 		ClassFontSize:               12,
 		ClassStereotypeFontColor:    "#000000",
+		IconPublicColor:             "#6A8759",
+		IconPrivateColor:            "#CC7832",
+		IconProtectedColor:          "#FFC66D",
+		IconPackageColor:            "#6897BB",
 		InterfaceBackgroundColor:    "#FEFECE",
 		InterfaceBorderColor:        "#A80036",
 		InterfaceFontColor:          "#000000",
@@ -115,12 +400,23 @@ func hardcodedFallback() *Theme {
 		ParticipantBorderColor:      "#A80036",
 		ParticipantFontColor:        "#000000",
 		SequenceLifeLineBorderColor: "#A80036",
+		ActivityBackgroundColor:     "#FEFECE",
+		ActivityBorderColor:         "#A80036",
+		ActivityFontColor:           "#000000",
+		ComponentBackgroundColor:    "#FEFECE",
+		ComponentBorderColor:        "#A80036",
+		ComponentFontColor:          "#000000",
+		UsecaseBackgroundColor:      "#FEFECE",
+		UsecaseBorderColor:          "#A80036",
+		UsecaseFontColor:            "#000000",
 		PackageBackgroundColor:      "#FFFFFF",
 		PackageBorderColor:          "#000000",
 		PackageFontColor:            "#000000",
+		PackageStyle:                "folder",
 		Padding:                     10,
 		ClassPadding:                8,
 		NotePadding:                 8,
+		NoteMaxWidth:                200,
 		BorderWidth:                 1,
 		ArrowThickness:              1,
 		AnnotationColor:             "#000000",
@@ -129,9 +425,12 @@ func hardcodedFallback() *Theme {
 
 // Resolver resolves style properties using a three-level hierarchy:
 // skinparam overrides → theme → hardcoded fallback.
+// A Resolver is safe for concurrent use; use Clone to give each renderer its
+// own skinparams.
 type Resolver struct {
 	theme      *Theme
 	fallback   *Theme
+	mu         sync.RWMutex
 	skinparams map[string]string
 }
 
@@ -150,9 +449,37 @@ func NewResolver(theme *Theme) *Resolver {
 
 // SetSkinparam sets a skinparam override that takes highest priority.
 func (r *Resolver) SetSkinparam(name, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.skinparams[name] = value
 }
 
+// Clone returns a copy of r whose skinparams can be changed without
+// affecting r. The theme is shared, as resolvers never modify it.
+func (r *Resolver) Clone() *Resolver {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	skinparams := make(map[string]string, len(r.skinparams))
+	for k, v := range r.skinparams {
+		skinparams[k] = v
+	}
+	return &Resolver{theme: r.theme, fallback: r.fallback, skinparams: skinparams}
+}
+
+// skinparam returns the skinparam set for property, preferring the PlantUML
+// name from skinparamKeys over the property name itself.
+func (r *Resolver) skinparam(property string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if key, ok := skinparamKeys[property]; ok {
+		if v, exists := r.skinparams[key]; exists {
+			return v, true
+		}
+	}
+	v, exists := r.skinparams[property]
+	return v, exists
+}
+
 // skinparamKeys maps Theme field purpose to the skinparam name PlantUML uses.
 var skinparamKeys = map[string]string{
 	"BackgroundColor":             "backgroundColor",
@@ -163,7 +490,12 @@ var skinparamKeys = map[string]string{
 	"ClassBorderColor":            "classBorderColor",
 	"ClassFontColor":              "classFontColor",
 	"ClassFontSize":               "classFontSize",
+	"ClassFontName":               "classFontName",
 	"ClassStereotypeFontColor":    "classStereotypeFontColor",
+	"IconPublicColor":             "iconPublicColor",
+	"IconPrivateColor":            "iconPrivateColor",
+	"IconProtectedColor":          "iconProtectedColor",
+	"IconPackageColor":            "iconPackageColor",
 	"InterfaceBackgroundColor":    "interfaceBackgroundColor",
 	"InterfaceBorderColor":        "interfaceBorderColor",
 	"InterfaceFontColor":          "interfaceFontColor",
@@ -175,26 +507,56 @@ var skinparamKeys = map[string]string{
 	"NoteBackgroundColor":         "noteBackgroundColor",
 	"NoteBorderColor":             "noteBorderColor",
 	"NoteFontColor":               "noteFontColor",
+	"NoteMaxWidth":                "wrapWidth",
 	"ParticipantBackgroundColor":  "participantBackgroundColor",
 	"ParticipantBorderColor":      "participantBorderColor",
 	"ParticipantFontColor":        "participantFontColor",
 	"SequenceLifeLineBorderColor": "sequenceLifeLineBorderColor",
+	"ActivityBackgroundColor":     "activityBackgroundColor",
+	"ActivityBorderColor":         "activityBorderColor",
+	"ActivityFontColor":           "activityFontColor",
+	"ComponentBackgroundColor":    "componentBackgroundColor",
+	"ComponentBorderColor":        "componentBorderColor",
+	"ComponentFontColor":          "componentFontColor",
+	"UsecaseBackgroundColor":      "usecaseBackgroundColor",
+	"UsecaseBorderColor":          "usecaseBorderColor",
+	"UsecaseFontColor":            "usecaseFontColor",
 	"PackageBackgroundColor":      "packageBackgroundColor",
 	"PackageBorderColor":          "packageBorderColor",
 	"PackageFontColor":            "packageFontColor",
+	"PackageStyle":                "packageStyle",
 	"AnnotationColor":             "annotationColor",
+	"ResponsiveSVG":               "responsiveSVG",
+	"Padding":                     "padding",
+	"ClassPadding":                "classPadding",
+	"NotePadding":                 "notePadding",
+	"BorderWidth":                 "borderWidth",
+	"ArrowThickness":              "arrowThickness",
+	"Shadowing":                   "shadowing",
+}
+
+// IsSkinparam reports whether name is a skinparam the resolver understands,
+// given either as the PlantUML name or as the property name.
+func IsSkinparam(name string) bool {
+	if _, ok := skinparamKeys[name]; ok {
+		return true
+	}
+	for _, key := range skinparamKeys {
+		if key == name {
+			return true
+		}
+	}
+	return false
 }
 
 // ResolveColor returns the color for a named property.
 // Resolution order: skinparam → theme → fallback.
+// Colour names given as skinparams, such as LightBlue, are converted to hex.
+// Of a gradient such as "#White|#LightBlue" only the first colour is used.
 func (r *Resolver) ResolveColor(property string) string {
-	if key, ok := skinparamKeys[property]; ok {
-		if v, exists := r.skinparams[key]; exists {
-			return v
-		}
-	}
-	if v, exists := r.skinparams[property]; exists {
-		return v
+	if v, exists := r.skinparam(property); exists {
+		top, _, _ := strings.Cut(v, "|")
+		return colorOrName(strings.TrimSpace(top))
 	}
 	if v := r.themeColor(property); v != "" {
 		return v
@@ -202,15 +564,46 @@ func (r *Resolver) ResolveColor(property string) string {
 	return r.fallbackColor(property)
 }
 
+// ResolveGradient returns the colours of a gradient given as a skinparam
+// such as "#White|#LightBlue", top colour first. ok is false when the
+// property is a single colour; ResolveColor then gives its value.
+func (r *Resolver) ResolveGradient(property string) (top, bottom string, ok bool) {
+	v, exists := r.skinparam(property)
+	if !exists {
+		return "", "", false
+	}
+	top, bottom, ok = strings.Cut(v, "|")
+	if !ok {
+		return "", "", false
+	}
+	return colorOrName(strings.TrimSpace(top)), colorOrName(strings.TrimSpace(bottom)), true
+}
+
+// ResolveString returns the text value, such as a font name, for a named
+// property. Resolution order: skinparam → theme → fallback; "" if unset.
+func (r *Resolver) ResolveString(property string) string {
+	if v, exists := r.skinparam(property); exists {
+		return v
+	}
+	if v := fieldByName(r.theme, property); v != "" {
+		return v
+	}
+	return fieldByName(r.fallback, property)
+}
+
+// colorOrName returns the hex form of a colour name, or v unchanged if it is
+// not a recognised colour.
+func colorOrName(v string) string {
+	if hex, ok := ResolveColorName(v); ok {
+		return hex
+	}
+	return v
+}
+
 // ResolveInt returns the integer value for a named property.
 // Resolution order: skinparam → theme → fallback default.
 func (r *Resolver) ResolveInt(property string, fallback int) int {
-	if key, ok := skinparamKeys[property]; ok {
-		if v, exists := r.skinparams[key]; exists {
-			return atoiOr(v, fallback)
-		}
-	}
-	if v, exists := r.skinparams[property]; exists {
+	if v, exists := r.skinparam(property); exists {
 		return atoiOr(v, fallback)
 	}
 	if v := intFieldByName(r.theme, property); v != 0 {
@@ -222,6 +615,23 @@ func (r *Resolver) ResolveInt(property string, fallback int) int {
 	return fallback
 }
 
+// ResolveBool returns the boolean value for a named property, which only
+// skinparams can set. Values other than "true" or "false" (in any case)
+// yield fallback.
+func (r *Resolver) ResolveBool(property string, fallback bool) bool {
+	v, exists := r.skinparam(property)
+	if !exists {
+		return fallback
+	}
+	switch strings.ToLower(v) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return fallback
+}
+
 func (r *Resolver) themeColor(property string) string {
 	return fieldByName(r.theme, property)
 }
@@ -248,6 +658,14 @@ func fieldByName(t *Theme, name string) string {
 		return t.ClassFontColor
 	case "ClassStereotypeFontColor":
 		return t.ClassStereotypeFontColor
+	case "IconPublicColor":
+		return t.IconPublicColor
+	case "IconPrivateColor":
+		return t.IconPrivateColor
+	case "IconProtectedColor":
+		return t.IconProtectedColor
+	case "IconPackageColor":
+		return t.IconPackageColor
 	case "InterfaceBackgroundColor":
 		return t.InterfaceBackgroundColor
 	case "InterfaceBorderColor":
@@ -276,12 +694,32 @@ func fieldByName(t *Theme, name string) string {
 		return t.ParticipantFontColor
 	case "SequenceLifeLineBorderColor":
 		return t.SequenceLifeLineBorderColor
+	case "ActivityBackgroundColor":
+		return t.ActivityBackgroundColor
+	case "ActivityBorderColor":
+		return t.ActivityBorderColor
+	case "ActivityFontColor":
+		return t.ActivityFontColor
+	case "ComponentBackgroundColor":
+		return t.ComponentBackgroundColor
+	case "ComponentBorderColor":
+		return t.ComponentBorderColor
+	case "ComponentFontColor":
+		return t.ComponentFontColor
+	case "UsecaseBackgroundColor":
+		return t.UsecaseBackgroundColor
+	case "UsecaseBorderColor":
+		return t.UsecaseBorderColor
+	case "UsecaseFontColor":
+		return t.UsecaseFontColor
 	case "PackageBackgroundColor":
 		return t.PackageBackgroundColor
 	case "PackageBorderColor":
 		return t.PackageBorderColor
 	case "PackageFontColor":
 		return t.PackageFontColor
+	case "PackageStyle":
+		return t.PackageStyle
 	case "AnnotationColor":
 		return t.AnnotationColor
 	default:
@@ -304,6 +742,8 @@ func intFieldByName(t *Theme, name string) int {
 		return t.ClassPadding
 	case "NotePadding":
 		return t.NotePadding
+	case "NoteMaxWidth":
+		return t.NoteMaxWidth
 	case "BorderWidth":
 		return t.BorderWidth
 	case "ArrowThickness":
@@ -1,6 +1,9 @@
 package theme
 
 import (
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,11 +39,75 @@ func TestDarcula(t *testing.T) {
 		assert.Equal(t, 10, d.Padding)
 		assert.Equal(t, 8, d.ClassPadding)
 		assert.Equal(t, 8, d.NotePadding)
+		assert.Equal(t, 200, d.NoteMaxWidth)
 		assert.Equal(t, 1, d.BorderWidth)
 		assert.Equal(t, 1, d.ArrowThickness)
 	})
 }
 
+func TestMonokai(t *testing.T) {
+	t.Parallel()
+	m := Monokai()
+	assert.Equal(t, "#272822", m.BackgroundColor)
+	assert.Equal(t, "#F8F8F2", m.FontColor)
+	assert.Equal(t, "#A6E22E", m.IconPublicColor)
+	assert.Equal(t, "#FD971F", m.IconPrivateColor)
+	assert.Equal(t, "#66D9EF", m.InterfaceFontColor)
+	v := reflect.ValueOf(*m)
+	for i := range v.NumField() {
+		assert.False(t, v.Field(i).IsZero(), "%s is unset", v.Type().Field(i).Name)
+	}
+}
+
+func TestSolarizedLightAndMono(t *testing.T) {
+	t.Parallel()
+	s := Solarized()
+	assert.Equal(t, "#002B36", s.BackgroundColor)
+	assert.Equal(t, "#839496", s.FontColor)
+	l := Light()
+	assert.Equal(t, "#FFFFFF", l.BackgroundColor)
+	assert.Equal(t, "#24292F", l.FontColor)
+	m := Mono()
+	assert.Equal(t, "#FFFFFF", m.BackgroundColor)
+	assert.Equal(t, "#000000", m.ClassBorderColor)
+	for _, th := range []*Theme{s, l, m} {
+		v := reflect.ValueOf(*th)
+		for i := range v.NumField() {
+			assert.False(t, v.Field(i).IsZero(), "%s is unset", v.Type().Field(i).Name)
+		}
+	}
+}
+
+func TestByName(t *testing.T) {
+	t.Parallel()
+	t.Run("Builtin", func(t *testing.T) {
+		t.Parallel()
+		for name, want := range map[string]*Theme{
+			"darcula":   Darcula(),
+			"monokai":   Monokai(),
+			"solarized": Solarized(),
+			"light":     Light(),
+			"mono":      Mono(),
+		} {
+			got, err := ByName(name)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, name)
+		}
+	})
+	t.Run("DarculaAnyCase", func(t *testing.T) {
+		t.Parallel()
+		got, err := ByName("Darcula")
+		require.NoError(t, err)
+		assert.Equal(t, Darcula(), got)
+	})
+	t.Run("Unknown", func(t *testing.T) {
+		t.Parallel()
+		_, err := ByName("nord")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nord")
+	})
+}
+
 func TestNewResolver(t *testing.T) {
 	t.Parallel()
 	t.Run("NilThemeUsesDarcula", func(t *testing.T) {
@@ -60,6 +127,50 @@ func TestNewResolver(t *testing.T) {
 
 func TestResolver(t *testing.T) {
 	t.Parallel()
+	t.Run("Clone", func(t *testing.T) {
+		t.Parallel()
+		base := NewResolver(Darcula())
+		base.SetSkinparam("backgroundColor", "#111111")
+		clone := base.Clone()
+		clone.SetSkinparam("backgroundColor", "#222222")
+		clone.SetSkinparam("arrowColor", "#333333")
+		assert.Equal(t, "#111111", base.ResolveColor("BackgroundColor"))
+		assert.Equal(t, Darcula().ArrowColor, base.ResolveColor("ArrowColor"))
+		assert.Equal(t, "#222222", clone.ResolveColor("BackgroundColor"))
+		assert.Equal(t, "#333333", clone.ResolveColor("ArrowColor"))
+	})
+	t.Run("ConcurrentUse", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.SetSkinparam(fmt.Sprintf("p%d", i), "1")
+				_ = r.ResolveInt("ClassFontSize", 13)
+				_ = r.ResolveBool("ResponsiveSVG", false)
+				_ = r.Clone()
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, r.ResolveInt("p49", 0))
+	})
+	t.Run("Gradient", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		_, _, ok := r.ResolveGradient("ClassBackgroundColor")
+		assert.False(t, ok)
+		r.SetSkinparam("classBackgroundColor", "#FFFFFF | LightBlue")
+		top, bottom, ok := r.ResolveGradient("ClassBackgroundColor")
+		assert.True(t, ok)
+		assert.Equal(t, "#FFFFFF", top)
+		assert.Equal(t, "#ADD8E6", bottom)
+		assert.Equal(t, "#FFFFFF", r.ResolveColor("ClassBackgroundColor"))
+		r.SetSkinparam("classBackgroundColor", "#FF0000")
+		_, _, ok = r.ResolveGradient("ClassBackgroundColor")
+		assert.False(t, ok)
+	})
 	t.Run("SkinparamOverridesTheme", func(t *testing.T) {
 		t.Parallel()
 		r := NewResolver(Darcula())
@@ -72,6 +183,16 @@ func TestResolver(t *testing.T) {
 		r.SetSkinparam("ClassBackgroundColor", "#112233")
 		assert.Equal(t, "#112233", r.ResolveColor("ClassBackgroundColor"))
 	})
+	t.Run("SkinparamColorName", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		r.SetSkinparam("backgroundColor", "LightBlue")
+		r.SetSkinparam("ClassBackgroundColor", "#motivation")
+		r.SetSkinparam("ClassBorderColor", "notacolour")
+		assert.Equal(t, "#ADD8E6", r.ResolveColor("BackgroundColor"))
+		assert.Equal(t, "#CCCCFF", r.ResolveColor("ClassBackgroundColor"))
+		assert.Equal(t, "notacolour", r.ResolveColor("ClassBorderColor"))
+	})
 	t.Run("ThemeOverridesFallback", func(t *testing.T) {
 		t.Parallel()
 		r := NewResolver(Darcula())
@@ -155,6 +276,15 @@ func TestResolver(t *testing.T) {
 			{"ParticipantBorderColor", "#555555"},
 			{"ParticipantFontColor", "#A9B7C6"},
 			{"SequenceLifeLineBorderColor", "#555555"},
+			{"ActivityBackgroundColor", "#3C3F41"},
+			{"ActivityBorderColor", "#555555"},
+			{"ActivityFontColor", "#A9B7C6"},
+			{"ComponentBackgroundColor", "#3C3F41"},
+			{"ComponentBorderColor", "#555555"},
+			{"ComponentFontColor", "#A9B7C6"},
+			{"UsecaseBackgroundColor", "#3C3F41"},
+			{"UsecaseBorderColor", "#555555"},
+			{"UsecaseFontColor", "#A9B7C6"},
 			{"PackageBackgroundColor", "#2B2B2B"},
 			{"PackageBorderColor", "#555555"},
 			{"PackageFontColor", "#A9B7C6"},
@@ -169,6 +299,66 @@ func TestResolver(t *testing.T) {
 	})
 }
 
+func TestResolveBool(t *testing.T) {
+	t.Parallel()
+	t.Run("Default", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		assert.False(t, r.ResolveBool("ResponsiveSVG", false))
+		assert.True(t, r.ResolveBool("ResponsiveSVG", true))
+	})
+	t.Run("SkinparamOverride", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		r.SetSkinparam("responsiveSVG", "TRUE")
+		assert.True(t, r.ResolveBool("ResponsiveSVG", false))
+	})
+	t.Run("SkinparamDirectKey", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		r.SetSkinparam("ResponsiveSVG", "true")
+		assert.True(t, r.ResolveBool("ResponsiveSVG", false))
+	})
+	t.Run("InvalidValue", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(Darcula())
+		r.SetSkinparam("responsiveSVG", "maybe")
+		assert.True(t, r.ResolveBool("ResponsiveSVG", true))
+	})
+}
+
+func TestResolveString(t *testing.T) {
+	t.Parallel()
+	t.Run("ThemeValue", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(nil)
+		assert.Equal(t, "DejaVu Sans", r.ResolveString("FontName"))
+	})
+	t.Run("SkinparamOverride", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(nil)
+		r.SetSkinparam("defaultFontName", "Monospaced")
+		assert.Equal(t, "Monospaced", r.ResolveString("FontName"))
+	})
+	t.Run("SkinparamOnly", func(t *testing.T) {
+		t.Parallel()
+		r := NewResolver(nil)
+		assert.Equal(t, "", r.ResolveString("ClassFontName"))
+		r.SetSkinparam("classFontName", "Courier")
+		assert.Equal(t, "Courier", r.ResolveString("ClassFontName"))
+	})
+	t.Run("ThemeOnly", func(t *testing.T) {
+		t.Parallel()
+		th := Darcula()
+		th.PackageStyle = "frame"
+		r := NewResolver(th)
+		assert.Equal(t, "frame", r.ResolveString("PackageStyle"))
+		r.SetSkinparam("packageStyle", "cloud")
+		assert.Equal(t, "cloud", r.ResolveString("PackageStyle"))
+		assert.Equal(t, "folder", NewResolver(nil).ResolveString("PackageStyle"))
+	})
+}
+
 func TestResolveInt(t *testing.T) {
 	t.Parallel()
 	t.Run("ThemeValue", func(t *testing.T) {
@@ -180,6 +370,7 @@ func TestResolveInt(t *testing.T) {
 		assert.Equal(t, 10, r.ResolveInt("Padding", 0))
 		assert.Equal(t, 8, r.ResolveInt("ClassPadding", 0))
 		assert.Equal(t, 8, r.ResolveInt("NotePadding", 0))
+		assert.Equal(t, 200, r.ResolveInt("NoteMaxWidth", 0))
 		assert.Equal(t, 1, r.ResolveInt("BorderWidth", 0))
 		assert.Equal(t, 1, r.ResolveInt("ArrowThickness", 0))
 	})
@@ -223,3 +414,13 @@ func TestHardcodedFallback(t *testing.T) {
 	assert.Equal(t, "sans-serif", f.FontName)
 	assert.Equal(t, 12, f.FontSize)
 }
+
+func TestIsSkinparam(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsSkinparam("backgroundColor"))
+	assert.True(t, IsSkinparam("BackgroundColor"))
+	assert.True(t, IsSkinparam("wrapWidth"))
+	assert.True(t, IsSkinparam("arrowThickness"))
+	assert.False(t, IsSkinparam("clasBackgroundColor"))
+	assert.False(t, IsSkinparam(""))
+}
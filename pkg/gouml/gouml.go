@@ -17,14 +17,63 @@
 // For parsing to an AST:
 //
 //	diagram, errs := gouml.Parse(input)
+//
+// Source already held in a string can be passed directly:
+//
+//	err := gouml.RenderString(source, os.Stdout)
+//
+// Mermaid class and sequence diagrams are rendered the same way:
+//
+//	err := gouml.RenderMermaid(strings.NewReader("sequenceDiagram\nAlice->>Bob: Hi"), os.Stdout)
+//
+// A class diagram can also be written out as Mermaid source:
+//
+//	err := gouml.RenderAsMermaid(input, output)
+//
+// or as Graphviz DOT:
+//
+//	err := gouml.RenderDOT(input, output)
+//
+// RenderPNG writes a PNG image instead, for places that cannot show SVG:
+//
+//	err := gouml.RenderPNG(input, output)
+//
+// Format rewrites PlantUML source in a canonical layout:
+//
+//	err := gouml.Format(input, output)
+//
+// To render a file on disk, writing diagram.svg next to it:
+//
+//	err := gouml.RenderFile("diagram.puml", "")
+//
+// RenderFile and ParseFile expand preprocessor directives such as
+// "!include common.puml" and "!define NAME value", and report errors in
+// the file that holds them. PreprocessFile returns the expanded source for
+// the other entry points, with a LineMap for positioning their errors.
 package gouml
 
 import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/bobcob7/go-uml/internal/ast"
+	"github.com/bobcob7/go-uml/internal/lexer"
+	"github.com/bobcob7/go-uml/internal/mermaid"
 	"github.com/bobcob7/go-uml/internal/parser"
+	"github.com/bobcob7/go-uml/internal/preprocess"
+	"github.com/bobcob7/go-uml/internal/printer"
+	"github.com/bobcob7/go-uml/internal/renderer/dot"
+	mermaidout "github.com/bobcob7/go-uml/internal/renderer/mermaid"
+	"github.com/bobcob7/go-uml/internal/renderer/png"
 	"github.com/bobcob7/go-uml/internal/renderer/svg"
 	"github.com/bobcob7/go-uml/internal/theme"
 )
@@ -35,26 +84,144 @@ type Diagram struct {
 	internal *ast.Diagram
 }
 
-// Error represents a parse or validation error with source position.
+// DiagramType is the type of a diagram, such as class or sequence, which
+// decides how RenderDiagram draws it.
+type DiagramType = ast.DiagramKind
+
+// The diagram types DetectType reports.
+const (
+	TypeUnknown   = ast.DiagramKindUnknown
+	TypeClass     = ast.DiagramKindClass
+	TypeSequence  = ast.DiagramKindSequence
+	TypeActivity  = ast.DiagramKindActivity
+	TypeUsecase   = ast.DiagramKindUsecase
+	TypeComponent = ast.DiagramKindComponent
+	TypeER        = ast.DiagramKindER
+)
+
+// DetectType returns the type of diagram d is, judged from its statements,
+// so that callers can branch on it before rendering. A diagram that mixes
+// sequence statements such as messages with class declarations is a
+// sequence diagram; an empty one is TypeUnknown and renders as an empty
+// class diagram.
+func DetectType(d *Diagram) DiagramType {
+	return d.internal.DiagramType()
+}
+
+// DiagramType returns the type of diagram d is. It is the same as
+// DetectType(d).
+func (d *Diagram) DiagramType() DiagramType {
+	return DetectType(d)
+}
+
+// DiagramKind is the former name of DiagramType.
+//
+// Deprecated: Use DiagramType.
+type DiagramKind = DiagramType
+
+// The former names of the diagram types.
+//
+// Deprecated: Use TypeUnknown, TypeClass and the other Type constants.
+const (
+	DiagramKindUnknown   = TypeUnknown
+	DiagramKindClass     = TypeClass
+	DiagramKindSequence  = TypeSequence
+	DiagramKindActivity  = TypeActivity
+	DiagramKindUsecase   = TypeUsecase
+	DiagramKindComponent = TypeComponent
+	DiagramKindER        = TypeER
+)
+
+// Error represents a parse or validation error with source position. File
+// names the file holding the error when it is known, as for the errors of
+// ParseFile and RenderFile; Line then counts the lines of that file.
 type Error struct {
-	Line    int
-	Column  int
-	Message string
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+
+	recoverable bool  // the diagram is complete despite the error
+	cause       error // the underlying parser error, if any
 }
 
+// Sentinel errors that parse errors match with errors.Is. Every parse error
+// matches ErrSyntax; errors for a missing @enduml or an unexpected token also
+// match the more specific sentinel.
+var (
+	ErrSyntax          = parser.ErrSyntax
+	ErrMissingEndUML   = parser.ErrMissingEndUML
+	ErrUnexpectedToken = parser.ErrUnexpectedToken
+)
+
+// ErrPreprocess is matched by the errors PreprocessFile reports for
+// directives it cannot expand.
+var ErrPreprocess = preprocess.ErrPreprocess
+
 // Error implements the error interface.
 func (e *Error) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+	}
 	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
 }
 
+// locate moves e from its line of a preprocessed source to the file and
+// line that lines maps it to.
+func (e *Error) locate(lines LineMap) {
+	if file, line := lines.Locate(e.Line); file != "" {
+		e.File, e.Line = file, line
+	}
+}
+
+// Unwrap returns the parser error e was converted from, so that errors.Is
+// matches the sentinel errors.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
 // Option configures rendering behavior.
 type Option func(*options)
 
 type options struct {
-	theme      *theme.Theme
-	skinparams map[string]string
+	theme       *theme.Theme
+	skinparams  map[string]string
+	minify      bool
+	responsive  bool
+	background  Background
+	accessible  bool
+	maxWidth    int
+	maxHeight   int
+	layoutDebug io.Writer
+	// err is the first error an option ran into, such as a theme file
+	// that could not be loaded; rendering reports it.
+	err error
 }
 
+// applyOptions returns the options opts set, or the first error one of
+// them ran into.
+func applyOptions(opts []Option) (*options, error) {
+	o := &options{skinparams: make(map[string]string)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.err != nil {
+		return nil, o.err
+	}
+	return o, nil
+}
+
+// Background selects how the area behind a diagram is drawn.
+type Background int
+
+const (
+	// BackgroundOpaque fills the drawing with the theme background colour.
+	BackgroundOpaque Background = iota
+	// BackgroundTransparent leaves the drawing unfilled, so that the page
+	// the SVG is embedded in shows through.
+	BackgroundTransparent
+)
+
 // WithTheme sets the theme for rendering.
 // If not specified, the Darcula theme is used.
 func WithTheme(t *theme.Theme) Option {
@@ -63,6 +230,38 @@ func WithTheme(t *theme.Theme) Option {
 	}
 }
 
+// WithThemeName sets the theme for rendering to the built-in theme with the
+// given name, as accepted by theme.ByName: "darcula", "monokai",
+// "solarized", "light" or "mono". Rendering fails for any other name.
+func WithThemeName(name string) Option {
+	return func(o *options) {
+		t, err := theme.ByName(name)
+		if err != nil {
+			if o.err == nil {
+				o.err = err
+			}
+			return
+		}
+		o.theme = t
+	}
+}
+
+// WithThemeFile sets the theme for rendering to the JSON theme stored at
+// path, read with theme.LoadFile. Rendering fails if the file cannot be
+// loaded.
+func WithThemeFile(path string) Option {
+	return func(o *options) {
+		t, err := theme.LoadFile(path)
+		if err != nil {
+			if o.err == nil {
+				o.err = err
+			}
+			return
+		}
+		o.theme = t
+	}
+}
+
 // WithSkinparam sets a skinparam override that takes highest priority
 // in the property resolution chain.
 func WithSkinparam(name, value string) Option {
@@ -71,30 +270,187 @@ func WithSkinparam(name, value string) Option {
 	}
 }
 
+// WithMinify controls whether the SVG is written on a single line, without
+// the newlines and indentation between elements. The default is false.
+func WithMinify(minify bool) Option {
+	return func(o *options) {
+		o.minify = minify
+	}
+}
+
+// WithResponsive controls whether the SVG omits its width and height,
+// keeping only the viewBox, so that it scales to fit its container. The
+// default is false, which gives a drawing of fixed size. The skinparam
+// responsiveSVG has the same effect.
+func WithResponsive(on bool) Option {
+	return func(o *options) {
+		o.responsive = on
+	}
+}
+
+// WithBackground sets how the area behind the diagram is drawn. The default
+// is BackgroundOpaque.
+func WithBackground(b Background) Option {
+	return func(o *options) {
+		o.background = b
+	}
+}
+
+// WithAccessibility controls whether class boxes, sequence participants and
+// messages are wrapped in groups carrying a <title> element, which screen
+// readers announce and browsers show on hover. The default is false.
+func WithAccessibility(on bool) Option {
+	return func(o *options) {
+		o.accessible = on
+	}
+}
+
+// WithMaxSize caps the displayed size of the SVG. A drawing wider than
+// maxWidth or taller than maxHeight is scaled down by
+// min(maxWidth/width, maxHeight/height), keeping its aspect ratio; the
+// viewBox is unchanged. A limit of zero or less leaves that dimension
+// unbounded. Responsive SVGs, which carry no size, are not affected.
+func WithMaxSize(maxWidth, maxHeight int) Option {
+	return func(o *options) {
+		o.maxWidth = maxWidth
+		o.maxHeight = maxHeight
+	}
+}
+
+// WithLayoutDebug writes the positioned layout graph of each diagram that
+// is laid out as a graph, listing its nodes and edges with layout.Dump, to
+// w. Sequence diagrams are laid out otherwise and write nothing. It is a
+// debugging aid; the default is no dump.
+func WithLayoutDebug(w io.Writer) Option {
+	return func(o *options) {
+		o.layoutDebug = w
+	}
+}
+
 // Render reads PlantUML from r and writes SVG to w.
 // Options may be provided to customize theme and skinparam overrides.
 func Render(r io.Reader, w io.Writer, opts ...Option) error {
 	diagram, errs := Parse(r)
-	if len(errs) > 0 {
-		return errs[0]
+	if err := blockingError(errs); err != nil {
+		return err
 	}
 	return RenderDiagram(w, diagram, opts...)
 }
 
 // RenderDiagram renders a previously parsed diagram to SVG.
 func RenderDiagram(w io.Writer, d *Diagram, opts ...Option) error {
-	o := &options{skinparams: make(map[string]string)}
-	for _, opt := range opts {
-		opt(o)
+	o, err := applyOptions(opts)
+	if err != nil {
+		return err
+	}
+	if o.minify {
+		w = svg.NewMinifyWriter(w)
+	}
+	if o.maxWidth > 0 || o.maxHeight > 0 {
+		w = svg.NewMaxSizeWriter(w, o.maxWidth, o.maxHeight)
 	}
+	return newSVGRenderer(d, o).Render(w, d.internal)
+}
+
+// svgRenderer is the SVG renderer of one kind of diagram.
+type svgRenderer interface {
+	Render(w io.Writer, diagram *ast.Diagram) error
+	Bounds() (width, height float64, responsive bool)
+}
+
+// newSVGRenderer returns the renderer for the kind of d, configured by o.
+func newSVGRenderer(d *Diagram, o *options) svgRenderer {
 	resolver := theme.NewResolver(o.theme)
 	for k, v := range o.skinparams {
 		resolver.SetSkinparam(k, v)
 	}
-	if isSequenceDiagram(d.internal) {
-		return svg.NewSequenceRenderer(resolver).Render(w, d.internal)
+	transparent := o.background == BackgroundTransparent
+	switch DetectType(d) {
+	case TypeActivity:
+		r := svg.NewActivityRenderer(resolver)
+		r.Responsive = o.responsive
+		r.Transparent = transparent
+		r.LayoutDebug = o.layoutDebug
+		return r
+	case TypeUsecase:
+		r := svg.NewUsecaseRenderer(resolver)
+		r.Responsive = o.responsive
+		r.Transparent = transparent
+		r.LayoutDebug = o.layoutDebug
+		return r
+	case TypeSequence:
+		r := svg.NewSequenceRenderer(resolver)
+		r.Responsive = o.responsive
+		r.Transparent = transparent
+		r.Accessible = o.accessible
+		return r
+	case TypeComponent:
+		r := svg.NewComponentRenderer(resolver)
+		r.Responsive = o.responsive
+		r.Transparent = transparent
+		r.LayoutDebug = o.layoutDebug
+		return r
+	case TypeER:
+		r := svg.NewERRenderer(resolver)
+		r.Responsive = o.responsive
+		r.Transparent = transparent
+		r.LayoutDebug = o.layoutDebug
+		return r
+	}
+	// Class diagrams, and diagrams of no particular kind such as empty ones.
+	r := svg.NewClassRenderer(resolver)
+	r.Responsive = o.responsive
+	r.Transparent = transparent
+	r.Accessible = o.accessible
+	r.LayoutDebug = o.layoutDebug
+	return r
+}
+
+// Size is the width and height of a rendered diagram, in pixels.
+type Size struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Measure lays out d and returns the size of the SVG RenderDiagram would
+// write for it, without the caller having to handle the SVG itself. It
+// accepts the same options as RenderDiagram, so WithMaxSize caps the size it
+// reports.
+func Measure(d *Diagram, opts ...Option) (Size, error) {
+	o, err := applyOptions(opts)
+	if err != nil {
+		return Size{}, err
+	}
+	r := newSVGRenderer(d, o)
+	if err := r.Render(io.Discard, d.internal); err != nil {
+		return Size{}, err
+	}
+	w, h, responsive := r.Bounds()
+	// A responsive SVG has no width or height for WithMaxSize to cap.
+	if !responsive {
+		w, h = svg.FitSize(w, h, float64(o.maxWidth), float64(o.maxHeight))
+	}
+	return Size{Width: int(math.RoundToEven(w)), Height: int(math.RoundToEven(h))}, nil
+}
+
+// RenderPNG reads PlantUML from r and writes it to w as a PNG image, one
+// pixel per SVG unit. It accepts the same options as Render.
+func RenderPNG(r io.Reader, w io.Writer, opts ...Option) error {
+	diagram, errs := Parse(r)
+	if err := blockingError(errs); err != nil {
+		return err
 	}
-	return svg.NewClassRenderer(resolver).Render(w, d.internal)
+	return RenderDiagramPNG(w, diagram, opts...)
+}
+
+// RenderDiagramPNG renders a previously parsed diagram to a PNG image by
+// rasterizing the SVG RenderDiagram produces.
+func RenderDiagramPNG(w io.Writer, d *Diagram, opts ...Option) error {
+	var buf bytes.Buffer
+	if err := RenderDiagram(&buf, d, opts...); err != nil {
+		return err
+	}
+	return png.Encode(w, &buf, 1)
 }
 
 // Parse reads PlantUML from r and returns the parsed diagram and any errors.
@@ -104,35 +460,366 @@ func Parse(r io.Reader) (*Diagram, []*Error) {
 	if err != nil {
 		return nil, []*Error{{Line: 1, Column: 1, Message: fmt.Sprintf("reading input: %s", err)}}
 	}
-	diagram, parseErrs := parser.Parse(string(data))
-	if len(parseErrs) > 0 {
-		errs := make([]*Error, len(parseErrs))
-		for i, pe := range parseErrs {
-			errs[i] = &Error{
-				Line:    pe.Pos.Line,
-				Column:  pe.Pos.Column,
-				Message: pe.Message,
-			}
+	return ParseString(string(data))
+}
+
+// ParseString parses PlantUML source held in a string. It behaves like Parse
+// without the need to wrap the source in a reader:
+//
+//	diagram, errs := gouml.ParseString("@startuml\nA --> B\n@enduml")
+func ParseString(source string) (*Diagram, []*Error) {
+	diagram, parseErrs := parser.Parse(source)
+	return &Diagram{internal: diagram}, convertErrors(parseErrs)
+}
+
+// ParseMermaid reads a Mermaid class or sequence diagram from r and returns
+// the parsed diagram and any errors. The diagram renders like one parsed
+// from PlantUML.
+func ParseMermaid(r io.Reader) (*Diagram, []*Error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, []*Error{{Line: 1, Column: 1, Message: fmt.Sprintf("reading input: %s", err)}}
+	}
+	diagram, parseErrs := mermaid.Parse(string(data))
+	return &Diagram{internal: diagram}, convertErrors(parseErrs)
+}
+
+// RenderMermaid reads a Mermaid class or sequence diagram from r and writes
+// SVG to w. It accepts the same options as Render.
+func RenderMermaid(r io.Reader, w io.Writer, opts ...Option) error {
+	diagram, errs := ParseMermaid(r)
+	if err := blockingError(errs); err != nil {
+		return err
+	}
+	return RenderDiagram(w, diagram, opts...)
+}
+
+// RenderAsMermaid reads a PlantUML class diagram from r and writes it to w
+// as Mermaid classDiagram source, for tools that display Mermaid but not
+// PlantUML. Other kinds of diagram are not supported. It accepts the same
+// options as Render, so that one set can be passed for every format; those
+// that style the drawing have no effect on Mermaid source, but an option
+// that fails, such as a theme file that cannot be loaded, is reported.
+func RenderAsMermaid(r io.Reader, w io.Writer, opts ...Option) error {
+	diagram, errs := Parse(r)
+	if err := blockingError(errs); err != nil {
+		return err
+	}
+	return RenderDiagramAsMermaid(w, diagram, opts...)
+}
+
+// RenderDiagramAsMermaid writes a previously parsed class diagram to w as
+// Mermaid source. It treats opts as RenderAsMermaid does.
+func RenderDiagramAsMermaid(w io.Writer, d *Diagram, opts ...Option) error {
+	if _, err := applyOptions(opts); err != nil {
+		return err
+	}
+	if kind := DetectType(d); kind != TypeClass && kind != TypeUnknown {
+		return fmt.Errorf("mermaid output supports class diagrams, not %s diagrams", kind)
+	}
+	return mermaidout.NewClassRenderer().Render(w, d.internal)
+}
+
+// RenderDOT reads a PlantUML class diagram from r and writes it to w as a
+// Graphviz DOT digraph, for tools that lay out or post-process DOT. Other
+// kinds of diagram are not supported. It treats opts as RenderAsMermaid
+// does.
+func RenderDOT(r io.Reader, w io.Writer, opts ...Option) error {
+	diagram, errs := Parse(r)
+	if err := blockingError(errs); err != nil {
+		return err
+	}
+	return RenderDiagramDOT(w, diagram, opts...)
+}
+
+// RenderDiagramDOT writes a previously parsed class diagram to w as DOT. It
+// treats opts as RenderAsMermaid does.
+func RenderDiagramDOT(w io.Writer, d *Diagram, opts ...Option) error {
+	if _, err := applyOptions(opts); err != nil {
+		return err
+	}
+	if kind := DetectType(d); kind != TypeClass && kind != TypeUnknown {
+		return fmt.Errorf("DOT output supports class diagrams, not %s diagrams", kind)
+	}
+	return dot.NewClassRenderer().Render(w, d.internal)
+}
+
+// convertErrors converts parser errors to the public Error type. It returns
+// nil when there are none.
+func convertErrors(parseErrs []*parser.Error) []*Error {
+	if len(parseErrs) == 0 {
+		return nil
+	}
+	errs := make([]*Error, len(parseErrs))
+	for i, pe := range parseErrs {
+		errs[i] = &Error{
+			Line:        pe.Pos.Line,
+			Column:      pe.Pos.Column,
+			Message:     pe.Message,
+			recoverable: pe.Recoverable,
+			cause:       pe,
 		}
-		return &Diagram{internal: diagram}, errs
 	}
-	return &Diagram{internal: diagram}, nil
+	return errs
 }
 
-// Validate reads PlantUML from r and returns any parse errors without rendering.
-func Validate(r io.Reader) []*Error {
-	_, errs := Parse(r)
+// blockingError returns the first error that prevents rendering. Recoverable
+// errors, such as a package left open at the end of the input, are skipped;
+// Validate reports them as warnings.
+func blockingError(errs []*Error) error {
+	for _, e := range errs {
+		if !e.recoverable {
+			return e
+		}
+	}
+	return nil
+}
+
+// RenderString renders PlantUML source held in a string to w as SVG. It
+// behaves like Render:
+//
+//	err := gouml.RenderString("@startuml\nA --> B\n@enduml", os.Stdout)
+func RenderString(source string, w io.Writer, opts ...Option) error {
+	diagram, errs := ParseString(source)
+	if err := blockingError(errs); err != nil {
+		return err
+	}
+	return RenderDiagram(w, diagram, opts...)
+}
+
+// Severity distinguishes blocking validation errors from advisory warnings.
+type Severity int
+
+const (
+	// SeverityError marks problems that stop the diagram from rendering as
+	// written, such as a missing @startuml or an unclosed class body.
+	SeverityError Severity = iota
+	// SeverityWarning marks problems the diagram renders despite, such as an
+	// unknown skinparam name.
+	SeverityWarning
+)
+
+// String returns "error" or "warning".
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// MarshalText encodes the severity as its name, so it reads as "error" or
+// "warning" in JSON.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ValidationError is a problem found by Validate. Validate leaves File
+// empty; a caller that validated a preprocessed source can fill it in from
+// the LineMap.
+type ValidationError struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Severity, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Severity, e.Message)
+}
+
+// Validate reads PlantUML from r and reports problems without rendering,
+// ordered by position. Besides parse errors it warns about unknown
+// skinparams and, in sequence diagrams, participants that are only
+// introduced by a message.
+func Validate(r io.Reader) []ValidationError {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return []ValidationError{{Line: 1, Column: 1, Message: fmt.Sprintf("reading input: %s", err)}}
+	}
+	diagram, parseErrs := parser.Parse(string(data))
+	var errs []ValidationError
+	for _, pe := range parseErrs {
+		severity := SeverityError
+		if pe.Recoverable {
+			severity = SeverityWarning
+		}
+		errs = append(errs, ValidationError{Line: pe.Pos.Line, Column: pe.Pos.Column, Message: pe.Message, Severity: severity})
+	}
+	if diagram != nil {
+		errs = append(errs, lint(diagram)...)
+	}
+	slices.SortStableFunc(errs, func(a, b ValidationError) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
 	return errs
 }
 
-// isSequenceDiagram inspects the AST to determine if it's a sequence diagram.
-func isSequenceDiagram(d *ast.Diagram) bool {
-	for _, stmt := range d.Statements {
-		switch stmt.(type) {
-		case *ast.Participant, *ast.Message, *ast.Fragment,
-			*ast.Activate, *ast.Autonumber, *ast.Divider, *ast.Delay:
+// lint returns warnings for diagram constructs that parse but are likely
+// mistakes.
+func lint(diagram *ast.Diagram) []ValidationError {
+	var warnings []ValidationError
+	warn := func(pos lexer.Pos, format string, args ...any) {
+		warnings = append(warnings, ValidationError{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf(format, args...), Severity: SeverityWarning})
+	}
+	ast.Inspect(diagram, func(n ast.Node) bool {
+		if sp, ok := n.(*ast.Skinparam); ok && !theme.IsSkinparam(sp.Name) {
+			warn(sp.Pos, "unknown skinparam %q", sp.Name)
+		}
+		return true
+	})
+	if diagram.DiagramType() != TypeSequence {
+		return warnings
+	}
+	declared := map[string]bool{}
+	ast.Inspect(diagram, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.Participant:
+			declared[s.Name] = true
+			declared[s.Alias] = true
+		case *ast.Lifecycle:
+			declared[s.Target] = true
+		}
+		return true
+	})
+	ast.Inspect(diagram, func(n ast.Node) bool {
+		m, ok := n.(*ast.Message)
+		if !ok {
 			return true
 		}
+		for _, name := range []string{m.From, m.To} {
+			if name != "" && !declared[name] {
+				declared[name] = true
+				warn(m.Pos, "participant %q is not declared", name)
+			}
+		}
+		return true
+	})
+	return warnings
+}
+
+// RenderFile reads PlantUML from inputPath and writes SVG to outputPath,
+// creating or truncating it. If outputPath is empty, it is derived from
+// inputPath by replacing its extension with ".svg". Preprocessor directives
+// are expanded as by PreprocessFile, and one that cannot be is returned as
+// an error before outputPath is created.
+func RenderFile(inputPath, outputPath string, opts ...Option) (err error) {
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".svg"
+	}
+	source, lines, preErrs, err := PreprocessFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("opening input: %w", err)
+	}
+	if len(preErrs) > 0 {
+		return preErrs[0]
+	}
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("creating output: %w", err)
+	}
+	defer func() {
+		if cerr := out.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("closing output: %w", cerr)
+		}
+	}()
+	err = RenderString(source, out, opts...)
+	var pe *Error
+	if errors.As(err, &pe) {
+		pe.locate(lines)
+	}
+	return err
+}
+
+// ParseFile reads and parses the PlantUML file at path, expanding its
+// preprocessor directives as by PreprocessFile. The returned error reports
+// I/O failures on path itself; syntax problems and directives that cannot
+// be expanded are returned as parse errors, positioned in the file that
+// holds them.
+func ParseFile(path string) (*Diagram, []*Error, error) {
+	source, lines, preErrs, err := PreprocessFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening input: %w", err)
+	}
+	diagram, errs := ParseString(source)
+	for _, e := range errs {
+		e.locate(lines)
+	}
+	return diagram, append(preErrs, errs...), nil
+}
+
+// LineMap gives the file and line that each line of a source returned by
+// PreprocessFile was read from. Its Locate method translates a line of the
+// source, such as the Line of a parse error, to a file and line in it:
+//
+//	source, lines, _, err := gouml.PreprocessFile("diagram.puml")
+//	...
+//	for _, e := range gouml.Validate(strings.NewReader(source)) {
+//		file, line := lines.Locate(e.Line)
+//		fmt.Printf("%s:%d: %s\n", file, line, e.Message)
+//	}
+type LineMap = preprocess.LineMap
+
+// PreprocessFile reads the PlantUML file at path and returns its source
+// with the preprocessor directives expanded:
+//
+//   - "!include file" is replaced by the contents of the file, resolved
+//     relative to the including file, without its @startuml and @enduml
+//     lines. Includes may nest up to 16 deep.
+//   - "!define NAME value" replaces later occurrences of the word NAME.
+//   - "!$name = value" sets a variable that later lines use as $name.
+//
+// The returned error reports a failure to read path itself. A missing
+// included file, an include cycle or a malformed directive is returned as
+// an Error matching ErrPreprocess, positioned at the directive in the file
+// that holds it. Line numbers of later parse errors count the included
+// lines; the returned LineMap translates them back to the file and line
+// they were read from.
+func PreprocessFile(path string) (string, LineMap, []*Error, error) {
+	source, lines, preErrs, err := preprocess.New().File(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var errs []*Error
+	for _, pe := range preErrs {
+		errs = append(errs, &Error{File: pe.File, Line: pe.Pos.Line, Column: pe.Pos.Column, Message: pe.Message, cause: pe})
+	}
+	return source, lines, errs, nil
+}
+
+// DumpAST parses PlantUML from r and writes the AST to w as indented JSON.
+// Every node carries a "type" field naming its kind. Parse errors do not
+// abort the dump; they are reported in a top-level "errors" array.
+func DumpAST(r io.Reader, w io.Writer) error {
+	diagram, errs := Parse(r)
+	if diagram == nil {
+		return errs[0]
+	}
+	if errs == nil {
+		errs = []*Error{}
+	}
+	out := struct {
+		Diagram *ast.Diagram `json:"diagram"`
+		Errors  []*Error     `json:"errors"`
+	}{Diagram: diagram.internal, Errors: errs}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Format reads PlantUML from r and writes it to w in canonical form: bodies
+// and blocks indented by two spaces, class and interface members sorted with
+// fields before methods, relationship arrows normalised to "--" or ".." between
+// their heads, and comments dropped. Formatting its own output changes
+// nothing. Input that does not parse is not formatted.
+func Format(r io.Reader, w io.Writer) error {
+	diagram, errs := Parse(r)
+	if err := blockingError(errs); err != nil {
+		return err
 	}
-	return false
+	return printer.Fprint(w, diagram.internal)
 }
@@ -2,7 +2,16 @@ package gouml_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
 	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -38,6 +47,40 @@ func TestRender(t *testing.T) {
 		assert.Contains(t, out, "Bob")
 		assert.Contains(t, out, "hello")
 	})
+	t.Run("ComponentDiagram", func(t *testing.T) {
+		t.Parallel()
+		input := strings.NewReader("@startuml\n[Web] --> [API] : calls\n() \"HTTP\" as H\nH - [Web]\n@enduml")
+		var buf bytes.Buffer
+		err := gouml.Render(input, &buf)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, "Web")
+		assert.Contains(t, out, "API")
+		assert.Contains(t, out, "calls")
+		assert.Contains(t, out, "<circle")
+	})
+	t.Run("UsecaseDiagram", func(t *testing.T) {
+		t.Parallel()
+		input := strings.NewReader("@startuml\nactor User\nUser --> (Log In)\n@enduml")
+		var buf bytes.Buffer
+		err := gouml.Render(input, &buf)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, "<ellipse")
+		assert.Contains(t, out, "Log In")
+	})
+	t.Run("ERDiagram", func(t *testing.T) {
+		t.Parallel()
+		input := strings.NewReader("@startuml\nentity User {\n  * id : int\n}\nentity Order\nUser ||--o{ Order : places\n@enduml")
+		var buf bytes.Buffer
+		err := gouml.Render(input, &buf)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, ">* id : int<")
+		assert.Contains(t, out, ">Order<")
+		assert.Contains(t, out, ">places<")
+		assert.Contains(t, out, "<circle")
+	})
 	t.Run("EmptyDiagram", func(t *testing.T) {
 		t.Parallel()
 		input := strings.NewReader("@startuml\n@enduml")
@@ -56,6 +99,140 @@ func TestRender(t *testing.T) {
 		require.NoError(t, err)
 		assert.Contains(t, buf.String(), "#FF0000")
 	})
+	t.Run("WithResponsiveSkinparam", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"@startuml\nclass Foo\n@enduml", "@startuml\nAlice -> Bob\n@enduml"} {
+			var buf bytes.Buffer
+			require.NoError(t, gouml.Render(strings.NewReader(input), &buf,
+				gouml.WithSkinparam("responsiveSVG", "true"),
+			))
+			tag, _, _ := strings.Cut(buf.String(), ">")
+			assert.NotContains(t, tag, "width=")
+			assert.NotContains(t, tag, "height=")
+			assert.Contains(t, tag, "viewBox=")
+		}
+	})
+	t.Run("WithResponsive", func(t *testing.T) {
+		t.Parallel()
+		inputs := []string{
+			"@startuml\nclass Foo\n@enduml",
+			"@startuml\nAlice -> Bob\n@enduml",
+			"@startuml\nstart\n:step;\nstop\n@enduml",
+			"@startuml\nactor User\nUser --> (Login)\n@enduml",
+			"@startuml\n[Web] --> [API]\n@enduml",
+			"@startuml\nentity User {\n* id : int\n}\n@enduml",
+		}
+		for _, input := range inputs {
+			var fixed, responsive bytes.Buffer
+			require.NoError(t, gouml.Render(strings.NewReader(input), &fixed))
+			require.NoError(t, gouml.Render(strings.NewReader(input), &responsive, gouml.WithResponsive(true)))
+			tag, _, _ := strings.Cut(fixed.String(), ">")
+			assert.Contains(t, tag, "width=", input)
+			tag, _, _ = strings.Cut(responsive.String(), ">")
+			assert.NotContains(t, tag, "width=", input)
+			assert.NotContains(t, tag, "height=", input)
+			assert.Contains(t, tag, "viewBox=", input)
+		}
+	})
+	t.Run("WithBackground", func(t *testing.T) {
+		t.Parallel()
+		inputs := []string{
+			"@startuml\nclass Foo\n@enduml",
+			"@startuml\nAlice -> Bob\n@enduml",
+			"@startuml\n[Web] --> [API]\n@enduml",
+			"@startuml\nactor User\nUser --> (Log In)\n@enduml",
+			"@startuml\nstart\n:work;\nstop\n@enduml",
+		}
+		for _, input := range inputs {
+			var opaque, transparent bytes.Buffer
+			require.NoError(t, gouml.Render(strings.NewReader(input), &opaque))
+			require.NoError(t, gouml.Render(strings.NewReader(input), &transparent,
+				gouml.WithBackground(gouml.BackgroundTransparent),
+			))
+			assert.Contains(t, opaque.String(), `<rect width="`, input)
+			assert.NotContains(t, transparent.String(), `<rect width="`, input)
+		}
+	})
+	t.Run("WithAccessibility", func(t *testing.T) {
+		t.Parallel()
+		for _, input := range []string{"@startuml\nclass Foo\n@enduml", "@startuml\nFoo -> Bar\n@enduml"} {
+			var plain, accessible bytes.Buffer
+			require.NoError(t, gouml.Render(strings.NewReader(input), &plain))
+			require.NoError(t, gouml.Render(strings.NewReader(input), &accessible,
+				gouml.WithAccessibility(true),
+			))
+			assert.NotContains(t, plain.String(), "<title>", input)
+			assert.Contains(t, accessible.String(), "<g><title>Foo</title>", input)
+		}
+	})
+	t.Run("WithMaxSize", func(t *testing.T) {
+		t.Parallel()
+		svgSize := regexp.MustCompile(`<svg [^>]*width="([0-9]+)" height="([0-9]+)" viewBox="0 0 ([0-9]+) ([0-9]+)"`)
+		size := func(out string) (w, h, vw, vh int) {
+			m := svgSize.FindStringSubmatch(out)
+			require.NotNil(t, m, out)
+			n := make([]int, 4)
+			for i := range n {
+				var err error
+				n[i], err = strconv.Atoi(m[i+1])
+				require.NoError(t, err)
+			}
+			return n[0], n[1], n[2], n[3]
+		}
+		const input = "@startuml\nclass A\nclass B\nclass C\nclass D\n@enduml"
+		var plain, capped bytes.Buffer
+		require.NoError(t, gouml.RenderString(input, &plain))
+		require.NoError(t, gouml.RenderString(input, &capped, gouml.WithMaxSize(200, 1000), gouml.WithMinify(true)))
+		w, h, _, _ := size(plain.String())
+		require.Greater(t, w, 200)
+		cw, ch, vw, vh := size(capped.String())
+		assert.Equal(t, 200, cw)
+		assert.InDelta(t, float64(h)*200/float64(w), ch, 1)
+		// The viewBox keeps the full drawing.
+		assert.Equal(t, w, vw)
+		assert.Equal(t, h, vh)
+		assert.NotContains(t, capped.String(), "\n")
+		// A drawing within the limits is untouched.
+		var roomy bytes.Buffer
+		require.NoError(t, gouml.RenderString(input, &roomy, gouml.WithMaxSize(5000, 5000)))
+		assert.Equal(t, plain.String(), roomy.String())
+	})
+	t.Run("WithLayoutDebug", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			node  string // the start of a node row in the dump
+		}{
+			{"@startuml\nclass A\nclass B\nA --> B\n@enduml", "A "},
+			{"@startuml\nactor User\nUser --> (Log In)\n@enduml", "User "},
+			{"@startuml\n[API] --> [DB]\n@enduml", "API "},
+			{"@startuml\nentity Customer {\n}\n@enduml", "Customer "},
+			{"@startuml\nstart\n:work;\nstop\n@enduml", ""},
+		}
+		for _, tt := range tests {
+			var out, dump bytes.Buffer
+			require.NoError(t, gouml.RenderString(tt.input, &out, gouml.WithLayoutDebug(&dump)), tt.input)
+			assert.Contains(t, out.String(), "<svg", tt.input)
+			assert.True(t, strings.HasPrefix(dump.String(), "NODE "), tt.input)
+			assert.Contains(t, dump.String(), "\n"+tt.node, tt.input)
+		}
+		// Sequence diagrams have no layout graph to dump.
+		var out, dump bytes.Buffer
+		require.NoError(t, gouml.RenderString("@startuml\nAlice -> Bob : hi\n@enduml", &out, gouml.WithLayoutDebug(&dump)))
+		assert.Empty(t, dump.String())
+	})
+	t.Run("RenderPNG", func(t *testing.T) {
+		t.Parallel()
+		const input = "@startuml\nclass Foo\n@enduml"
+		var svgOut, pngOut bytes.Buffer
+		require.NoError(t, gouml.Render(strings.NewReader(input), &svgOut))
+		require.NoError(t, gouml.RenderPNG(strings.NewReader(input), &pngOut))
+		img, err := png.Decode(&pngOut)
+		require.NoError(t, err)
+		// One pixel per SVG unit.
+		assert.Contains(t, svgOut.String(), fmt.Sprintf(`width="%d" height="%d"`, img.Bounds().Dx(), img.Bounds().Dy()))
+		require.Error(t, gouml.RenderPNG(strings.NewReader("not a diagram"), &pngOut))
+	})
 	t.Run("WithCustomTheme", func(t *testing.T) {
 		t.Parallel()
 		custom := theme.Darcula()
@@ -66,6 +243,50 @@ func TestRender(t *testing.T) {
 		require.NoError(t, err)
 		assert.Contains(t, buf.String(), "#AABBCC")
 	})
+	t.Run("WithThemeName", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, gouml.Render(strings.NewReader("@startuml\nclass Foo\n@enduml"), &buf, gouml.WithThemeName("light")))
+		assert.Contains(t, buf.String(), theme.Light().ClassBackgroundColor)
+		err := gouml.Render(strings.NewReader("@startuml\nclass Foo\n@enduml"), &buf, gouml.WithThemeName("nope"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nope")
+	})
+	t.Run("WithThemeFile", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "theme.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"backgroundColor": "#AABBCC", "unknown": true}`), 0o644))
+		var buf bytes.Buffer
+		require.NoError(t, gouml.Render(strings.NewReader("@startuml\nclass Foo\n@enduml"), &buf, gouml.WithThemeFile(path)))
+		assert.Contains(t, buf.String(), "#AABBCC")
+		// Unset properties keep their Darcula values.
+		assert.Contains(t, buf.String(), theme.Darcula().ClassBackgroundColor)
+		err := gouml.Render(strings.NewReader("@startuml\nclass Foo\n@enduml"), &buf, gouml.WithThemeFile(path+".missing"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("WithMinify", func(t *testing.T) {
+		t.Parallel()
+		inputs := []string{
+			"@startuml\nclass Foo {\n+name : String\n}\nobject \"o1 : Foo\"\nFoo --> Bar\n@enduml",
+			"@startuml\nAlice -> Bob : hello world\n@enduml",
+		}
+		for _, input := range inputs {
+			var pretty, minified bytes.Buffer
+			require.NoError(t, gouml.Render(strings.NewReader(input), &pretty))
+			require.NoError(t, gouml.Render(strings.NewReader(input), &minified, gouml.WithMinify(true)))
+			out := minified.String()
+			assert.NotContains(t, out, "\n")
+			assert.NotContains(t, out, "> <")
+			assert.LessOrEqual(t, len(out), len(pretty.String()))
+			assert.Equal(t, strings.Count(pretty.String(), "<"), strings.Count(out, "<"), "same elements")
+			// Text content, including leading spaces, is untouched.
+			for _, text := range []string{">hello world<", "> : Foo<"} {
+				if strings.Contains(pretty.String(), text) {
+					assert.Contains(t, out, text)
+				}
+			}
+		}
+	})
 	t.Run("InvalidInput", func(t *testing.T) {
 		t.Parallel()
 		input := strings.NewReader("not a diagram")
@@ -107,6 +328,55 @@ func TestParse(t *testing.T) {
 		assert.NotEmpty(t, errs[0].Message)
 		assert.Contains(t, errs[0].Error(), errs[0].Message)
 	})
+	t.Run("DiagramType", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			want  gouml.DiagramType
+		}{
+			{"@startuml\n@enduml", gouml.TypeUnknown},
+			{"@startuml\nclass Foo\nFoo --> Bar\n@enduml", gouml.TypeClass},
+			{"@startuml\nAlice -> Bob : hi\n@enduml", gouml.TypeSequence},
+			{"@startuml\nclass Foo\nAlice -> Bob : hi\n@enduml", gouml.TypeSequence},
+			{"@startuml\ncomponent Web\ncomponent API\nWeb --> API\n@enduml", gouml.TypeComponent},
+			{"@startuml\nUser ||--o{ Order\n@enduml", gouml.TypeER},
+			// Ambiguous inputs: a dashed arrow between undeclared names is a
+			// class relationship, a single-dash one a message, and a
+			// participant beside a class makes a sequence diagram.
+			{"@startuml\nA --> B\n@enduml", gouml.TypeClass},
+			{"@startuml\nA -> B\n@enduml", gouml.TypeSequence},
+			{"@startuml\nparticipant A\nclass Foo\n@enduml", gouml.TypeSequence},
+			{"@startuml\npackage P {\nclass Foo\n}\n@enduml", gouml.TypeClass},
+			{"@startuml\nactor User\nUser -> (Log In)\n@enduml", gouml.TypeUsecase},
+			{"@startuml\ntitle Hello\nskinparam shadowing true\n@enduml", gouml.TypeUnknown},
+		}
+		for _, tt := range tests {
+			diagram, errs := gouml.ParseString(tt.input)
+			require.Empty(t, errs, tt.input)
+			assert.Equal(t, tt.want, diagram.DiagramType(), tt.input)
+			assert.Equal(t, tt.want, gouml.DetectType(diagram), tt.input)
+		}
+	})
+	t.Run("DetectType", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			input string
+			want  gouml.DiagramType
+		}{
+			{"@startuml\n@enduml", gouml.TypeUnknown},
+			{"@startuml\nclass Foo\n@enduml", gouml.TypeClass},
+			{"@startuml\nAlice -> Bob\n@enduml", gouml.TypeSequence},
+			{"@startuml\nstart\n:work;\nstop\n@enduml", gouml.TypeActivity},
+			{"@startuml\nusecase Login\n@enduml", gouml.TypeUsecase},
+			{"@startuml\ncomponent Web\n@enduml", gouml.TypeComponent},
+			{"@startuml\nentity User\nUser ||--o{ Order\n@enduml", gouml.TypeER},
+		}
+		for _, tt := range tests {
+			diagram, errs := gouml.ParseString(tt.input)
+			require.Empty(t, errs, tt.input)
+			assert.Equal(t, tt.want, gouml.DetectType(diagram), tt.input)
+		}
+	})
 	t.Run("RenderAfterParse", func(t *testing.T) {
 		t.Parallel()
 		input := strings.NewReader("@startuml\nclass Foo\n@enduml")
@@ -119,6 +389,95 @@ func TestParse(t *testing.T) {
 	})
 }
 
+func TestParseString(t *testing.T) {
+	t.Parallel()
+	t.Run("MatchesParse", func(t *testing.T) {
+		t.Parallel()
+		const src = "@startuml\nclass Foo\n$bad\nFoo --> Bar\n@enduml"
+		want, wantErrs := gouml.Parse(strings.NewReader(src))
+		got, gotErrs := gouml.ParseString(src)
+		require.NotEmpty(t, gotErrs)
+		assert.Equal(t, wantErrs, gotErrs)
+		require.NotNil(t, want)
+		require.NotNil(t, got)
+		var gotSVG, wantSVG bytes.Buffer
+		require.NoError(t, gouml.RenderDiagram(&wantSVG, want))
+		require.NoError(t, gouml.RenderDiagram(&gotSVG, got))
+		assert.Equal(t, wantSVG.String(), gotSVG.String())
+		assert.Equal(t, 3, gotErrs[0].Line)
+	})
+	t.Run("RenderString", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, gouml.RenderString("@startuml\nclass Foo\n@enduml", &buf, gouml.WithMinify(true)))
+		assert.Contains(t, buf.String(), "Foo")
+		assert.NotContains(t, buf.String(), ">\n<")
+	})
+	t.Run("RenderStringError", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		err := gouml.RenderString("not a diagram", &buf)
+		var perr *gouml.Error
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, 1, perr.Line)
+		assert.Zero(t, buf.Len())
+	})
+	t.Run("SentinelErrors", func(t *testing.T) {
+		t.Parallel()
+		_, errs := gouml.ParseString("@startuml\n$bad\ntitle Hello")
+		require.Len(t, errs, 2)
+		assert.True(t, errors.Is(errs[0], gouml.ErrSyntax))
+		assert.True(t, errors.Is(errs[0], gouml.ErrUnexpectedToken))
+		assert.True(t, errors.Is(errs[1], gouml.ErrMissingEndUML))
+		err := gouml.RenderString("@startuml\n$bad\n@enduml", io.Discard)
+		assert.ErrorIs(t, err, gouml.ErrSyntax)
+	})
+}
+
+func TestRenderMermaid(t *testing.T) {
+	t.Parallel()
+	t.Run("ClassDiagram", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		err := gouml.RenderMermaid(strings.NewReader("classDiagram\nclass Animal {\n  +String name\n}\nAnimal <|-- Duck"), &buf)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, "Animal")
+		assert.Contains(t, out, "Duck")
+		assert.Contains(t, out, "marker-triangle-open")
+	})
+	t.Run("SequenceDiagram", func(t *testing.T) {
+		t.Parallel()
+		var mermaidSVG, plantSVG bytes.Buffer
+		require.NoError(t, gouml.RenderMermaid(strings.NewReader("sequenceDiagram\nAlice->>Bob: msg"), &mermaidSVG))
+		require.NoError(t, gouml.RenderString("@startuml\nAlice -> Bob : msg\n@enduml", &plantSVG))
+		assert.Equal(t, plantSVG.String(), mermaidSVG.String())
+	})
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		err := gouml.RenderMermaid(strings.NewReader("sequenceDiagram\nAlice=>Bob"), &buf)
+		var perr *gouml.Error
+		require.ErrorAs(t, err, &perr)
+		assert.Equal(t, 2, perr.Line)
+		assert.Zero(t, buf.Len())
+	})
+}
+
+const benchmarkSource = "@startuml\nclass Foo {\n+name : String\n}\nFoo --> Bar : uses\n@enduml"
+
+func BenchmarkParseString(b *testing.B) {
+	for b.Loop() {
+		gouml.ParseString(benchmarkSource)
+	}
+}
+
+func BenchmarkParseReader(b *testing.B) {
+	for b.Loop() {
+		gouml.Parse(strings.NewReader(benchmarkSource))
+	}
+}
+
 func TestValidate(t *testing.T) {
 	t.Parallel()
 	t.Run("ValidInput", func(t *testing.T) {
@@ -133,6 +492,338 @@ func TestValidate(t *testing.T) {
 		errs := gouml.Validate(input)
 		assert.NotEmpty(t, errs)
 	})
+	t.Run("Severity", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			name     string
+			input    string
+			severity gouml.Severity
+			message  string
+		}{
+			{"MissingStartuml", "class Foo", gouml.SeverityError, "expected @startuml"},
+			{"UnclosedBrace", "@startuml\nclass Foo {\n+bar()\n@enduml", gouml.SeverityError, "expected closing }"},
+			{"UnclosedPackage", "@startuml\npackage app {\nclass Foo\n@enduml", gouml.SeverityWarning, "expected closing } for package"},
+			{"UnknownSkinparam", "@startuml\nskinparam clasBackgroundColor red\nclass Foo\n@enduml", gouml.SeverityWarning, `unknown skinparam "clasBackgroundColor"`},
+			{"ImplicitParticipant", "@startuml\nparticipant Alice\nAlice -> Bob\n@enduml", gouml.SeverityWarning, `participant "Bob" is not declared`},
+		}
+		for _, tt := range tests {
+			errs := gouml.Validate(strings.NewReader(tt.input))
+			require.NotEmpty(t, errs, tt.name)
+			assert.Equal(t, tt.severity, errs[0].Severity, tt.name)
+			assert.Contains(t, errs[0].Message, tt.message, tt.name)
+		}
+	})
+	t.Run("WarningsOnlyOnce", func(t *testing.T) {
+		t.Parallel()
+		errs := gouml.Validate(strings.NewReader("@startuml\nskinparam backgroundColor white\nAlice -> Bob\nBob -> Alice\nloop\nAlice -> Carol\nend\n@enduml"))
+		require.Len(t, errs, 3)
+		assert.Equal(t, []int{3, 3, 6}, []int{errs[0].Line, errs[1].Line, errs[2].Line})
+		assert.Contains(t, errs[2].Message, `"Carol"`)
+	})
+	t.Run("ErrorAndJSON", func(t *testing.T) {
+		t.Parallel()
+		e := gouml.ValidationError{Line: 2, Column: 3, Message: "oops", Severity: gouml.SeverityWarning}
+		assert.Equal(t, "2:3: warning: oops", e.Error())
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"line":2,"column":3,"message":"oops","severity":"warning"}`, string(data))
+	})
+	t.Run("RecoverableStillRenders", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, gouml.RenderString("@startuml\npackage app {\nclass Foo\n@enduml", &buf))
+		assert.Contains(t, buf.String(), ">Foo<")
+	})
+}
+
+func TestRenderFile(t *testing.T) {
+	t.Parallel()
+	t.Run("WritesSVG", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "diagram.puml")
+		out := filepath.Join(dir, "out.svg")
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\nclass Foo\n@enduml"), 0o600))
+		require.NoError(t, gouml.RenderFile(in, out))
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "<svg")
+		assert.Contains(t, string(data), "Foo")
+	})
+	t.Run("DerivesOutputPath", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "diagram.puml")
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\nclass Foo\n@enduml"), 0o600))
+		require.NoError(t, gouml.RenderFile(in, ""))
+		data, err := os.ReadFile(filepath.Join(dir, "diagram.svg"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "</svg>")
+	})
+	t.Run("UnwritableOutput", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "diagram.puml")
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\nclass Foo\n@enduml"), 0o600))
+		err := gouml.RenderFile(in, filepath.Join(dir, "missing", "out.svg"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "creating output")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+	t.Run("MissingInput", func(t *testing.T) {
+		t.Parallel()
+		err := gouml.RenderFile(filepath.Join(t.TempDir(), "nope.puml"), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "opening input")
+	})
+	t.Run("Includes", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "diagram.puml")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "common.puml"), []byte("@startuml\nclass Shared\n@enduml"), 0o600))
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\n!include common.puml\nclass Foo\nFoo --> Shared\n@enduml"), 0o600))
+		require.NoError(t, gouml.RenderFile(in, ""))
+		data, err := os.ReadFile(filepath.Join(dir, "diagram.svg"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), ">Shared<")
+	})
+	t.Run("MissingInclude", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "diagram.puml")
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\n!include common.puml\n@enduml"), 0o600))
+		err := gouml.RenderFile(in, "")
+		require.ErrorIs(t, err, gouml.ErrPreprocess)
+		assert.Equal(t, in+`:2:1: included file "common.puml" not found`, err.Error())
+		assert.NoFileExists(t, filepath.Join(dir, "diagram.svg"))
+	})
+}
+
+func TestParseFile(t *testing.T) {
+	t.Parallel()
+	t.Run("ValidFile", func(t *testing.T) {
+		t.Parallel()
+		in := filepath.Join(t.TempDir(), "diagram.puml")
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\nclass Foo\n@enduml"), 0o600))
+		diagram, errs, err := gouml.ParseFile(in)
+		require.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.NotNil(t, diagram)
+	})
+	t.Run("SyntaxErrors", func(t *testing.T) {
+		t.Parallel()
+		in := filepath.Join(t.TempDir(), "bad.puml")
+		require.NoError(t, os.WriteFile(in, []byte("not a diagram"), 0o600))
+		_, errs, err := gouml.ParseFile(in)
+		require.NoError(t, err)
+		assert.NotEmpty(t, errs)
+	})
+	t.Run("MissingFile", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := gouml.ParseFile(filepath.Join(t.TempDir(), "nope.puml"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+	t.Run("IncludeErrors", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "diagram.puml")
+		nested := filepath.Join(dir, "a.puml")
+		require.NoError(t, os.WriteFile(nested, []byte("class A\n!include diagram.puml"), 0o600))
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\n!include a.puml\n@enduml"), 0o600))
+		diagram, errs, err := gouml.ParseFile(in)
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		assert.Equal(t, nested, errs[0].File)
+		assert.Equal(t, 2, errs[0].Line)
+		assert.Equal(t, "include cycle: diagram.puml -> a.puml -> diagram.puml", errs[0].Message)
+		// The rest of the include still parses.
+		assert.Equal(t, gouml.TypeClass, diagram.DiagramType())
+	})
+	t.Run("ErrorAfterInclude", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		in := filepath.Join(dir, "main.puml")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "shapes.puml"), []byte("class A\nclass B\nclass C"), 0o600))
+		require.NoError(t, os.WriteFile(in, []byte("@startuml\n!include shapes.puml\nclass Foo {\n@enduml"), 0o600))
+		_, errs, err := gouml.ParseFile(in)
+		require.NoError(t, err)
+		require.NotEmpty(t, errs)
+		// The merged source puts @enduml on line 6; the error is reported
+		// on line 4 of main.puml, where it is.
+		assert.Equal(t, in+":4:7: expected closing }", errs[0].Error())
+		err = gouml.RenderFile(in, "")
+		assert.Equal(t, in+":4:7: expected closing }", err.Error())
+	})
+}
+
+func TestPreprocessFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	in := filepath.Join(dir, "diagram.puml")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "types.puml"), []byte("!define BASE Bar\nclass BASE"), 0o600))
+	require.NoError(t, os.WriteFile(in, []byte("@startuml\n!include lib/types.puml\n!$name = \"Foo\"\nclass $name\n$name --|> BASE\n@enduml"), 0o600))
+	source, lines, errs, err := gouml.PreprocessFile(in)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+	assert.Equal(t, "@startuml\n\nclass Bar\n\nclass Foo\nFoo --|> Bar\n@enduml", source)
+	file, line := lines.Locate(3)
+	assert.Equal(t, filepath.Join(dir, "lib", "types.puml"), file)
+	assert.Equal(t, 2, line)
+	file, line = lines.Locate(5)
+	assert.Equal(t, in, file)
+	assert.Equal(t, 4, line)
+}
+
+func TestDumpAST(t *testing.T) {
+	t.Parallel()
+	t.Run("ValidDiagram", func(t *testing.T) {
+		t.Parallel()
+		input := strings.NewReader("@startuml\nclass Foo {\n+name : String\n}\n@enduml")
+		var buf bytes.Buffer
+		require.NoError(t, gouml.DumpAST(input, &buf))
+		out := buf.String()
+		assert.Contains(t, out, `"type": "ClassDef"`)
+		assert.Contains(t, out, `"type": "Field"`)
+		assert.Contains(t, out, `"errors": []`)
+	})
+	t.Run("ParseErrorsIncluded", func(t *testing.T) {
+		t.Parallel()
+		input := strings.NewReader("@startuml\n$bad\nclass Foo\n@enduml")
+		var buf bytes.Buffer
+		require.NoError(t, gouml.DumpAST(input, &buf))
+		var doc struct {
+			Diagram map[string]any `json:"diagram"`
+			Errors  []*gouml.Error `json:"errors"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		require.NotEmpty(t, doc.Errors)
+		assert.Equal(t, 2, doc.Errors[0].Line)
+		assert.Equal(t, "Diagram", doc.Diagram["type"])
+	})
+}
+
+func TestMeasure(t *testing.T) {
+	t.Parallel()
+	diagram, errs := gouml.ParseString("@startuml\nclass A\nclass B\nclass C\nA --> B\nA --> C\n@enduml")
+	require.Empty(t, errs)
+	var buf bytes.Buffer
+	require.NoError(t, gouml.RenderDiagram(&buf, diagram))
+	size, err := gouml.Measure(diagram)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), fmt.Sprintf(`width="%d" height="%d"`, size.Width, size.Height))
+	t.Run("Responsive", func(t *testing.T) {
+		t.Parallel()
+		responsive, err := gouml.Measure(diagram, gouml.WithSkinparam("responsiveSVG", "true"))
+		require.NoError(t, err)
+		assert.Equal(t, size, responsive)
+	})
+	t.Run("MaxSize", func(t *testing.T) {
+		t.Parallel()
+		capped, err := gouml.Measure(diagram, gouml.WithMaxSize(size.Width/2, 0))
+		require.NoError(t, err)
+		assert.Equal(t, size.Width/2, capped.Width)
+		assert.Less(t, capped.Height, size.Height)
+	})
+	t.Run("EveryKind", func(t *testing.T) {
+		t.Parallel()
+		inputs := map[string]string{
+			"Empty":     "@startuml\n@enduml",
+			"Sequence":  "@startuml\nAlice -> Bob : hello there\n@enduml",
+			"Activity":  "@startuml\nstart\n:step;\nstop\n@enduml",
+			"Usecase":   "@startuml\nactor User\nUser --> (Login)\n@enduml",
+			"Component": "@startuml\n[API]\n[DB]\n[API] --> [DB]\n@enduml",
+			"ER":        "@startuml\nentity User {\n  * id : int\n}\n@enduml",
+		}
+		for name, input := range inputs {
+			d, errs := gouml.ParseString(input)
+			require.Empty(t, errs, name)
+			for _, opts := range [][]gouml.Option{nil, {gouml.WithMaxSize(50, 40)}} {
+				var buf bytes.Buffer
+				require.NoError(t, gouml.RenderDiagram(&buf, d, opts...), name)
+				got, err := gouml.Measure(d, opts...)
+				require.NoError(t, err, name)
+				assert.Contains(t, buf.String(), fmt.Sprintf(`width="%d" height="%d"`, got.Width, got.Height), name)
+			}
+		}
+	})
+}
+
+func TestRenderAsMermaid(t *testing.T) {
+	t.Parallel()
+	t.Run("ClassDiagram", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, gouml.RenderAsMermaid(strings.NewReader("@startuml\nclass Foo {\n+name : String\n}\nFoo --|> Bar\n@enduml"), &buf))
+		assert.Equal(t, "classDiagram\n  class Foo {\n    +String name\n  }\n  Foo --|> Bar\n", buf.String())
+	})
+	t.Run("OtherKindsRejected", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		err := gouml.RenderAsMermaid(strings.NewReader("@startuml\nAlice -> Bob : hi\n@enduml"), &buf)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "sequence")
+	})
+	t.Run("Options", func(t *testing.T) {
+		t.Parallel()
+		const input = "@startuml\nclass Foo\n@enduml"
+		var plain, styled bytes.Buffer
+		require.NoError(t, gouml.RenderAsMermaid(strings.NewReader(input), &plain))
+		require.NoError(t, gouml.RenderAsMermaid(strings.NewReader(input), &styled, gouml.WithThemeName("monokai"), gouml.WithMinify(true)))
+		assert.Equal(t, plain.String(), styled.String())
+		err := gouml.RenderAsMermaid(strings.NewReader(input), &plain, gouml.WithThemeName("nope"))
+		assert.ErrorContains(t, err, "nope")
+	})
+}
+
+func TestRenderDOT(t *testing.T) {
+	t.Parallel()
+	t.Run("ClassDiagram", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		require.NoError(t, gouml.RenderDOT(strings.NewReader("@startuml\nclass Foo\nclass Bar\nFoo --|> Bar\n@enduml"), &buf))
+		out := buf.String()
+		assert.True(t, strings.HasPrefix(out, "digraph G {"), out)
+		assert.Contains(t, out, `"Foo" -> "Bar" [arrowhead=empty];`)
+	})
+	t.Run("OtherKindsRejected", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		err := gouml.RenderDOT(strings.NewReader("@startuml\nstart\n:step;\nstop\n@enduml"), &buf)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "activity")
+	})
+	t.Run("Options", func(t *testing.T) {
+		t.Parallel()
+		const input = "@startuml\nclass Foo\n@enduml"
+		var plain, styled bytes.Buffer
+		require.NoError(t, gouml.RenderDOT(strings.NewReader(input), &plain))
+		require.NoError(t, gouml.RenderDOT(strings.NewReader(input), &styled, gouml.WithThemeName("monokai"), gouml.WithMinify(true)))
+		assert.Equal(t, plain.String(), styled.String())
+		err := gouml.RenderDOT(strings.NewReader(input), &plain, gouml.WithThemeName("nope"))
+		assert.ErrorContains(t, err, "nope")
+	})
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	t.Run("Canonical", func(t *testing.T) {
+		t.Parallel()
+		const messy = "@startuml\n' comment\nclass   Foo {\n+run()\n  -name : String\n}\nFoo ...|> Bar\n@enduml"
+		var first, second bytes.Buffer
+		require.NoError(t, gouml.Format(strings.NewReader(messy), &first))
+		assert.Equal(t, "@startuml\nclass Foo {\n  -name : String\n  +run()\n}\nFoo ..|> Bar\n@enduml\n", first.String())
+		require.NoError(t, gouml.Format(bytes.NewReader(first.Bytes()), &second))
+		assert.Equal(t, first.String(), second.String())
+	})
+	t.Run("ParseError", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		err := gouml.Format(strings.NewReader("@startuml\nclass Foo {\n"), &buf)
+		var gerr *gouml.Error
+		require.ErrorAs(t, err, &gerr)
+		assert.Empty(t, buf.String())
+	})
 }
 
 func TestRenderDiagram(t *testing.T) {
@@ -178,6 +869,19 @@ func TestRenderDiagram(t *testing.T) {
 		assert.Contains(t, out, "Foo")
 		assert.Contains(t, out, "name")
 	})
+	t.Run("ActivityDetection", func(t *testing.T) {
+		t.Parallel()
+		input := strings.NewReader("@startuml\nstart\n:work;\nstop\n@enduml")
+		diagram, errs := gouml.Parse(input)
+		require.Empty(t, errs)
+		var buf bytes.Buffer
+		err := gouml.RenderDiagram(&buf, diagram)
+		require.NoError(t, err)
+		out := buf.String()
+		assert.Contains(t, out, "work")
+		assert.Contains(t, out, "<circle")
+		assert.NotContains(t, out, `stroke-dasharray="5,5"`)
+	})
 }
 
 // errReader is an io.Reader that always returns an error.